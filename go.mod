@@ -15,10 +15,12 @@ require (
 	github.com/PuerkitoBio/goquery v1.10.0
 	github.com/goccy/go-zetasql v0.5.5
 	github.com/golang/mock v1.6.0
+	github.com/gorilla/websocket v1.4.1
 	github.com/mattn/go-sqlite3 v1.14.24
 	github.com/sirupsen/logrus v1.9.3
 	golang.org/x/text v0.21.0
 	google.golang.org/api v0.210.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
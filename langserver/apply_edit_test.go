@@ -0,0 +1,63 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/sirupsen/logrus"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func TestHandler_applyEdit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	h := &Handler{logger: logrus.New(), positionEncoding: lsp.PositionEncodingUTF8}
+	h.initializeParams.Capabilities.Workspace.ApplyEdit = true
+	edits := []lsp.TextEdit{{NewText: "SELECT 1"}}
+
+	// The server runs applyEdit in the handler of the request like workspace/executeCommand.
+	serverSide, clientSide := net.Pipe()
+	serverConn := jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(serverSide, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		h.conn = conn
+		return h.applyEdit(ctx, "Test edit", "file:///query.sql", edits)
+	}))
+	defer serverConn.Close()
+
+	applied := make(chan lsp.ApplyWorkspaceEditParams, 1)
+	clientConn := jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(clientSide, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		var params lsp.ApplyWorkspaceEditParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			return nil, err
+		}
+		applied <- params
+		return lsp.ApplyWorkspaceEditResult{Applied: true}, nil
+	}))
+	defer clientConn.Close()
+
+	var result *lsp.WorkspaceEdit
+	if err := clientConn.Call(ctx, "workspace/executeCommand", nil, &result); err != nil {
+		t.Fatalf("the command should return without waiting for workspace/applyEdit: %v", err)
+	}
+	if result != nil {
+		t.Errorf("the edit should be applied by workspace/applyEdit, but got %v", result)
+	}
+
+	select {
+	case got := <-applied:
+		expect := lsp.ApplyWorkspaceEditParams{
+			Label: "Test edit",
+			Edit:  lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{"file:///query.sql": edits}},
+		}
+		if diff := cmp.Diff(expect, got); diff != "" {
+			t.Errorf("workspace/applyEdit params diff (-expect, +got)\n%s", diff)
+		}
+	case <-ctx.Done():
+		t.Fatal("workspace/applyEdit is not requested")
+	}
+}
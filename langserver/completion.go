@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/completion"
 	"github.com/sourcegraph/jsonrpc2"
 )
 
@@ -18,19 +19,70 @@ func (h *Handler) handleTextDocumentCompletion(ctx context.Context, conn *jsonrp
 		return nil, err
 	}
 
-	items, err := h.project.Complete(ctx, documentURIToURI(params.TextDocument.URI), params.Position)
+	converter := h.positionConverter(params.TextDocument.URI)
+	position := converter.toByte(params.Position)
+	items, err := h.projectOf(params.TextDocument.URI).Complete(ctx, documentURIToURI(params.TextDocument.URI), position)
 	if err != nil {
 		return nil, err
 	}
 
-	completionItems := make([]lsp.CompletionItem, len(items))
-	for i, item := range items {
-		completionItems[i] = item.ToLspCompletionItem(params.Position, h.clientSupportSnippets())
+	completionItems := make([]lsp.CompletionItem, 0, len(items))
+	for _, item := range items {
+		// the snippet cannot be inserted as it is
+		if item.Kind == lsp.CIKSnippet && !h.clientSupportSnippets() {
+			continue
+		}
+		lspItem := item.ToLspCompletionItem(position, h.clientSupportSnippets())
+		if lspItem.TextEdit != nil {
+			lspItem.TextEdit.Range = converter.fromByteRange(lspItem.TextEdit.Range)
+		}
+		if item.Resolve != nil {
+			lspItem.Data = completionItemData{URI: params.TextDocument.URI, ResolveData: *item.Resolve}
+		}
+		completionItems = append(completionItems, lspItem)
 	}
 
 	return completionItems, nil
 }
 
+// completionItemData is sent as the data of the completion item, and sent back by completionItem/resolve.
+type completionItemData struct {
+	URI lsp.DocumentURI `json:"uri"`
+	completion.ResolveData
+}
+
+func (h *Handler) handleCompletionItemResolve(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var item lsp.CompletionItem
+	if err := json.Unmarshal(*req.Params, &item); err != nil {
+		return nil, err
+	}
+	if item.Data == nil {
+		return item, nil
+	}
+
+	b, err := json.Marshal(item.Data)
+	if err != nil {
+		return nil, err
+	}
+	var data completionItemData
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+
+	documentation, err := h.projectOf(data.URI).ResolveCompletionItem(ctx, data.ResolveData)
+	if err != nil {
+		// the item is still usable without the documentation
+		h.logger.Debugf("failed to resolve completion item: %v", err)
+		return item, nil
+	}
+	item.Documentation = documentation
+	return item, nil
+}
+
 func (h *Handler) clientSupportSnippets() bool {
 	return h.initializeParams.Capabilities.TextDocument.Completion.CompletionItem.SnippetSupport
 }
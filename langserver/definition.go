@@ -0,0 +1,30 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *Handler) handleTextDocumentDefinition(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.TextDocumentPositionParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	position := h.positionConverter(params.TextDocument.URI).toByte(params.Position)
+	locations, err := h.projectOf(params.TextDocument.URI).LookupDefinition(ctx, documentURIToURI(params.TextDocument.URI), position)
+	if err != nil {
+		return nil, err
+	}
+	for i, l := range locations {
+		locations[i].Range = h.positionConverter(l.URI).fromByteRange(l.Range)
+	}
+	return locations, nil
+}
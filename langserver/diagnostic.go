@@ -7,17 +7,26 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"time"
 
+	"github.com/kitagry/bqls/langserver/internal/bigquery"
 	"github.com/kitagry/bqls/langserver/internal/lsp"
 	"github.com/kitagry/bqls/langserver/internal/source/file"
 )
 
+// diagnosticsDebounce is the delay before diagnosing a document.
+// While the user is typing, only the last change is diagnosed.
+const diagnosticsDebounce = 200 * time.Millisecond
+
 func (h *Handler) scheduleDiagnostics() {
 	running := make(map[lsp.DocumentURI]context.CancelFunc)
 
 	for {
 		uri, ok := <-h.diagnosticRequest
 		if !ok {
+			for _, cancel := range running {
+				cancel()
+			}
 			break
 		}
 
@@ -35,9 +44,16 @@ func (h *Handler) scheduleDiagnostics() {
 					h.logger.Errorf("panic in diagnostics: %v", err)
 				}
 			}()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(diagnosticsDebounce):
+			}
+
 			diagnostics, err := h.diagnose(ctx, uri)
 			if err != nil {
-				h.logger.Println(err)
+				h.logger.Errorf("failed to diagnose %s: %v", uri, err)
 				return
 			}
 
@@ -57,6 +73,9 @@ func (h *Handler) scheduleDryRun() {
 	for {
 		uri, ok := <-h.dryrunRequest
 		if !ok {
+			for _, cancel := range running {
+				cancel()
+			}
 			break
 		}
 
@@ -77,7 +96,7 @@ func (h *Handler) scheduleDryRun() {
 
 			diagnostics, totalProcessed, err := h.dryrun(ctx, uri)
 			if err != nil {
-				if errors.Is(err, context.Canceled) {
+				if errors.Is(err, context.Canceled) || errors.Is(err, bigquery.ErrOffline) {
 					return
 				}
 				sendErr := h.showMessage(ctx, lsp.MTError, errors.Unwrap(err).Error())
@@ -102,9 +121,15 @@ func (h *Handler) scheduleDryRun() {
 }
 
 func (h *Handler) publishDiagnostics(ctx context.Context, uri lsp.DocumentURI, diagnostics []lsp.Diagnostic) error {
+	converter := h.positionConverter(uri)
+	converted := make([]lsp.Diagnostic, len(diagnostics))
+	for i, d := range diagnostics {
+		d.Range = converter.fromByteRange(d.Range)
+		converted[i] = d
+	}
 	return h.conn.Notify(ctx, "textDocument/publishDiagnostics", lsp.PublishDiagnosticsParams{
 		URI:         uri,
-		Diagnostics: diagnostics,
+		Diagnostics: converted,
 	})
 }
 
@@ -118,7 +143,7 @@ func (h *Handler) showMessage(ctx context.Context, level lsp.MessageType, messag
 func (h *Handler) diagnose(ctx context.Context, uri lsp.DocumentURI) (map[lsp.DocumentURI][]lsp.Diagnostic, error) {
 	result := make(map[lsp.DocumentURI][]lsp.Diagnostic)
 
-	pathToErrs := h.project.GetErrors(documentURIToURI(uri))
+	pathToErrs := h.projectOf(uri).GetErrors(ctx, documentURIToURI(uri))
 	for path, errs := range pathToErrs {
 		uri := uriToDocumentURI(path)
 		result[uri] = convertErrorsToDiagnostics(errs)
@@ -128,7 +153,7 @@ func (h *Handler) diagnose(ctx context.Context, uri lsp.DocumentURI) (map[lsp.Do
 }
 
 func (h *Handler) dryrun(ctx context.Context, uri lsp.DocumentURI) (errs map[lsp.DocumentURI][]lsp.Diagnostic, totalProcessed string, err error) {
-	js, err := h.project.Dryrun(ctx, documentURIToURI(uri))
+	js, err := h.projectOf(uri).Dryrun(ctx, documentURIToURI(uri))
 	if err != nil {
 		return nil, "", err
 	}
@@ -139,7 +164,7 @@ func (h *Handler) dryrun(ctx context.Context, uri lsp.DocumentURI) (errs map[lsp
 
 	errs, _ = h.diagnose(ctx, uri)
 	for _, err := range js.Errors {
-		h.logger.Info("error location", err.Location)
+		h.logger.WithField("location", err.Location).Debugf("dry run error: %s", err.Message)
 	}
 
 	totalProcessed = bytesConvert(js.Statistics.TotalBytesProcessed)
@@ -175,6 +200,7 @@ func convertErrorsToDiagnostics(errs []file.Error) []lsp.Diagnostic {
 			},
 			Message:  err.Msg,
 			Severity: cmp.Or(err.Severity, lsp.Error),
+			Code:     err.Code,
 		}
 	}
 	return result
@@ -0,0 +1,30 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *Handler) handleTextDocumentDocumentHighlight(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.TextDocumentPositionParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	converter := h.positionConverter(params.TextDocument.URI)
+	highlights, err := h.projectOf(params.TextDocument.URI).DocumentHighlight(documentURIToURI(params.TextDocument.URI), converter.toByte(params.Position))
+	if err != nil {
+		return nil, err
+	}
+	for i, hl := range highlights {
+		highlights[i].Range = converter.fromByteRange(hl.Range)
+	}
+	return highlights, nil
+}
@@ -0,0 +1,30 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *Handler) handleTextDocumentDocumentLink(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.DocumentLinkParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	links, err := h.projectOf(params.TextDocument.URI).DocumentLinks(documentURIToURI(params.TextDocument.URI))
+	if err != nil {
+		return nil, err
+	}
+	converter := h.positionConverter(params.TextDocument.URI)
+	for i, l := range links {
+		links[i].Range = converter.fromByteRange(l.Range)
+	}
+	return links, nil
+}
@@ -0,0 +1,88 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+type DryRunOption struct {
+	ProjectID        string
+	BillingProjectID string
+	Location         string
+	// Files are the paths of the dry-run files.
+	Files   []string
+	IsDebug bool
+}
+
+// DryRunStatement is the estimated cost of a statement. Line and Column are 1-based.
+type DryRunStatement struct {
+	Path                string `json:"path"`
+	Line                int    `json:"line"`
+	Column              int    `json:"column"`
+	Query               string `json:"query"`
+	TotalBytesProcessed int64  `json:"totalBytesProcessed"`
+	Error               string `json:"error,omitempty"`
+}
+
+// DryRun dry-runs each statement of the files and writes the estimated bytes processed into w as JSON.
+// It returns the number of the statements which failed the dry run.
+func DryRun(ctx context.Context, opt DryRunOption, w io.Writer) (int, error) {
+	logger := logrus.New()
+	logger.Out = os.Stderr
+	if opt.IsDebug {
+		logger.SetLevel(logrus.DebugLevel)
+	}
+
+	p, err := source.NewProject(ctx, source.Config{
+		ProjectID:           opt.ProjectID,
+		BillingProjectID:    opt.BillingProjectID,
+		Location:            opt.Location,
+		DisableQueryHistory: true,
+	}, logger)
+	if err != nil {
+		return 0, err
+	}
+	defer p.Close()
+
+	failed := 0
+	result := make([]DryRunStatement, 0)
+	for _, path := range opt.Files {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+
+		estimates, err := p.EstimateStatements(ctx, path, string(b))
+		if err != nil {
+			return 0, err
+		}
+
+		for _, e := range estimates {
+			s := DryRunStatement{
+				Path:                path,
+				Line:                e.Position.Line + 1,
+				Column:              e.Position.Character + 1,
+				Query:               e.Query,
+				TotalBytesProcessed: e.TotalBytesProcessed,
+			}
+			if e.Err != nil {
+				s.Error = e.Err.Error()
+				failed++
+			}
+			result = append(result, s)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return 0, fmt.Errorf("failed to write the result: %w", err)
+	}
+	return failed, nil
+}
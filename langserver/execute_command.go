@@ -5,16 +5,57 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/kitagry/bqls/langserver/internal/bigquery"
 	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
 	"github.com/sourcegraph/jsonrpc2"
 )
 
 const (
-	CommandExecuteQuery     = "executeQuery"
-	CommandListDatasets     = "listDatasets"
-	CommandListTables       = "listTables"
-	CommandListJobHistories = "listJobHistories"
+	CommandExecuteQuery           = "executeQuery"
+	CommandListDatasets           = "listDatasets"
+	CommandListTables             = "listTables"
+	CommandListJobHistories       = "listJobHistories"
+	CommandShowLineage            = "bqls.showLineage"
+	CommandExportSchemas          = "bqls.exportSchemas"
+	CommandPreviewTable           = "bqls.previewTable"
+	CommandFetchMoreResults       = "bqls.fetchMoreResults"
+	CommandSaveResults            = "bqls.saveResults"
+	CommandQueryHistory           = "bqls.queryHistory"
+	CommandRerunQuery             = "bqls.rerunQuery"
+	CommandExtractSubqueryToCTE   = "bqls.extractSubqueryToCTE"
+	CommandInlineCTE              = "bqls.inlineCTE"
+	CommandFixUngroupedColumn     = "bqls.fixUngroupedColumn"
+	CommandConvertLegacySQL       = "bqls.convertLegacySQL"
+	CommandExpandStar             = "bqls.expandStar"
+	CommandCastExpression         = "bqls.castExpression"
+	CommandAddDistinctAlias       = "bqls.addDistinctAlias"
+	CommandShowOutputSchema       = "bqls.showOutputSchema"
+	CommandExplainQuery           = "bqls.explainQuery"
+	CommandValidateScheduledQuery = "bqls.validateScheduledQuery"
+	CommandUpdateTable            = "bqls.updateTable"
+	CommandFindColumn             = "bqls.findColumn"
+	CommandAddJoinCondition       = "bqls.addJoinCondition"
+	CommandUnnestArrayColumn      = "bqls.unnestArrayColumn"
+	CommandWrapJSONExpression     = "bqls.wrapJSONExpression"
+	CommandReplaceOrdinals        = "bqls.replaceOrdinals"
+	CommandEvaluateExpression     = "bqls.evaluateExpression"
+	CommandGenerateTestScaffold   = "bqls.generateTestScaffold"
+	CommandExecuteWithFixtures    = "bqls.executeWithFixtures"
+	CommandDiffQueries            = "bqls.diffQueries"
+)
+
+var (
+	ungroupedColumnRegex   = regexp.MustCompile(`references (?:column )?(\S+) which is neither grouped nor aggregated`)
+	implicitCoercionRegex  = regexp.MustCompile(`is implicitly coerced from \S+ to (\S+) in the comparison`)
+	mismatchedOperandRegex = regexp.MustCompile(`No matching signature for operator (\S+) for argument types: (\w+), \w+`)
 )
 
 func (h *Handler) handleTextDocumentCodeAction(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
@@ -33,6 +74,21 @@ func (h *Handler) handleTextDocumentCodeAction(ctx context.Context, conn *jsonrp
 			Command:   CommandExecuteQuery,
 			Arguments: []any{params.TextDocument.URI},
 		},
+		{
+			Title:     "Show Column Lineage",
+			Command:   CommandShowLineage,
+			Arguments: []any{params.TextDocument.URI, params.Range.Start.Line, params.Range.Start.Character},
+		},
+		{
+			Title:     "Show Output Schema",
+			Command:   CommandShowOutputSchema,
+			Arguments: []any{params.TextDocument.URI, params.Range.Start.Line, params.Range.Start.Character},
+		},
+		{
+			Title:     "Generate Test Scaffold",
+			Command:   CommandGenerateTestScaffold,
+			Arguments: []any{params.TextDocument.URI, params.Range.Start.Line, params.Range.Start.Character},
+		},
 		{
 			Title:   "List Personal Job Histories",
 			Command: CommandListJobHistories,
@@ -43,9 +99,179 @@ func (h *Handler) handleTextDocumentCodeAction(ctx context.Context, conn *jsonrp
 			Arguments: []any{"--all-user"},
 		},
 	}
+
+	// The analysis uses the byte offsets, while the arguments of the commands are in the encoding of the client.
+	converter := h.positionConverter(params.TextDocument.URI)
+	rng := converter.toByteRange(params.Range)
+	path := documentURIToURI(params.TextDocument.URI)
+	if h.projectOf(params.TextDocument.URI).IsSingleQuery(path) {
+		commands = append(commands, lsp.Command{
+			Title:     "Explain Query Plan",
+			Command:   CommandExplainQuery,
+			Arguments: []any{params.TextDocument.URI},
+		})
+	}
+	// The refactorings are checked with the syntax only, and their edits are built when the commands are executed.
+	refactorings := h.projectOf(params.TextDocument.URI).AvailableRefactorings(path, rng)
+	if refactorings.ExtractSubqueryToCTE {
+		// the client prompts the name of the WITH query and passes it with --name
+		commands = append(commands, lsp.Command{
+			Title:     "Extract Subquery to CTE",
+			Command:   CommandExtractSubqueryToCTE,
+			Arguments: []any{params.TextDocument.URI, params.Range.Start.Line, params.Range.Start.Character, params.Range.End.Line, params.Range.End.Character},
+		})
+	}
+	if refactorings.InlineCTE {
+		commands = append(commands, lsp.Command{
+			Title:     "Inline CTE",
+			Command:   CommandInlineCTE,
+			Arguments: []any{params.TextDocument.URI, params.Range.Start.Line, params.Range.Start.Character},
+		})
+	}
+	if refactorings.AddJoinCondition {
+		commands = append(commands, lsp.Command{
+			Title:     "Add JOIN condition",
+			Command:   CommandAddJoinCondition,
+			Arguments: []any{params.TextDocument.URI, params.Range.Start.Line, params.Range.Start.Character},
+		})
+	}
+	if refactorings.ReplaceOrdinals {
+		commands = append(commands, lsp.Command{
+			Title:     "Replace ordinals with expressions",
+			Command:   CommandReplaceOrdinals,
+			Arguments: []any{params.TextDocument.URI, params.Range.Start.Line, params.Range.Start.Character},
+		})
+	}
+	if refactorings.ReplaceWithOrdinals {
+		commands = append(commands, lsp.Command{
+			Title:     "Replace expressions with ordinals",
+			Command:   CommandReplaceOrdinals,
+			Arguments: []any{"--to-ordinals", params.TextDocument.URI, params.Range.Start.Line, params.Range.Start.Character},
+		})
+	}
+	if refactorings.EvaluateExpression {
+		commands = append(commands, lsp.Command{
+			Title:     "Evaluate expression",
+			Command:   CommandEvaluateExpression,
+			Arguments: []any{params.TextDocument.URI, params.Range.Start.Line, params.Range.Start.Character, params.Range.End.Line, params.Range.End.Character},
+		})
+	}
+	if source.HasFixtureFile(path) {
+		commands = append(commands, lsp.Command{
+			Title:     "Execute Query with Fixtures",
+			Command:   CommandExecuteWithFixtures,
+			Arguments: []any{params.TextDocument.URI, params.Range.Start.Line, params.Range.Start.Character},
+		})
+	}
+	if refactorings.ConvertLegacySQL {
+		commands = append(commands, lsp.Command{
+			Title:     "Convert Legacy SQL to Standard SQL",
+			Command:   CommandConvertLegacySQL,
+			Arguments: []any{params.TextDocument.URI},
+		})
+	}
+
+	for _, d := range params.Context.Diagnostics {
+		if d.Message == file.SelectStarMessage {
+			commands = append(commands, lsp.Command{
+				Title:     "Expand *",
+				Command:   CommandExpandStar,
+				Arguments: []any{params.TextDocument.URI, d.Range.Start.Line, d.Range.Start.Character},
+			})
+			continue
+		}
+
+		if d.Code == file.ArrayScalarCode {
+			commands = append(commands, lsp.Command{
+				Title:     "UNNEST ARRAY column",
+				Command:   CommandUnnestArrayColumn,
+				Arguments: []any{params.TextDocument.URI, d.Range.Start.Line, d.Range.Start.Character},
+			})
+			continue
+		}
+
+		if strings.HasPrefix(d.Message, file.DuplicateColumnMessage) {
+			commands = append(commands, lsp.Command{
+				Title:     "Add distinct alias",
+				Command:   CommandAddDistinctAlias,
+				Arguments: []any{params.TextDocument.URI, d.Range.Start.Line, d.Range.Start.Character},
+			})
+			continue
+		}
+
+		if m := implicitCoercionRegex.FindStringSubmatch(d.Message); m != nil {
+			commands = append(commands, castCommands(params.TextDocument.URI, d.Range, m[1])...)
+			continue
+		}
+
+		if m := mismatchedOperandRegex.FindStringSubmatch(d.Message); m != nil && d.Code == file.JSONComparisonCode {
+			// convert the JSON operand instead of casting the other one, because JSON can't be compared
+			if lhs, rhs, ok := h.projectOf(params.TextDocument.URI).ComparisonOperands(path, converter.toByte(d.Range.Start), m[1]); ok {
+				operand := rhs
+				if m[2] == "JSON" {
+					operand = lhs
+				}
+				commands = append(commands, jsonCommands(params.TextDocument.URI, converter.fromByteRange(operand))...)
+			}
+			continue
+		}
+
+		if m := mismatchedOperandRegex.FindStringSubmatch(d.Message); m != nil {
+			// cast the right operand to the type of the left one
+			if rhs, ok := h.projectOf(params.TextDocument.URI).ComparisonRightOperand(path, converter.toByte(d.Range.Start), m[1]); ok {
+				commands = append(commands, castCommands(params.TextDocument.URI, converter.fromByteRange(rhs), m[2])...)
+			}
+			continue
+		}
+
+		m := ungroupedColumnRegex.FindStringSubmatch(d.Message)
+		if m == nil {
+			continue
+		}
+		commands = append(commands,
+			lsp.Command{
+				Title:     fmt.Sprintf("Add %s to GROUP BY", m[1]),
+				Command:   CommandFixUngroupedColumn,
+				Arguments: []any{params.TextDocument.URI, d.Range.Start.Line, d.Range.Start.Character},
+			},
+			lsp.Command{
+				Title:     fmt.Sprintf("Wrap %s with ANY_VALUE", m[1]),
+				Command:   CommandFixUngroupedColumn,
+				Arguments: []any{"--any-value", params.TextDocument.URI, d.Range.Start.Line, d.Range.Start.Character},
+			},
+		)
+	}
 	return commands, nil
 }
 
+func castCommands(uri lsp.DocumentURI, rng lsp.Range, typeName string) []lsp.Command {
+	arguments := []any{uri, rng.Start.Line, rng.Start.Character, rng.End.Line, rng.End.Character, typeName}
+	return []lsp.Command{
+		{
+			Title:     fmt.Sprintf("CAST to %s", typeName),
+			Command:   CommandCastExpression,
+			Arguments: arguments,
+		},
+		{
+			Title:     fmt.Sprintf("SAFE_CAST to %s", typeName),
+			Command:   CommandCastExpression,
+			Arguments: append([]any{"--safe"}, arguments...),
+		},
+	}
+}
+
+func jsonCommands(uri lsp.DocumentURI, rng lsp.Range) []lsp.Command {
+	result := make([]lsp.Command, 0, 2)
+	for _, function := range []string{"JSON_VALUE", "JSON_QUERY"} {
+		result = append(result, lsp.Command{
+			Title:     fmt.Sprintf("Wrap with %s", function),
+			Command:   CommandWrapJSONExpression,
+			Arguments: []any{uri, rng.Start.Line, rng.Start.Character, rng.End.Line, rng.End.Character, function},
+		})
+	}
+	return result
+}
+
 func (h *Handler) handleWorkspaceExecuteCommand(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
 	if req.Params == nil {
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
@@ -65,19 +291,82 @@ func (h *Handler) handleWorkspaceExecuteCommand(ctx context.Context, conn *jsonr
 		return h.commandListTables(ctx, params)
 	case CommandListJobHistories:
 		return h.commandListJobHistories(ctx, params)
+	case CommandShowLineage:
+		return h.commandShowLineage(ctx, params)
+	case CommandExportSchemas:
+		return h.commandExportSchemas(ctx, params)
+	case CommandPreviewTable:
+		return h.commandPreviewTable(ctx, params)
+	case CommandFetchMoreResults:
+		return h.commandFetchMoreResults(ctx, params)
+	case CommandSaveResults:
+		return h.commandSaveResults(ctx, params)
+	case CommandQueryHistory:
+		return h.commandQueryHistory(ctx, params)
+	case CommandRerunQuery:
+		return h.commandRerunQuery(ctx, params)
+	case CommandExtractSubqueryToCTE:
+		return h.commandExtractSubqueryToCTE(ctx, params)
+	case CommandInlineCTE:
+		return h.commandInlineCTE(ctx, params)
+	case CommandFixUngroupedColumn:
+		return h.commandFixUngroupedColumn(ctx, params)
+	case CommandConvertLegacySQL:
+		return h.commandConvertLegacySQL(ctx, params)
+	case CommandExpandStar:
+		return h.commandExpandStar(ctx, params)
+	case CommandCastExpression:
+		return h.commandCastExpression(ctx, params)
+	case CommandAddDistinctAlias:
+		return h.commandAddDistinctAlias(ctx, params)
+	case CommandShowOutputSchema:
+		return h.commandShowOutputSchema(ctx, params)
+	case CommandExplainQuery:
+		return h.commandExplainQuery(ctx, params)
+	case CommandValidateScheduledQuery:
+		return h.commandValidateScheduledQuery(ctx, params)
+	case CommandUpdateTable:
+		return h.commandUpdateTable(ctx, params)
+	case CommandFindColumn:
+		return h.commandFindColumn(ctx, params)
+	case CommandAddJoinCondition:
+		return h.commandAddJoinCondition(ctx, params)
+	case CommandUnnestArrayColumn:
+		return h.commandUnnestArrayColumn(ctx, params)
+	case CommandWrapJSONExpression:
+		return h.commandWrapJSONExpression(ctx, params)
+	case CommandReplaceOrdinals:
+		return h.commandReplaceOrdinals(ctx, params)
+	case CommandEvaluateExpression:
+		return h.commandEvaluateExpression(ctx, params)
+	case CommandGenerateTestScaffold:
+		return h.commandGenerateTestScaffold(ctx, params)
+	case CommandExecuteWithFixtures:
+		return h.commandExecuteWithFixtures(ctx, params)
+	case CommandDiffQueries:
+		return h.commandDiffQueries(ctx, params)
 	default:
 		return nil, fmt.Errorf("unknown command: %s", params.Command)
 	}
 }
 
 func (h *Handler) commandExecuteQuery(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.ExecuteQueryResult, error) {
-	if len(params.Arguments) != 1 {
-		return nil, fmt.Errorf("file uri arguments is not provided")
+	f := flag.NewFlagSet("executeQuery", flag.ContinueOnError)
+	force := f.Bool("force", false, "execute the query even if the estimated bytes processed exceed max_bytes_processed")
+	destinationTable := f.String("destination", "", "write the result into the table like project.dataset.table")
+	writeDisposition := f.String("write-disposition", "", "WRITE_TRUNCATE, WRITE_APPEND or WRITE_EMPTY. It is used with --destination")
+	createDisposition := f.String("create-disposition", "", "CREATE_IF_NEEDED or CREATE_NEVER. It is used with --destination")
+	noSample := f.Bool("no-sample", false, "execute the query without exploration_sample")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
 	}
-	uri, ok := params.Arguments[0].(string)
-	if !ok {
-		return nil, fmt.Errorf("arguments should be string, but got %T", params.Arguments[0])
+
+	if f.NArg() != 1 {
+		return nil, fmt.Errorf("file uri arguments is not provided")
 	}
+	uri := f.Arg(0)
 
 	path := documentURIToURI(lsp.DocumentURI(uri))
 
@@ -87,14 +376,27 @@ func (h *Handler) commandExecuteQuery(ctx context.Context, params lsp.ExecuteCom
 		Message: "Runing query...",
 	})
 	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
-	job, err := h.project.Run(ctx, path)
-	if err != nil {
-		return nil, err
+	project := h.projectOf(lsp.DocumentURI(uri))
+	var job bigquery.BigqueryJob
+	if *destinationTable != "" {
+		destination, err := bigquery.NewDestination(*destinationTable, project.BigQueryProjectID, *writeDisposition, *createDisposition)
+		if err != nil {
+			return nil, err
+		}
+		job, err = project.RunWithDestination(ctx, path, *force, destination)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		job, err = project.Run(ctx, path, *force, !*noSample)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &lsp.ExecuteQueryResult{
 		TextDocument: lsp.TextDocumentIdentifier{
-			URI: lsp.NewJobVirtualTextDocumentURI(h.project.BigQueryProjectID, job.ID()),
+			URI: h.setLastJob(project, job.ID()),
 		},
 	}, nil
 }
@@ -116,7 +418,7 @@ func (h *Handler) commandListDatasets(ctx context.Context, params lsp.ExecuteCom
 	})
 	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
 
-	datasets, err := h.project.ListDatasets(ctx, projectID)
+	datasets, err := h.projectOfTable(projectID).ListDatasets(ctx, projectID)
 	if err != nil {
 		return nil, err
 	}
@@ -162,7 +464,7 @@ func (h *Handler) commandListTables(ctx context.Context, params lsp.ExecuteComma
 	})
 	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
 
-	tables, err := h.project.ListTables(ctx, projectID, datasetID)
+	tables, err := h.projectOfTable(projectID).ListTables(ctx, projectID, datasetID)
 	if err != nil {
 		return nil, err
 	}
@@ -177,22 +479,794 @@ func (h *Handler) commandListTables(ctx context.Context, params lsp.ExecuteComma
 	}, nil
 }
 
+func (h *Handler) commandFindColumn(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.FindColumnResult, error) {
+	f := flag.NewFlagSet("findColumn", flag.ContinueOnError)
+	datasets := make([]string, 0)
+	f.Func("dataset", "the dataset like project.dataset or dataset. It can be specified multiple times. When it is not specified, all datasets of the default project are searched", func(s string) error {
+		datasets = append(datasets, s)
+		return nil
+	})
+	uri := f.String("uri", "", "the document or the workspace folder whose project searches the tables. The project of the root is used by default")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	if f.NArg() != 1 {
+		return nil, fmt.Errorf("column name argument is required")
+	}
+
+	workDoneToken := lsp.ProgressToken("find_column")
+	h.workDoneProgressBegin(ctx, workDoneToken, lsp.WorkDoneProgressBegin{
+		Title:   "Find column",
+		Message: "Searching tables...",
+	})
+	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
+
+	columns, err := h.projectOfURIFlag(*uri).FindColumn(ctx, f.Arg(0), datasets)
+	if err != nil {
+		return nil, err
+	}
+	return &lsp.FindColumnResult{Columns: columns}, nil
+}
+
 func (h *Handler) commandListJobHistories(ctx context.Context, params lsp.ExecuteCommandParams) (any, error) {
 	f := flag.NewFlagSet("listJobHistory", flag.ContinueOnError)
 	allUser := f.Bool("all-user", false, "list personal job histories")
+	uri := f.String("uri", "", "the document or the workspace folder whose billing project is listed. The project of the root is used by default")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	project := h.projectOfURIFlag(*uri)
+	jobs, err := project.ListJobs(ctx, project.BillingProjectID, *allUser)
+	if err != nil {
+		return nil, err
+	}
+	return lsp.ListJobHistoryResult{Jobs: jobs}, nil
+}
+
+func (h *Handler) commandShowLineage(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.ShowLineageResult, error) {
+	f := flag.NewFlagSet("showLineage", flag.ContinueOnError)
+	expandViews := f.Bool("expand-views", false, "analyze view queries inline and trace the lineage into them")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	uri, position, err := h.documentPositionArgs(f.Args())
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := h.projectOf(uri).ColumnLineage(ctx, documentURIToURI(uri), position, *expandViews)
+	if err != nil {
+		return nil, err
+	}
+	return &lsp.ShowLineageResult{Contents: contents}, nil
+}
+
+func (h *Handler) commandShowOutputSchema(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.ShowOutputSchemaResult, error) {
+	documentURI, position, err := h.documentPositionArgs(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := h.projectOf(documentURI).OutputSchema(documentURIToURI(documentURI), position)
+	if err != nil {
+		return nil, err
+	}
+	return &lsp.ShowOutputSchemaResult{Contents: contents}, nil
+}
+
+func (h *Handler) commandExplainQuery(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.ExplainQueryResult, error) {
+	jobURI := h.getLastJobURI()
+	if len(params.Arguments) > 0 {
+		jobURI = lsp.DocumentURI(fmt.Sprint(params.Arguments[0]))
+	}
+	if jobURI == "" {
+		return nil, fmt.Errorf("no query has been executed")
+	}
+
+	workDoneToken := lsp.ProgressToken("explain_query")
+	h.workDoneProgressBegin(ctx, workDoneToken, lsp.WorkDoneProgressBegin{
+		Title:   "Explain query",
+		Message: "Waiting for the query plan...",
+	})
+	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
+
+	if !strings.HasPrefix(string(jobURI), "bqls://") {
+		// the document is dry-run, because the query plan is only returned by the executed job
+		contents, err := h.projectOf(jobURI).DryRunPlan(ctx, documentURIToURI(jobURI))
+		if err != nil {
+			return nil, err
+		}
+		return &lsp.ExplainQueryResult{Contents: contents}, nil
+	}
+
+	virtualTextDocument, err := ParseVirtualTextDocument(jobURI)
+	if err != nil {
+		return nil, err
+	}
+	if virtualTextDocument.JobID == "" {
+		return nil, fmt.Errorf("%s is not a job", jobURI)
+	}
+
+	contents, err := h.projectOfJob(virtualTextDocument.ProjectID, virtualTextDocument.JobID).QueryPlan(ctx, virtualTextDocument.ProjectID, virtualTextDocument.JobID)
+	if err != nil {
+		return nil, err
+	}
+	return &lsp.ExplainQueryResult{Contents: contents}, nil
+}
+
+func (h *Handler) commandValidateScheduledQuery(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.ValidateScheduledQueryResult, error) {
+	f := flag.NewFlagSet("validateScheduledQuery", flag.ContinueOnError)
+	destination := f.Bool("destination", false, "the scheduled query writes the result into the destination table")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	if f.NArg() != 1 {
+		return nil, fmt.Errorf("file uri arguments is not provided")
+	}
+	documentURI := lsp.DocumentURI(f.Arg(0))
+	errs, err := h.projectOf(documentURI).ValidateScheduledQuery(documentURIToURI(documentURI), *destination)
+	if err != nil {
+		return nil, err
+	}
+	return &lsp.ValidateScheduledQueryResult{Diagnostics: convertErrorsToDiagnostics(errs)}, nil
+}
+
+func (h *Handler) commandExportSchemas(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.ExportSchemasResult, error) {
+	outputDir := filepath.Join(h.initializeParams.RootPath, defaultSchemaDir)
+	if len(params.Arguments) > 0 {
+		dir, ok := params.Arguments[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("arguments should be string, but got %T", params.Arguments[0])
+		}
+		outputDir = dir
+		if !filepath.IsAbs(outputDir) {
+			outputDir = filepath.Join(h.initializeParams.RootPath, outputDir)
+		}
+	}
+
+	workDoneToken := lsp.ProgressToken("export_schemas")
+	h.workDoneProgressBegin(ctx, workDoneToken, lsp.WorkDoneProgressBegin{
+		Title:   "Export schemas",
+		Message: "Exporting schemas...",
+	})
+	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
+
+	files, err := h.projectOf(uriToDocumentURI(outputDir)).ExportSchemas(ctx, outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lsp.ExportSchemasResult{Files: files}, nil
+}
+
+func (h *Handler) commandPreviewTable(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.PreviewTableResult, error) {
+	f := flag.NewFlagSet("previewTable", flag.ContinueOnError)
+	rows := f.Int("rows", source.DefaultPreviewRows, "the number of rows")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	if f.NArg() != 1 {
+		return nil, fmt.Errorf("table path argument is required")
+	}
+
+	contents, err := h.projectOfTablePath(f.Arg(0)).PreviewTable(ctx, f.Arg(0), *rows)
+	if err != nil {
+		return nil, err
+	}
+	return &lsp.PreviewTableResult{Contents: contents}, nil
+}
+
+func (h *Handler) commandUpdateTable(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.UpdateTableResult, error) {
+	f := flag.NewFlagSet("updateTable", flag.ContinueOnError)
+	description := f.String("description", "", "the description of the table")
+	expiration := f.String("expiration", "", "the expiration time of the table like 2024-12-31T00:00:00Z or 2024-12-31. never removes the expiration")
+	labels := make(map[string]string)
+	f.Func("label", "the label like key=value. key= deletes the label. It can be specified multiple times", func(s string) error {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("label should be key=value, but got %s", s)
+		}
+		labels[k] = v
+		return nil
+	})
+	columnDescriptions := make(map[string]string)
+	f.Func("column-description", "the description of the column like column=description. It can be specified multiple times", func(s string) error {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("column-description should be column=description, but got %s", s)
+		}
+		columnDescriptions[k] = v
+		return nil
+	})
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	if f.NArg() != 1 {
+		return nil, fmt.Errorf("table path argument is required")
+	}
+
+	update := source.TableUpdate{
+		Labels:             labels,
+		ColumnDescriptions: columnDescriptions,
+	}
+	// the empty description is valid to clear the description, so the flag is checked whether it is set.
+	f.Visit(func(fl *flag.Flag) {
+		if fl.Name == "description" {
+			update.Description = description
+		}
+	})
+	if *expiration != "" {
+		t, err := parseExpiration(*expiration)
+		if err != nil {
+			return nil, err
+		}
+		update.ExpirationTime = &t
+	}
+
+	contents, err := h.projectOfTablePath(f.Arg(0)).UpdateTable(ctx, f.Arg(0), update)
+	if err != nil {
+		return nil, err
+	}
+	return &lsp.UpdateTableResult{Contents: contents}, nil
+}
+
+// parseExpiration parses the expiration time of the table. It returns the zero time for never.
+func parseExpiration(s string) (time.Time, error) {
+	if strings.EqualFold(s, "never") {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expiration should be RFC3339, YYYY-MM-DD or never, but got %s", s)
+}
+
+func (h *Handler) commandFetchMoreResults(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.QueryResult, error) {
+	if len(params.Arguments) != 2 {
+		return nil, fmt.Errorf("virtual text document uri and page token arguments are required")
+	}
+	uri, ok := params.Arguments[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("arguments should be string, but got %T", params.Arguments[0])
+	}
+	pageToken, ok := params.Arguments[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("arguments should be string, but got %T", params.Arguments[1])
+	}
+
+	virtualTextDocument, err := ParseVirtualTextDocument(lsp.DocumentURI(uri))
+	if err != nil {
+		return nil, err
+	}
+
+	workDoneToken := lsp.ProgressToken("fetch_more_results")
+	h.workDoneProgressBegin(ctx, workDoneToken, lsp.WorkDoneProgressBegin{
+		Title:   "Fetch more results",
+		Message: "Fetching the next page...",
+	})
+	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
+
+	var result lsp.QueryResult
+	if virtualTextDocument.TableID != "" {
+		result, err = h.projectOfTable(virtualTextDocument.ProjectID).GetTableRecordPage(ctx, virtualTextDocument.ProjectID, virtualTextDocument.DatasetID, virtualTextDocument.TableID, pageToken)
+	} else {
+		result, err = h.projectOfJob(virtualTextDocument.ProjectID, virtualTextDocument.JobID).GetJobResultPage(ctx, virtualTextDocument.ProjectID, virtualTextDocument.JobID, pageToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (h *Handler) commandSaveResults(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.SaveResultsResult, error) {
+	f := flag.NewFlagSet("saveResults", flag.ContinueOnError)
+	format := f.String("format", "", "csv or json. When it is empty, the format is inferred from the extension of the path")
+	documentURI := f.String("uri", "", "run the query of the document instead of using the result of the last executed query")
+	force := f.Bool("force", false, "execute the query of --uri even if the estimated bytes processed exceed max_bytes_processed")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	if f.NArg() != 1 {
+		return nil, fmt.Errorf("output path argument is required")
+	}
+	path := f.Arg(0)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(h.initializeParams.RootPath, path)
+	}
+
+	resultFormat := source.ResultFormat(*format)
+	if resultFormat == "" {
+		resultFormat, err = source.ResultFormatFromPath(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	workDoneToken := lsp.ProgressToken("save_results")
+	h.workDoneProgressBegin(ctx, workDoneToken, lsp.WorkDoneProgressBegin{
+		Title:   "Save results",
+		Message: "Saving the query result...",
+	})
+	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
+
+	jobURI := h.getLastJobURI()
+	if *documentURI != "" {
+		// only the query is run, so that saving the results never changes any data
+		if !h.projectOf(lsp.DocumentURI(*documentURI)).IsSingleQuery(documentURIToURI(lsp.DocumentURI(*documentURI))) {
+			return nil, fmt.Errorf("%s should be a single query statement", *documentURI)
+		}
+		result, err := h.commandExecuteQuery(ctx, lsp.ExecuteCommandParams{Arguments: []any{fmt.Sprintf("--force=%t", *force), "--no-sample", *documentURI}})
+		if err != nil {
+			return nil, err
+		}
+		jobURI = result.TextDocument.URI
+	}
+	if jobURI == "" {
+		return nil, fmt.Errorf("no query has been executed")
+	}
+
+	virtualTextDocument, err := ParseVirtualTextDocument(jobURI)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := h.projectOfJob(virtualTextDocument.ProjectID, virtualTextDocument.JobID).SaveJobResult(ctx, virtualTextDocument.ProjectID, virtualTextDocument.JobID, path, resultFormat)
+	if err != nil {
+		return nil, err
+	}
+	return &lsp.SaveResultsResult{Path: path, Rows: rows}, nil
+}
+
+func (h *Handler) commandQueryHistory(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.QueryHistoryResult, error) {
+	f := flag.NewFlagSet("queryHistory", flag.ContinueOnError)
+	limit := f.Int("limit", source.DefaultQueryHistoryLimit, "the number of entries")
+	uri := f.String("uri", "", "the document or the workspace folder whose project reads the history. The project of the root is used by default")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.projectOfURIFlag(*uri).QueryHistory(ctx, *limit)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (h *Handler) commandRerunQuery(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.ExecuteQueryResult, error) {
+	f := flag.NewFlagSet("rerunQuery", flag.ContinueOnError)
+	force := f.Bool("force", false, "execute the query even if the estimated bytes processed exceed max_bytes_processed")
+	uri := f.String("uri", "", "the document or the workspace folder whose project runs the query. The project of the root is used by default")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	if f.NArg() != 1 {
+		return nil, fmt.Errorf("history entry id argument is required")
+	}
+	id, err := strconv.ParseInt(f.Arg(0), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("history entry id should be integer: %w", err)
+	}
+
+	workDoneToken := lsp.ProgressToken("rerun_query")
+	h.workDoneProgressBegin(ctx, workDoneToken, lsp.WorkDoneProgressBegin{
+		Title:   "Rerun Query",
+		Message: "Runing query...",
+	})
+	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
+
+	project := h.projectOfURIFlag(*uri)
+	job, err := project.RerunQuery(ctx, id, *force)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lsp.ExecuteQueryResult{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: h.setLastJob(project, job.ID()),
+		},
+	}, nil
+}
+
+func (h *Handler) commandExtractSubqueryToCTE(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.WorkspaceEdit, error) {
+	f := flag.NewFlagSet("extractSubqueryToCTE", flag.ContinueOnError)
+	name := f.String("name", "", "the name of the WITH query. When it is empty, a name which doesn't conflict with the other WITH queries is used")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	uri, rng, err := h.documentRangeArgs(f.Args())
+	if err != nil {
+		return nil, err
+	}
+
+	edits, err := h.projectOf(uri).ExtractSubqueryToCTE(documentURIToURI(uri), rng, *name)
+	if err != nil {
+		return nil, err
+	}
+	return h.applyEdit(ctx, "Extract Subquery to CTE", uri, edits)
+}
+
+func (h *Handler) commandInlineCTE(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.WorkspaceEdit, error) {
+	documentURI, position, err := h.documentPositionArgs(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	edits, err := h.projectOf(documentURI).InlineCTE(documentURIToURI(documentURI), position)
+	if err != nil {
+		return nil, err
+	}
+	return h.applyEdit(ctx, "Inline CTE", documentURI, edits)
+}
+
+func (h *Handler) commandFixUngroupedColumn(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.WorkspaceEdit, error) {
+	f := flag.NewFlagSet("fixUngroupedColumn", flag.ContinueOnError)
+	anyValue := f.Bool("any-value", false, "wrap the column with ANY_VALUE instead of appending it to GROUP BY clause")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	uri, position, err := h.documentPositionArgs(f.Args())
+	if err != nil {
+		return nil, err
+	}
+
+	edits, err := h.projectOf(uri).FixUngroupedColumn(documentURIToURI(uri), position, *anyValue)
+	if err != nil {
+		return nil, err
+	}
+	return h.applyEdit(ctx, "Fix ungrouped column", uri, edits)
+}
+
+func (h *Handler) commandConvertLegacySQL(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.WorkspaceEdit, error) {
+	if len(params.Arguments) != 1 {
+		return nil, fmt.Errorf("file uri argument is required")
+	}
+	uri, ok := params.Arguments[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("arguments should be string, but got %T", params.Arguments[0])
+	}
+
+	documentURI := lsp.DocumentURI(uri)
+	edits, err := h.projectOf(documentURI).ConvertLegacySQL(documentURIToURI(documentURI))
+	if err != nil {
+		return nil, err
+	}
+	return h.applyEdit(ctx, "Convert Legacy SQL to Standard SQL", documentURI, edits)
+}
+
+func (h *Handler) commandExpandStar(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.WorkspaceEdit, error) {
+	documentURI, position, err := h.documentPositionArgs(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	edits, err := h.projectOf(documentURI).ExpandStar(documentURIToURI(documentURI), position)
+	if err != nil {
+		return nil, err
+	}
+	return h.applyEdit(ctx, "Expand *", documentURI, edits)
+}
+
+func (h *Handler) commandCastExpression(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.WorkspaceEdit, error) {
+	f := flag.NewFlagSet("castExpression", flag.ContinueOnError)
+	safe := f.Bool("safe", false, "use SAFE_CAST instead of CAST")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	if f.NArg() != 6 {
+		return nil, fmt.Errorf("file uri, range and type arguments are required")
+	}
+	uri, rng, err := h.documentRangeArgs(f.Args()[:5])
+	if err != nil {
+		return nil, err
+	}
 
-	strArgs := make([]string, 0, len(params.Arguments))
+	edits, err := h.projectOf(uri).CastExpression(documentURIToURI(uri), rng, f.Arg(5), *safe)
+	if err != nil {
+		return nil, err
+	}
+	return h.applyEdit(ctx, "Cast expression", uri, edits)
+}
+
+func (h *Handler) commandWrapJSONExpression(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.WorkspaceEdit, error) {
+	args := commandArgs(params)
+	if len(args) != 6 {
+		return nil, fmt.Errorf("file uri, range and function arguments are required")
+	}
+	uri, rng, err := h.documentRangeArgs(args[:5])
+	if err != nil {
+		return nil, err
+	}
+
+	edits, err := h.projectOf(uri).WrapJSONExpression(documentURIToURI(uri), rng, args[5])
+	if err != nil {
+		return nil, err
+	}
+	return h.applyEdit(ctx, "Wrap JSON expression", uri, edits)
+}
+
+// commandArgs converts the arguments of the command into the strings, so that they are parsed in the same way as the command line.
+func commandArgs(params lsp.ExecuteCommandParams) []string {
+	result := make([]string, 0, len(params.Arguments))
 	for _, a := range params.Arguments {
-		strArgs = append(strArgs, fmt.Sprint(a))
+		result = append(result, fmt.Sprint(a))
+	}
+	return result
+}
+
+// documentPositionArgs parses the arguments of the document and the position like `uri line character`.
+// The position is converted into the byte offset of the document.
+func (h *Handler) documentPositionArgs(args []string) (lsp.DocumentURI, lsp.Position, error) {
+	if len(args) != 3 {
+		return "", lsp.Position{}, fmt.Errorf("file uri, line and character arguments are required")
+	}
+	line, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "", lsp.Position{}, fmt.Errorf("line should be integer: %w", err)
+	}
+	character, err := strconv.Atoi(args[2])
+	if err != nil {
+		return "", lsp.Position{}, fmt.Errorf("character should be integer: %w", err)
+	}
+	uri := lsp.DocumentURI(args[0])
+	return uri, h.positionConverter(uri).toByte(lsp.Position{Line: line, Character: character}), nil
+}
+
+// documentRangeArgs parses the arguments of the document and the range like `uri startLine startCharacter endLine endCharacter`.
+// The range is converted into the byte offsets of the document.
+func (h *Handler) documentRangeArgs(args []string) (lsp.DocumentURI, lsp.Range, error) {
+	if len(args) != 5 {
+		return "", lsp.Range{}, fmt.Errorf("file uri and range arguments are required")
+	}
+	positions := make([]int, 4)
+	for i := range positions {
+		var err error
+		positions[i], err = strconv.Atoi(args[i+1])
+		if err != nil {
+			return "", lsp.Range{}, fmt.Errorf("range should be integer: %w", err)
+		}
+	}
+	rng := lsp.Range{
+		Start: lsp.Position{Line: positions[0], Character: positions[1]},
+		End:   lsp.Position{Line: positions[2], Character: positions[3]},
+	}
+	uri := lsp.DocumentURI(args[0])
+	return uri, h.positionConverter(uri).toByteRange(rng), nil
+}
+
+// setLastJob records the job executed by the command, and returns its virtual text document.
+// The job is the default of the commands which read the result of the last query like saveResults.
+func (h *Handler) setLastJob(project *source.Project, jobID string) lsp.DocumentURI {
+	h.recordJob(project, jobID)
+	uri := lsp.NewJobVirtualTextDocumentURI(project.BillingProjectID, jobID)
+
+	h.lastJobURILock.Lock()
+	defer h.lastJobURILock.Unlock()
+	h.lastJobURI = uri
+	return uri
+}
+
+func (h *Handler) getLastJobURI() lsp.DocumentURI {
+	h.lastJobURILock.RLock()
+	defer h.lastJobURILock.RUnlock()
+	return h.lastJobURI
+}
+
+// applyEdit requests the client to apply the edits to the document.
+// The edit is returned to the client which doesn't support workspace/applyEdit, so that it can apply the edit by itself.
+func (h *Handler) applyEdit(ctx context.Context, label string, uri lsp.DocumentURI, edits []lsp.TextEdit) (*lsp.WorkspaceEdit, error) {
+	edits = h.positionConverter(uri).fromByteEdits(edits)
+	edit := lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{string(uri): edits}}
+	if !h.initializeParams.Capabilities.Workspace.ApplyEdit {
+		return &edit, nil
+	}
+
+	// The handler must not wait for the response of the client in the same goroutine,
+	// because the response is read by the goroutine which runs the handler.
+	go func() {
+		ctx := context.Background()
+		var result lsp.ApplyWorkspaceEditResult
+		err := h.conn.Call(ctx, "workspace/applyEdit", lsp.ApplyWorkspaceEditParams{Label: label, Edit: edit}, &result)
+		if err == nil && !result.Applied {
+			err = fmt.Errorf("failed to apply the edit: %s", result.FailureReason)
+		}
+		if err != nil {
+			message := fmt.Sprintf("%s: %v", label, err)
+			h.logger.Error(message)
+			if err := h.showMessage(ctx, lsp.MTError, message); err != nil {
+				h.logger.Debugf(`failed to send "window/showMessage": %v`, err)
+			}
+		}
+	}()
+	return nil, nil
+}
+
+func (h *Handler) commandAddDistinctAlias(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.WorkspaceEdit, error) {
+	documentURI, position, err := h.documentPositionArgs(commandArgs(params))
+	if err != nil {
+		return nil, err
 	}
-	err := f.Parse(strArgs)
+
+	edits, err := h.projectOf(documentURI).AddDistinctAlias(documentURIToURI(documentURI), position)
 	if err != nil {
 		return nil, err
 	}
+	return h.applyEdit(ctx, "Add distinct alias", documentURI, edits)
+}
 
-	jobs, err := h.project.ListJobs(ctx, h.initializeParams.InitializationOptions.ProjectID, *allUser)
+func (h *Handler) commandAddJoinCondition(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.WorkspaceEdit, error) {
+	documentURI, position, err := h.documentPositionArgs(commandArgs(params))
 	if err != nil {
 		return nil, err
 	}
-	return lsp.ListJobHistoryResult{Jobs: jobs}, nil
+
+	edits, err := h.projectOf(documentURI).AddJoinCondition(ctx, documentURIToURI(documentURI), position)
+	if err != nil {
+		return nil, err
+	}
+	return h.applyEdit(ctx, "Add JOIN condition", documentURI, edits)
+}
+
+func (h *Handler) commandUnnestArrayColumn(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.WorkspaceEdit, error) {
+	documentURI, position, err := h.documentPositionArgs(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	edits, err := h.projectOf(documentURI).UnnestArrayColumn(ctx, documentURIToURI(documentURI), position)
+	if err != nil {
+		return nil, err
+	}
+	return h.applyEdit(ctx, "UNNEST ARRAY column", documentURI, edits)
+}
+
+func (h *Handler) commandReplaceOrdinals(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.WorkspaceEdit, error) {
+	f := flag.NewFlagSet("replaceOrdinals", flag.ContinueOnError)
+	toOrdinals := f.Bool("to-ordinals", false, "replace the expressions with the ordinals instead of replacing the ordinals with the expressions")
+
+	err := f.Parse(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	uri, position, err := h.documentPositionArgs(f.Args())
+	if err != nil {
+		return nil, err
+	}
+
+	edits, err := h.projectOf(uri).ReplaceOrdinals(documentURIToURI(uri), position, *toOrdinals)
+	if err != nil {
+		return nil, err
+	}
+	return h.applyEdit(ctx, "Replace ordinals", uri, edits)
+}
+
+func (h *Handler) commandEvaluateExpression(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.EvaluateExpressionResult, error) {
+	documentURI, rng, err := h.documentRangeArgs(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	value, typ, err := h.projectOf(documentURI).EvaluateExpression(ctx, documentURIToURI(documentURI), rng)
+	if err != nil {
+		return nil, err
+	}
+	return &lsp.EvaluateExpressionResult{Value: value, Type: typ}, nil
+}
+
+func (h *Handler) commandGenerateTestScaffold(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.GenerateTestScaffoldResult, error) {
+	documentURI, position, err := h.documentPositionArgs(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := h.projectOf(documentURI).GenerateTestScaffold(ctx, documentURIToURI(documentURI), position)
+	if err != nil {
+		return nil, err
+	}
+	return &lsp.GenerateTestScaffoldResult{Contents: contents}, nil
+}
+
+func (h *Handler) commandExecuteWithFixtures(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.ExecuteQueryResult, error) {
+	documentURI, position, err := h.documentPositionArgs(commandArgs(params))
+	if err != nil {
+		return nil, err
+	}
+
+	workDoneToken := lsp.ProgressToken("execute_with_fixtures")
+	h.workDoneProgressBegin(ctx, workDoneToken, lsp.WorkDoneProgressBegin{
+		Title:   "Execute Query with Fixtures",
+		Message: "Running query...",
+	})
+	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
+
+	project := h.projectOf(documentURI)
+	job, err := project.RunWithFixtures(ctx, documentURIToURI(documentURI), position)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lsp.ExecuteQueryResult{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: h.setLastJob(project, job.ID()),
+		},
+	}, nil
+}
+
+func (h *Handler) commandDiffQueries(ctx context.Context, params lsp.ExecuteCommandParams) (*lsp.DiffQueriesResult, error) {
+	f := flag.NewFlagSet("diffQueries", flag.ContinueOnError)
+	revision := f.String("revision", "", "compare the document with the file at the git revision like HEAD")
+
+	if err := f.Parse(commandArgs(params)); err != nil {
+		return nil, err
+	}
+
+	var oldSQL, newSQL string
+	var err error
+	switch {
+	case *revision != "" && f.NArg() == 1:
+		uri := lsp.DocumentURI(f.Arg(0))
+		oldSQL, err = source.GitRevisionText(ctx, documentURIToURI(uri), *revision)
+		if err != nil {
+			return nil, err
+		}
+		newSQL, err = h.projectOf(uri).FileText(documentURIToURI(uri))
+		if err != nil {
+			return nil, err
+		}
+	case *revision == "" && f.NArg() == 2:
+		oldURI, newURI := lsp.DocumentURI(f.Arg(0)), lsp.DocumentURI(f.Arg(1))
+		oldSQL, err = h.projectOf(oldURI).FileText(documentURIToURI(oldURI))
+		if err != nil {
+			return nil, err
+		}
+		newSQL, err = h.projectOf(newURI).FileText(documentURIToURI(newURI))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("two file uris, or --revision and a file uri are required")
+	}
+
+	contents, err := h.projectOf(lsp.DocumentURI(f.Arg(f.NArg()-1))).DiffQueries(ctx, oldSQL, newSQL)
+	if err != nil {
+		return nil, err
+	}
+	return &lsp.DiffQueriesResult{Contents: contents}, nil
 }
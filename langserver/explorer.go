@@ -0,0 +1,171 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// handleListDatasets lists the datasets for the tree view of the client.
+func (h *Handler) handleListDatasets(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	var params lsp.ListDatasetsParams
+	if req.Params != nil {
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			return nil, err
+		}
+	}
+
+	workDoneToken := lsp.ProgressToken("list_datasets")
+	h.workDoneProgressBegin(ctx, workDoneToken, lsp.WorkDoneProgressBegin{
+		Title:   "List datasets",
+		Message: "Loading datasets...",
+	})
+	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
+
+	datasets := make([]lsp.DatasetItem, 0)
+	for _, target := range h.explorerProjects(params.ProjectID) {
+		list, err := target.project.ListDatasets(ctx, target.projectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list datasets of %s: %w", target.projectID, err)
+		}
+		for _, d := range list {
+			datasets = append(datasets, lsp.DatasetItem{
+				ProjectID: d.ProjectID,
+				DatasetID: d.DatasetID,
+				Reference: fmt.Sprintf("%s.%s", d.ProjectID, d.DatasetID),
+			})
+		}
+	}
+
+	return lsp.ListDatasetsResponse{Datasets: datasets}, nil
+}
+
+// handleListTables lists the tables of the dataset for the tree view of the client.
+func (h *Handler) handleListTables(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.ListTablesParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+	if params.DatasetID == "" {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: "datasetId is required"}
+	}
+
+	projectID := params.ProjectID
+	if projectID == "" {
+		projectID = h.project.BigQueryProjectID
+	}
+
+	workDoneToken := lsp.ProgressToken("list_tables")
+	h.workDoneProgressBegin(ctx, workDoneToken, lsp.WorkDoneProgressBegin{
+		Title:   "List tables",
+		Message: "Loading tables...",
+	})
+	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
+
+	list, err := h.projectOfTable(projectID).ListTables(ctx, projectID, params.DatasetID)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]lsp.TableItem, 0, len(list))
+	for _, t := range list {
+		tables = append(tables, lsp.TableItem{
+			ProjectID: t.ProjectID,
+			DatasetID: t.DatasetID,
+			TableID:   t.TableID,
+			Reference: fmt.Sprintf("`%s.%s.%s`", t.ProjectID, t.DatasetID, t.TableID),
+			URI:       lsp.NewTableVirtualTextDocumentURI(t.ProjectID, t.DatasetID, t.TableID),
+		})
+	}
+
+	return lsp.ListTablesResponse{Tables: tables}, nil
+}
+
+// handleListJobs lists the recent jobs of the project.
+func (h *Handler) handleListJobs(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	var params lsp.ListJobsParams
+	if req.Params != nil {
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			return nil, err
+		}
+	}
+
+	projectID := params.ProjectID
+	if projectID == "" {
+		projectID = h.project.BillingProjectID
+	}
+
+	workDoneToken := lsp.ProgressToken("list_jobs")
+	h.workDoneProgressBegin(ctx, workDoneToken, lsp.WorkDoneProgressBegin{
+		Title:   "List jobs",
+		Message: "Loading jobs...",
+	})
+	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
+
+	jobs, err := h.projectOfBillingProject(projectID).ListJobs(ctx, projectID, params.AllUsers)
+	if err != nil {
+		return nil, err
+	}
+	return lsp.ListJobsResponse{Jobs: jobs}, nil
+}
+
+// handleGetJob returns the query and the statistics of the job as the virtual text document.
+func (h *Handler) handleGetJob(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.GetJobParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+	if params.JobID == "" {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: "jobId is required"}
+	}
+
+	projectID := params.ProjectID
+	if projectID == "" {
+		projectID = h.project.BillingProjectID
+	}
+
+	workDoneToken := lsp.ProgressToken("get_job")
+	h.workDoneProgressBegin(ctx, workDoneToken, lsp.WorkDoneProgressBegin{
+		Title:   "Get job",
+		Message: "Fetching job info...",
+	})
+	defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
+
+	return h.projectOfJob(projectID, params.JobID).GetJobInfo(ctx, projectID, params.JobID)
+}
+
+type explorerProject struct {
+	project   *source.Project
+	projectID string
+}
+
+// explorerProjects returns the projects whose datasets are listed.
+// When projectID is empty, the default projects of the workspace folders are listed without duplicates.
+func (h *Handler) explorerProjects(projectID string) []explorerProject {
+	if projectID != "" {
+		return []explorerProject{{project: h.projectOfTable(projectID), projectID: projectID}}
+	}
+
+	result := make([]explorerProject, 0)
+	seen := make(map[string]struct{})
+	for _, p := range h.projects() {
+		if _, ok := seen[p.BigQueryProjectID]; ok || p.BigQueryProjectID == "" {
+			continue
+		}
+		seen[p.BigQueryProjectID] = struct{}{}
+		result = append(result, explorerProject{project: p, projectID: p.BigQueryProjectID})
+	}
+	return result
+}
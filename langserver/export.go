@@ -0,0 +1,29 @@
+package langserver
+
+import (
+	"context"
+	"os"
+
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultSchemaDir = "schemas"
+
+// ExportSchemas writes the schemas of all tables referenced in the .sql files under rootPath into outputDir.
+// The output can be used as `schema_dir` of the offline mode.
+func ExportSchemas(ctx context.Context, rootPath, projectID, outputDir string, isDebug bool) ([]string, error) {
+	logger := logrus.New()
+	logger.Out = os.Stderr
+	if isDebug {
+		logger.SetLevel(logrus.DebugLevel)
+	}
+
+	p, err := source.NewProject(ctx, source.Config{RootPath: rootPath, ProjectID: projectID}, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	return p.ExportSchemas(ctx, outputDir)
+}
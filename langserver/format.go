@@ -8,6 +8,7 @@ import (
 
 	"github.com/goccy/go-zetasql"
 	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
 	"github.com/sourcegraph/jsonrpc2"
 )
 
@@ -21,21 +22,173 @@ func (h *Handler) handleTextDocumentFormatting(ctx context.Context, conn *jsonrp
 		return nil, err
 	}
 
-	rawText, ok := h.project.GetFile(documentURIToURI(params.TextDocument.URI))
+	rawText, ok := h.projectOf(params.TextDocument.URI).GetFile(documentURIToURI(params.TextDocument.URI))
 	if !ok {
 		return nil, fmt.Errorf("failed to find document %s", params.TextDocument.URI)
 	}
 
-	formatted, err := zetasql.FormatSQL(rawText)
-	if !ok {
-		return nil, fmt.Errorf("failed to format: %w", err)
+	formatted, err := h.formatDocument(params.TextDocument.URI, rawText)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(formatted) == 0 {
 		return nil, nil
 	}
 
-	return ComputeEdits(params.TextDocument.URI, rawText, string(formatted)), nil
+	return ComputeEdits(params.TextDocument.URI, rawText, formatted), nil
+}
+
+func (h *Handler) handleTextDocumentRangeFormatting(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.DocumentRangeFormattingParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	rawText, ok := h.projectOf(params.TextDocument.URI).GetFile(documentURIToURI(params.TextDocument.URI))
+	if !ok {
+		return nil, fmt.Errorf("failed to find document %s", params.TextDocument.URI)
+	}
+
+	option, err := h.formatOption(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	converter := h.positionConverter(params.TextDocument.URI)
+	edits, err := FormatSQLRange(rawText, converter.toByteRange(params.Range), params.Options, option)
+	if err != nil {
+		return nil, err
+	}
+	return converter.fromByteEdits(edits), nil
+}
+
+// formatOption returns the style of the document, which is resolved in the same way as `bqls fmt` does for the file.
+func (h *Handler) formatOption(uri lsp.DocumentURI) (FormatOption, error) {
+	return formatOptionForFile(documentURIToURI(uri), h.initializeParams.InitializationOptions)
+}
+
+// formatDocument formats the text of the document with its style.
+func (h *Handler) formatDocument(uri lsp.DocumentURI, text string) (string, error) {
+	return formatSQLForFile(documentURIToURI(uri), text, h.initializeParams.InitializationOptions)
+}
+
+// wrappedInParentheses reports whether the first parenthesis of s closes at the end of s,
+// so `(SELECT 1) UNION ALL (SELECT 2)` is not wrapped. The parentheses in the literals and the comments are ignored.
+func wrappedInParentheses(s string) bool {
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return false
+	}
+	mask := file.CodeMask(s)
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		if !mask[i] {
+			continue
+		}
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i == len(s)-1
+			}
+		}
+	}
+	return false
+}
+
+// FormatSQLRange formats only the SQL in rng, like a CTE body or a subquery.
+// The selection wrapped in parentheses is formatted as a subquery and indented from the line of the selection.
+func FormatSQLRange(text string, rng lsp.Range, options lsp.FormattingOptions, style FormatOption) ([]lsp.TextEdit, error) {
+	start, end := positionOffset(text, rng.Start), positionOffset(text, rng.End)
+	if start >= end {
+		return nil, nil
+	}
+
+	selected := text[start:end]
+	core := strings.TrimSpace(selected)
+	if core == "" {
+		return nil, nil
+	}
+	leading := selected[:strings.Index(selected, core)]
+	trailing := selected[len(leading)+len(core):]
+
+	wrapped := wrappedInParentheses(core)
+	query := core
+	if wrapped {
+		query = core[1 : len(core)-1]
+	}
+
+	formatted, err := FormatSQLWithOption(query, style)
+	if err != nil {
+		return nil, err
+	}
+	formatted = strings.TrimRight(formatted, " \t\n")
+	if !strings.HasSuffix(query, ";") {
+		formatted = strings.TrimSuffix(formatted, ";")
+	}
+
+	lineStart := strings.LastIndex(text[:start], "\n") + 1
+	baseIndent := text[lineStart:start]
+	baseIndent = baseIndent[:len(baseIndent)-len(strings.TrimLeft(baseIndent, " \t"))]
+
+	var newText string
+	if wrapped {
+		newText = "(\n" + indentLines(formatted, baseIndent+indentUnit(options), true) + "\n" + baseIndent + ")"
+	} else {
+		newText = indentLines(formatted, baseIndent, false)
+	}
+
+	return []lsp.TextEdit{
+		{
+			Range:   rng,
+			NewText: leading + newText + trailing,
+		},
+	}, nil
+}
+
+// indentLines prefixes the lines with indent. The first line is not indented unless indentFirst is true,
+// because it follows the text before the selection.
+func indentLines(text, indent string, indentFirst bool) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" || (i == 0 && !indentFirst) {
+			continue
+		}
+		lines[i] = indent + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// positionOffset converts the position into the byte offset of text.
+func positionOffset(text string, pos lsp.Position) int {
+	offset := 0
+	for i := 0; i < pos.Line; i++ {
+		next := strings.Index(text[offset:], "\n")
+		if next < 0 {
+			return len(text)
+		}
+		offset += next + 1
+	}
+	offset += pos.Character
+	if offset > len(text) {
+		return len(text)
+	}
+	return offset
+}
+
+// FormatSQL formats the SQL with the ZetaSQL formatter, which is used by textDocument/formatting.
+// The comments dropped by the formatter are restored from the token stream of text.
+func FormatSQL(text string) (string, error) {
+	formatted, err := zetasql.FormatSQL(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to format: %w", err)
+	}
+	return restoreComments(text, formatted), nil
 }
 
 // ComputeEdits computes diff edits from 2 string inputs
@@ -0,0 +1,136 @@
+package langserver
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+type commentInsertion struct {
+	offset int
+	text   string
+}
+
+// restoreComments inserts the comments of original into formatted, which is formatted from original without the comments.
+// The code tokens of both are matched by the diff, and each comment is placed at the end of the line of the preceding token
+// when it follows the code in the same line, or at the line before the following token otherwise.
+func restoreComments(original, formatted string) string {
+	originalTokens := file.Tokenize(original)
+	comments := 0
+	originalCode := make([]file.Token, 0, len(originalTokens))
+	for _, t := range originalTokens {
+		if t.Kind == file.CommentToken {
+			comments++
+			continue
+		}
+		originalCode = append(originalCode, t)
+	}
+	if comments == 0 {
+		return formatted
+	}
+
+	formattedCode := make([]file.Token, 0)
+	for _, t := range file.Tokenize(formatted) {
+		if t.Kind == file.CommentToken {
+			// the formatter keeps the comments
+			return formatted
+		}
+		formattedCode = append(formattedCode, t)
+	}
+	mapping := matchTokens(originalCode, formattedCode)
+
+	insertions := make([]commentInsertion, 0, comments)
+	codeIndex := 0
+	for _, t := range originalTokens {
+		if t.Kind != file.CommentToken {
+			codeIndex++
+			continue
+		}
+		// the spaces after the line comment are dropped
+		comment := strings.TrimRight(t.Text, " \t\r")
+
+		trailing := codeIndex > 0 && !strings.Contains(original[originalCode[codeIndex-1].End:t.Start], "\n")
+		if trailing {
+			if j, ok := mappedToken(mapping, codeIndex-1, -1); ok {
+				insertions = append(insertions, trailingCommentInsertion(formatted, formattedCode[j], comment))
+				continue
+			}
+		}
+
+		j, ok := mappedToken(mapping, codeIndex, 1)
+		if !ok {
+			insertions = append(insertions, commentInsertion{offset: len(strings.TrimRight(formatted, "\n")), text: "\n" + comment})
+			continue
+		}
+		lineStart := strings.LastIndex(formatted[:formattedCode[j].Start], "\n") + 1
+		line := formatted[lineStart:]
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		insertions = append(insertions, commentInsertion{offset: lineStart, text: indent + comment + "\n"})
+	}
+
+	sort.SliceStable(insertions, func(i, j int) bool { return insertions[i].offset < insertions[j].offset })
+	var b strings.Builder
+	prev := 0
+	for _, ins := range insertions {
+		b.WriteString(formatted[prev:ins.offset])
+		b.WriteString(ins.text)
+		prev = ins.offset
+	}
+	b.WriteString(formatted[prev:])
+	return b.String()
+}
+
+// trailingCommentInsertion places the comment after the token. The line comment is placed at the end of the line.
+func trailingCommentInsertion(formatted string, token file.Token, comment string) commentInsertion {
+	if strings.HasPrefix(comment, "/*") {
+		return commentInsertion{offset: token.End, text: " " + comment}
+	}
+	lineEnd := len(formatted)
+	if end := strings.IndexByte(formatted[token.End:], '\n'); end >= 0 {
+		lineEnd = token.End + end
+	}
+	return commentInsertion{offset: lineEnd, text: " " + comment}
+}
+
+// matchTokens maps the indexes of a into the indexes of the same tokens in b. The unmatched tokens are -1.
+func matchTokens(a, b []file.Token) []int {
+	normalize := func(tokens []file.Token) []string {
+		result := make([]string, len(tokens))
+		for i, t := range tokens {
+			result[i] = strings.ToUpper(t.Text)
+		}
+		return result
+	}
+
+	mapping := make([]int, len(a))
+	for i := range mapping {
+		mapping[i] = -1
+	}
+	x, y := 0, 0
+	for _, op := range operations(normalize(a), normalize(b)) {
+		for ; x < op.I1; x, y = x+1, y+1 {
+			mapping[x] = y
+		}
+		switch op.Kind {
+		case Delete:
+			x = op.I2
+		case Insert:
+			y = op.J1 + len(op.Content)
+		}
+	}
+	for ; x < len(a) && y < len(b); x, y = x+1, y+1 {
+		mapping[x] = y
+	}
+	return mapping
+}
+
+// mappedToken finds the nearest matched token from i in the direction.
+func mappedToken(mapping []int, i, direction int) (int, bool) {
+	for ; 0 <= i && i < len(mapping); i += direction {
+		if mapping[i] >= 0 {
+			return mapping[i], true
+		}
+	}
+	return 0, false
+}
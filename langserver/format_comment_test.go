@@ -0,0 +1,46 @@
+package langserver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRestoreComments(t *testing.T) {
+	tests := map[string]struct {
+		original  string
+		formatted string
+
+		expect string
+	}{
+		"line and block comments": {
+			original:  "-- header\nselect a, -- first\n  b /* second */ from t\n-- footer\n",
+			formatted: "SELECT\n  a,\n  b\nFROM\n  t;\n",
+			expect:    "-- header\nSELECT\n  a, -- first\n  b /* second */\nFROM\n  t;\n-- footer\n",
+		},
+		"comment before clause": {
+			original:  "SELECT a\n# the source\nFROM t",
+			formatted: "SELECT\n  a\nFROM\n  t;\n",
+			expect:    "SELECT\n  a\n# the source\nFROM\n  t;\n",
+		},
+		"comment keeps the indent of the next line": {
+			original:  "SELECT\n  a,\n      -- b is important\n  b\nFROM t",
+			formatted: "SELECT\n  a,\n  b\nFROM\n  t;\n",
+			expect:    "SELECT\n  a,\n  -- b is important\n  b\nFROM\n  t;\n",
+		},
+		"comment in string literal": {
+			original:  "SELECT '-- not a comment' AS s",
+			formatted: "SELECT\n  '-- not a comment' AS s;\n",
+			expect:    "SELECT\n  '-- not a comment' AS s;\n",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := restoreComments(tt.original, tt.formatted)
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("restoreComments result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
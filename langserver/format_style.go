@@ -0,0 +1,347 @@
+package langserver
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+const (
+	keywordCaseLower = "lower"
+
+	// formatterIndentWidth is the indent width of the ZetaSQL formatter.
+	formatterIndentWidth = 2
+)
+
+// FormatOption is the style applied to the output of the ZetaSQL formatter.
+type FormatOption struct {
+	// KeywordCase is `upper` (default) or `lower`.
+	KeywordCase string `json:"keyword_case"`
+
+	// IndentWidth is the number of the spaces of an indent. When it is not positive, 2 is used.
+	IndentWidth int `json:"indent_width"`
+
+	// MaxLineWidth wraps the lines longer than it at AND, OR or commas. When it is not positive, the lines are not wrapped.
+	MaxLineWidth int `json:"max_line_width"`
+
+	// AlignAliases aligns AS of the consecutive items like the SELECT columns.
+	AlignAliases bool `json:"align_aliases"`
+
+	// CommaStyle is `trailing` (default) or `leading`. It is set from comma_style, which is shared with the on-type formatting.
+	CommaStyle string `json:"-"`
+}
+
+// LoadFormatOptionForFile loads the style of `bqls fmt` from the nearest workspace config file in the directory of path or its parents,
+// so that the files of a workspace are formatted in the same way from any directory.
+// When path is empty like stdin without the file name, the config file is looked up from the current directory.
+// When no config file is found, the default style is used.
+func LoadFormatOptionForFile(path string) (FormatOption, error) {
+	return formatOptionForFile(path, InitializeOption{})
+}
+
+// formatOptionForFile resolves the style of the file for both `bqls fmt` and the formatting of the language server.
+// The nearest workspace config file of path overrides base, which is initializationOptions in the language server.
+func formatOptionForFile(path string, base InitializeOption) (FormatOption, error) {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return FormatOption{}, err
+	}
+	option := base
+	if err := readWorkspaceConfig(findWorkspaceConfigDir(dir), &option); err != nil {
+		return FormatOption{}, err
+	}
+	return option.formatOption(), nil
+}
+
+// FormatSQLForFile formats the SQL of the file with the style of the workspace which has the file.
+// It is the formatting of `bqls fmt`, which gives the same result as the formatting of the language server without initializationOptions.
+func FormatSQLForFile(path, text string) (string, error) {
+	return formatSQLForFile(path, text, InitializeOption{})
+}
+
+func formatSQLForFile(path, text string, base InitializeOption) (string, error) {
+	option, err := formatOptionForFile(path, base)
+	if err != nil {
+		return "", err
+	}
+	return FormatSQLWithOption(text, option)
+}
+
+// findWorkspaceConfigDir returns dir or its nearest parent which has the workspace config file, or "" when there is none.
+func findWorkspaceConfigDir(dir string) string {
+	for {
+		if _, ok := workspaceConfigPath(dir); ok {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// FormatSQLWithOption formats the SQL with the ZetaSQL formatter, and applies the style of option.
+func FormatSQLWithOption(text string, option FormatOption) (string, error) {
+	formatted, err := FormatSQL(text)
+	if err != nil {
+		return "", err
+	}
+	return applyFormatOption(formatted, option), nil
+}
+
+func applyFormatOption(text string, option FormatOption) string {
+	indentWidth := option.IndentWidth
+	if indentWidth <= 0 {
+		indentWidth = formatterIndentWidth
+	}
+	text = reindent(text, indentWidth)
+	if option.AlignAliases {
+		text = alignAliases(text)
+	}
+	if option.MaxLineWidth > 0 {
+		text = wrapLongLines(text, option.MaxLineWidth, strings.Repeat(" ", indentWidth))
+	}
+	if option.CommaStyle == commaStyleLeading {
+		text = leadingCommas(text)
+	}
+	if option.KeywordCase == keywordCaseLower {
+		text = lowerKeywords(text)
+	}
+	return text
+}
+
+// codeLines splits text into the lines with the code mask of each line.
+func codeLines(text string) ([]string, [][]bool) {
+	mask := file.CodeMask(text)
+	lines := strings.Split(text, "\n")
+	masks := make([][]bool, len(lines))
+	offset := 0
+	for i, line := range lines {
+		masks[i] = mask[offset : offset+len(line)]
+		offset += len(line) + 1
+	}
+	return lines, masks
+}
+
+func lineIndent(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " "))]
+}
+
+// reindent converts the indents of the ZetaSQL formatter into width spaces.
+// The lines in the multi-line string literals and comments are kept.
+func reindent(text string, width int) string {
+	if width == formatterIndentWidth {
+		return text
+	}
+	lines, masks := codeLines(text)
+	for i, line := range lines {
+		if line == "" || !masks[i][0] {
+			continue
+		}
+		n := len(lineIndent(line))
+		lines[i] = strings.Repeat(" ", n/formatterIndentWidth*width+n%formatterIndentWidth) + line[n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// aliasIndex returns the index of ` AS ` which gives the alias to the whole line like `  column AS alias,`.
+func aliasIndex(line string, mask []bool) (int, bool) {
+	depth := 0
+	index := -1
+	for i := 0; i < len(line); i++ {
+		if !mask[i] {
+			continue
+		}
+		switch line[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ' ':
+			if depth == 0 && i+4 <= len(line) && strings.EqualFold(line[i:i+4], " AS ") {
+				index = i
+			}
+		}
+	}
+	if index < 0 {
+		return 0, false
+	}
+	alias := strings.TrimSuffix(line[index+4:], ",")
+	if alias == "" || strings.ContainsAny(alias, " ()") {
+		return 0, false
+	}
+	return index, true
+}
+
+// alignAliases aligns AS of the consecutive lines which have the same indent.
+func alignAliases(text string) string {
+	lines, masks := codeLines(text)
+	for start := 0; start < len(lines); {
+		end := start + 1
+		indent := lineIndent(lines[start])
+		for end < len(lines) && lines[end] != "" && lineIndent(lines[end]) == indent && len(indent) > 0 {
+			end++
+		}
+
+		width := 0
+		count := 0
+		for i := start; i < end; i++ {
+			if index, ok := aliasIndex(lines[i], masks[i]); ok {
+				width = max(width, len(strings.TrimRight(lines[i][:index], " ")))
+				count++
+			}
+		}
+		if count >= 2 {
+			for i := start; i < end; i++ {
+				if index, ok := aliasIndex(lines[i], masks[i]); ok {
+					expr := strings.TrimRight(lines[i][:index], " ")
+					lines[i] = expr + strings.Repeat(" ", width-len(expr)) + lines[i][index:]
+				}
+			}
+		}
+		start = end
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapLongLines breaks the lines longer than width before AND or OR, or after the commas, at the shallowest parentheses.
+// The continued lines are indented by unit from the line.
+func wrapLongLines(text string, width int, unit string) string {
+	lines, masks := codeLines(text)
+	result := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if len(line) <= width || line == "" || !masks[i][0] {
+			result = append(result, line)
+			continue
+		}
+
+		breaks := lineBreakPoints(line, masks[i])
+		if len(breaks) == 0 {
+			result = append(result, line)
+			continue
+		}
+		indent := lineIndent(line)
+		prev := 0
+		for _, b := range breaks {
+			segment := strings.TrimRight(line[prev:b], " ")
+			if prev > 0 {
+				segment = indent + unit + segment
+			}
+			result = append(result, segment)
+			prev = b
+			for prev < len(line) && line[prev] == ' ' {
+				prev++
+			}
+		}
+		result = append(result, indent+unit+line[prev:])
+	}
+	return strings.Join(result, "\n")
+}
+
+// lineBreakPoints returns the offsets where the line is broken.
+func lineBreakPoints(line string, mask []bool) []int {
+	indent := len(lineIndent(line))
+	candidates := make(map[int][]int)
+	minDepth, found := 0, false
+	depth := 0
+	for i := indent; i < len(line); i++ {
+		if !mask[i] {
+			continue
+		}
+		point := -1
+		switch c := line[i]; {
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == ',' && i+1 < len(line):
+			point = i + 1
+		case c == ' ' && (hasWordAt(line, i+1, "AND") || hasWordAt(line, i+1, "OR")):
+			point = i + 1
+		}
+		if point < 0 {
+			continue
+		}
+		candidates[depth] = append(candidates[depth], point)
+		if !found || depth < minDepth {
+			minDepth, found = depth, true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return candidates[minDepth]
+}
+
+func hasWordAt(line string, i int, word string) bool {
+	if i+len(word) >= len(line) || !strings.EqualFold(line[i:i+len(word)], word) {
+		return false
+	}
+	return line[i+len(word)] == ' '
+}
+
+// leadingCommas moves the commas at the end of the lines to the head of the next lines.
+func leadingCommas(text string) string {
+	lines, masks := codeLines(text)
+	// the masks are checked before the lines are modified
+	moved := make([]bool, len(lines))
+	for i := 0; i+1 < len(lines); i++ {
+		line, next := lines[i], lines[i+1]
+		moved[i] = strings.HasSuffix(line, ",") && masks[i][len(line)-1] && strings.TrimSpace(next) != "" && masks[i+1][0]
+	}
+	for i := range lines {
+		if !moved[i] {
+			continue
+		}
+		lines[i] = strings.TrimSuffix(lines[i], ",")
+		indent := lineIndent(lines[i+1])
+		lines[i+1] = indent + ", " + lines[i+1][len(indent):]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lowerKeywords converts the reserved keywords into the lower case.
+func lowerKeywords(text string) string {
+	mask := file.CodeMask(text)
+	b := []byte(text)
+	for i := 0; i < len(b); {
+		if !mask[i] || !isWordByte(b[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(b) && mask[j] && isWordByte(b[j]) {
+			j++
+		}
+		// the keyword can be used as the field name like `t.select` or the parameter like `@limit`
+		qualified := i > 0 && (b[i-1] == '.' || b[i-1] == '@')
+		if _, ok := reservedKeywords[text[i:j]]; ok && !qualified {
+			copy(b[i:j], strings.ToLower(text[i:j]))
+		}
+		i = j
+	}
+	return string(b)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// reservedKeywords are the reserved keywords of GoogleSQL, which the ZetaSQL formatter writes in the upper case.
+// https://cloud.google.com/bigquery/docs/reference/standard-sql/lexical#reserved_keywords
+var reservedKeywords = map[string]struct{}{
+	"ALL": {}, "AND": {}, "ANY": {}, "ARRAY": {}, "AS": {}, "ASC": {}, "ASSERT_ROWS_MODIFIED": {}, "AT": {},
+	"BETWEEN": {}, "BY": {}, "CASE": {}, "CAST": {}, "COLLATE": {}, "CONTAINS": {}, "CREATE": {}, "CROSS": {},
+	"CUBE": {}, "CURRENT": {}, "DEFAULT": {}, "DEFINE": {}, "DESC": {}, "DISTINCT": {}, "ELSE": {}, "END": {},
+	"ENUM": {}, "ESCAPE": {}, "EXCEPT": {}, "EXCLUDE": {}, "EXISTS": {}, "EXTRACT": {}, "FALSE": {}, "FETCH": {},
+	"FOLLOWING": {}, "FOR": {}, "FROM": {}, "FULL": {}, "GROUP": {}, "GROUPING": {}, "GROUPS": {}, "HASH": {},
+	"HAVING": {}, "IF": {}, "IGNORE": {}, "IN": {}, "INNER": {}, "INTERSECT": {}, "INTERVAL": {}, "INTO": {},
+	"IS": {}, "JOIN": {}, "LATERAL": {}, "LEFT": {}, "LIKE": {}, "LIMIT": {}, "LOOKUP": {}, "MERGE": {},
+	"NATURAL": {}, "NEW": {}, "NO": {}, "NOT": {}, "NULL": {}, "NULLS": {}, "OF": {}, "ON": {}, "OR": {},
+	"ORDER": {}, "OUTER": {}, "OVER": {}, "PARTITION": {}, "PRECEDING": {}, "PROTO": {}, "QUALIFY": {},
+	"RANGE": {}, "RECURSIVE": {}, "RESPECT": {}, "RIGHT": {}, "ROLLUP": {}, "ROWS": {}, "SELECT": {}, "SET": {},
+	"SOME": {}, "STRUCT": {}, "TABLESAMPLE": {}, "THEN": {}, "TO": {}, "TREAT": {}, "TRUE": {}, "UNBOUNDED": {},
+	"UNION": {}, "UNNEST": {}, "USING": {}, "WHEN": {}, "WHERE": {}, "WINDOW": {}, "WITH": {}, "WITHIN": {},
+}
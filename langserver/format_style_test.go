@@ -0,0 +1,106 @@
+package langserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApplyFormatOption(t *testing.T) {
+	formatted := "SELECT\n  id,\n  name AS n,\n  CAST(score AS STRING) AS score_text,\n  'AS IS' AS label\nFROM\n  t\nWHERE\n  id > 0 AND name LIKE 'a%';\n"
+
+	tests := map[string]struct {
+		option FormatOption
+
+		expect string
+	}{
+		"default": {
+			option: FormatOption{},
+			expect: formatted,
+		},
+		"lower keywords": {
+			option: FormatOption{KeywordCase: "lower"},
+			expect: "select\n  id,\n  name as n,\n  cast(score as STRING) as score_text,\n  'AS IS' as label\nfrom\n  t\nwhere\n  id > 0 and name like 'a%';\n",
+		},
+		"indent width": {
+			option: FormatOption{IndentWidth: 4},
+			expect: "SELECT\n    id,\n    name AS n,\n    CAST(score AS STRING) AS score_text,\n    'AS IS' AS label\nFROM\n    t\nWHERE\n    id > 0 AND name LIKE 'a%';\n",
+		},
+		"leading commas": {
+			option: FormatOption{CommaStyle: "leading"},
+			expect: "SELECT\n  id\n  , name AS n\n  , CAST(score AS STRING) AS score_text\n  , 'AS IS' AS label\nFROM\n  t\nWHERE\n  id > 0 AND name LIKE 'a%';\n",
+		},
+		"align aliases": {
+			option: FormatOption{AlignAliases: true},
+			expect: "SELECT\n  id,\n  name                  AS n,\n  CAST(score AS STRING) AS score_text,\n  'AS IS'               AS label\nFROM\n  t\nWHERE\n  id > 0 AND name LIKE 'a%';\n",
+		},
+		"max line width": {
+			option: FormatOption{MaxLineWidth: 20},
+			expect: "SELECT\n  id,\n  name AS n,\n  CAST(score AS STRING) AS score_text,\n  'AS IS' AS label\nFROM\n  t\nWHERE\n  id > 0\n    AND name LIKE 'a%';\n",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := applyFormatOption(formatted, tt.option)
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("applyFormatOption result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadFormatOptionForFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".bqls.yaml"), []byte("format:\n  indent_width: 4\ncomma_style: leading\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "queries", "users"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadFormatOptionForFile(filepath.Join(root, "queries", "users", "select.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := FormatOption{IndentWidth: 4, CommaStyle: commaStyleLeading}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("LoadFormatOptionForFile result diff (-expect, +got)\n%s", diff)
+	}
+
+	if dir := findWorkspaceConfigDir(filepath.Join(root, "queries")); dir != root {
+		t.Errorf("findWorkspaceConfigDir should find the config of the parent, but got %q", dir)
+	}
+}
+
+func TestFormatDocumentIsSameAsFmt(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".bqls.json"), []byte(`{"format": {"indent_width": 4, "keyword_case": "lower"}, "comma_style": "leading"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, "queries", "select.sql")
+	src := "select id, name from t where id > 0"
+
+	h := &Handler{}
+	got, err := h.formatDocument(uriToDocumentURI(path), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmtResult, err := FormatSQLForFile(path, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(fmtResult, got); diff != "" {
+		t.Errorf("the formatting of the language server should be the same as bqls fmt (-fmt, +got)\n%s", diff)
+	}
+
+	defaultResult, err := FormatSQLWithOption(src, FormatOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == defaultResult {
+		t.Errorf("the style of .bqls.json should be applied, but got the default style\n%s", got)
+	}
+}
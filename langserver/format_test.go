@@ -0,0 +1,52 @@
+package langserver_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kitagry/bqls/langserver"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+func TestFormatSQLRange(t *testing.T) {
+	text := "WITH data AS (select   id from t)\nSELECT * FROM data"
+	rng := lsp.Range{
+		Start: lsp.Position{Line: 0, Character: 13},
+		End:   lsp.Position{Line: 0, Character: 33},
+	}
+
+	edits, err := langserver.FormatSQLRange(text, rng, lsp.FormattingOptions{TabSize: 2, InsertSpaces: true}, langserver.FormatOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 1 {
+		t.Fatalf("FormatSQLRange should return 1 edit, got %d", len(edits))
+	}
+
+	edit := edits[0]
+	if edit.Range != rng {
+		t.Errorf("the edit should replace only the selected range %v, got %v", rng, edit.Range)
+	}
+	if !strings.HasPrefix(edit.NewText, "(\n  SELECT") || !strings.HasSuffix(edit.NewText, "\n)") {
+		t.Errorf("the subquery should be formatted in the parentheses, got %q", edit.NewText)
+	}
+	if strings.Contains(edit.NewText, ";") {
+		t.Errorf("the subquery should not end with a semicolon, got %q", edit.NewText)
+	}
+}
+
+func TestFormatSQLRange_NotWrapped(t *testing.T) {
+	text := "(select 1) union all (select ')')"
+	rng := lsp.Range{
+		Start: lsp.Position{Line: 0, Character: 0},
+		End:   lsp.Position{Line: 0, Character: len(text)},
+	}
+
+	edits, err := langserver.FormatSQLRange(text, rng, lsp.FormattingOptions{TabSize: 2, InsertSpaces: true}, langserver.FormatOption{})
+	if err != nil {
+		t.Fatalf("the parentheses which don't wrap the whole selection should be formatted as the query: %v", err)
+	}
+	if len(edits) != 1 || !strings.Contains(edits[0].NewText, "UNION ALL") {
+		t.Errorf("the set operation should be formatted, got %v", edits)
+	}
+}
@@ -18,11 +18,12 @@ func (h *Handler) handleTextDocumentHover(ctx context.Context, conn *jsonrpc2.Co
 		return nil, err
 	}
 
-	return h.documentIdent(ctx, params.TextDocument.URI, params.Position)
+	position := h.positionConverter(params.TextDocument.URI).toByte(params.Position)
+	return h.documentIdent(ctx, params.TextDocument.URI, position)
 }
 
 func (h *Handler) documentIdent(ctx context.Context, uri lsp.DocumentURI, position lsp.Position) (lsp.Hover, error) {
-	result, err := h.project.TermDocument(documentURIToURI(uri), position)
+	result, err := h.projectOf(uri).TermDocument(documentURIToURI(uri), position)
 	if err != nil {
 		return lsp.Hover{}, err
 	}
@@ -3,14 +3,351 @@ package langserver
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"regexp"
 
+	"github.com/kitagry/bqls/langserver/internal/bigquery"
 	"github.com/kitagry/bqls/langserver/internal/lsp"
 	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
 	"github.com/sourcegraph/jsonrpc2"
 )
 
 type InitializeOption struct {
 	ProjectID string `json:"project_id"`
+
+	// BillingProjectID is the project which runs query jobs.
+	// When it is empty, ProjectID is used.
+	BillingProjectID string `json:"billing_project_id"`
+
+	// Location is the location of the query jobs and the datasets like `asia-northeast1`.
+	Location string `json:"location"`
+
+	// SchemaDir is the directory of the local schema files.
+	// When it is set, bqls runs in offline mode and doesn't call the BigQuery API.
+	SchemaDir string `json:"schema_dir"`
+
+	// CredentialsFile is the path of the service account key file.
+	// When it is empty, Application Default Credentials are used.
+	CredentialsFile string `json:"credentials_file"`
+
+	// ImpersonateServiceAccount is the service account which the client impersonates.
+	ImpersonateServiceAccount string `json:"impersonate_service_account"`
+
+	// Endpoint overrides the BigQuery API endpoint, e.g. for bigquery-emulator.
+	Endpoint string `json:"endpoint"`
+
+	// CacheMaxDocuments is the max number of the closed documents kept in memory. The opened documents are always kept.
+	CacheMaxDocuments int `json:"cache_max_documents"`
+
+	// CacheMaxTables is the max number of the table metadata kept in memory.
+	CacheMaxTables int `json:"cache_max_tables"`
+
+	// HoverPreviewRows is the number of rows shown in the hover of tables.
+	// When it is 0, the rows are not shown.
+	HoverPreviewRows int `json:"hover_preview_rows"`
+
+	// ResultPageSize is the number of rows in a page of the query result.
+	ResultPageSize int `json:"result_page_size"`
+
+	// WorkspaceDiagnostics enables the diagnostics of all .sql files under the root path,
+	// not only the opened documents.
+	WorkspaceDiagnostics bool `json:"workspace_diagnostics"`
+
+	// MaxBytesProcessed is the limit of the estimated bytes processed of the executed query.
+	// The query over the limit is executed only with --force. When it is 0, there is no limit.
+	MaxBytesProcessed int64 `json:"max_bytes_processed"`
+
+	// ExplorationSample samples the queries executed by executeQuery to keep the cost of the exploration low.
+	ExplorationSample SampleOption `json:"exploration_sample"`
+
+	// MaximumBytesBilled limits the bytes billed of the query jobs launched by bqls.
+	MaximumBytesBilled int64 `json:"maximum_bytes_billed"`
+
+	// JobLabels are attached to the query jobs launched by bqls.
+	JobLabels map[string]string `json:"job_labels"`
+
+	// JobPriority is `interactive` or `batch`.
+	JobPriority string `json:"job_priority"`
+
+	// LogLevel overrides the log level of the server like `debug`.
+	LogLevel string `json:"log_level"`
+
+	// DisableQueryHistory disables recording the executed and dry-run queries in the local history.
+	DisableQueryHistory bool `json:"disable_query_history"`
+
+	// CommaStyle is `trailing` or `leading`, which is used by the formatting and the on-type formatting.
+	CommaStyle string `json:"comma_style"`
+
+	// Format is the style of the formatting.
+	Format FormatOption `json:"format"`
+
+	// DisableFormatting disables the formatting providers, e.g. to use another formatter.
+	DisableFormatting bool `json:"disable_formatting"`
+
+	// LintSelectStar reports `SELECT *` which is not used with EXCEPT or REPLACE as a warning.
+	LintSelectStar bool `json:"lint_select_star"`
+
+	// LintNonDeterministicLimit reports ORDER BY with LIMIT whose keys may have ties as a warning.
+	LintNonDeterministicLimit bool `json:"lint_non_deterministic_limit"`
+
+	// LintOrdinals reports the ordinals of GROUP BY and ORDER BY as warnings.
+	LintOrdinals bool `json:"lint_ordinals"`
+
+	// BannedFunctions are reported when they are called.
+	BannedFunctions []BannedFunctionOption `json:"banned_functions"`
+
+	// LintRules are the structural lint rules interpreted over the AST.
+	LintRules []LintRuleOption `json:"lint_rules"`
+
+	// DestinationTables maps the glob patterns of the file paths relative to the workspace root to the tables which the query results are written into.
+	DestinationTables map[string]string `json:"destination_tables"`
+
+	// LanguageOptions overrides the syntax which the analyzer accepts.
+	LanguageOptions LanguageOption `json:"language_options"`
+
+	// EmbeddedSQL extracts SQL from the string literals of Python and Go files.
+	EmbeddedSQL EmbeddedSQLOption `json:"embedded_sql"`
+}
+
+// SampleOption samples the exploratory queries, which consist of a single SELECT statement.
+type SampleOption struct {
+	// Percent adds `TABLESAMPLE SYSTEM (percent PERCENT)` to the tables.
+	Percent float64 `json:"percent"`
+
+	// Limit appends `LIMIT limit` to the query without LIMIT.
+	Limit int `json:"limit"`
+}
+
+// BannedFunctionOption is the function which the team doesn't want to use.
+type BannedFunctionOption struct {
+	// Name is the name of the function like `CURRENT_TIMESTAMP`.
+	Name string `json:"name"`
+
+	// Pattern is the regular expression which limits the reported calls to the ones matching it.
+	Pattern string `json:"pattern"`
+
+	// Message is shown in the diagnostic instead of the default message.
+	Message string `json:"message"`
+
+	// Severity is `error`, `warning` (default), `information` or `hint`.
+	Severity string `json:"severity"`
+}
+
+// LintRuleOption is the structural lint rule which reports the AST nodes of the kind satisfying all the predicates.
+type LintRuleOption struct {
+	// Name is reported as the code of the diagnostic.
+	Name string `json:"name"`
+
+	// Node is the kind of the AST node like `Join` or `FunctionCall`.
+	Node string `json:"node"`
+
+	// Pattern is the regular expression which the SQL of the node should match.
+	Pattern string `json:"pattern"`
+
+	// NotPattern is the regular expression which the SQL of the node should not match.
+	NotPattern string `json:"not_pattern"`
+
+	// Within is the kind of the AST node which should contain the node.
+	Within string `json:"within"`
+
+	Message string `json:"message"`
+
+	// Severity is `error`, `warning` (default), `information` or `hint`.
+	Severity string `json:"severity"`
+}
+
+// LanguageOption configures the language features of the analyzer.
+type LanguageOption struct {
+	// EnabledFeatures are the features like `V_1_3_PIVOT` enabled in addition to the default ones.
+	EnabledFeatures []string `json:"enabled_features"`
+
+	// DisabledFeatures are the features which are disabled even if they are enabled by default.
+	DisabledFeatures []string `json:"disabled_features"`
+
+	// ProductMode is `external` (default) or `internal`.
+	ProductMode string `json:"product_mode"`
+
+	// ReservedKeywords are the reservable keywords treated as reserved. When it is nil, `QUALIFY` is reserved.
+	ReservedKeywords []string `json:"reserved_keywords"`
+}
+
+// EmbeddedSQLOption configures which string literals of Python and Go files are analyzed as SQL.
+type EmbeddedSQLOption struct {
+	Enabled bool `json:"enabled"`
+
+	// Markers are the comments which mark the string literal in the same or the next line as SQL.
+	// When it is nil, `bqls:sql` is used.
+	Markers []string `json:"markers"`
+
+	// CallSites are the names of the functions whose first argument is SQL.
+	// When it is nil, `query`, `read_gbq` and `Query` are used.
+	CallSites []string `json:"call_sites"`
+}
+
+func (o InitializeOption) connectionOption() bigquery.ConnectionOption {
+	return bigquery.ConnectionOption{
+		CredentialsFile:           o.CredentialsFile,
+		ImpersonateServiceAccount: o.ImpersonateServiceAccount,
+		Endpoint:                  o.Endpoint,
+	}
+}
+
+func (o InitializeOption) queryOption() bigquery.QueryOption {
+	return bigquery.QueryOption{
+		MaxBytesBilled: o.MaximumBytesBilled,
+		Labels:         o.JobLabels,
+		Priority:       o.JobPriority,
+	}
+}
+
+func (o InitializeOption) formatOption() FormatOption {
+	option := o.Format
+	option.CommaStyle = o.CommaStyle
+	return option
+}
+
+func (o InitializeOption) bannedFunctions() ([]file.BannedFunction, error) {
+	result := make([]file.BannedFunction, 0, len(o.BannedFunctions))
+	for _, f := range o.BannedFunctions {
+		if f.Name == "" {
+			return nil, fmt.Errorf("name of banned_functions is required")
+		}
+
+		bannedFunction := file.BannedFunction{
+			Name:    f.Name,
+			Message: f.Message,
+		}
+		if f.Pattern != "" {
+			pattern, err := regexp.Compile(f.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern of banned function %s: %w", f.Name, err)
+			}
+			bannedFunction.Pattern = pattern
+		}
+		severity, err := parseSeverity(f.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid severity of banned function %s: %w", f.Name, err)
+		}
+		bannedFunction.Severity = severity
+		result = append(result, bannedFunction)
+	}
+	return result, nil
+}
+
+func (o InitializeOption) lintRules() ([]file.LintRule, error) {
+	result := make([]file.LintRule, 0, len(o.LintRules))
+	for _, r := range o.LintRules {
+		if r.Name == "" || r.Node == "" {
+			return nil, fmt.Errorf("name and node of lint_rules are required")
+		}
+
+		rule := file.LintRule{
+			Name:    r.Name,
+			Node:    r.Node,
+			Within:  r.Within,
+			Message: r.Message,
+		}
+		var err error
+		if r.Pattern != "" {
+			rule.Pattern, err = regexp.Compile(r.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern of lint rule %s: %w", r.Name, err)
+			}
+		}
+		if r.NotPattern != "" {
+			rule.NotPattern, err = regexp.Compile(r.NotPattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid not_pattern of lint rule %s: %w", r.Name, err)
+			}
+		}
+		rule.Severity, err = parseSeverity(r.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid severity of lint rule %s: %w", r.Name, err)
+		}
+		result = append(result, rule)
+	}
+	return result, nil
+}
+
+func (o InitializeOption) languageOption() (file.LanguageOption, error) {
+	result := file.DefaultLanguageOption()
+	for _, name := range o.LanguageOptions.EnabledFeatures {
+		feature, err := file.ParseLanguageFeature(name)
+		if err != nil {
+			return file.LanguageOption{}, fmt.Errorf("invalid enabled_features: %w", err)
+		}
+		result.EnabledFeatures = append(result.EnabledFeatures, feature)
+	}
+	for _, name := range o.LanguageOptions.DisabledFeatures {
+		feature, err := file.ParseLanguageFeature(name)
+		if err != nil {
+			return file.LanguageOption{}, fmt.Errorf("invalid disabled_features: %w", err)
+		}
+		result.DisabledFeatures = append(result.DisabledFeatures, feature)
+	}
+	productMode, err := file.ParseProductMode(o.LanguageOptions.ProductMode)
+	if err != nil {
+		return file.LanguageOption{}, fmt.Errorf("invalid product_mode: %w", err)
+	}
+	result.ProductMode = productMode
+	if o.LanguageOptions.ReservedKeywords != nil {
+		result.ReservedKeywords = o.LanguageOptions.ReservedKeywords
+	}
+	return result, nil
+}
+
+func (o InitializeOption) embeddedSQLOption() file.EmbeddedSQLOption {
+	result := file.DefaultEmbeddedSQLOption()
+	result.Enabled = o.EmbeddedSQL.Enabled
+	if o.EmbeddedSQL.Markers != nil {
+		result.Markers = o.EmbeddedSQL.Markers
+	}
+	if o.EmbeddedSQL.CallSites != nil {
+		result.CallSites = o.EmbeddedSQL.CallSites
+	}
+	return result
+}
+
+func parseSeverity(s string) (lsp.DiagnosticSeverity, error) {
+	switch s {
+	case "", "warning":
+		return lsp.Warning, nil
+	case "error":
+		return lsp.Error, nil
+	case "information":
+		return lsp.Information, nil
+	case "hint":
+		return lsp.Hint, nil
+	default:
+		return 0, fmt.Errorf("unknown severity %q: it should be error, warning, information or hint", s)
+	}
+}
+
+func (o InitializeOption) projectConfig(rootPath string) source.Config {
+	return source.Config{
+		RootPath:          rootPath,
+		ProjectID:         o.ProjectID,
+		BillingProjectID:  o.BillingProjectID,
+		Location:          o.Location,
+		ConnectionOption:  o.connectionOption(),
+		QueryOption:       o.queryOption(),
+		MaxDocuments:      o.CacheMaxDocuments,
+		MaxTableMetadata:  o.CacheMaxTables,
+		HoverPreviewRows:  o.HoverPreviewRows,
+		ResultPageSize:    o.ResultPageSize,
+		MaxBytesProcessed: o.MaxBytesProcessed,
+		SampleOption: source.SampleOption{
+			Percent: o.ExplorationSample.Percent,
+			Limit:   o.ExplorationSample.Limit,
+		},
+
+		DisableQueryHistory:       o.DisableQueryHistory,
+		LintSelectStar:            o.LintSelectStar,
+		LintNonDeterministicLimit: o.LintNonDeterministicLimit,
+		LintOrdinals:              o.LintOrdinals,
+	}
 }
 
 func (h *Handler) handleInitialize(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
@@ -25,23 +362,50 @@ func (h *Handler) handleInitialize(ctx context.Context, conn *jsonrpc2.Conn, req
 		return nil, err
 	}
 	h.initializeParams = params
+	h.positionEncoding = negotiatePositionEncoding(params.Capabilities.General.PositionEncodings)
+
+	if params.InitializationOptions.LogLevel != "" {
+		level, err := logrus.ParseLevel(params.InitializationOptions.LogLevel)
+		if err != nil {
+			h.logger.Warnf("invalid log_level: %v", err)
+		} else {
+			h.logger.SetLevel(level)
+		}
+	}
+	h.setTrace(params.Trace)
 
-	p, err := source.NewProject(context.Background(), params.RootPath, params.InitializationOptions.ProjectID, h.logger)
+	p, err := h.newProject(context.Background(), params.RootPath)
 	if err != nil {
 		return nil, err
 	}
 	h.project = p
 
+	for _, folder := range params.WorkspaceFolders {
+		if err := h.addWorkspaceFolder(context.Background(), folder); err != nil {
+			h.logger.Errorf("failed to create the project of %s: %v", folder.URI, err)
+		}
+	}
+
 	return lsp.InitializeResult{
 		Capabilities: lsp.ServerCapabilities{
+			PositionEncoding: h.positionEncoding,
 			TextDocumentSync: &lsp.TextDocumentSyncOptionsOrKind{
 				Kind: toPtr(lsp.TDSKFull),
 			},
-			DocumentFormattingProvider: true,
-			HoverProvider:              true,
-			CodeActionProvider:         true,
+			DocumentFormattingProvider:       h.staticFormattingProvider(formattingMethod),
+			DocumentRangeFormattingProvider:  h.staticFormattingProvider(rangeFormattingMethod),
+			DocumentOnTypeFormattingProvider: h.staticOnTypeFormattingProvider(),
+			HoverProvider:                    true,
+			DefinitionProvider:               true,
+			DocumentHighlightProvider:        true,
+			SelectionRangeProvider:           true,
+			CodeActionProvider:               true,
+			WorkspaceSymbolProvider:          true,
+			DocumentLinkProvider: &lsp.DocumentLinkOptions{
+				ResolveProvider: false,
+			},
 			CompletionProvider: &lsp.CompletionOptions{
-				ResolveProvider:   false,
+				ResolveProvider:   true,
 				TriggerCharacters: []string{"*", "."},
 			},
 			ExecuteCommandProvider: &lsp.ExecuteCommandOptions{
@@ -50,8 +414,48 @@ func (h *Handler) handleInitialize(ctx context.Context, conn *jsonrpc2.Conn, req
 					CommandListDatasets,
 					CommandListTables,
 					CommandListJobHistories,
+					CommandShowLineage,
+					CommandExportSchemas,
+					CommandPreviewTable,
+					CommandFetchMoreResults,
+					CommandSaveResults,
+					CommandQueryHistory,
+					CommandRerunQuery,
+					CommandExtractSubqueryToCTE,
+					CommandInlineCTE,
+					CommandFixUngroupedColumn,
+					CommandConvertLegacySQL,
+					CommandExpandStar,
+					CommandCastExpression,
+					CommandAddDistinctAlias,
+					CommandShowOutputSchema,
+					CommandExplainQuery,
+					CommandValidateScheduledQuery,
+					CommandUpdateTable,
+					CommandFindColumn,
+					CommandAddJoinCondition,
+					CommandUnnestArrayColumn,
+					CommandWrapJSONExpression,
+					CommandReplaceOrdinals,
+					CommandEvaluateExpression,
+					CommandGenerateTestScaffold,
+					CommandExecuteWithFixtures,
+					CommandDiffQueries,
 				},
 			},
+			Workspace: &lsp.WorkspaceServerCapabilities{
+				WorkspaceFolders: &lsp.WorkspaceFoldersServerCapabilities{
+					Supported:           true,
+					ChangeNotifications: true,
+				},
+			},
+			Experimental: lsp.ExperimentalServerCapabilities{
+				VirtualTextDocument: true,
+				ListDatasets:        true,
+				ListTables:          true,
+				ListJobs:            true,
+				GetJob:              true,
+			},
 		},
 	}, nil
 }
@@ -2,12 +2,20 @@ package bigquery
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 
 	"cloud.google.com/go/bigquery"
+	"github.com/kitagry/bqls/langserver/internal/metrics"
+	"github.com/sirupsen/logrus"
+	bqv2 "google.golang.org/api/bigquery/v2"
 	"google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/impersonate"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 )
 
 type Client interface {
@@ -31,9 +39,21 @@ type Client interface {
 	// GetTableRecord returns the row of the specified table.
 	GetTableRecord(ctx context.Context, projectID, datasetID, tableID string) (*bigquery.RowIterator, error)
 
+	// GetRoutineMetadata returns the metadata of the specified routine(UDF, TVF or procedure).
+	GetRoutineMetadata(ctx context.Context, projectID, datasetID, routineID string) (*bigquery.RoutineMetadata, error)
+
+	// UpdateTableMetadata updates the metadata like the description and the labels of the specified table.
+	UpdateTableMetadata(ctx context.Context, projectID, datasetID, tableID string, update bigquery.TableMetadataToUpdate) (*bigquery.TableMetadata, error)
+
+	// ListRowAccessPolicies lists the row access policies of the specified table.
+	ListRowAccessPolicies(ctx context.Context, projectID, datasetID, tableID string) ([]RowAccessPolicy, error)
+
 	// Run runs the specified query.
 	Run(ctx context.Context, q string, dryrun bool) (BigqueryJob, error)
 
+	// RunWithDestination runs the specified query and writes the result into the destination table.
+	RunWithDestination(ctx context.Context, q string, destination Destination) (BigqueryJob, error)
+
 	// JobFromID returns the job with the specified ID.
 	JobFromProject(ctx context.Context, projectID, id string) (BigqueryJob, error)
 
@@ -42,24 +62,177 @@ type Client interface {
 }
 
 type client struct {
-	bqClient                    *bigquery.Client
+	// bqClient is the client for the billing project, which runs query jobs.
+	bqClient *bigquery.Client
+	// bqService is the REST client for the APIs which bqClient doesn't support like the row access policies.
+	bqService                   *bqv2.Service
 	cloudresourcemanagerService *cloudresourcemanager.Service
+	defaultProjectID            string
+	clientOptions               []option.ClientOption
+	queryOption                 QueryOption
+
+	// projectClients are the clients for the data projects, which are created on demand.
+	projectClientsLock sync.Mutex
+	projectClients     map[string]*bigquery.Client
+}
+
+// ConnectionOption configures how the client connects to and authenticates with BigQuery.
+// When no option is set, Application Default Credentials are used.
+type ConnectionOption struct {
+	// CredentialsFile is the path of the service account key file.
+	CredentialsFile string
+
+	// ImpersonateServiceAccount is the email of the service account to impersonate.
+	ImpersonateServiceAccount string
+
+	// Endpoint overrides the BigQuery API endpoint like `http://localhost:9050` for bigquery-emulator.
+	// When it is set, the client doesn't authenticate.
+	Endpoint string
+}
+
+func (o ConnectionOption) clientOptions(ctx context.Context) ([]option.ClientOption, error) {
+	if o.Endpoint != "" {
+		return []option.ClientOption{
+			option.WithEndpoint(o.Endpoint),
+			option.WithoutAuthentication(),
+		}, nil
+	}
+
+	opts := make([]option.ClientOption, 0)
+	if o.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(o.CredentialsFile))
+	}
+
+	if o.ImpersonateServiceAccount != "" {
+		ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+			TargetPrincipal: o.ImpersonateServiceAccount,
+			Scopes:          []string{bigquery.Scope, cloudresourcemanager.CloudPlatformScope},
+		}, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("impersonate.CredentialsTokenSource: %w", err)
+		}
+		opts = []option.ClientOption{option.WithTokenSource(ts)}
+	}
+
+	return opts, nil
+}
+
+// QueryOption configures the query jobs launched by the client.
+type QueryOption struct {
+	// MaxBytesBilled limits the bytes billed of the query jobs. The job over the limit fails without charge.
+	// When it is 0, the default of the project is used.
+	MaxBytesBilled int64
+
+	// Labels are attached to the query jobs, so that the cost can be attributed to bqls.
+	Labels map[string]string
+
+	// Priority is `interactive` or `batch`. When it is empty, interactive is used.
+	Priority string
+}
+
+// Destination is the table which the query result is written into.
+type Destination struct {
+	ProjectID string
+	DatasetID string
+	TableID   string
+
+	// WriteDisposition is WRITE_TRUNCATE, WRITE_APPEND or WRITE_EMPTY. When it is empty, BigQuery uses WRITE_EMPTY.
+	WriteDisposition bigquery.TableWriteDisposition
+
+	// CreateDisposition is CREATE_IF_NEEDED or CREATE_NEVER. When it is empty, BigQuery uses CREATE_IF_NEEDED.
+	CreateDisposition bigquery.TableCreateDisposition
+}
+
+// NewDestination parses the table like `project.dataset.table` or `dataset.table` and the dispositions.
+// When the project is omitted, defaultProjectID is used.
+func NewDestination(table, defaultProjectID, writeDisposition, createDisposition string) (Destination, error) {
+	names := strings.Split(strings.Trim(strings.ReplaceAll(table, ":", "."), "`"), ".")
+	if len(names) == 2 {
+		names = append([]string{defaultProjectID}, names...)
+	}
+	if len(names) != 3 || slices.Contains(names, "") {
+		return Destination{}, fmt.Errorf("invalid destination table %q: it should be project.dataset.table or dataset.table", table)
+	}
+
+	result := Destination{ProjectID: names[0], DatasetID: names[1], TableID: names[2]}
+	switch w := bigquery.TableWriteDisposition(strings.ToUpper(writeDisposition)); w {
+	case "", bigquery.WriteTruncate, bigquery.WriteAppend, bigquery.WriteEmpty:
+		result.WriteDisposition = w
+	default:
+		return Destination{}, fmt.Errorf("invalid write disposition %q: it should be WRITE_TRUNCATE, WRITE_APPEND or WRITE_EMPTY", writeDisposition)
+	}
+	switch c := bigquery.TableCreateDisposition(strings.ToUpper(createDisposition)); c {
+	case "", bigquery.CreateIfNeeded, bigquery.CreateNever:
+		result.CreateDisposition = c
+	default:
+		return Destination{}, fmt.Errorf("invalid create disposition %q: it should be CREATE_IF_NEEDED or CREATE_NEVER", createDisposition)
+	}
+	return result, nil
+}
+
+func (o QueryOption) priority() (bigquery.QueryPriority, error) {
+	switch strings.ToLower(o.Priority) {
+	case "", "interactive":
+		return bigquery.InteractivePriority, nil
+	case "batch":
+		return bigquery.BatchPriority, nil
+	default:
+		return "", fmt.Errorf("invalid job priority %q: it should be interactive or batch", o.Priority)
+	}
 }
 
-func New(ctx context.Context, projectID string, withCache bool) (Client, error) {
-	cloudresourcemanagerService, err := cloudresourcemanager.NewService(ctx)
+// New creates the client.
+// projectID is the default project of the tables, and billingProjectID is the project which runs query jobs.
+// When billingProjectID is empty, projectID is used for both.
+// location is the location of the query jobs like `asia-northeast1`. When it is empty, BigQuery infers it from the query.
+// cacheSize is the max number of the cached metadata. When it is 0, the client doesn't cache the API results.
+// logger receives the failures of the cache, which don't fail the API calls.
+func New(ctx context.Context, projectID, billingProjectID, location string, cacheSize int, connectionOption ConnectionOption, queryOption QueryOption, logger *logrus.Logger) (Client, error) {
+	if _, err := queryOption.priority(); err != nil {
+		return nil, err
+	}
+
+	opts, err := connectionOption.clientOptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	crmOpts := opts
+	if connectionOption.Endpoint != "" {
+		// the endpoint is only for BigQuery API.
+		crmOpts = []option.ClientOption{option.WithoutAuthentication()}
+	}
+	cloudresourcemanagerService, err := cloudresourcemanager.NewService(ctx, crmOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("cloudresourcemanager.NewService: %w", err)
 	}
 
-	bqClient, err := bigquery.NewClient(ctx, projectID)
+	if billingProjectID == "" {
+		billingProjectID = projectID
+	}
+
+	bqClient, err := bigquery.NewClient(ctx, billingProjectID, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("bigquery.NewClient: %w", err)
 	}
+	bqClient.Location = location
 
-	var client Client = &client{bqClient, cloudresourcemanagerService}
-	if withCache {
-		client, err = newCache(client)
+	bqService, err := bqv2.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("bigquery.NewService: %w", err)
+	}
+
+	var client Client = &client{
+		bqClient:                    bqClient,
+		bqService:                   bqService,
+		cloudresourcemanagerService: cloudresourcemanagerService,
+		defaultProjectID:            projectID,
+		clientOptions:               opts,
+		queryOption:                 queryOption,
+		projectClients:              make(map[string]*bigquery.Client),
+	}
+	if cacheSize > 0 {
+		client, err = newCache(client, cacheSize, logger)
 		if err != nil {
 			return nil, fmt.Errorf("newCache: %w", err)
 		}
@@ -69,14 +242,46 @@ func New(ctx context.Context, projectID string, withCache bool) (Client, error)
 }
 
 func (c *client) Close() error {
-	return c.bqClient.Close()
+	c.projectClientsLock.Lock()
+	defer c.projectClientsLock.Unlock()
+
+	errs := make([]error, 0, len(c.projectClients)+1)
+	for _, projectClient := range c.projectClients {
+		errs = append(errs, projectClient.Close())
+	}
+	errs = append(errs, c.bqClient.Close())
+	return errors.Join(errs...)
 }
 
 func (c *client) GetDefaultProject() string {
-	return c.bqClient.Project()
+	return c.defaultProjectID
+}
+
+// projectClient returns the pooled client for the specified data project.
+func (c *client) projectClient(projectID string) (*bigquery.Client, error) {
+	if projectID == c.bqClient.Project() {
+		return c.bqClient, nil
+	}
+
+	c.projectClientsLock.Lock()
+	defer c.projectClientsLock.Unlock()
+
+	if projectClient, ok := c.projectClients[projectID]; ok {
+		return projectClient, nil
+	}
+
+	// The pooled client outlives the request, so it shouldn't depend on the request context.
+	projectClient, err := bigquery.NewClient(context.Background(), projectID, c.clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("bigquery.NewClient(%s): %w", projectID, err)
+	}
+	projectClient.Location = c.bqClient.Location
+	c.projectClients[projectID] = projectClient
+	return projectClient, nil
 }
 
 func (c *client) ListProjects(ctx context.Context) ([]*cloudresourcemanager.Project, error) {
+	metrics.Default.IncBigQueryCall("ListProjects")
 	caller := c.cloudresourcemanagerService.Projects.List().Context(ctx)
 
 	list, err := caller.Do()
@@ -100,26 +305,52 @@ func (c *client) ListProjects(ctx context.Context) ([]*cloudresourcemanager.Proj
 }
 
 func (c *client) ListDatasets(ctx context.Context, projectID string) ([]*bigquery.Dataset, error) {
-	d := c.bqClient.Datasets(ctx)
-	d.ProjectID = projectID
+	metrics.Default.IncBigQueryCall("ListDatasets")
+	bqClient, err := c.projectClient(projectID)
+	if err != nil {
+		return nil, err
+	}
 
+	// datasets.list returns the location of each dataset, which the iterator of bqClient drops.
+	// So the datasets are filtered by the response without fetching the metadata of each dataset.
 	datasets := make([]*bigquery.Dataset, 0)
-	for {
-		dt, err := d.Next()
-		if err == iterator.Done {
-			break
+	err = c.bqService.Datasets.List(projectID).Pages(ctx, func(page *bqv2.DatasetList) error {
+		for _, dt := range page.Datasets {
+			if dt.DatasetReference == nil {
+				continue
+			}
+			if c.bqClient.Location != "" && !strings.EqualFold(dt.Location, c.bqClient.Location) {
+				continue
+			}
+			datasets = append(datasets, bqClient.DatasetInProject(dt.DatasetReference.ProjectId, dt.DatasetReference.DatasetId))
 		}
-		if err != nil {
-			return nil, fmt.Errorf("fail to scan DatasetsInProject: %w", err)
-		}
-
-		datasets = append(datasets, dt)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to scan DatasetsInProject: %w", err)
 	}
 	return datasets, nil
 }
 
 func (c *client) ListTables(ctx context.Context, projectID, datasetID string) ([]*bigquery.Table, error) {
-	dataset := c.bqClient.DatasetInProject(projectID, datasetID)
+	metrics.Default.IncBigQueryCall("ListTables")
+	tables, err := c.listAllTables(ctx, projectID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+
+	tables = extractLatestSuffixTables(tables)
+
+	return tables, nil
+}
+
+func (c *client) listAllTables(ctx context.Context, projectID, datasetID string) ([]*bigquery.Table, error) {
+	bqClient, err := c.projectClient(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset := bqClient.DatasetInProject(projectID, datasetID)
 
 	it := dataset.Tables(ctx)
 	it.PageInfo().MaxSize = 1000
@@ -141,13 +372,21 @@ func (c *client) ListTables(ctx context.Context, projectID, datasetID string) ([
 		tables = append(tables, table)
 	}
 
-	tables = extractLatestSuffixTables(tables)
-
 	return tables, nil
 }
 
 func (c *client) GetTableMetadata(ctx context.Context, projectID, datasetID, tableID string) (*bigquery.TableMetadata, error) {
-	md, err := c.bqClient.DatasetInProject(projectID, datasetID).Table(tableID).Metadata(ctx)
+	metrics.Default.IncBigQueryCall("GetTableMetadata")
+	if strings.HasSuffix(tableID, "*") {
+		return c.getWildcardTableMetadata(ctx, projectID, datasetID, strings.TrimSuffix(tableID, "*"))
+	}
+
+	bqClient, err := c.projectClient(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := bqClient.DatasetInProject(projectID, datasetID).Table(tableID).Metadata(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("fail to get metadata: %w", err)
 	}
@@ -155,8 +394,108 @@ func (c *client) GetTableMetadata(ctx context.Context, projectID, datasetID, tab
 	return md, nil
 }
 
+func (c *client) UpdateTableMetadata(ctx context.Context, projectID, datasetID, tableID string, update bigquery.TableMetadataToUpdate) (*bigquery.TableMetadata, error) {
+	metrics.Default.IncBigQueryCall("UpdateTableMetadata")
+	bqClient, err := c.projectClient(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := bqClient.DatasetInProject(projectID, datasetID).Table(tableID).Update(ctx, update, "")
+	if err != nil {
+		return nil, fmt.Errorf("fail to update metadata: %w", err)
+	}
+
+	return md, nil
+}
+
+// getWildcardTableMetadata returns the metadata of the wildcard table `prefix*`.
+// The schema is merged from the latest table of each suffix family matching the prefix.
+func (c *client) getWildcardTableMetadata(ctx context.Context, projectID, datasetID, prefix string) (*bigquery.TableMetadata, error) {
+	tables, err := c.listAllTables(ctx, projectID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+
+	matchedTables := make([]*bigquery.Table, 0)
+	for _, table := range tables {
+		if strings.HasPrefix(table.TableID, prefix) {
+			matchedTables = append(matchedTables, table)
+		}
+	}
+
+	var result *bigquery.TableMetadata
+	for _, table := range extractLatestSuffixTables(matchedTables) {
+		md, err := table.Metadata(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("fail to get metadata(%s): %w", table.TableID, err)
+		}
+
+		if result == nil {
+			result = md
+			continue
+		}
+		result.Schema = mergeSchema(result.Schema, md.Schema)
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("no tables match %s.%s.%s*", projectID, datasetID, prefix)
+	}
+
+	return result, nil
+}
+
 func (c *client) GetTableRecord(ctx context.Context, projectID, datasetID, tableID string) (*bigquery.RowIterator, error) {
-	return c.bqClient.DatasetInProject(projectID, datasetID).Table(tableID).Read(ctx), nil
+	metrics.Default.IncBigQueryCall("GetTableRecord")
+	bqClient, err := c.projectClient(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return bqClient.DatasetInProject(projectID, datasetID).Table(tableID).Read(ctx), nil
+}
+
+func (c *client) GetRoutineMetadata(ctx context.Context, projectID, datasetID, routineID string) (*bigquery.RoutineMetadata, error) {
+	metrics.Default.IncBigQueryCall("GetRoutineMetadata")
+	bqClient, err := c.projectClient(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := bqClient.DatasetInProject(projectID, datasetID).Routine(routineID).Metadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get routine metadata: %w", err)
+	}
+
+	return md, nil
+}
+
+// RowAccessPolicy is the row-level security policy of the table.
+type RowAccessPolicy struct {
+	ID string
+	// FilterPredicate is the condition of the rows which the grantees can read like `region = 'US'`.
+	FilterPredicate string
+}
+
+func (c *client) ListRowAccessPolicies(ctx context.Context, projectID, datasetID, tableID string) ([]RowAccessPolicy, error) {
+	metrics.Default.IncBigQueryCall("ListRowAccessPolicies")
+	result := make([]RowAccessPolicy, 0)
+	err := c.bqService.RowAccessPolicies.List(projectID, datasetID, tableID).Pages(ctx, func(page *bqv2.ListRowAccessPoliciesResponse) error {
+		for _, policy := range page.RowAccessPolicies {
+			if policy.RowAccessPolicyReference == nil {
+				continue
+			}
+			result = append(result, RowAccessPolicy{
+				ID:              policy.RowAccessPolicyReference.PolicyId,
+				FilterPredicate: policy.FilterPredicate,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to list row access policies: %w", err)
+	}
+	return result, nil
 }
 
 type BigqueryJob interface {
@@ -164,12 +503,14 @@ type BigqueryJob interface {
 	Read(context.Context) (*bigquery.RowIterator, error)
 	LastStatus() *bigquery.JobStatus
 	Config() (bigquery.JobConfig, error)
+	Wait(context.Context) (*bigquery.JobStatus, error)
+	Cancel(context.Context) error
 }
 
 func (c *client) Run(ctx context.Context, q string, dryrun bool) (BigqueryJob, error) {
-	query := c.bqClient.Query(q)
+	metrics.Default.IncBigQueryCall("Run")
+	query := c.newQuery(q)
 	query.DryRun = dryrun
-	query.UseLegacySQL = false
 	job, err := query.Run(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("fail to run query: %w", err)
@@ -178,10 +519,36 @@ func (c *client) Run(ctx context.Context, q string, dryrun bool) (BigqueryJob, e
 	return job, nil
 }
 
+func (c *client) RunWithDestination(ctx context.Context, q string, destination Destination) (BigqueryJob, error) {
+	metrics.Default.IncBigQueryCall("RunWithDestination")
+	query := c.newQuery(q)
+	query.Dst = c.bqClient.DatasetInProject(destination.ProjectID, destination.DatasetID).Table(destination.TableID)
+	query.WriteDisposition = destination.WriteDisposition
+	query.CreateDisposition = destination.CreateDisposition
+	job, err := query.Run(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fail to run query: %w", err)
+	}
+
+	return job, nil
+}
+
+func (c *client) newQuery(q string) *bigquery.Query {
+	query := c.bqClient.Query(q)
+	query.UseLegacySQL = false
+	query.MaxBytesBilled = c.queryOption.MaxBytesBilled
+	query.Labels = c.queryOption.Labels
+	// The priority is validated in New.
+	query.Priority, _ = c.queryOption.priority()
+	return query
+}
+
 func (c *client) JobFromProject(ctx context.Context, projectID, id string) (BigqueryJob, error) {
+	metrics.Default.IncBigQueryCall("JobFromProject")
 	return c.bqClient.JobFromProject(ctx, projectID, id, c.bqClient.Location)
 }
 
 func (c *client) Jobs(ctx context.Context) *bigquery.JobIterator {
+	metrics.Default.IncBigQueryCall("Jobs")
 	return c.bqClient.Jobs(ctx)
 }
@@ -0,0 +1,70 @@
+package bigquery
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewDestination(t *testing.T) {
+	tests := map[string]struct {
+		table             string
+		writeDisposition  string
+		createDisposition string
+
+		expectDestination Destination
+		expectErr         bool
+	}{
+		"full table name": {
+			table:            "project.dataset.table",
+			writeDisposition: "write_truncate",
+			expectDestination: Destination{
+				ProjectID:        "project",
+				DatasetID:        "dataset",
+				TableID:          "table",
+				WriteDisposition: bigquery.WriteTruncate,
+			},
+		},
+		"table name without project": {
+			table:             "`dataset.table`",
+			writeDisposition:  "WRITE_APPEND",
+			createDisposition: "CREATE_NEVER",
+			expectDestination: Destination{
+				ProjectID:         "default",
+				DatasetID:         "dataset",
+				TableID:           "table",
+				WriteDisposition:  bigquery.WriteAppend,
+				CreateDisposition: bigquery.CreateNever,
+			},
+		},
+		"legacy table name": {
+			table: "project:dataset.table",
+			expectDestination: Destination{
+				ProjectID: "project",
+				DatasetID: "dataset",
+				TableID:   "table",
+			},
+		},
+		"invalid table name": {
+			table:     "table",
+			expectErr: true,
+		},
+		"invalid write disposition": {
+			table:            "dataset.table",
+			writeDisposition: "OVERWRITE",
+			expectErr:        true,
+		},
+	}
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got, err := NewDestination(tt.table, "default", tt.writeDisposition, tt.createDisposition)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("NewDestination error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if diff := cmp.Diff(tt.expectDestination, got); diff != "" {
+				t.Errorf("NewDestination result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -4,25 +4,36 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"sync"
 
 	"cloud.google.com/go/bigquery"
+	lcache "github.com/kitagry/bqls/langserver/internal/cache"
+	"github.com/sirupsen/logrus"
 	"google.golang.org/api/cloudresourcemanager/v1"
 )
 
+// DefaultCacheSize is the default number of the table and routine metadata held by the cache.
+const DefaultCacheSize = 1000
+
 type cache struct {
-	db                     *database
-	bqClient               Client
-	tableMetadataCacheLock sync.Mutex
-	tableMetadataCache     map[string]*bigquery.TableMetadata
+	db                    *database
+	bqClient              Client
+	logger                *logrus.Logger
+	tableMetadataCache    *lcache.LRU[string, *bigquery.TableMetadata]
+	tableMetadataKeyLocks *lcache.KeyLocks
+
+	routineMetadataCacheLock sync.Mutex
+	routineMetadataCache     *lcache.LRU[string, *bigquery.RoutineMetadata]
+
+	rowAccessPolicyCacheLock sync.Mutex
+	rowAccessPolicyCache     *lcache.LRU[string, []RowAccessPolicy]
 
 	onceListProjects *sync.Once
 	onceListDatasets map[string]*sync.Once
 	onceListTables   map[string]*sync.Once
 }
 
-func newCache(bqClient Client) (*cache, error) {
+func newCache(bqClient Client, size int, logger *logrus.Logger) (*cache, error) {
 	db, err := newDB()
 	if err != nil {
 		return nil, err
@@ -34,13 +45,16 @@ func newCache(bqClient Client) (*cache, error) {
 	}
 
 	return &cache{
-		db:                     db,
-		bqClient:               bqClient,
-		tableMetadataCacheLock: sync.Mutex{},
-		tableMetadataCache:     make(map[string]*bigquery.TableMetadata),
-		onceListProjects:       &sync.Once{},
-		onceListDatasets:       make(map[string]*sync.Once),
-		onceListTables:         make(map[string]*sync.Once),
+		db:                    db,
+		bqClient:              bqClient,
+		logger:                logger,
+		tableMetadataCache:    lcache.NewLRU[string, *bigquery.TableMetadata](size),
+		tableMetadataKeyLocks: lcache.NewKeyLocks(),
+		routineMetadataCache:  lcache.NewLRU[string, *bigquery.RoutineMetadata](size),
+		rowAccessPolicyCache:  lcache.NewLRU[string, []RowAccessPolicy](size),
+		onceListProjects:      &sync.Once{},
+		onceListDatasets:      make(map[string]*sync.Once),
+		onceListTables:        make(map[string]*sync.Once),
 	}, nil
 }
 
@@ -63,14 +77,13 @@ func (c *cache) ListProjects(ctx context.Context) ([]*cloudresourcemanager.Proje
 			ctx := context.WithoutCancel(ctx)
 			_, err := c.callListProjects(ctx)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "failed to recache projects: %v\n", err)
+				c.logger.Debugf("failed to recache projects: %v", err)
 			}
 		})
 		return results, nil
 	}
 	if err != nil {
-		// TODO
-		fmt.Fprintf(os.Stderr, "failed to select projects: %v\n", err)
+		c.logger.Warnf("failed to select projects: %v", err)
 	}
 
 	return c.callListProjects(ctx)
@@ -85,8 +98,7 @@ func (c *cache) callListProjects(ctx context.Context) ([]*cloudresourcemanager.P
 	if len(result) > 0 {
 		err := c.db.InsertProjects(ctx, result)
 		if err != nil {
-			// TODO
-			fmt.Fprintf(os.Stderr, "failed to insert projects: %v\n", err)
+			c.logger.Warnf("failed to insert projects: %v", err)
 		}
 	}
 	return result, nil
@@ -102,14 +114,13 @@ func (c *cache) ListDatasets(ctx context.Context, projectID string) ([]*bigquery
 			ctx := context.WithoutCancel(ctx)
 			_, err := c.callListDatasets(ctx, projectID)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "failed to recache datasets: %v\n", err)
+				c.logger.Debugf("failed to recache datasets: %v", err)
 			}
 		})
 		return results, nil
 	}
 	if err != nil {
-		// TODO
-		fmt.Fprintf(os.Stderr, "failed to select datasets: %v\n", err)
+		c.logger.Warnf("failed to select datasets: %v", err)
 	}
 
 	return c.callListDatasets(ctx, projectID)
@@ -124,8 +135,7 @@ func (c *cache) callListDatasets(ctx context.Context, projectID string) ([]*bigq
 	if len(result) > 0 {
 		err := c.db.ReplaceDatasets(ctx, projectID, result)
 		if err != nil {
-			// TODO
-			fmt.Fprintf(os.Stderr, "failed to insert datasets: %v\n", err)
+			c.logger.Warnf("failed to insert datasets: %v", err)
 		}
 	}
 	return result, nil
@@ -143,15 +153,14 @@ func (c *cache) ListTables(ctx context.Context, projectID, datasetID string) ([]
 			ctx := context.WithoutCancel(ctx)
 			_, err := c.callListTables(ctx, projectID, datasetID)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "failed to recache tables: %v\n", err)
+				c.logger.Debugf("failed to recache tables: %v", err)
 			}
 		})
 
 		return results, nil
 	}
 	if err != nil {
-		// TODO
-		fmt.Fprintf(os.Stderr, "failed to select tables: %v\n", err)
+		c.logger.Warnf("failed to select tables: %v", err)
 	}
 
 	return c.callListTables(ctx, projectID, datasetID)
@@ -167,8 +176,7 @@ func (c *cache) callListTables(ctx context.Context, projectID, datasetID string)
 	if len(result) > 0 {
 		err := c.db.ReplaceTables(ctx, projectID, datasetID, result)
 		if err != nil {
-			// TODO
-			fmt.Fprintf(os.Stderr, "failed to insert tables: %v\n", err)
+			c.logger.Warnf("failed to insert tables: %v", err)
 		}
 	}
 
@@ -177,10 +185,13 @@ func (c *cache) callListTables(ctx context.Context, projectID, datasetID string)
 
 func (c *cache) GetTableMetadata(ctx context.Context, projectID, datasetID, tableID string) (*bigquery.TableMetadata, error) {
 	cacheKey := fmt.Sprintf("%s:%s:%s", projectID, datasetID, tableID)
-	c.tableMetadataCacheLock.Lock()
-	defer c.tableMetadataCacheLock.Unlock()
-	cache, ok := c.tableMetadataCache[cacheKey]
-	if ok {
+
+	// Lock per table so that concurrent requests for the same table call the API only once,
+	// and requests for other tables are not blocked.
+	unlock := c.tableMetadataKeyLocks.Lock(cacheKey)
+	defer unlock()
+
+	if cache, ok := c.tableMetadataCache.Get(cacheKey); ok {
 		return cache, nil
 	}
 
@@ -190,19 +201,83 @@ func (c *cache) GetTableMetadata(ctx context.Context, projectID, datasetID, tabl
 	}
 
 	if result != nil {
-		c.tableMetadataCache[cacheKey] = result
+		c.tableMetadataCache.Put(cacheKey, result)
 	}
 	return result, nil
 }
 
+func (c *cache) UpdateTableMetadata(ctx context.Context, projectID, datasetID, tableID string, update bigquery.TableMetadataToUpdate) (*bigquery.TableMetadata, error) {
+	cacheKey := fmt.Sprintf("%s:%s:%s", projectID, datasetID, tableID)
+
+	unlock := c.tableMetadataKeyLocks.Lock(cacheKey)
+	defer unlock()
+
+	result, err := c.bqClient.UpdateTableMetadata(ctx, projectID, datasetID, tableID, update)
+	if err != nil {
+		// the table may be partially updated, so the old metadata is not reused.
+		c.tableMetadataCache.Delete(cacheKey)
+		return nil, err
+	}
+
+	c.tableMetadataCache.Put(cacheKey, result)
+	return result, nil
+}
+
+// Stats returns the statistics of the table metadata cache.
+func (c *cache) Stats() lcache.Stats {
+	return c.tableMetadataCache.Stats()
+}
+
 func (c *cache) GetTableRecord(ctx context.Context, projectID, datasetID, tableID string) (*bigquery.RowIterator, error) {
 	return c.bqClient.GetTableRecord(ctx, projectID, datasetID, tableID)
 }
 
+func (c *cache) GetRoutineMetadata(ctx context.Context, projectID, datasetID, routineID string) (*bigquery.RoutineMetadata, error) {
+	cacheKey := fmt.Sprintf("%s:%s:%s", projectID, datasetID, routineID)
+	c.routineMetadataCacheLock.Lock()
+	defer c.routineMetadataCacheLock.Unlock()
+	cache, ok := c.routineMetadataCache.Get(cacheKey)
+	if ok {
+		return cache, nil
+	}
+
+	result, err := c.bqClient.GetRoutineMetadata(ctx, projectID, datasetID, routineID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result != nil {
+		c.routineMetadataCache.Put(cacheKey, result)
+	}
+	return result, nil
+}
+
+func (c *cache) ListRowAccessPolicies(ctx context.Context, projectID, datasetID, tableID string) ([]RowAccessPolicy, error) {
+	cacheKey := fmt.Sprintf("%s:%s:%s", projectID, datasetID, tableID)
+	c.rowAccessPolicyCacheLock.Lock()
+	defer c.rowAccessPolicyCacheLock.Unlock()
+	cache, ok := c.rowAccessPolicyCache.Get(cacheKey)
+	if ok {
+		return cache, nil
+	}
+
+	result, err := c.bqClient.ListRowAccessPolicies(ctx, projectID, datasetID, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.rowAccessPolicyCache.Put(cacheKey, result)
+	return result, nil
+}
+
 func (c *cache) Run(ctx context.Context, q string, dryrun bool) (BigqueryJob, error) {
 	return c.bqClient.Run(ctx, q, dryrun)
 }
 
+func (c *cache) RunWithDestination(ctx context.Context, q string, destination Destination) (BigqueryJob, error) {
+	return c.bqClient.RunWithDestination(ctx, q, destination)
+}
+
 func (c *cache) JobFromProject(ctx context.Context, projectID, id string) (BigqueryJob, error) {
 	return c.bqClient.JobFromProject(ctx, projectID, id)
 }
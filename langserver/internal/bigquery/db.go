@@ -31,6 +31,11 @@ func newDB() (*database, error) {
 	return &database{db}, nil
 }
 
+// CacheDir returns the directory of the local files of bqls. It is created when it doesn't exist.
+func CacheDir() (string, error) {
+	return bqCachePath()
+}
+
 func bqCachePath() (string, error) {
 	cachePath, ok := cachePath()
 	if !ok {
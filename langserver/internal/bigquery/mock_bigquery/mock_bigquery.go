@@ -65,6 +65,21 @@ func (mr *MockClientMockRecorder) GetDefaultProject() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefaultProject", reflect.TypeOf((*MockClient)(nil).GetDefaultProject))
 }
 
+// GetRoutineMetadata mocks base method.
+func (m *MockClient) GetRoutineMetadata(ctx context.Context, projectID, datasetID, routineID string) (*bigquery.RoutineMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoutineMetadata", ctx, projectID, datasetID, routineID)
+	ret0, _ := ret[0].(*bigquery.RoutineMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoutineMetadata indicates an expected call of GetRoutineMetadata.
+func (mr *MockClientMockRecorder) GetRoutineMetadata(ctx, projectID, datasetID, routineID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoutineMetadata", reflect.TypeOf((*MockClient)(nil).GetRoutineMetadata), ctx, projectID, datasetID, routineID)
+}
+
 // GetTableMetadata mocks base method.
 func (m *MockClient) GetTableMetadata(ctx context.Context, projectID, datasetID, tableID string) (*bigquery.TableMetadata, error) {
 	m.ctrl.T.Helper()
@@ -154,6 +169,36 @@ func (mr *MockClientMockRecorder) ListProjects(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProjects", reflect.TypeOf((*MockClient)(nil).ListProjects), ctx)
 }
 
+// UpdateTableMetadata mocks base method.
+func (m *MockClient) UpdateTableMetadata(ctx context.Context, projectID, datasetID, tableID string, update bigquery.TableMetadataToUpdate) (*bigquery.TableMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTableMetadata", ctx, projectID, datasetID, tableID, update)
+	ret0, _ := ret[0].(*bigquery.TableMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTableMetadata indicates an expected call of UpdateTableMetadata.
+func (mr *MockClientMockRecorder) UpdateTableMetadata(ctx, projectID, datasetID, tableID, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTableMetadata", reflect.TypeOf((*MockClient)(nil).UpdateTableMetadata), ctx, projectID, datasetID, tableID, update)
+}
+
+// ListRowAccessPolicies mocks base method.
+func (m *MockClient) ListRowAccessPolicies(ctx context.Context, projectID, datasetID, tableID string) ([]bigquery0.RowAccessPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRowAccessPolicies", ctx, projectID, datasetID, tableID)
+	ret0, _ := ret[0].([]bigquery0.RowAccessPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRowAccessPolicies indicates an expected call of ListRowAccessPolicies.
+func (mr *MockClientMockRecorder) ListRowAccessPolicies(ctx, projectID, datasetID, tableID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRowAccessPolicies", reflect.TypeOf((*MockClient)(nil).ListRowAccessPolicies), ctx, projectID, datasetID, tableID)
+}
+
 // ListTables mocks base method.
 func (m *MockClient) ListTables(ctx context.Context, projectID, datasetID string) ([]*bigquery.Table, error) {
 	m.ctrl.T.Helper()
@@ -184,6 +229,21 @@ func (mr *MockClientMockRecorder) Run(ctx, q, dryrun interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockClient)(nil).Run), ctx, q, dryrun)
 }
 
+// RunWithDestination mocks base method.
+func (m *MockClient) RunWithDestination(ctx context.Context, q string, destination bigquery0.Destination) (bigquery0.BigqueryJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunWithDestination", ctx, q, destination)
+	ret0, _ := ret[0].(bigquery0.BigqueryJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunWithDestination indicates an expected call of RunWithDestination.
+func (mr *MockClientMockRecorder) RunWithDestination(ctx, q, destination interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunWithDestination", reflect.TypeOf((*MockClient)(nil).RunWithDestination), ctx, q, destination)
+}
+
 // MockBigqueryJob is a mock of BigqueryJob interface.
 type MockBigqueryJob struct {
 	ctrl     *gomock.Controller
@@ -207,6 +267,20 @@ func (m *MockBigqueryJob) EXPECT() *MockBigqueryJobMockRecorder {
 	return m.recorder
 }
 
+// Cancel mocks base method.
+func (m *MockBigqueryJob) Cancel(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockBigqueryJobMockRecorder) Cancel(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockBigqueryJob)(nil).Cancel), arg0)
+}
+
 // Config mocks base method.
 func (m *MockBigqueryJob) Config() (bigquery.JobConfig, error) {
 	m.ctrl.T.Helper()
@@ -264,3 +338,18 @@ func (mr *MockBigqueryJobMockRecorder) Read(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*MockBigqueryJob)(nil).Read), arg0)
 }
+
+// Wait mocks base method.
+func (m *MockBigqueryJob) Wait(arg0 context.Context) (*bigquery.JobStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Wait", arg0)
+	ret0, _ := ret[0].(*bigquery.JobStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Wait indicates an expected call of Wait.
+func (mr *MockBigqueryJobMockRecorder) Wait(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Wait", reflect.TypeOf((*MockBigqueryJob)(nil).Wait), arg0)
+}
@@ -0,0 +1,295 @@
+package bigquery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/cloudresourcemanager/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// schemaFileExts are the extensions of the schema files. When the table has several files, the former is used.
+var schemaFileExts = []string{".json", ".yaml", ".yml"}
+
+// ErrOffline is returned by the operations which need the BigQuery API in offline mode.
+var ErrOffline = errors.New("this operation is not supported in offline mode")
+
+// offline is the client which loads table schemas from local files instead of the BigQuery API.
+// The schema files are placed as `{schemaDir}/{project}/{dataset}/{table}.json`, and the content is
+// the output of `bq show --schema --format=json` or `bq show --format=json`.
+// The same content can be written in YAML as `{table}.yaml` or `{table}.yml`.
+type offline struct {
+	schemaDir string
+	projectID string
+}
+
+var _ Client = (*offline)(nil)
+
+func NewOffline(schemaDir, projectID string) Client {
+	return &offline{
+		schemaDir: schemaDir,
+		projectID: projectID,
+	}
+}
+
+func (o *offline) Close() error {
+	return nil
+}
+
+func (o *offline) GetDefaultProject() string {
+	return o.projectID
+}
+
+func (o *offline) ListProjects(ctx context.Context) ([]*cloudresourcemanager.Project, error) {
+	names, err := listDirNames(o.schemaDir)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := make([]*cloudresourcemanager.Project, len(names))
+	for i, name := range names {
+		projects[i] = &cloudresourcemanager.Project{ProjectId: name}
+	}
+	return projects, nil
+}
+
+func (o *offline) ListDatasets(ctx context.Context, projectID string) ([]*bigquery.Dataset, error) {
+	names, err := listDirNames(filepath.Join(o.schemaDir, projectID))
+	if err != nil {
+		return nil, err
+	}
+
+	datasets := make([]*bigquery.Dataset, len(names))
+	for i, name := range names {
+		datasets[i] = &bigquery.Dataset{ProjectID: projectID, DatasetID: name}
+	}
+	return datasets, nil
+}
+
+func (o *offline) ListTables(ctx context.Context, projectID, datasetID string) ([]*bigquery.Table, error) {
+	tables, err := o.listAllTables(projectID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return extractLatestSuffixTables(tables), nil
+}
+
+func (o *offline) listAllTables(projectID, datasetID string) ([]*bigquery.Table, error) {
+	entries, err := os.ReadDir(filepath.Join(o.schemaDir, projectID, datasetID))
+	if err != nil {
+		return nil, fmt.Errorf("fail to read dataset directory: %w", err)
+	}
+
+	tables := make([]*bigquery.Table, 0, len(entries))
+	seen := make(map[string]struct{})
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || !slices.Contains(schemaFileExts, ext) {
+			continue
+		}
+		tableID := strings.TrimSuffix(e.Name(), ext)
+		if _, ok := seen[tableID]; ok {
+			continue
+		}
+		seen[tableID] = struct{}{}
+		tables = append(tables, &bigquery.Table{
+			ProjectID: projectID,
+			DatasetID: datasetID,
+			TableID:   tableID,
+		})
+	}
+	return tables, nil
+}
+
+func (o *offline) GetTableMetadata(ctx context.Context, projectID, datasetID, tableID string) (*bigquery.TableMetadata, error) {
+	if strings.HasSuffix(tableID, "*") {
+		return o.getWildcardTableMetadata(projectID, datasetID, strings.TrimSuffix(tableID, "*"))
+	}
+
+	return o.readTableMetadata(projectID, datasetID, tableID)
+}
+
+func (o *offline) getWildcardTableMetadata(projectID, datasetID, prefix string) (*bigquery.TableMetadata, error) {
+	tables, err := o.listAllTables(projectID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+
+	matchedTables := make([]*bigquery.Table, 0)
+	for _, table := range tables {
+		if strings.HasPrefix(table.TableID, prefix) {
+			matchedTables = append(matchedTables, table)
+		}
+	}
+
+	var result *bigquery.TableMetadata
+	for _, table := range extractLatestSuffixTables(matchedTables) {
+		md, err := o.readTableMetadata(projectID, datasetID, table.TableID)
+		if err != nil {
+			return nil, err
+		}
+
+		if result == nil {
+			result = md
+			continue
+		}
+		result.Schema = mergeSchema(result.Schema, md.Schema)
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("no tables match %s.%s.%s*", projectID, datasetID, prefix)
+	}
+
+	return result, nil
+}
+
+// schemaFile is the subset of the output of `bq show --format=json`.
+type schemaFile struct {
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Schema      struct {
+		Fields json.RawMessage `json:"fields"`
+	} `json:"schema"`
+	TimePartitioning *struct {
+		Type  string `json:"type"`
+		Field string `json:"field"`
+	} `json:"timePartitioning"`
+	View *struct {
+		Query string `json:"query"`
+	} `json:"view"`
+}
+
+func (o *offline) readTableMetadata(projectID, datasetID, tableID string) (*bigquery.TableMetadata, error) {
+	b, err := o.readSchemaFile(projectID, datasetID, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &bigquery.TableMetadata{
+		Name:   tableID,
+		FullID: fmt.Sprintf("%s:%s.%s", projectID, datasetID, tableID),
+		Type:   bigquery.RegularTable,
+	}
+
+	// the output of `bq show --schema` is the array of fields.
+	if trimmed := strings.TrimSpace(string(b)); strings.HasPrefix(trimmed, "[") {
+		metadata.Schema, err = bigquery.SchemaFromJSON(b)
+		if err != nil {
+			return nil, fmt.Errorf("fail to parse schema file: %w", err)
+		}
+		return metadata, nil
+	}
+
+	var file schemaFile
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("fail to parse schema file: %w", err)
+	}
+
+	if len(file.Schema.Fields) > 0 {
+		metadata.Schema, err = bigquery.SchemaFromJSON(file.Schema.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("fail to parse schema file: %w", err)
+		}
+	}
+	metadata.Description = file.Description
+	if file.Type != "" {
+		metadata.Type = bigquery.TableType(file.Type)
+	}
+	if tp := file.TimePartitioning; tp != nil {
+		metadata.TimePartitioning = &bigquery.TimePartitioning{
+			Type:  bigquery.TimePartitioningType(tp.Type),
+			Field: tp.Field,
+		}
+	}
+	if file.View != nil {
+		metadata.ViewQuery = file.View.Query
+	}
+
+	return metadata, nil
+}
+
+// readSchemaFile returns the content of the schema file as JSON. The YAML file is converted into JSON.
+func (o *offline) readSchemaFile(projectID, datasetID, tableID string) ([]byte, error) {
+	for _, ext := range schemaFileExts {
+		path := filepath.Join(o.schemaDir, projectID, datasetID, tableID+ext)
+		b, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fail to read schema file: %w", err)
+		}
+		if ext == ".json" {
+			return b, nil
+		}
+
+		var content any
+		if err := yaml.Unmarshal(b, &content); err != nil {
+			return nil, fmt.Errorf("fail to parse schema file %s: %w", path, err)
+		}
+		b, err = json.Marshal(content)
+		if err != nil {
+			return nil, fmt.Errorf("fail to convert schema file %s into JSON: %w", path, err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("fail to read schema file: %s.%s.%s is not found in %s", projectID, datasetID, tableID, o.schemaDir)
+}
+
+func (o *offline) GetTableRecord(ctx context.Context, projectID, datasetID, tableID string) (*bigquery.RowIterator, error) {
+	return nil, ErrOffline
+}
+
+func (o *offline) GetRoutineMetadata(ctx context.Context, projectID, datasetID, routineID string) (*bigquery.RoutineMetadata, error) {
+	return nil, ErrOffline
+}
+
+func (o *offline) UpdateTableMetadata(ctx context.Context, projectID, datasetID, tableID string, update bigquery.TableMetadataToUpdate) (*bigquery.TableMetadata, error) {
+	return nil, ErrOffline
+}
+
+func (o *offline) ListRowAccessPolicies(ctx context.Context, projectID, datasetID, tableID string) ([]RowAccessPolicy, error) {
+	return nil, ErrOffline
+}
+
+func (o *offline) Run(ctx context.Context, q string, dryrun bool) (BigqueryJob, error) {
+	return nil, ErrOffline
+}
+
+func (o *offline) RunWithDestination(ctx context.Context, q string, destination Destination) (BigqueryJob, error) {
+	return nil, ErrOffline
+}
+
+func (o *offline) JobFromProject(ctx context.Context, projectID, id string) (BigqueryJob, error) {
+	return nil, ErrOffline
+}
+
+func (o *offline) Jobs(ctx context.Context) *bigquery.JobIterator {
+	return nil
+}
+
+func listDirNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read directory: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,183 @@
+package bigquery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestOffline_GetTableMetadata(t *testing.T) {
+	tests := map[string]struct {
+		files   map[string]string
+		tableID string
+
+		expectMetadata *bigquery.TableMetadata
+	}{
+		"bq show --schema output": {
+			files: map[string]string{
+				"project/dataset/table.json": `[{"name":"id","type":"INTEGER","mode":"REQUIRED","description":"id description"}]`,
+			},
+			tableID: "table",
+			expectMetadata: &bigquery.TableMetadata{
+				Name:   "table",
+				FullID: "project:dataset.table",
+				Type:   bigquery.RegularTable,
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true, Description: "id description"},
+				},
+			},
+		},
+		"bq show output": {
+			files: map[string]string{
+				"project/dataset/table.json": `{
+  "description": "table description",
+  "type": "TABLE",
+  "schema": {"fields": [{"name":"name","type":"STRING","mode":"NULLABLE"}]},
+  "timePartitioning": {"type": "DAY"}
+}`,
+			},
+			tableID: "table",
+			expectMetadata: &bigquery.TableMetadata{
+				Name:        "table",
+				FullID:      "project:dataset.table",
+				Description: "table description",
+				Type:        bigquery.RegularTable,
+				Schema: bigquery.Schema{
+					{Name: "name", Type: bigquery.StringFieldType},
+				},
+				TimePartitioning: &bigquery.TimePartitioning{
+					Type: bigquery.DayPartitioningType,
+				},
+			},
+		},
+		"yaml schema": {
+			files: map[string]string{
+				"project/dataset/table.yaml": `description: table description
+schema:
+  fields:
+    - name: id
+      type: INTEGER
+      mode: REQUIRED
+    - name: tags
+      type: STRING
+      mode: REPEATED
+`,
+			},
+			tableID: "table",
+			expectMetadata: &bigquery.TableMetadata{
+				Name:        "table",
+				FullID:      "project:dataset.table",
+				Description: "table description",
+				Type:        bigquery.RegularTable,
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+					{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+				},
+			},
+		},
+		"yml fields": {
+			files: map[string]string{
+				"project/dataset/table.yml": "- name: id\n  type: INTEGER\n",
+			},
+			tableID: "table",
+			expectMetadata: &bigquery.TableMetadata{
+				Name:   "table",
+				FullID: "project:dataset.table",
+				Type:   bigquery.RegularTable,
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType},
+				},
+			},
+		},
+		"json is preferred to yaml": {
+			files: map[string]string{
+				"project/dataset/table.json": `[{"name":"id","type":"INTEGER"}]`,
+				"project/dataset/table.yaml": "- name: name\n  type: STRING\n",
+			},
+			tableID: "table",
+			expectMetadata: &bigquery.TableMetadata{
+				Name:   "table",
+				FullID: "project:dataset.table",
+				Type:   bigquery.RegularTable,
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType},
+				},
+			},
+		},
+		"wildcard table": {
+			files: map[string]string{
+				"project/dataset/events_20240101.json":          `[{"name":"id","type":"INTEGER"}]`,
+				"project/dataset/events_20240102.json":          `[{"name":"id","type":"INTEGER"},{"name":"name","type":"STRING"}]`,
+				"project/dataset/events_intraday_20240103.json": `[{"name":"id","type":"INTEGER"},{"name":"flag","type":"BOOLEAN"}]`,
+			},
+			tableID: "events_*",
+			expectMetadata: &bigquery.TableMetadata{
+				Name:   "events_20240102",
+				FullID: "project:dataset.events_20240102",
+				Type:   bigquery.RegularTable,
+				Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType},
+					{Name: "name", Type: bigquery.StringFieldType},
+					{Name: "flag", Type: bigquery.BooleanFieldType},
+				},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			dir := t.TempDir()
+			for path, content := range tt.files {
+				path = filepath.Join(dir, path)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			client := NewOffline(dir, "project")
+			got, err := client.GetTableMetadata(context.Background(), "project", "dataset", tt.tableID)
+			if err != nil {
+				t.Fatalf("failed to GetTableMetadata: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expectMetadata, got, cmpopts.IgnoreUnexported(bigquery.FieldSchema{})); diff != "" {
+				t.Errorf("GetTableMetadata result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOffline_ListTables(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"a.json", "b.yaml", "c.yml", "c.json", "README.md"} {
+		path = filepath.Join(dir, "project", "dataset", path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("[]"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := NewOffline(dir, "project")
+	got, err := client.ListTables(context.Background(), "project", "dataset")
+	if err != nil {
+		t.Fatalf("failed to ListTables: %v", err)
+	}
+
+	tableIDs := make([]string, 0, len(got))
+	for _, table := range got {
+		tableIDs = append(tableIDs, table.TableID)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c"}, tableIDs, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("ListTables result diff (-expect, +got)\n%s", diff)
+	}
+}
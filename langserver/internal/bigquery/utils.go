@@ -1,6 +1,7 @@
 package bigquery
 
 import (
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -61,3 +62,28 @@ func extractLatestSuffixTables(tables []*bigquery.Table) []*bigquery.Table {
 
 	return filteredTables
 }
+
+// mergeSchema merges the fields of src into dst.
+// Fields which already exist in dst are kept, and nested RECORD fields are merged recursively.
+func mergeSchema(dst, src bigquery.Schema) bigquery.Schema {
+	result := make(bigquery.Schema, len(dst), len(dst)+len(src))
+	copy(result, dst)
+
+	for _, field := range src {
+		i := slices.IndexFunc(result, func(f *bigquery.FieldSchema) bool {
+			return strings.EqualFold(f.Name, field.Name)
+		})
+		if i < 0 {
+			result = append(result, field)
+			continue
+		}
+
+		if result[i].Type == bigquery.RecordFieldType && field.Type == bigquery.RecordFieldType {
+			merged := *result[i]
+			merged.Schema = mergeSchema(result[i].Schema, field.Schema)
+			result[i] = &merged
+		}
+	}
+
+	return result
+}
@@ -2,35 +2,133 @@ package cache
 
 import (
 	"sync"
+	"time"
 )
 
+// DefaultMaxDocuments is the default number of the closed documents held by GlobalCache.
+const DefaultMaxDocuments = 1000
+
+// GlobalCache holds the snapshots of the documents.
+// The opened documents are never evicted, because the client doesn't send them again until they are reopened.
+// The closed documents are kept only to reuse their parse, and the least recently used ones are evicted.
 type GlobalCache struct {
-	mu        sync.RWMutex
-	pathToSQL map[string]*SQL
+	mu     sync.RWMutex
+	opened map[string]*SQL
+	closed *LRU[string, *closedDocument]
+}
+
+// FileStamp identifies the content of the file on disk without reading it.
+type FileStamp struct {
+	ModTime time.Time
+	Size    int64
 }
 
-func NewGlobalCache() *GlobalCache {
-	g := &GlobalCache{pathToSQL: make(map[string]*SQL)}
+type closedDocument struct {
+	sql *SQL
+	// stamp is zero when the snapshot is not read from the file by GetFile.
+	stamp FileStamp
+}
+
+// NewGlobalCache creates the cache which holds at most maxDocuments closed documents.
+// When maxDocuments is not positive, DefaultMaxDocuments is used.
+func NewGlobalCache(maxDocuments int) *GlobalCache {
+	if maxDocuments <= 0 {
+		maxDocuments = DefaultMaxDocuments
+	}
+	g := &GlobalCache{
+		opened: make(map[string]*SQL),
+		closed: NewLRU[string, *closedDocument](maxDocuments),
+	}
 
 	return g
 }
 
+// Get returns the opened document, or nil when the document is not opened.
 func (g *GlobalCache) Get(path string) *SQL {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
-	return g.pathToSQL[path]
+	return g.opened[path]
+}
+
+// Put replaces the opened document with the new snapshot and returns it.
+func (g *GlobalCache) Put(path string, rawText string, version int) *SQL {
+	sql := NewSQL(rawText)
+	sql.Version = version
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.opened[path] = sql
+	g.closed.Delete(path)
+	return sql
 }
 
-func (g *GlobalCache) Put(path string, rawText string) error {
+// SetVersion replaces the opened document with the snapshot of the same text and the new version.
+// The parse of the document is kept.
+func (g *GlobalCache) SetVersion(path string, version int) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	g.pathToSQL[path] = NewSQL(rawText)
-	return nil
+	sql, ok := g.opened[path]
+	if !ok {
+		return
+	}
+	// The snapshot may be read by the other requests, so it isn't modified.
+	updated := *sql
+	updated.Version = version
+	g.opened[path] = &updated
 }
 
-func (g *GlobalCache) Delete(path string) {
+// Close unpins the opened document. Its snapshot is kept as the closed document.
+func (g *GlobalCache) Close(path string) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	delete(g.pathToSQL, path)
+
+	sql, ok := g.opened[path]
+	if !ok {
+		return
+	}
+	delete(g.opened, path)
+
+	// The version is given by the client only while the document is opened,
+	// and it starts over when the document is reopened.
+	closed := *sql
+	closed.Version = 0
+	g.closed.Put(path, &closedDocument{sql: &closed})
+}
+
+// GetClosed returns the snapshot of the document which is not opened, e.g. the file on disk.
+// The snapshot is reused while the text is the same as rawText.
+func (g *GlobalCache) GetClosed(path string, rawText string) *SQL {
+	if d, ok := g.closed.Get(path); ok && d.sql.Hash == HashText(rawText) {
+		return d.sql
+	}
+
+	sql := NewSQL(rawText)
+	g.closed.Put(path, &closedDocument{sql: sql})
+	return sql
+}
+
+// GetFile returns the snapshot of the file on disk which is not opened.
+// The file is read by read only when its stamp is changed after the last read.
+func (g *GlobalCache) GetFile(path string, stamp FileStamp, read func() (string, error)) (*SQL, error) {
+	if d, ok := g.closed.Get(path); ok && !d.stamp.ModTime.IsZero() && d.stamp.ModTime.Equal(stamp.ModTime) && d.stamp.Size == stamp.Size {
+		return d.sql, nil
+	}
+
+	rawText, err := read()
+	if err != nil {
+		return nil, err
+	}
+	sql := g.GetClosed(path, rawText)
+	g.closed.Put(path, &closedDocument{sql: sql, stamp: stamp})
+	return sql, nil
+}
+
+func (g *GlobalCache) Stats() Stats {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	stats := g.closed.Stats()
+	stats.Len += len(g.opened)
+	return stats
 }
@@ -1 +1,74 @@
 package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGlobalCache(t *testing.T) {
+	g := NewGlobalCache(1)
+	g.Put("a.sql", "SELECT 1", 1)
+	g.Put("b.sql", "SELECT 2", 1)
+
+	// The opened documents are not evicted even when they exceed maxDocuments.
+	if sql := g.Get("a.sql"); sql == nil || sql.RawText != "SELECT 1" {
+		t.Fatalf("Get(a.sql) should return the opened document, but got %v", sql)
+	}
+	if sql := g.Get("b.sql"); sql == nil || sql.RawText != "SELECT 2" {
+		t.Fatalf("Get(b.sql) should return the opened document, but got %v", sql)
+	}
+
+	g.Close("a.sql")
+	if sql := g.Get("a.sql"); sql != nil {
+		t.Errorf("Get(a.sql) should not return the closed document")
+	}
+	closed := g.GetClosed("a.sql", "SELECT 1")
+	if closed.RawText != "SELECT 1" || closed.Version != 0 {
+		t.Errorf("GetClosed(a.sql) should reuse the snapshot with version 0, but got %q, %d", closed.RawText, closed.Version)
+	}
+	if got := g.GetClosed("a.sql", "SELECT 1"); got != closed {
+		t.Errorf("GetClosed(a.sql) should reuse the snapshot of the same text")
+	}
+	if got := g.GetClosed("a.sql", "SELECT 3"); got == closed || got.RawText != "SELECT 3" {
+		t.Errorf("GetClosed(a.sql) should parse the changed text, but got %q", got.RawText)
+	}
+
+	// Only the closed documents are bounded.
+	g.Close("b.sql")
+	if got := g.Stats(); got.Len != 1 || got.Evictions != 1 {
+		t.Errorf("the closed documents should be bounded by 1, but got %+v", got)
+	}
+}
+
+func TestGlobalCache_GetFile(t *testing.T) {
+	g := NewGlobalCache(0)
+	reads := 0
+	read := func(text string) func() (string, error) {
+		return func() (string, error) {
+			reads++
+			return text, nil
+		}
+	}
+	stamp := FileStamp{ModTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Size: 8}
+
+	first, err := g.GetFile("a.sql", stamp, read("SELECT 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := g.GetFile("a.sql", stamp, read("SELECT 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reads != 1 || second != first {
+		t.Errorf("the unmodified file should not be read again, but read %d times", reads)
+	}
+
+	modified := FileStamp{ModTime: stamp.ModTime.Add(time.Second), Size: 8}
+	third, err := g.GetFile("a.sql", modified, read("SELECT 2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reads != 2 || third.RawText != "SELECT 2" {
+		t.Errorf("the modified file should be read again, but got %q", third.RawText)
+	}
+}
@@ -0,0 +1,51 @@
+package cache
+
+import "sync"
+
+// KeyLocks locks each key separately.
+// The lock of a key is removed when nobody holds or waits for it, so that the locks don't grow with the keys.
+type KeyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	mu sync.Mutex
+	// refs counts the holder and the waiters.
+	refs int
+}
+
+func NewKeyLocks() *KeyLocks {
+	return &KeyLocks{locks: make(map[string]*keyLock)}
+}
+
+// Lock locks the key and returns the function which unlocks it.
+func (k *KeyLocks) Lock(key string) (unlock func()) {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+	}
+}
+
+// Len returns the number of the keys which are locked or waited for.
+func (k *KeyLocks) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.locks)
+}
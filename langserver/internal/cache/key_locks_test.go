@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestKeyLocks(t *testing.T) {
+	k := NewKeyLocks()
+
+	var wg sync.WaitGroup
+	count := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := k.Lock("a")
+			defer unlock()
+			count++
+		}()
+	}
+	wg.Wait()
+
+	if count != 10 {
+		t.Errorf("the key should be locked exclusively, but count is %d", count)
+	}
+	if got := k.Len(); got != 0 {
+		t.Errorf("the unused locks should be removed, but %d locks remain", got)
+	}
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLRU(t *testing.T) {
+	c := NewLRU[string, int](2)
+	c.Put("a", 1)
+	c.Put("b", 2)
+
+	// "a" becomes the most recently used.
+	if got, ok := c.Get("a"); !ok || got != 1 {
+		t.Errorf("Get(a) = %d, %v, want 1, true", got, ok)
+	}
+
+	// "b" is evicted.
+	c.Put("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("Get(b) should be evicted")
+	}
+	if got, ok := c.Get("c"); !ok || got != 3 {
+		t.Errorf("Get(c) = %d, %v, want 3, true", got, ok)
+	}
+
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("Get(a) should be deleted")
+	}
+
+	expect := Stats{
+		Hits:      2,
+		Misses:    2,
+		Evictions: 1,
+		Len:       1,
+		Capacity:  2,
+	}
+	if diff := cmp.Diff(expect, c.Stats()); diff != "" {
+		t.Errorf("Stats diff (-expect, +got)\n%s", diff)
+	}
+}
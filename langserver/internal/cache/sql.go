@@ -1,19 +1,30 @@
 package cache
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+
 	"github.com/goccy/go-zetasql"
 	"github.com/goccy/go-zetasql/ast"
 )
 
+// SQL is the snapshot of a document.
+// It must not be modified after it is put into GlobalCache,
+// so that requests can keep using it while the document is updated.
 type SQL struct {
 	RawText string
-	Node    ast.Node
-	Errors  []error
+	// Version is the version of the document given by the client.
+	Version int
+	// Hash is the sha256 of RawText. It is used to detect whether the text has changed.
+	Hash   string
+	Node   ast.Node
+	Errors []error
 }
 
 func NewSQL(rawText string) *SQL {
 	sql := &SQL{}
 	sql.RawText = rawText
+	sql.Hash = HashText(rawText)
 
 	node, err := zetasql.ParseScript(rawText, zetasql.NewParserOptions(), zetasql.ErrorMessageOneLine)
 	if err != nil {
@@ -37,3 +48,9 @@ func (s *SQL) GetStatementNodes() (stmts []ast.StatementNode) {
 	})
 	return
 }
+
+// HashText returns the hash used as SQL.Hash.
+func HashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
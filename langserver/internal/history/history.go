@@ -0,0 +1,167 @@
+// Package history stores the query jobs launched by bqls in a local sqlite database.
+package history
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DefaultMaxEntries is the number of entries kept in the store.
+const DefaultMaxEntries = 1000
+
+// ErrNotFound is returned when the entry doesn't exist.
+var ErrNotFound = errors.New("history entry not found")
+
+// Entry is a query job launched by bqls.
+type Entry struct {
+	ID        int64
+	Query     string
+	ProjectID string
+	JobID     string
+	DryRun    bool
+	CreatedAt time.Time
+
+	TotalBytesProcessed int64
+	Duration            time.Duration
+	// Destination is the table of the query result like `project.dataset.table`.
+	Destination string
+	// Error is the error message of the failed job.
+	Error string
+}
+
+type Store struct {
+	db         *sql.DB
+	maxEntries int
+}
+
+// Open opens the store at path and creates the table when it doesn't exist.
+// When maxEntries is not positive, DefaultMaxEntries is used.
+func Open(path string, maxEntries int) (*Store, error) {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s", path))
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open: %w", err)
+	}
+
+	s := &Store{db: db, maxEntries: maxEntries}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) migrate() error {
+	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query TEXT NOT NULL,
+		project_id TEXT,
+		job_id TEXT,
+		dry_run BOOLEAN,
+		total_bytes_processed INTEGER,
+		duration_ms INTEGER,
+		destination TEXT,
+		error TEXT,
+		created_at TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create history table: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add inserts the entry and returns its ID. The oldest entries over the max entries are deleted.
+func (s *Store) Add(ctx context.Context, e Entry) (int64, error) {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO history(query, project_id, job_id, dry_run, total_bytes_processed, duration_ms, destination, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Query, e.ProjectID, e.JobID, e.DryRun, e.TotalBytesProcessed, e.Duration.Milliseconds(), e.Destination, e.Error, e.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert history: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM history WHERE id <= ?", id-int64(s.maxEntries))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old history: %w", err)
+	}
+	return id, nil
+}
+
+// Update overwrites the statistics of the entry after the job is finished.
+func (s *Store) Update(ctx context.Context, e Entry) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE history SET job_id = ?, total_bytes_processed = ?, duration_ms = ?, destination = ?, error = ? WHERE id = ?",
+		e.JobID, e.TotalBytesProcessed, e.Duration.Milliseconds(), e.Destination, e.Error, e.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update history: %w", err)
+	}
+	return nil
+}
+
+// List returns the latest entries first.
+func (s *Store) List(ctx context.Context, limit int) ([]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, selectQuery+" ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]Entry, 0)
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, e)
+	}
+	return results, rows.Err()
+}
+
+func (s *Store) Get(ctx context.Context, id int64) (Entry, error) {
+	e, err := scanEntry(s.db.QueryRowContext(ctx, selectQuery+" WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entry{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	return e, err
+}
+
+const selectQuery = "SELECT id, query, project_id, job_id, dry_run, total_bytes_processed, duration_ms, destination, error, created_at FROM history"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEntry(row scanner) (Entry, error) {
+	var (
+		e          Entry
+		durationMs int64
+	)
+	err := row.Scan(&e.ID, &e.Query, &e.ProjectID, &e.JobID, &e.DryRun, &e.TotalBytesProcessed, &durationMs, &e.Destination, &e.Error, &e.CreatedAt)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to scan history: %w", err)
+	}
+	e.Duration = time.Duration(durationMs) * time.Millisecond
+	return e, nil
+}
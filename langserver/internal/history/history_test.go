@@ -0,0 +1,56 @@
+package history_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/kitagry/bqls/langserver/internal/history"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	s, err := history.Open(filepath.Join(t.TempDir(), "history.sqlite3"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	first, err := s.Add(ctx, history.Entry{Query: "SELECT 1", ProjectID: "project", DryRun: true, TotalBytesProcessed: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Add(ctx, history.Entry{Query: "SELECT 2", ProjectID: "project", JobID: "job2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Update(ctx, history.Entry{ID: second, JobID: "job2", TotalBytesProcessed: 20, Duration: 3 * time.Second, Destination: "project.dataset.table"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.List(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []history.Entry{
+		{ID: second, Query: "SELECT 2", ProjectID: "project", JobID: "job2", TotalBytesProcessed: 20, Duration: 3 * time.Second, Destination: "project.dataset.table"},
+		{ID: first, Query: "SELECT 1", ProjectID: "project", DryRun: true, TotalBytesProcessed: 10},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(history.Entry{}, "CreatedAt")); diff != "" {
+		t.Errorf("List result diff (-want, +got)\n%s", diff)
+	}
+
+	// The oldest entry is deleted over the max entries.
+	if _, err := s.Add(ctx, history.Entry{Query: "SELECT 3"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, first); !errors.Is(err, history.ErrNotFound) {
+		t.Errorf("Get(%d) error = %v, want ErrNotFound", first, err)
+	}
+}
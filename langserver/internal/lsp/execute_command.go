@@ -1,5 +1,7 @@
 package lsp
 
+import "time"
+
 type ExecuteQueryResult struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 	Result       QueryResult            `json:"result"`
@@ -13,6 +15,21 @@ type ListTablesResult struct {
 	Tables []string `json:"tables"`
 }
 
+type FindColumnResult struct {
+	Columns []ColumnLocation `json:"columns"`
+}
+
+// ColumnLocation is the table which has the searched column.
+type ColumnLocation struct {
+	// Table is the table path like project.dataset.table.
+	Table string `json:"table"`
+	// Reference is the quoted table path which can be inserted into the query.
+	Reference string `json:"reference"`
+	// Column is the column path like record.field.
+	Column string `json:"column"`
+	Type   string `json:"type"`
+}
+
 type ListJobHistoryResult struct {
 	Jobs []JobHistory `json:"jobs"`
 }
@@ -26,4 +43,96 @@ type JobHistory struct {
 	// Summary is a human-readable summary of the job.
 	// When the job is a query job, it is the query string.
 	Summary string `json:"summary"`
+
+	// State is one of PENDING, RUNNING and DONE.
+	State        string    `json:"state"`
+	CreationTime time.Time `json:"creationTime"`
+	// DurationMs is the elapsed time from the start to the end of the job.
+	// It is 0 when the job is not finished.
+	DurationMs  int64 `json:"durationMs"`
+	BytesBilled int64 `json:"bytesBilled"`
+	// ErrorMessage is set when the job is failed.
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+type ShowLineageResult struct {
+	// Contents is a markdown tree of the source tables/columns.
+	Contents []MarkedString `json:"contents"`
+}
+
+type ShowOutputSchemaResult struct {
+	// Contents is a YAML list of the output columns.
+	Contents []MarkedString `json:"contents"`
+}
+
+type ExplainQueryResult struct {
+	// Contents is a markdown tree of the query stages.
+	Contents []MarkedString `json:"contents"`
+}
+
+type EvaluateExpressionResult struct {
+	// Value is the value of the expression as the literal like `DATE '2024-01-04'`.
+	Value string `json:"value"`
+	// Type is the type of the value like DATE.
+	Type string `json:"type"`
+}
+
+type GenerateTestScaffoldResult struct {
+	// Contents is the SQL which compares the result of the query on the fixtures with the expected rows.
+	Contents []MarkedString `json:"contents"`
+}
+
+type DiffQueriesResult struct {
+	// Contents is a markdown list of the changes of the output columns, the filters and the tables.
+	Contents []MarkedString `json:"contents"`
+}
+
+type ValidateScheduledQueryResult struct {
+	// Diagnostics are the problems which prevent the file from being scheduled. It is empty when the file can be scheduled.
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type UpdateTableResult struct {
+	// Contents is the table info after the update.
+	Contents []MarkedString `json:"contents"`
+}
+
+type PreviewTableResult struct {
+	// Contents is a markdown table of the first rows.
+	Contents []MarkedString `json:"contents"`
+}
+
+type SaveResultsResult struct {
+	// Path is the path of the written file.
+	Path string `json:"path"`
+	// Rows is the number of the written rows.
+	Rows int `json:"rows"`
+}
+
+type ExportSchemasResult struct {
+	// Files are the paths of the written schema files.
+	Files []string `json:"files"`
+}
+
+type QueryHistoryResult struct {
+	// Contents is a markdown table of the entries.
+	Contents []MarkedString      `json:"contents"`
+	Entries  []QueryHistoryEntry `json:"entries"`
+}
+
+type QueryHistoryEntry struct {
+	// TextDocument is the virtual text document of the job. It is empty for dry runs.
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+
+	ID                  int64  `json:"id"`
+	Query               string `json:"query"`
+	JobID               string `json:"jobId,omitempty"`
+	DryRun              bool   `json:"dryRun"`
+	TotalBytesProcessed int64  `json:"totalBytesProcessed"`
+	// DurationMs is the elapsed time of the job in milliseconds.
+	DurationMs  int64  `json:"durationMs"`
+	Destination string `json:"destination,omitempty"`
+	Error       string `json:"error,omitempty"`
+	// CreatedAt is the time in RFC3339 format.
+	CreatedAt string `json:"createdAt"`
 }
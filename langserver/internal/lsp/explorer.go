@@ -0,0 +1,56 @@
+package lsp
+
+type ListDatasetsParams struct {
+	// ProjectID is the project of the datasets. When it is empty, the datasets of the configured projects are listed.
+	ProjectID string `json:"projectId,omitempty"`
+}
+
+type ListDatasetsResponse struct {
+	Datasets []DatasetItem `json:"datasets"`
+}
+
+// DatasetItem is the node of the dataset in the explorer.
+type DatasetItem struct {
+	ProjectID string `json:"projectId"`
+	DatasetID string `json:"datasetId"`
+	// Reference is the dataset path like `project.dataset`.
+	Reference string `json:"reference"`
+}
+
+type ListTablesParams struct {
+	// ProjectID is the project of the dataset. When it is empty, the default project is used.
+	ProjectID string `json:"projectId,omitempty"`
+	DatasetID string `json:"datasetId"`
+}
+
+type ListTablesResponse struct {
+	Tables []TableItem `json:"tables"`
+}
+
+// TableItem is the node of the table in the explorer.
+type TableItem struct {
+	ProjectID string `json:"projectId"`
+	DatasetID string `json:"datasetId"`
+	TableID   string `json:"tableId"`
+	// Reference is the quoted table path which can be inserted into the query.
+	Reference string `json:"reference"`
+	// URI is the virtual text document of the table, which is opened with bqls/virtualTextDocument.
+	URI DocumentURI `json:"uri"`
+}
+
+type ListJobsParams struct {
+	// ProjectID is the project of the jobs. When it is empty, the billing project is used.
+	ProjectID string `json:"projectId,omitempty"`
+	// AllUsers lists the jobs of all users instead of the user's own jobs.
+	AllUsers bool `json:"allUsers,omitempty"`
+}
+
+type ListJobsResponse struct {
+	Jobs []JobHistory `json:"jobs"`
+}
+
+type GetJobParams struct {
+	// ProjectID is the project of the job. When it is empty, the billing project is used.
+	ProjectID string `json:"projectId,omitempty"`
+	JobID     string `json:"jobId"`
+}
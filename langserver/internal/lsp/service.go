@@ -26,6 +26,7 @@ type InitializeParams[T any] struct {
 	Trace                 Trace              `json:"trace,omitempty"`
 	InitializationOptions T                  `json:"initializationOptions,omitempty"`
 	Capabilities          ClientCapabilities `json:"capabilities"`
+	WorkspaceFolders      []WorkspaceFolder  `json:"workspaceFolders,omitempty"`
 
 	WorkDoneToken string `json:"workDoneToken,omitempty"`
 }
@@ -43,6 +44,20 @@ func (p *InitializeParams[T]) Root() DocumentURI {
 
 type DocumentURI string
 
+type WorkspaceFolder struct {
+	URI  DocumentURI `json:"uri"`
+	Name string      `json:"name"`
+}
+
+type WorkspaceFoldersChangeEvent struct {
+	Added   []WorkspaceFolder `json:"added"`
+	Removed []WorkspaceFolder `json:"removed"`
+}
+
+type DidChangeWorkspaceFoldersParams struct {
+	Event WorkspaceFoldersChangeEvent `json:"event"`
+}
+
 type ClientInfo struct {
 	Name    string `json:"name,omitempty"`
 	Version string `json:"version,omitempty"`
@@ -50,10 +65,21 @@ type ClientInfo struct {
 
 type Trace string
 
+const (
+	TraceOff      Trace = "off"
+	TraceMessages Trace = "messages"
+	TraceVerbose  Trace = "verbose"
+)
+
+type SetTraceParams struct {
+	Value Trace `json:"value"`
+}
+
 type ClientCapabilities struct {
 	Workspace    WorkspaceClientCapabilities    `json:"workspace,omitempty"`
 	TextDocument TextDocumentClientCapabilities `json:"textDocument,omitempty"`
 	Window       WindowClientCapabilities       `json:"window,omitempty"`
+	General      GeneralClientCapabilities      `json:"general,omitempty"`
 	Experimental any                            `json:"experimental,omitempty"`
 
 	// Below are Sourcegraph extensions. They do not live in lspext since
@@ -140,6 +166,10 @@ type TextDocumentClientCapabilities struct {
 		DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 	} `json:"rangeFormatting,omitempty"`
 
+	OnTypeFormatting *struct {
+		DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+	} `json:"onTypeFormatting,omitempty"`
+
 	Rename *struct {
 		DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 
@@ -210,6 +240,20 @@ type TextDocumentClientCapabilities struct {
 	} `json:"colorProvider,omitempty"`
 }
 
+type GeneralClientCapabilities struct {
+	// PositionEncodings are the encodings supported by the client in the order of preference.
+	// When it is empty, only utf-16 is supported.
+	PositionEncodings []PositionEncodingKind `json:"positionEncodings,omitempty"`
+}
+
+type PositionEncodingKind string
+
+const (
+	PositionEncodingUTF8  PositionEncodingKind = "utf-8"
+	PositionEncodingUTF16 PositionEncodingKind = "utf-16"
+	PositionEncodingUTF32 PositionEncodingKind = "utf-32"
+)
+
 type WindowClientCapabilities struct {
 	WorkDoneProgress bool `json:"workDoneProgress,omitempty"`
 }
@@ -301,6 +345,7 @@ type SaveOptions struct {
 }
 
 type ServerCapabilities struct {
+	PositionEncoding                 PositionEncodingKind             `json:"positionEncoding,omitempty"`
 	TextDocumentSync                 *TextDocumentSyncOptionsOrKind   `json:"textDocumentSync,omitempty"`
 	HoverProvider                    bool                             `json:"hoverProvider,omitempty"`
 	CompletionProvider               *CompletionOptions               `json:"completionProvider,omitempty"`
@@ -320,6 +365,9 @@ type ServerCapabilities struct {
 	RenameProvider                   *RenameOptions                   `json:"renameProvider,omitempty"`
 	ExecuteCommandProvider           *ExecuteCommandOptions           `json:"executeCommandProvider,omitempty"`
 	SemanticHighlighting             *SemanticHighlightingOptions     `json:"semanticHighlighting,omitempty"`
+	DocumentLinkProvider             *DocumentLinkOptions             `json:"documentLinkProvider,omitempty"`
+	SelectionRangeProvider           bool                             `json:"selectionRangeProvider,omitempty"`
+	Workspace                        *WorkspaceServerCapabilities     `json:"workspace,omitempty"`
 
 	// XWorkspaceReferencesProvider indicates the server provides support for
 	// xworkspace/references. This is a Sourcegraph extension.
@@ -337,6 +385,29 @@ type ServerCapabilities struct {
 	Experimental any `json:"experimental,omitempty"`
 }
 
+type DocumentLinkOptions struct {
+	ResolveProvider bool `json:"resolveProvider,omitempty"`
+}
+
+type DocumentLinkParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type DocumentLink struct {
+	Range   Range  `json:"range"`
+	Target  string `json:"target,omitempty"`
+	Tooltip string `json:"tooltip,omitempty"`
+}
+
+type WorkspaceServerCapabilities struct {
+	WorkspaceFolders *WorkspaceFoldersServerCapabilities `json:"workspaceFolders,omitempty"`
+}
+
+type WorkspaceFoldersServerCapabilities struct {
+	Supported           bool `json:"supported,omitempty"`
+	ChangeNotifications bool `json:"changeNotifications,omitempty"`
+}
+
 type CompletionOptions struct {
 	ResolveProvider   bool     `json:"resolveProvider,omitempty"`
 	TriggerCharacters []string `json:"triggerCharacters,omitempty"`
@@ -606,6 +677,16 @@ type DocumentHighlight struct {
 	Kind  int   `json:"kind,omitempty"`
 }
 
+type SelectionRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Positions    []Position             `json:"positions"`
+}
+
+type SelectionRange struct {
+	Range  Range           `json:"range"`
+	Parent *SelectionRange `json:"parent,omitempty"`
+}
+
 type DocumentSymbolParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 }
@@ -782,6 +863,16 @@ type ShowMessageRequestParams struct {
 	Actions []MessageActionItem `json:"actions"`
 }
 
+type ApplyWorkspaceEditParams struct {
+	Label string        `json:"label,omitempty"`
+	Edit  WorkspaceEdit `json:"edit"`
+}
+
+type ApplyWorkspaceEditResult struct {
+	Applied       bool   `json:"applied"`
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
 type LogMessageParams struct {
 	Type    MessageType `json:"type"`
 	Message string      `json:"message"`
@@ -808,6 +899,40 @@ type DidChangeWatchedFilesParams struct {
 	Changes []FileEvent `json:"changes"`
 }
 
+type Registration struct {
+	ID              string `json:"id"`
+	Method          string `json:"method"`
+	RegisterOptions any    `json:"registerOptions,omitempty"`
+}
+
+type RegistrationParams struct {
+	Registrations []Registration `json:"registrations"`
+}
+
+type DocumentFilter struct {
+	Language string `json:"language,omitempty"`
+	Scheme   string `json:"scheme,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+}
+
+type TextDocumentRegistrationOptions struct {
+	DocumentSelector []DocumentFilter `json:"documentSelector"`
+}
+
+type DocumentOnTypeFormattingRegistrationOptions struct {
+	TextDocumentRegistrationOptions
+	DocumentOnTypeFormattingOptions
+}
+
+type FileSystemWatcher struct {
+	GlobPattern string `json:"globPattern"`
+	Kind        int    `json:"kind,omitempty"`
+}
+
+type DidChangeWatchedFilesRegistrationOptions struct {
+	Watchers []FileSystemWatcher `json:"watchers"`
+}
+
 type PublishDiagnosticsParams struct {
 	URI         DocumentURI  `json:"uri"`
 	Diagnostics []Diagnostic `json:"diagnostics"`
@@ -823,7 +948,7 @@ type DocumentOnTypeFormattingParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 	Position     Position               `json:"position"`
 	Ch           string                 `json:"ch"`
-	Options      FormattingOptions      `json:"formattingOptions"`
+	Options      FormattingOptions      `json:"options"`
 }
 
 type CancelParams struct {
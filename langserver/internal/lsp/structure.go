@@ -174,6 +174,13 @@ type TextDocumentPositionParams struct {
 
 type ProgressToken string
 
+type WorkDoneProgressCreateParams struct {
+	/**
+	 * The token to be used to report progress.
+	 */
+	Token ProgressToken `json:"token"`
+}
+
 type WorkDoneProgressParams struct {
 	/**
 	 * An optional token that a server can use to report work done progress.
@@ -6,6 +6,20 @@ import (
 	"cloud.google.com/go/bigquery"
 )
 
+// ExperimentalServerCapabilities are the capabilities of the custom methods.
+type ExperimentalServerCapabilities struct {
+	// VirtualTextDocument is true when the server handles `bqls/virtualTextDocument` for `bqls://` URIs.
+	VirtualTextDocument bool `json:"virtualTextDocument"`
+	// ListDatasets is true when the server handles `bqls/listDatasets`.
+	ListDatasets bool `json:"listDatasets"`
+	// ListTables is true when the server handles `bqls/listTables`.
+	ListTables bool `json:"listTables"`
+	// ListJobs is true when the server handles `bqls/listJobs`.
+	ListJobs bool `json:"listJobs"`
+	// GetJob is true when the server handles `bqls/getJob`.
+	GetJob bool `json:"getJob"`
+}
+
 type VirtualTextDocumentParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 }
@@ -18,6 +32,9 @@ type VirtualTextDocument struct {
 type QueryResult struct {
 	Columns []string           `json:"columns"`
 	Data    [][]bigquery.Value `json:"data"`
+	// NextPageToken is the token to fetch the next page with bqls.fetchMoreResults.
+	// It is empty when all rows are fetched.
+	NextPageToken string `json:"nextPageToken,omitempty"`
 }
 
 func NewJobVirtualTextDocumentURI(projectID, jobID string) DocumentURI {
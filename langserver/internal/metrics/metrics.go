@@ -0,0 +1,205 @@
+// Package metrics records the latencies and the counters of the language server,
+// and exposes them in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kitagry/bqls/langserver/internal/cache"
+)
+
+// Default is the registry used by the language server.
+var Default = NewRegistry()
+
+// latencyBuckets are the upper bounds of the histogram in seconds.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type Registry struct {
+	mu sync.Mutex
+
+	requests      map[string]*histogram
+	analysis      *histogram
+	bigqueryCalls map[string]uint64
+
+	cacheSources map[any]func() map[string]cache.Stats
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		requests:      make(map[string]*histogram),
+		analysis:      newHistogram(),
+		bigqueryCalls: make(map[string]uint64),
+		cacheSources:  make(map[any]func() map[string]cache.Stats),
+	}
+}
+
+// ObserveRequest records the latency of the LSP method.
+func (r *Registry) ObserveRequest(method string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.requests[method]
+	if !ok {
+		h = newHistogram()
+		r.requests[method] = h
+	}
+	h.observe(d.Seconds())
+}
+
+// ObserveAnalysis records the duration of the analysis of a file.
+func (r *Registry) ObserveAnalysis(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.analysis.observe(d.Seconds())
+}
+
+// IncBigQueryCall counts the call of the BigQuery API.
+func (r *Registry) IncBigQueryCall(method string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bigqueryCalls[method]++
+}
+
+// RegisterCacheStats adds the source of the cache statistics keyed by key.
+// The statistics of the same cache name are summed up.
+func (r *Registry) RegisterCacheStats(key any, stats func() map[string]cache.Stats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cacheSources[key] = stats
+}
+
+func (r *Registry) UnregisterCacheStats(key any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cacheSources, key)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.WriteTo(w)
+	})
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	sources := make([]func() map[string]cache.Stats, 0, len(r.cacheSources))
+	for _, s := range r.cacheSources {
+		sources = append(sources, s)
+	}
+	r.mu.Unlock()
+
+	// The sources are called without the lock, because they may take their own locks.
+	cacheStats := make(map[string]cache.Stats)
+	for _, source := range sources {
+		for name, s := range source() {
+			cacheStats[name] = cacheStats[name].Add(s)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cw := &countWriter{w: w}
+
+	fmt.Fprintln(cw, "# HELP bqls_request_duration_seconds The latency of the LSP requests and notifications.")
+	fmt.Fprintln(cw, "# TYPE bqls_request_duration_seconds histogram")
+	for _, method := range sortedKeys(r.requests) {
+		r.requests[method].write(cw, "bqls_request_duration_seconds", fmt.Sprintf("method=%q", method))
+	}
+
+	fmt.Fprintln(cw, "# HELP bqls_analysis_duration_seconds The duration of the analysis of a file.")
+	fmt.Fprintln(cw, "# TYPE bqls_analysis_duration_seconds histogram")
+	r.analysis.write(cw, "bqls_analysis_duration_seconds", "")
+
+	fmt.Fprintln(cw, "# HELP bqls_bigquery_api_calls_total The number of the BigQuery API calls.")
+	fmt.Fprintln(cw, "# TYPE bqls_bigquery_api_calls_total counter")
+	for _, method := range sortedKeys(r.bigqueryCalls) {
+		fmt.Fprintf(cw, "bqls_bigquery_api_calls_total{method=%q} %d\n", method, r.bigqueryCalls[method])
+	}
+
+	names := sortedKeys(cacheStats)
+	writeCacheMetric(cw, "bqls_cache_hits_total", "counter", "The number of the cache hits.", names, func(name string) any { return cacheStats[name].Hits })
+	writeCacheMetric(cw, "bqls_cache_misses_total", "counter", "The number of the cache misses.", names, func(name string) any { return cacheStats[name].Misses })
+	writeCacheMetric(cw, "bqls_cache_evictions_total", "counter", "The number of the cache evictions.", names, func(name string) any { return cacheStats[name].Evictions })
+	writeCacheMetric(cw, "bqls_cache_entries", "gauge", "The number of the cached entries.", names, func(name string) any { return cacheStats[name].Len })
+
+	return cw.n, cw.err
+}
+
+func writeCacheMetric(w io.Writer, metric, typ, help string, names []string, value func(name string) any) {
+	fmt.Fprintf(w, "# HELP %s %s\n", metric, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", metric, typ)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s{cache=%q} %d\n", metric, name, value(name))
+	}
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(latencyBuckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, le := range latencyBuckets {
+		if v <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) write(w io.Writer, metric, labels string) {
+	prefix := ""
+	if labels != "" {
+		prefix = labels + ","
+	}
+	for i, le := range latencyBuckets {
+		fmt.Fprintf(w, "%s_bucket{%sle=\"%g\"} %d\n", metric, prefix, le, h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", metric, prefix, h.count)
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s_sum%s %g\n", metric, labels, h.sum)
+	fmt.Fprintf(w, "%s_count%s %d\n", metric, labels, h.count)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// countWriter keeps the first error, so that WriteTo doesn't check the error of each line.
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,49 @@
+package metrics_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/bqls/langserver/internal/cache"
+	"github.com/kitagry/bqls/langserver/internal/metrics"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.ObserveRequest("textDocument/hover", 20*time.Millisecond)
+	r.ObserveRequest("textDocument/hover", 2*time.Second)
+	r.ObserveAnalysis(100 * time.Millisecond)
+	r.IncBigQueryCall("GetTableMetadata")
+	r.IncBigQueryCall("GetTableMetadata")
+	for i := 0; i < 2; i++ {
+		r.RegisterCacheStats(i, func() map[string]cache.Stats {
+			return map[string]cache.Stats{"documents": {Hits: 3, Misses: 1, Len: 2, Capacity: 10}}
+		})
+	}
+	r.UnregisterCacheStats(1)
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	expectLines := []string{
+		`bqls_request_duration_seconds_bucket{method="textDocument/hover",le="0.025"} 1`,
+		`bqls_request_duration_seconds_bucket{method="textDocument/hover",le="+Inf"} 2`,
+		`bqls_request_duration_seconds_count{method="textDocument/hover"} 2`,
+		`bqls_analysis_duration_seconds_bucket{le="0.1"} 1`,
+		`bqls_analysis_duration_seconds_count 1`,
+		`bqls_bigquery_api_calls_total{method="GetTableMetadata"} 2`,
+		`bqls_cache_hits_total{cache="documents"} 3`,
+		`bqls_cache_misses_total{cache="documents"} 1`,
+		`bqls_cache_entries{cache="documents"} 2`,
+	}
+	for _, line := range expectLines {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("metrics should contain %q, got\n%s", line, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// AddDistinctAlias returns the edit which gives the item of the SELECT list at position an alias not used by the other items.
+// The qualified column like `b.id` is named `b_id`, and the others are suffixed with the number like `id_2`.
+func (p *Project) AddDistinctAlias(uri string, position lsp.Position) ([]lsp.TextEdit, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+	if parsedFile.Node == nil {
+		return nil, fmt.Errorf("failed to parse document %s", uri)
+	}
+
+	termOffset := parsedFile.TermOffset(position)
+	column, ok := file.SearchAstNode[*ast.SelectColumnNode](parsedFile.Node, termOffset)
+	if !ok {
+		return nil, fmt.Errorf("SELECT column is not found at the position")
+	}
+	name, ok := file.SelectColumnName(column)
+	if !ok {
+		return nil, fmt.Errorf("the column has no name")
+	}
+	selectList, ok := column.Parent().(*ast.SelectListNode)
+	if !ok {
+		return nil, fmt.Errorf("failed to find the SELECT list")
+	}
+
+	used := make(map[string]struct{})
+	for _, c := range selectList.Columns() {
+		if c.ParseLocationRange().Start().ByteOffset() == column.ParseLocationRange().Start().ByteOffset() {
+			continue
+		}
+		if n, ok := file.SelectColumnName(c); ok {
+			used[strings.ToLower(n)] = struct{}{}
+		}
+	}
+	alias := distinctAlias(column, name, used)
+
+	if a := column.Alias(); a != nil {
+		rng, ok := parsedFile.PositionRange(a.Identifier().ParseLocationRange())
+		if !ok {
+			return nil, fmt.Errorf("failed to find the range of the alias")
+		}
+		return []lsp.TextEdit{{Range: rng, NewText: alias}}, nil
+	}
+
+	rng, ok := parsedFile.PositionRange(column.ParseLocationRange())
+	if !ok {
+		return nil, fmt.Errorf("failed to find the range of the column")
+	}
+	return []lsp.TextEdit{{Range: lsp.Range{Start: rng.End, End: rng.End}, NewText: " AS " + alias}}, nil
+}
+
+func distinctAlias(column *ast.SelectColumnNode, name string, used map[string]struct{}) string {
+	if column.Alias() == nil {
+		if path, ok := column.Expression().(*ast.PathExpressionNode); ok && len(path.Names()) > 1 {
+			names := make([]string, 0, len(path.Names()))
+			for _, n := range path.Names() {
+				names = append(names, n.Name())
+			}
+			name = strings.Join(names, "_")
+			if _, ok := used[strings.ToLower(name)]; !ok {
+				return name
+			}
+		}
+	}
+
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s_%d", name, i)
+		if _, ok := used[strings.ToLower(candidate)]; !ok {
+			return candidate
+		}
+	}
+}
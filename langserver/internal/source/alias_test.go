@@ -0,0 +1,105 @@
+package source_test
+
+import (
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_AddDistinctAlias(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+
+		expectEdits []lsp.TextEdit
+		expectErr   bool
+	}{
+		"qualified column": {
+			files: map[string]string{
+				"file1.sql": "SELECT a.id, b.|id FROM `project.dataset.table` a JOIN `project.dataset.table` b USING (name)",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 17}, End: lsp.Position{Line: 0, Character: 17}},
+					NewText: " AS b_id",
+				},
+			},
+		},
+		"unqualified column": {
+			files: map[string]string{
+				"file1.sql": "SELECT id, |id FROM `project.dataset.table`",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 13}, End: lsp.Position{Line: 0, Character: 13}},
+					NewText: " AS id_2",
+				},
+			},
+		},
+		"replace alias": {
+			files: map[string]string{
+				"file1.sql": "SELECT id AS value, name AS |value FROM `project.dataset.table`",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 28}, End: lsp.Position{Line: 0, Character: 33}},
+					NewText: "value_2",
+				},
+			},
+		},
+		"anonymous column": {
+			files: map[string]string{
+				"file1.sql": "SELECT |1 + 1",
+			},
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.AddDistinctAlias(path, position)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("AddDistinctAlias should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectEdits, got); diff != "" {
+				t.Errorf("project.AddDistinctAlias result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
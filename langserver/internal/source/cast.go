@@ -0,0 +1,113 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// CastExpression returns the edit which wraps the expression at rng with CAST, or SAFE_CAST when safe is true.
+func (p *Project) CastExpression(uri string, rng lsp.Range, typeName string, safe bool) ([]lsp.TextEdit, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+
+	start := parsedFile.SrcOffset(rng.Start)
+	end := parsedFile.SrcOffset(rng.End)
+	if start >= end || end > len(parsedFile.Src) {
+		return nil, fmt.Errorf("the range is empty")
+	}
+
+	function := "CAST"
+	if safe {
+		function = "SAFE_CAST"
+	}
+	return []lsp.TextEdit{
+		{Range: rng, NewText: fmt.Sprintf("%s(%s AS %s)", function, parsedFile.Src[start:end], typeName)},
+	}, nil
+}
+
+// WrapJSONExpression returns the edit which wraps the JSON expression at rng with the function like JSON_VALUE.
+func (p *Project) WrapJSONExpression(uri string, rng lsp.Range, function string) ([]lsp.TextEdit, error) {
+	if _, ok := jsonConversionFunctions[strings.ToUpper(function)]; !ok {
+		return nil, fmt.Errorf("%s is not a JSON conversion function", function)
+	}
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+
+	start := parsedFile.SrcOffset(rng.Start)
+	end := parsedFile.SrcOffset(rng.End)
+	if start >= end || end > len(parsedFile.Src) {
+		return nil, fmt.Errorf("the range is empty")
+	}
+	return []lsp.TextEdit{
+		{Range: rng, NewText: fmt.Sprintf("%s(%s)", strings.ToUpper(function), parsedFile.Src[start:end])},
+	}, nil
+}
+
+// jsonConversionFunctions convert JSON to the value which can be compared.
+var jsonConversionFunctions = map[string]struct{}{
+	"JSON_VALUE":  {},
+	"JSON_QUERY":  {},
+	"LAX_STRING":  {},
+	"LAX_INT64":   {},
+	"LAX_FLOAT64": {},
+	"LAX_BOOL":    {},
+}
+
+// ComparisonRightOperand returns the range of the right operand of the comparison with operator which contains position.
+// It is used to fix the comparison of the mismatched types, whose error is reported at the beginning of the comparison.
+func (p *Project) ComparisonRightOperand(uri string, position lsp.Position, operator string) (lsp.Range, bool) {
+	_, rhs, ok := p.ComparisonOperands(uri, position, operator)
+	return rhs, ok
+}
+
+// ComparisonOperands returns the ranges of the left and right operands of the comparison with operator which contains position.
+func (p *Project) ComparisonOperands(uri string, position lsp.Position, operator string) (lhs lsp.Range, rhs lsp.Range, ok bool) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return lsp.Range{}, lsp.Range{}, false
+	}
+	parsedFile := p.parseFile(uri, sql)
+	if parsedFile.Node == nil {
+		return lsp.Range{}, lsp.Range{}, false
+	}
+
+	termOffset := parsedFile.TermOffset(position)
+	var found bool
+	ast.Walk(parsedFile.Node, func(n ast.Node) error {
+		binary, ok := n.(*ast.BinaryExpressionNode)
+		if !ok {
+			return nil
+		}
+		lRange := binary.ParseLocationRange()
+		if lRange == nil || termOffset < lRange.Start().ByteOffset() || lRange.End().ByteOffset() < termOffset {
+			return nil
+		}
+
+		lhsRange, ok := parsedFile.PositionRange(binary.Lhs().ParseLocationRange())
+		if !ok {
+			return nil
+		}
+		rhsRange, ok := parsedFile.PositionRange(binary.Rhs().ParseLocationRange())
+		if !ok {
+			return nil
+		}
+		// the operator is the text between the operands
+		op := parsedFile.Src[parsedFile.SrcOffset(lhsRange.End):parsedFile.SrcOffset(rhsRange.Start)]
+		if !strings.EqualFold(strings.TrimSpace(op), operator) {
+			return nil
+		}
+		// ast.Walk visits the parent first, so the innermost comparison is kept
+		lhs, rhs, found = lhsRange, rhsRange, true
+		return nil
+	})
+	return lhs, rhs, found
+}
@@ -0,0 +1,173 @@
+package source_test
+
+import (
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_CastComparisonRightOperand(t *testing.T) {
+	tests := map[string]struct {
+		files    map[string]string
+		operator string
+		typeName string
+		safe     bool
+
+		expectEdits []lsp.TextEdit
+	}{
+		"CAST the right operand": {
+			files: map[string]string{
+				"file1.sql": "SELECT id FROM `project.dataset.table` WHERE |name = 1",
+			},
+			operator: "=",
+			typeName: "STRING",
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 52}, End: lsp.Position{Line: 0, Character: 53}},
+					NewText: "CAST(1 AS STRING)",
+				},
+			},
+		},
+		"SAFE_CAST the right operand of the inner comparison": {
+			files: map[string]string{
+				"file1.sql": "SELECT id FROM `project.dataset.table` WHERE id > 0 AND |name = 1",
+			},
+			operator: "=",
+			typeName: "STRING",
+			safe:     true,
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 63}, End: lsp.Position{Line: 0, Character: 64}},
+					NewText: "SAFE_CAST(1 AS STRING)",
+				},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			rhs, ok := p.ComparisonRightOperand(path, position, tt.operator)
+			if !ok {
+				t.Fatal("ComparisonRightOperand should find the right operand")
+			}
+			got, err := p.CastExpression(path, rhs, tt.typeName, tt.safe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectEdits, got); diff != "" {
+				t.Errorf("project.CastExpression result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProject_WrapJSONComparisonOperand(t *testing.T) {
+	tests := map[string]struct {
+		files    map[string]string
+		left     bool
+		function string
+
+		expectEdits []lsp.TextEdit
+	}{
+		"JSON_VALUE the left operand": {
+			files: map[string]string{
+				"file1.sql": "SELECT id FROM `project.dataset.table` WHERE |payload.name = \"a\"",
+			},
+			left:     true,
+			function: "JSON_VALUE",
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 45}, End: lsp.Position{Line: 0, Character: 57}},
+					NewText: "JSON_VALUE(payload.name)",
+				},
+			},
+		},
+		"JSON_QUERY the right operand": {
+			files: map[string]string{
+				"file1.sql": "SELECT id FROM `project.dataset.table` WHERE |\"a\" = payload.name",
+			},
+			function: "json_query",
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 51}, End: lsp.Position{Line: 0, Character: 63}},
+					NewText: "JSON_QUERY(payload.name)",
+				},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "payload",
+						Type: bq.JSONFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			lhs, rhs, ok := p.ComparisonOperands(path, position, "=")
+			if !ok {
+				t.Fatal("ComparisonOperands should find the operands")
+			}
+			rng := rhs
+			if tt.left {
+				rng = lhs
+			}
+			got, err := p.WrapJSONExpression(path, rng, tt.function)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectEdits, got); diff != "" {
+				t.Errorf("project.WrapJSONExpression result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
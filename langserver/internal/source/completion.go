@@ -2,15 +2,46 @@ package source
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/kitagry/bqls/langserver/internal/function"
 	"github.com/kitagry/bqls/langserver/internal/lsp"
 	"github.com/kitagry/bqls/langserver/internal/source/completion"
 )
 
 func (p *Project) Complete(ctx context.Context, uri string, position lsp.Position) ([]completion.CompletionItem, error) {
 	sql := p.cache.Get(uri)
-	parsedFile := p.analyzer.ParseFile(uri, sql.RawText)
+	parsedFile := p.parseFile(uri, sql)
 
 	completor := completion.New(p.logger, p.analyzer, p.bqClient)
 	return completor.Complete(ctx, parsedFile, position)
 }
+
+// ResolveCompletionItem returns the documentation of the completion item which is omitted by Complete.
+func (p *Project) ResolveCompletionItem(ctx context.Context, data completion.ResolveData) (lsp.MarkupContent, error) {
+	switch {
+	case data.Function != "":
+		f, ok := function.FindBuiltInFunction(data.Function)
+		if !ok {
+			return lsp.MarkupContent{}, fmt.Errorf("function %s is not found", data.Function)
+		}
+		return lsp.MarkupContent{
+			Kind:  lsp.MKMarkdown,
+			Value: fmt.Sprintf("%s\n\n[bigquery documentation](%s)", f.Description, f.URL),
+		}, nil
+	case data.Table != "":
+		metadata, err := p.analyzer.GetTableMetadataFromPath(ctx, data.Table)
+		if err != nil {
+			return lsp.MarkupContent{}, fmt.Errorf("failed to get table metadata: %w", err)
+		}
+		result, err := buildBigQueryTableMetadataMarkedString(metadata)
+		if err != nil {
+			return lsp.MarkupContent{}, err
+		}
+		return lsp.MarkupContent{
+			Kind:  lsp.MKMarkdown,
+			Value: result[0].Value,
+		}, nil
+	}
+	return lsp.MarkupContent{}, fmt.Errorf("nothing to resolve")
+}
@@ -24,14 +24,15 @@ func (c *completor) completeBuiltinFunction(ctx context.Context, parsedFile file
 	result := make([]CompletionItem, 0)
 	for _, f := range function.BuiltInFunctions {
 		if strings.HasPrefix(f.Name, incompleteColumnName) {
+			// the description is resolved lazily because it is long
 			result = append(result, CompletionItem{
 				Kind:        lsp.CIKFunction,
 				NewText:     f.Name,
 				TypedPrefix: incompleteColumnName,
 				Documentation: lsp.MarkupContent{
-					Kind:  lsp.MKMarkdown,
-					Value: f.Description,
+					Kind: lsp.MKMarkdown,
 				},
+				Resolve: &ResolveData{Function: f.Name},
 			})
 		}
 	}
@@ -50,7 +50,7 @@ func TestCompletor_CompleteBuiltinFunction(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			parsedFile := analyzer.ParseFile(path, files[path])
+			parsedFile := analyzer.ParseFile(context.Background(), path, files[path])
 
 			got := completor.completeBuiltinFunction(context.Background(), parsedFile, position)
 			if diff := cmp.Diff(got, tt.expectCompletionItems); diff != "" {
@@ -2,6 +2,7 @@ package completion
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	bq "cloud.google.com/go/bigquery"
@@ -51,7 +52,7 @@ func (c *completor) completeColumns(ctx context.Context, parsedFile file.ParsedF
 
 	// for record column completion
 	for _, column := range columns {
-		if !strings.HasPrefix(incompleteColumnName, column.Name()) {
+		if !strings.HasPrefix(incompleteColumnName, column.Name()+".") {
 			continue
 		}
 		items := c.createCompletionItemForRecordType(ctx, incompleteColumnName, column)
@@ -78,11 +79,16 @@ func (c *completor) createCompletionItemFromColumn(ctx context.Context, incomple
 		}
 	}
 
+	// pseudo columns like _TABLE_SUFFIX are not included in the schema
+	if file.IsPseudoColumn(column.Name()) {
+		return createCompletionItemFromColumn(column, incompleteColumnName), true
+	}
+
 	return CompletionItem{}, false
 }
 
 func (c *completor) createCompletionItemForRecordType(ctx context.Context, incompleteColumnName string, column *rast.Column) []CompletionItem {
-	if !column.Type().IsStruct() {
+	if !isRecordType(column.Type()) {
 		return nil
 	}
 
@@ -94,13 +100,21 @@ func (c *completor) createCompletionItemForRecordType(ctx context.Context, incom
 
 	tableMetadata, err := c.analyzer.GetTableMetadataFromPath(ctx, column.TableName())
 	if err != nil {
-		return c.createCompletionItemForType(ctx, afterRecord, column.Type())
+		return c.createCompletionItemForType(ctx, afterRecord, column.Name(), column.Type(), "")
 	}
 
-	return c.createCompletionItemForBigquerySchema(ctx, incompleteColumnName, tableMetadata.Schema)
+	return c.createCompletionItemForBigquerySchema(ctx, incompleteColumnName, "", tableMetadata.Schema, "")
 }
 
-func (c *completor) createCompletionItemForType(ctx context.Context, incompleteColumnName string, typ types.Type) []CompletionItem {
+// createCompletionItemForType completes the fields of the struct typ whose path is fieldPath.
+// repeatedPath is the path of the first array on the way, and its fields need UNNEST to be accessed.
+func (c *completor) createCompletionItemForType(ctx context.Context, incompleteColumnName string, fieldPath string, typ types.Type, repeatedPath string) []CompletionItem {
+	if typ.IsArray() {
+		if repeatedPath == "" {
+			repeatedPath = fieldPath
+		}
+		typ = typ.AsArray().ElementType()
+	}
 	if !typ.IsStruct() {
 		return nil
 	}
@@ -111,10 +125,10 @@ func (c *completor) createCompletionItemForType(ctx context.Context, incompleteC
 	if len(inCompleteColumns) > 1 {
 		for _, field := range fields {
 			if field.Name() == inCompleteColumns[0] {
-				if !field.Type().IsStruct() {
+				if !isRecordType(field.Type()) {
 					return nil
 				}
-				return c.createCompletionItemForType(ctx, strings.Join(inCompleteColumns[1:], "."), field.Type())
+				return c.createCompletionItemForType(ctx, strings.Join(inCompleteColumns[1:], "."), fieldPath+"."+field.Name(), field.Type(), repeatedPath)
 			}
 		}
 		return nil
@@ -125,17 +139,26 @@ func (c *completor) createCompletionItemForType(ctx context.Context, incompleteC
 		if !strings.HasPrefix(field.Name(), incompleteColumnName) {
 			continue
 		}
-		items = append(items, createCompletionItemFromColumn(field, incompleteColumnName))
+		items = append(items, withUnnestHint(createCompletionItemFromColumn(field, incompleteColumnName), repeatedPath))
 	}
 	return items
 }
 
-func (c *completor) createCompletionItemForBigquerySchema(ctx context.Context, incompleteColumnName string, schema bq.Schema) []CompletionItem {
+// createCompletionItemForBigquerySchema completes the fields of schema whose parent path is parentPath.
+// repeatedPath is the path of the first REPEATED record on the way, and its fields need UNNEST to be accessed.
+func (c *completor) createCompletionItemForBigquerySchema(ctx context.Context, incompleteColumnName string, parentPath string, schema bq.Schema, repeatedPath string) []CompletionItem {
 	inCompleteColumns := strings.Split(incompleteColumnName, ".")
 	if len(inCompleteColumns) > 1 {
 		for _, field := range schema {
 			if field.Name == inCompleteColumns[0] {
-				return c.createCompletionItemForBigquerySchema(ctx, strings.Join(inCompleteColumns[1:], "."), field.Schema)
+				fieldPath := field.Name
+				if parentPath != "" {
+					fieldPath = parentPath + "." + field.Name
+				}
+				if field.Repeated && repeatedPath == "" {
+					repeatedPath = fieldPath
+				}
+				return c.createCompletionItemForBigquerySchema(ctx, strings.Join(inCompleteColumns[1:], "."), fieldPath, field.Schema, repeatedPath)
 			}
 		}
 		return nil
@@ -146,11 +169,27 @@ func (c *completor) createCompletionItemForBigquerySchema(ctx context.Context, i
 		if !strings.HasPrefix(field.Name, incompleteColumnName) {
 			continue
 		}
-		items = append(items, createCompletionItemFromSchema(field, incompleteColumnName))
+		items = append(items, withUnnestHint(createCompletionItemFromSchema(field, incompleteColumnName), repeatedPath))
 	}
 	return items
 }
 
+func isRecordType(typ types.Type) bool {
+	if typ.IsArray() {
+		return typ.AsArray().ElementType().IsStruct()
+	}
+	return typ.IsStruct()
+}
+
+// withUnnestHint notes that the field is inside the REPEATED record and cannot be accessed by the path.
+func withUnnestHint(item CompletionItem, repeatedPath string) CompletionItem {
+	if repeatedPath == "" {
+		return item
+	}
+	item.Documentation.Value += fmt.Sprintf("\n%s is REPEATED. Use UNNEST(%s) to access this field.", repeatedPath, repeatedPath)
+	return item
+}
+
 func (c *completor) completeScanField(ctx context.Context, node rast.ScanNode, incompleteColumnName string) []CompletionItem {
 	switch n := node.(type) {
 	case *rast.TableScanNode:
@@ -204,6 +243,14 @@ func (c *completor) completeTableScanField(ctx context.Context, tableScanNode *r
 
 		result = append(result, item)
 	}
+
+	// for record column completion
+	for _, column := range columns {
+		if !strings.HasPrefix(afterWord, column.Name()+".") {
+			continue
+		}
+		result = append(result, c.createCompletionItemForRecordType(ctx, afterWord, column)...)
+	}
 	return result
 }
 
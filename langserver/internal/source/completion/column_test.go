@@ -60,6 +60,74 @@ func TestProject_CompleteColumns(t *testing.T) {
 				},
 			},
 		},
+		"Select columns with policy tags": {
+			files: map[string]string{
+				"file1.sql": "SELECT email, | FROM `project.dataset.table`",
+			},
+			bqTableMetadataMap: map[string]*bq.TableMetadata{
+				"project.dataset.table": {
+					Schema: bq.Schema{
+						{
+							Name: "email",
+							Type: bq.StringFieldType,
+							PolicyTags: &bq.PolicyTagList{
+								Names: []string{"projects/project/locations/us/taxonomies/1/policyTags/2"},
+							},
+						},
+					},
+				},
+			},
+			expectCompletionItems: []CompletionItem{
+				{
+					Kind:    lsp.CIKField,
+					NewText: "email",
+					Documentation: lsp.MarkupContent{
+						Kind:  lsp.MKPlainText,
+						Value: "STRING\nPolicy tags: projects/project/locations/us/taxonomies/1/policyTags/2",
+					},
+				},
+			},
+		},
+		"Select RANGE and BIGNUMERIC columns": {
+			files: map[string]string{
+				"file1.sql": "SELECT amount, | FROM `project.dataset.table`",
+			},
+			bqTableMetadataMap: map[string]*bq.TableMetadata{
+				"project.dataset.table": {
+					Schema: bq.Schema{
+						{
+							Name:      "amount",
+							Type:      bq.BigNumericFieldType,
+							Precision: 76,
+							Scale:     38,
+						},
+						{
+							Name:             "period",
+							Type:             bq.RangeFieldType,
+							RangeElementType: &bq.RangeElementType{Type: bq.DateFieldType},
+						},
+					},
+				},
+			},
+			expectCompletionItems: []CompletionItem{
+				{
+					Kind:    lsp.CIKField,
+					NewText: "amount",
+					Documentation: lsp.MarkupContent{
+						Kind:  lsp.MKPlainText,
+						Value: "BIGNUMERIC(76, 38)",
+					},
+				},
+				{
+					Kind:    lsp.CIKField,
+					NewText: "period",
+					Documentation: lsp.MarkupContent{
+						Kind:  lsp.MKPlainText,
+						Value: "RANGE<DATE>",
+					},
+				},
+			},
+		},
 		"When file cannot be parsed": {
 			files: map[string]string{
 				"file1.sql": "SELECT | FROM `project.dataset.table`",
@@ -276,6 +344,82 @@ func TestProject_CompleteColumns(t *testing.T) {
 				},
 			},
 		},
+		"Complete repeated record column": {
+			files: map[string]string{
+				"file1.sql": "SELECT event.params.| FROM `project.dataset.table`",
+			},
+			bqTableMetadataMap: map[string]*bq.TableMetadata{
+				"project.dataset.table": {
+					Schema: bq.Schema{
+						{
+							Name: "event",
+							Type: bq.RecordFieldType,
+							Schema: bq.Schema{
+								{
+									Name:     "params",
+									Type:     bq.RecordFieldType,
+									Repeated: true,
+									Schema: bq.Schema{
+										{
+											Name: "key",
+											Type: bq.StringFieldType,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectCompletionItems: []CompletionItem{
+				{
+					Kind:    lsp.CIKField,
+					NewText: "key",
+					Documentation: lsp.MarkupContent{
+						Kind:  lsp.MKPlainText,
+						Value: "STRING\nevent.params is REPEATED. Use UNNEST(event.params) to access this field.",
+					},
+				},
+			},
+		},
+		"Complete record column with table alias": {
+			files: map[string]string{
+				"file1.sql": "SELECT t.record.record.| FROM `project.dataset.table` AS t",
+			},
+			bqTableMetadataMap: map[string]*bq.TableMetadata{
+				"project.dataset.table": {
+					Schema: bq.Schema{
+						{
+							Name: "record",
+							Type: bq.RecordFieldType,
+							Schema: bq.Schema{
+								{
+									Name: "record",
+									Type: bq.RecordFieldType,
+									Schema: bq.Schema{
+										{
+											Name:        "id",
+											Description: "id description",
+											Type:        bq.IntegerFieldType,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectCompletionItems: []CompletionItem{
+				{
+					Kind:    lsp.CIKField,
+					NewText: "id",
+					Documentation: lsp.MarkupContent{
+						Kind:  lsp.MKPlainText,
+						Value: "INTEGER\nid description",
+					},
+				},
+			},
+		},
 		"Complete record column with incomplete word": {
 			files: map[string]string{
 				"file1.sql": "SELECT record.i| FROM `project.dataset.table`",
@@ -809,6 +953,32 @@ func TestProject_CompleteColumns(t *testing.T) {
 				},
 			},
 		},
+		"Complete _TABLE_SUFFIX of wildcard table": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.table_*` WHERE _T|",
+			},
+			bqTableMetadataMap: map[string]*bq.TableMetadata{
+				"project.dataset.table_*": {
+					Schema: bq.Schema{
+						{
+							Name: "id",
+							Type: bq.IntegerFieldType,
+						},
+					},
+				},
+			},
+			expectCompletionItems: []CompletionItem{
+				{
+					Kind:    lsp.CIKField,
+					NewText: "_TABLE_SUFFIX",
+					Documentation: lsp.MarkupContent{
+						Kind:  lsp.MKPlainText,
+						Value: "STRING",
+					},
+					TypedPrefix: "_T",
+				},
+			},
+		},
 	}
 
 	for n, tt := range tests {
@@ -834,7 +1004,7 @@ func TestProject_CompleteColumns(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			parsedFile := analyzer.ParseFile(path, files[path])
+			parsedFile := analyzer.ParseFile(context.Background(), path, files[path])
 
 			got := completor.completeColumns(context.Background(), parsedFile, position)
 			if diff := cmp.Diff(got, tt.expectCompletionItems); diff != "" {
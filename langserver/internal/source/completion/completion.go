@@ -2,6 +2,7 @@ package completion
 
 import (
 	"context"
+	"strings"
 
 	bq "cloud.google.com/go/bigquery"
 	"github.com/goccy/go-zetasql"
@@ -36,6 +37,7 @@ func (c *completor) Complete(ctx context.Context, parsedFile file.ParsedFile, po
 	result = append(result, c.completeColumns(ctx, parsedFile, position)...)
 	result = append(result, c.completeBuiltinFunction(ctx, parsedFile, position)...)
 	result = append(result, c.completeDeclaration(ctx, parsedFile, position)...)
+	result = append(result, c.completeKeyword(ctx, parsedFile, position)...)
 	return result, nil
 }
 
@@ -93,10 +95,13 @@ func createCompletionItemFromColumn(column columnInterface, incompleteColumnName
 }
 
 func createCompletionItemFromSchema(schema *bq.FieldSchema, incompleteColumnName string) CompletionItem {
-	detail := string(schema.Type)
+	detail := file.FieldTypeString(schema)
 	if schema.Description != "" {
 		detail += "\n" + schema.Description
 	}
+	if schema.PolicyTags != nil && len(schema.PolicyTags.Names) > 0 {
+		detail += "\nPolicy tags: " + strings.Join(schema.PolicyTags.Names, ", ")
+	}
 	return CompletionItem{
 		Kind:    lsp.CIKField,
 		NewText: schema.Name,
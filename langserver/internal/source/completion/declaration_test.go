@@ -77,7 +77,7 @@ func TestProject_CompleteDeclaration(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			parsedFile := analyzer.ParseFile(path, files[path])
+			parsedFile := analyzer.ParseFile(context.Background(), path, files[path])
 
 			got := completor.completeDeclaration(context.Background(), parsedFile, position)
 			if diff := cmp.Diff(got, tt.expectCompletionItems); diff != "" {
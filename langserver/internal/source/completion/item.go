@@ -7,15 +7,34 @@ type CompletionItem struct {
 	NewText       string
 	Documentation lsp.MarkupContent
 	TypedPrefix   string
+
+	// Label is shown instead of NewText when it is set.
+	Label string
+	// SortText is used to rank the item above the others when it is set.
+	SortText string
+	// Resolve is set when the documentation is resolved lazily by completionItem/resolve.
+	Resolve *ResolveData
+}
+
+// ResolveData identifies the item whose documentation is resolved lazily.
+type ResolveData struct {
+	Table    string `json:"table,omitempty"`
+	Function string `json:"function,omitempty"`
 }
 
 func (c CompletionItem) ToLspCompletionItem(position lsp.Position, supportSnippet bool) lsp.CompletionItem {
+	label := c.NewText
+	if c.Label != "" {
+		label = c.Label
+	}
+
 	if !supportSnippet {
 		return lsp.CompletionItem{
 			InsertTextFormat: lsp.ITFPlainText,
 			Kind:             c.Kind,
-			Label:            c.NewText,
+			Label:            label,
 			Documentation:    c.Documentation,
+			SortText:         c.SortText,
 		}
 	}
 
@@ -24,8 +43,9 @@ func (c CompletionItem) ToLspCompletionItem(position lsp.Position, supportSnippe
 	return lsp.CompletionItem{
 		InsertTextFormat: lsp.ITFSnippet,
 		Kind:             c.Kind,
-		Label:            c.NewText,
+		Label:            label,
 		Documentation:    c.Documentation,
+		SortText:         c.SortText,
 		TextEdit: &lsp.TextEdit{
 			NewText: c.NewText,
 			Range: lsp.Range{
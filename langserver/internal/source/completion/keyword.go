@@ -0,0 +1,181 @@
+package completion
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// clauseKeywords are the clauses of the query in the order they can appear.
+var clauseKeywords = []string{"FROM", "WHERE", "GROUP BY", "HAVING", "QUALIFY", "WINDOW", "ORDER BY", "LIMIT"}
+
+// fromKeywords can follow the table in FROM clause.
+var fromKeywords = []string{"JOIN", "INNER JOIN", "LEFT JOIN", "RIGHT JOIN", "FULL JOIN", "CROSS JOIN", "TABLESAMPLE SYSTEM", "UNNEST"}
+
+var (
+	clauseRegex          = regexp.MustCompile(`(?i)\b(SELECT|FROM|WHERE|GROUP\s+BY|HAVING|QUALIFY|WINDOW|ORDER\s+BY|LIMIT)\b`)
+	expectedKeywordRegex = regexp.MustCompile(`keyword (\w+)`)
+	placeholderRegex     = regexp.MustCompile(`\$\{\d+:([^}]*)\}`)
+)
+
+type snippet struct {
+	keyword string
+	label   string
+	body    string
+}
+
+var snippets = []snippet{
+	{
+		keyword: "SELECT",
+		label:   "SELECT ... FROM ... WHERE",
+		body:    "SELECT\n  ${1:*}\nFROM\n  ${2:table}\nWHERE\n  ${3:TRUE}",
+	},
+	{
+		keyword: "SELECT",
+		label:   "SELECT ... QUALIFY ROW_NUMBER() = 1 (deduplicate)",
+		body:    "SELECT\n  *\nFROM\n  ${1:table}\nWHERE\n  TRUE\nQUALIFY ROW_NUMBER() OVER (PARTITION BY ${2:key} ORDER BY ${3:updated_at} DESC) = 1",
+	},
+	{
+		keyword: "MERGE",
+		label:   "MERGE ... USING ... ON",
+		body:    "MERGE ${1:target} T\nUSING ${2:source} S\nON T.${3:id} = S.${3:id}\nWHEN MATCHED THEN\n  UPDATE SET ${4:column} = S.${4:column}\nWHEN NOT MATCHED THEN\n  INSERT ROW",
+	},
+}
+
+// completeKeyword completes the snippets at the beginning of the statement,
+// and the keywords where the parser expects them.
+func (c *completor) completeKeyword(ctx context.Context, parsedFile file.ParsedFile, position lsp.Position) []CompletionItem {
+	offset := parsedFile.SrcOffset(position)
+	if offset > len(parsedFile.Src) {
+		return nil
+	}
+
+	word := typedWord(parsedFile.Src[:offset])
+	if word == "" {
+		return nil
+	}
+	wordStart := offset - len(word)
+	stmtStart := strings.LastIndex(parsedFile.Src[:wordStart], ";") + 1
+	before := parsedFile.Src[stmtStart:wordStart]
+
+	if strings.TrimSpace(before) == "" {
+		return completeSnippet(word)
+	}
+
+	expected, ok := c.expectedKeywords(parsedFile, position, wordStart)
+	if !ok {
+		return nil
+	}
+	keywords := append(expected, clauseKeywordsAfter(before)...)
+
+	result := make([]CompletionItem, 0)
+	seen := make(map[string]struct{})
+	for _, keyword := range keywords {
+		if _, ok := seen[keyword]; ok {
+			continue
+		}
+		seen[keyword] = struct{}{}
+
+		if !strings.HasPrefix(keyword, strings.ToUpper(word)) {
+			continue
+		}
+		result = append(result, CompletionItem{
+			Kind:    lsp.CIKKeyword,
+			NewText: keyword,
+			Documentation: lsp.MarkupContent{
+				Kind:  lsp.MKPlainText,
+				Value: "keyword",
+			},
+			TypedPrefix: word,
+		})
+	}
+	return result
+}
+
+// expectedKeywords reports whether the parser can accept a keyword at the word.
+// It is true when the word causes the syntax error or when the word is parsed as an alias like `FROM table gro`.
+func (c *completor) expectedKeywords(parsedFile file.ParsedFile, position lsp.Position, wordStart int) ([]string, bool) {
+	for _, err := range parsedFile.Errors {
+		if !strings.HasPrefix(err.Msg, "Syntax error:") || parsedFile.ErrorOffset(err) != wordStart {
+			continue
+		}
+
+		keywords := make([]string, 0)
+		for _, m := range expectedKeywordRegex.FindAllStringSubmatch(err.Msg, -1) {
+			keywords = append(keywords, strings.ToUpper(m[1]))
+		}
+		return keywords, true
+	}
+
+	if parsedFile.Node == nil {
+		return nil, false
+	}
+	if _, ok := file.SearchAstNode[*ast.AliasNode](parsedFile.Node, parsedFile.TermOffset(position)); ok {
+		return nil, true
+	}
+	return nil, false
+}
+
+// clauseKeywordsAfter returns the clauses which can follow the last clause in src.
+func clauseKeywordsAfter(src string) []string {
+	matches := clauseRegex.FindAllString(src, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	last := strings.ToUpper(strings.Join(strings.Fields(matches[len(matches)-1]), " "))
+
+	if last == "SELECT" {
+		return clauseKeywords
+	}
+
+	for i, keyword := range clauseKeywords {
+		if keyword != last {
+			continue
+		}
+
+		result := make([]string, 0)
+		if keyword == "FROM" {
+			result = append(result, fromKeywords...)
+		}
+		return append(result, clauseKeywords[i+1:]...)
+	}
+	return nil
+}
+
+func completeSnippet(word string) []CompletionItem {
+	result := make([]CompletionItem, 0)
+	for _, s := range snippets {
+		if !strings.HasPrefix(s.keyword, strings.ToUpper(word)) {
+			continue
+		}
+		result = append(result, CompletionItem{
+			Kind:    lsp.CIKSnippet,
+			Label:   s.label,
+			NewText: s.body,
+			Documentation: lsp.MarkupContent{
+				Kind:  lsp.MKMarkdown,
+				Value: "```sql\n" + placeholderRegex.ReplaceAllString(s.body, "$1") + "\n```",
+			},
+			TypedPrefix: word,
+		})
+	}
+	return result
+}
+
+// typedWord returns the identifier just before the cursor.
+func typedWord(src string) string {
+	i := len(src)
+	for i > 0 {
+		r := rune(src[i-1])
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			break
+		}
+		i--
+	}
+	return src[i:]
+}
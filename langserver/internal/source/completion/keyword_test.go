@@ -0,0 +1,111 @@
+package completion
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_CompleteKeyword(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+
+		expectCompletionItems []CompletionItem
+	}{
+		"Complete clause after WHERE": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.table` WHERE id = 1 gro|",
+			},
+			expectCompletionItems: []CompletionItem{
+				{
+					Kind:    lsp.CIKKeyword,
+					NewText: "GROUP BY",
+					Documentation: lsp.MarkupContent{
+						Kind:  lsp.MKPlainText,
+						Value: "keyword",
+					},
+					TypedPrefix: "gro",
+				},
+			},
+		},
+		"Complete TABLESAMPLE after table": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.table` tables|",
+			},
+			expectCompletionItems: []CompletionItem{
+				{
+					Kind:    lsp.CIKKeyword,
+					NewText: "TABLESAMPLE SYSTEM",
+					Documentation: lsp.MarkupContent{
+						Kind:  lsp.MKPlainText,
+						Value: "keyword",
+					},
+					TypedPrefix: "tables",
+				},
+			},
+		},
+		"Complete MERGE snippet": {
+			files: map[string]string{
+				"file1.sql": "mer|",
+			},
+			expectCompletionItems: []CompletionItem{
+				{
+					Kind:    lsp.CIKSnippet,
+					Label:   "MERGE ... USING ... ON",
+					NewText: "MERGE ${1:target} T\nUSING ${2:source} S\nON T.${3:id} = S.${3:id}\nWHEN MATCHED THEN\n  UPDATE SET ${4:column} = S.${4:column}\nWHEN NOT MATCHED THEN\n  INSERT ROW",
+					Documentation: lsp.MarkupContent{
+						Kind:  lsp.MKMarkdown,
+						Value: "```sql\nMERGE target T\nUSING source S\nON T.id = S.id\nWHEN MATCHED THEN\n  UPDATE SET column = S.column\nWHEN NOT MATCHED THEN\n  INSERT ROW\n```",
+					},
+					TypedPrefix: "mer",
+				},
+			},
+		},
+		"Do not complete keyword for column": {
+			files: map[string]string{
+				"file1.sql": "SELECT gro| FROM `project.dataset.table`",
+			},
+			expectCompletionItems: nil,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			logger := logrus.New()
+			logger.SetLevel(logrus.DebugLevel)
+
+			analyzer := file.NewAnalyzer(logger, bqClient)
+			completor := New(logger, analyzer, bqClient)
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsedFile := analyzer.ParseFile(context.Background(), path, files[path])
+
+			got := completor.completeKeyword(context.Background(), parsedFile, position)
+			if diff := cmp.Diff(got, tt.expectCompletionItems); diff != "" {
+				t.Errorf("(-got, +want)\n%s", diff)
+			}
+		})
+	}
+}
@@ -42,16 +42,78 @@ func (c *completor) completeTablePath(ctx context.Context, parsedFile file.Parse
 		params.TableID = splittedTablePath[2]
 	}
 
+	result := c.completeReferencedTables(parsedFile, tablePathNode, tablePath, termOffset)
+
+	var items []CompletionItem
+	var err error
 	switch len(splittedTablePath) {
 	case 0, 1:
-		return c.completeProjectForTablePath(ctx, params)
+		items, err = c.completeProjectForTablePath(ctx, params)
 	case 2:
-		return c.completeDatasetForTablePath(ctx, params)
+		items, err = c.completeDatasetForTablePath(ctx, params)
 	case 3:
-		return c.completeTableForTablePath(ctx, params)
+		items, err = c.completeTableForTablePath(ctx, params)
 	}
 
-	return nil, nil
+	return append(result, items...), err
+}
+
+// completeReferencedTables completes the WITH queries defined before the cursor and the tables already referenced in the file.
+// They are ranked above the tables in the catalog.
+func (c *completor) completeReferencedTables(parsedFile file.ParsedFile, tablePathNode *ast.TablePathExpressionNode, tablePath string, termOffset int) []CompletionItem {
+	result := make([]CompletionItem, 0)
+	if !strings.Contains(tablePath, ".") {
+		for _, entry := range file.ListAstNode[*ast.WithClauseEntryNode](parsedFile.Node) {
+			lRange := entry.ParseLocationRange()
+			if lRange == nil || termOffset < lRange.End().ByteOffset() {
+				continue
+			}
+
+			name := entry.Alias().Name()
+			if name == tablePath || !strings.HasPrefix(name, tablePath) {
+				continue
+			}
+			result = append(result, CompletionItem{
+				Kind:    lsp.CIKModule,
+				NewText: name,
+				Documentation: lsp.MarkupContent{
+					Kind:  lsp.MKPlainText,
+					Value: "WITH query",
+				},
+				TypedPrefix: tablePath,
+				SortText:    "0" + name,
+			})
+		}
+	}
+
+	referenced := make(map[string]struct{})
+	for _, node := range file.ListAstNode[*ast.TablePathExpressionNode](parsedFile.Node) {
+		if sameLocation(node, tablePathNode) {
+			continue
+		}
+
+		name, ok := file.CreateTableNameFromTablePathExpressionNode(node)
+		if !ok || !strings.Contains(name, ".") || name == tablePath || !strings.HasPrefix(name, tablePath) {
+			continue
+		}
+		if _, ok := referenced[name]; ok {
+			continue
+		}
+		referenced[name] = struct{}{}
+
+		result = append(result, CompletionItem{
+			Kind:    lsp.CIKModule,
+			NewText: name,
+			Documentation: lsp.MarkupContent{
+				Kind:  lsp.MKPlainText,
+				Value: "referenced table",
+			},
+			TypedPrefix: tablePath,
+			SortText:    "0" + name,
+			Resolve:     &ResolveData{Table: name},
+		})
+	}
+	return result
 }
 
 func (c *completor) completeProjectForTablePath(ctx context.Context, param tablePathParams) ([]CompletionItem, error) {
@@ -126,8 +188,17 @@ func (c *completor) completeTableForTablePath(ctx context.Context, param tablePa
 				Value: fmt.Sprintf("%s.%s.%s", t.ProjectID, t.DatasetID, t.TableID),
 			},
 			TypedPrefix: param.TableID,
+			Resolve:     &ResolveData{Table: fmt.Sprintf("%s.%s.%s", t.ProjectID, t.DatasetID, t.TableID)},
 		})
 	}
 
 	return result, nil
 }
+
+func sameLocation(a, b *ast.TablePathExpressionNode) bool {
+	aRange, bRange := a.ParseLocationRange(), b.ParseLocationRange()
+	if aRange == nil || bRange == nil {
+		return false
+	}
+	return aRange.Start().ByteOffset() == bRange.Start().ByteOffset()
+}
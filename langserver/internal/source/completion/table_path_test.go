@@ -57,6 +57,7 @@ func TestProject_CompleteTablePath(t *testing.T) {
 						Kind:  lsp.MKPlainText,
 						Value: "project.dataset.1table",
 					},
+					Resolve: &ResolveData{Table: "project.dataset.1table"},
 				},
 				{
 					Kind:    lsp.CIKModule,
@@ -65,6 +66,7 @@ func TestProject_CompleteTablePath(t *testing.T) {
 						Kind:  lsp.MKPlainText,
 						Value: "project.dataset.2table",
 					},
+					Resolve: &ResolveData{Table: "project.dataset.2table"},
 				},
 			},
 		},
@@ -95,6 +97,7 @@ func TestProject_CompleteTablePath(t *testing.T) {
 						Kind:  lsp.MKPlainText,
 						Value: "project.dataset.table20230622",
 					},
+					Resolve: &ResolveData{Table: "project.dataset.table20230622"},
 				},
 			},
 		},
@@ -181,6 +184,71 @@ func TestProject_CompleteTablePath(t *testing.T) {
 				},
 			},
 		},
+		"complete WITH query name": {
+			files: map[string]string{
+				"file1.sql": "WITH data AS (SELECT 1 AS id)\nSELECT * FROM d|",
+			},
+			bigqueryClientMockFunc: func(t *testing.T) bigquery.Client {
+				ctrl := gomock.NewController(t)
+				bqClient := mock_bigquery.NewMockClient(ctrl)
+
+				bqClient.EXPECT().ListProjects(gomock.Any()).Return([]*cloudresourcemanager.Project{}, nil)
+				bqClient.EXPECT().GetTableMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("not found")).MinTimes(0)
+				return bqClient
+			},
+			expectCompletionItems: []CompletionItem{
+				{
+					Kind:    lsp.CIKModule,
+					NewText: "data",
+					Documentation: lsp.MarkupContent{
+						Kind:  lsp.MKPlainText,
+						Value: "WITH query",
+					},
+					TypedPrefix: "d",
+					SortText:    "0data",
+				},
+			},
+		},
+		"complete referenced table for self join": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.table` t1 CROSS JOIN `p|`",
+			},
+			bigqueryClientMockFunc: func(t *testing.T) bigquery.Client {
+				ctrl := gomock.NewController(t)
+				bqClient := mock_bigquery.NewMockClient(ctrl)
+
+				bqClient.EXPECT().ListProjects(gomock.Any()).Return([]*cloudresourcemanager.Project{
+					{
+						ProjectId: "project",
+						Name:      "project name",
+					},
+				}, nil)
+				bqClient.EXPECT().GetTableMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("not found")).MinTimes(0)
+				return bqClient
+			},
+			expectCompletionItems: []CompletionItem{
+				{
+					Kind:    lsp.CIKModule,
+					NewText: "project.dataset.table",
+					Documentation: lsp.MarkupContent{
+						Kind:  lsp.MKPlainText,
+						Value: "referenced table",
+					},
+					TypedPrefix: "p",
+					SortText:    "0project.dataset.table",
+					Resolve:     &ResolveData{Table: "project.dataset.table"},
+				},
+				{
+					Kind:    lsp.CIKModule,
+					NewText: "project",
+					Documentation: lsp.MarkupContent{
+						Kind:  lsp.MKPlainText,
+						Value: "project name",
+					},
+					TypedPrefix: "p",
+				},
+			},
+		},
 	}
 
 	for n, tt := range tests {
@@ -197,7 +265,7 @@ func TestProject_CompleteTablePath(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			parsedFile := analyzer.ParseFile(path, files[path])
+			parsedFile := analyzer.ParseFile(context.Background(), path, files[path])
 
 			got, err := completor.completeTablePath(context.Background(), parsedFile, position)
 			if !errors.Is(err, tt.expectErr) {
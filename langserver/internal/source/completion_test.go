@@ -0,0 +1,67 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/completion"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_ResolveCompletionItem(t *testing.T) {
+	tests := map[string]struct {
+		data completion.ResolveData
+
+		expectDocumentation lsp.MarkupContent
+	}{
+		"resolve table": {
+			data: completion.ResolveData{Table: "project.dataset.table"},
+			expectDocumentation: lsp.MarkupContent{
+				Kind: lsp.MKMarkdown,
+				Value: `## project.dataset.table
+
+### Table info
+
+* Created: 2023-06-17 00:00:00
+* Last modified: 2023-06-17 00:00:00
+
+### Storage info
+
+* Number of rows: 1,000
+* Total logical bytes: 1 KiB
+`,
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				FullID:           "project.dataset.table",
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				NumRows:          1000,
+				NumBytes:         1024,
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			got, err := p.ResolveCompletionItem(context.Background(), tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.expectDocumentation, got); diff != "" {
+				t.Errorf("project.ResolveCompletionItem result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
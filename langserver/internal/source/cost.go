@@ -0,0 +1,50 @@
+package source
+
+import (
+	"context"
+	"fmt"
+)
+
+// BytesLimitExceededError is returned when the estimated bytes processed of the query exceed max_bytes_processed.
+type BytesLimitExceededError struct {
+	Estimated int64
+	Limit     int64
+}
+
+func (e *BytesLimitExceededError) Error() string {
+	return fmt.Sprintf("the query will process %s, which exceeds max_bytes_processed(%s). Run it with --force to execute it anyway", bytesConvert(e.Estimated), bytesConvert(e.Limit))
+}
+
+// checkBytesProcessed estimates the bytes processed of the query with a dry run,
+// so that the query scanning a huge table isn't executed by accident.
+func (p *Project) checkBytesProcessed(ctx context.Context, query string) error {
+	if p.maxBytesProcessed <= 0 {
+		return nil
+	}
+
+	dryrun := true
+	job, err := p.bqClient.Run(ctx, query, dryrun)
+	if err != nil {
+		return err
+	}
+
+	status := job.LastStatus()
+	if status == nil {
+		return fmt.Errorf("failed to estimate the bytes processed: the dry run has no status. Run it with --force to execute it anyway")
+	}
+	if err := status.Err(); err != nil {
+		return err
+	}
+	// The query is not executed when the limit can't be checked.
+	if status.Statistics == nil {
+		return fmt.Errorf("failed to estimate the bytes processed: the dry run has no statistics. Run it with --force to execute it anyway")
+	}
+
+	if status.Statistics.TotalBytesProcessed > p.maxBytesProcessed {
+		return &BytesLimitExceededError{
+			Estimated: status.Statistics.TotalBytesProcessed,
+			Limit:     p.maxBytesProcessed,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+func (p *Project) LookupDefinition(ctx context.Context, uri string, position lsp.Position) ([]lsp.Location, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+
+	if _, identifier, ok := variableDeclarationAt(parsedFile, position); ok {
+		rng, ok := parsedFile.PositionRange(identifier.ParseLocationRange())
+		if !ok {
+			return nil, nil
+		}
+		return []lsp.Location{
+			{
+				URI:   lsp.DocumentURI(fmt.Sprintf("file://%s", uri)),
+				Range: rng,
+			},
+		}, nil
+	}
+
+	termOffset := parsedFile.TermOffset(position)
+	targetNode, ok := file.SearchAstNode[*ast.PathExpressionNode](parsedFile.Node, termOffset)
+	if !ok {
+		p.logger.Debug("not found target node")
+		return nil, nil
+	}
+
+	if _, ok := targetNode.Parent().(*ast.FunctionCallNode); ok {
+		decl, ok := parsedFile.FindTempFunctionDeclaration(createNameFromPathExpressionNode(targetNode))
+		if !ok {
+			return nil, nil
+		}
+
+		rng, ok := parsedFile.PositionRange(decl.FunctionDeclaration().Name().ParseLocationRange())
+		if !ok {
+			return nil, nil
+		}
+		return []lsp.Location{
+			{
+				URI:   lsp.DocumentURI(fmt.Sprintf("file://%s", uri)),
+				Range: rng,
+			},
+		}, nil
+	}
+
+	if tablePathNode, ok := file.LookupNode[*ast.TablePathExpressionNode](targetNode); ok {
+		return p.lookupTableDefinition(ctx, uri, parsedFile, tablePathNode)
+	}
+
+	return nil, nil
+}
+
+func (p *Project) lookupTableDefinition(ctx context.Context, uri string, parsedFile file.ParsedFile, node *ast.TablePathExpressionNode) ([]lsp.Location, error) {
+	name, ok := file.CreateTableNameFromTablePathExpressionNode(node)
+	if !ok {
+		return nil, nil
+	}
+
+	// The name might be the reference of WITH clause.
+	if !strings.Contains(name, ".") {
+		for _, entry := range file.ListAstNode[*ast.WithClauseEntryNode](parsedFile.Node) {
+			if entry.Alias().Name() != name {
+				continue
+			}
+			rng, ok := parsedFile.PositionRange(entry.Alias().ParseLocationRange())
+			if !ok {
+				return nil, nil
+			}
+			return []lsp.Location{
+				{
+					URI:   lsp.DocumentURI(fmt.Sprintf("file://%s", uri)),
+					Range: rng,
+				},
+			}, nil
+		}
+	}
+
+	// Jump to the virtual text document of the table.
+	// When the table is a view, the document contains the view query.
+	metadata, err := p.analyzer.GetTableMetadataFromPath(ctx, name)
+	if err != nil {
+		return nil, nil
+	}
+	projectID, datasetID, tableID, ok := extractTableIDsFromMedatada(metadata)
+	if !ok {
+		return nil, nil
+	}
+	return []lsp.Location{
+		{
+			URI: lsp.NewTableVirtualTextDocumentURI(projectID, datasetID, tableID),
+		},
+	}, nil
+}
@@ -0,0 +1,131 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_LookupDefinition(t *testing.T) {
+	tests := map[string]struct {
+		files           map[string]string
+		bqTableMetadata *bq.TableMetadata
+
+		expectLocations []lsp.Location
+	}{
+		"temp function": {
+			files: map[string]string{
+				"file1.sql": "CREATE TEMP FUNCTION add_one(x INT64) RETURNS INT64 AS (x + 1);\nSELECT add_|one(1)",
+			},
+			expectLocations: []lsp.Location{
+				{
+					URI: "file://file1.sql",
+					Range: lsp.Range{
+						Start: lsp.Position{Line: 0, Character: 21},
+						End:   lsp.Position{Line: 0, Character: 28},
+					},
+				},
+			},
+		},
+		"script variable": {
+			files: map[string]string{
+				"file1.sql": "DECLARE target_id INT64 DEFAULT 10;\nSELECT * FROM `project.dataset.table` WHERE id = target_|id",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			},
+			expectLocations: []lsp.Location{
+				{
+					URI: "file://file1.sql",
+					Range: lsp.Range{
+						Start: lsp.Position{Line: 0, Character: 8},
+						End:   lsp.Position{Line: 0, Character: 17},
+					},
+				},
+			},
+		},
+		"view": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.|view`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:    "project:dataset.view",
+				Type:      bq.ViewTable,
+				ViewQuery: "SELECT 1 AS id",
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			},
+			expectLocations: []lsp.Location{
+				{
+					URI: "bqls://project/project/dataset/dataset/table/view",
+				},
+			},
+		},
+		"WITH clause reference": {
+			files: map[string]string{
+				"file1.sql": "WITH data AS (SELECT 1 AS id)\nSELECT * FROM da|ta",
+			},
+			expectLocations: []lsp.Location{
+				{
+					URI: "file://file1.sql",
+					Range: lsp.Range{
+						Start: lsp.Position{Line: 0, Character: 5},
+						End:   lsp.Position{Line: 0, Character: 9},
+					},
+				},
+			},
+		},
+		"builtin function": {
+			files: map[string]string{
+				"file1.sql": "SELECT ab|s(1)",
+			},
+			expectLocations: nil,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.bqTableMetadata, nil).MinTimes(0)
+			logger := logrus.New()
+			logger.SetLevel(logrus.DebugLevel)
+			p := source.NewProjectWithBQClient("/", bqClient, logger)
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatalf("failed to get position: %v", err)
+			}
+
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.LookupDefinition(context.Background(), path, position)
+			if err != nil {
+				t.Fatalf("failed to LookupDefinition: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expectLocations, got); diff != "" {
+				t.Errorf("project.LookupDefinition result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
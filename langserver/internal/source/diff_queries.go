@@ -0,0 +1,248 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-zetasql"
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/goccy/go-zetasql/types"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// FileText returns the text of the file. The opened document is preferred to the file on disk, because it may be unsaved.
+func (p *Project) FileText(path string) (string, error) {
+	if sql := p.cache.Get(path); sql != nil {
+		return sql.RawText, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(b), nil
+}
+
+// GitRevisionText returns the text of the file at the git revision like HEAD or main.
+func GitRevisionText(ctx context.Context, path, revision string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "show", fmt.Sprintf("%s:./%s", revision, filepath.Base(path)))
+	cmd.Dir = filepath.Dir(path)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("failed to read %s at %s: %s", path, revision, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("failed to read %s at %s: %w", path, revision, err)
+	}
+	return string(out), nil
+}
+
+// DiffQueries compares the old and new SQL semantically, and returns the changes of the output columns,
+// the filters and the referenced tables as markdown. The changes of the formatting and the comments are ignored.
+func (p *Project) DiffQueries(ctx context.Context, oldSQL, newSQL string) ([]lsp.MarkedString, error) {
+	oldSummary := p.summarizeQuery(ctx, oldSQL)
+	newSummary := p.summarizeQuery(ctx, newSQL)
+
+	var sb strings.Builder
+	if oldSummary.analyzed && newSummary.analyzed {
+		writeDiffSection(&sb, "Output columns", diffColumns(oldSummary.columns, newSummary.columns))
+	}
+	writeDiffSection(&sb, "Filters", diffStrings(oldSummary.filters, newSummary.filters))
+	writeDiffSection(&sb, "Tables", diffStrings(oldSummary.tables, newSummary.tables))
+	if !oldSummary.analyzed || !newSummary.analyzed {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("The output columns are not compared because the query failed to be analyzed.\n")
+	} else if sb.Len() == 0 {
+		sb.WriteString("No semantic changes.\n")
+	}
+
+	return []lsp.MarkedString{
+		{
+			Language: "markdown",
+			Value:    sb.String(),
+		},
+	}, nil
+}
+
+// querySummary is the semantic parts of the SQL which are compared by DiffQueries.
+type querySummary struct {
+	// analyzed is false when some statements fail to be analyzed, and then the output columns can't be compared.
+	analyzed bool
+	// columns are the output columns of the last statement which outputs columns.
+	columns []outputColumn
+	// filters are the conditions of WHERE, HAVING, QUALIFY and ON like `WHERE a > 1`. The AND conditions are split.
+	filters []string
+	// tables are the referenced tables except the WITH queries.
+	tables []string
+}
+
+type outputColumn struct {
+	name string
+	typ  string
+}
+
+func (p *Project) summarizeQuery(ctx context.Context, sql string) querySummary {
+	parsedFile := p.analyzer.ParseFile(ctx, "", sql)
+
+	result := querySummary{analyzed: parsedFile.Node != nil}
+	for _, output := range parsedFile.RNode {
+		if output == nil {
+			result.analyzed = false
+		}
+	}
+	for i := len(parsedFile.RNode) - 1; i >= 0; i-- {
+		if parsedFile.RNode[i] == nil {
+			continue
+		}
+		stmt, ok := parsedFile.RNode[i].Statement().(outputColumnsStatement)
+		if !ok {
+			continue
+		}
+		result.columns = make([]outputColumn, 0, len(stmt.OutputColumnList()))
+		for _, c := range stmt.OutputColumnList() {
+			result.columns = append(result.columns, outputColumn{name: c.Name(), typ: c.Column().Type().TypeName(types.ProductExternal)})
+		}
+		break
+	}
+
+	if parsedFile.Node == nil {
+		return result
+	}
+	result.filters = summarizeFilters(parsedFile)
+	result.tables = summarizeTables(parsedFile)
+	return result
+}
+
+func summarizeFilters(parsedFile file.ParsedFile) []string {
+	result := make([]string, 0)
+	add := func(clause string, expr ast.ExpressionNode) {
+		if expr == nil {
+			return
+		}
+		conjuncts := []ast.ExpressionNode{expr}
+		if and, ok := expr.(*ast.AndExprNode); ok {
+			conjuncts = and.Conjuncts()
+		}
+		for _, c := range conjuncts {
+			if text, ok := parsedFile.ExtractSQL(c.ParseLocationRange()); ok {
+				result = append(result, clause+" "+normalizeExpression(text))
+			}
+		}
+	}
+	ast.Walk(parsedFile.Node, func(n ast.Node) error {
+		switch n := n.(type) {
+		case *ast.WhereClauseNode:
+			add("WHERE", n.Expression())
+		case *ast.HavingNode:
+			add("HAVING", n.Expression())
+		case *ast.QualifyNode:
+			add("QUALIFY", n.Expression())
+		case *ast.OnClauseNode:
+			add("ON", n.Expression())
+		}
+		return nil
+	})
+	return result
+}
+
+func summarizeTables(parsedFile file.ParsedFile) []string {
+	cteNames := make(map[string]struct{})
+	for _, entry := range file.ListAstNode[*ast.WithClauseEntryNode](parsedFile.Node) {
+		cteNames[strings.ToLower(entry.Alias().Name())] = struct{}{}
+	}
+
+	result := make([]string, 0)
+	for _, table := range file.ListAstNode[*ast.TablePathExpressionNode](parsedFile.Node) {
+		if table.PathExpr() == nil {
+			continue
+		}
+		name, ok := file.CreateTableNameFromTablePathExpressionNode(table)
+		if !ok {
+			continue
+		}
+		if _, ok := cteNames[strings.ToLower(name)]; ok {
+			continue
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+// normalizeExpression formats the expression, so that the changes of the spaces and the cases of the keywords are ignored.
+func normalizeExpression(text string) string {
+	if formatted, err := zetasql.FormatSQL("SELECT " + text); err == nil {
+		text = strings.TrimPrefix(strings.TrimSpace(formatted), "SELECT")
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func diffColumns(oldColumns, newColumns []outputColumn) []string {
+	find := func(columns []outputColumn, name string) (outputColumn, bool) {
+		for _, c := range columns {
+			if strings.EqualFold(c.name, name) {
+				return c, true
+			}
+		}
+		return outputColumn{}, false
+	}
+
+	result := make([]string, 0)
+	for _, c := range newColumns {
+		old, ok := find(oldColumns, c.name)
+		if !ok {
+			result = append(result, fmt.Sprintf("added `%s` %s", c.name, c.typ))
+		} else if old.typ != c.typ {
+			result = append(result, fmt.Sprintf("changed `%s` %s -> %s", c.name, old.typ, c.typ))
+		}
+	}
+	for _, c := range oldColumns {
+		if _, ok := find(newColumns, c.name); !ok {
+			result = append(result, fmt.Sprintf("removed `%s` %s", c.name, c.typ))
+		}
+	}
+	return result
+}
+
+// diffStrings compares the lists as the sets. The order of the result follows the lists.
+func diffStrings(oldList, newList []string) []string {
+	contains := func(list []string, s string) bool {
+		for _, l := range list {
+			if strings.EqualFold(l, s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	result := make([]string, 0)
+	for _, s := range newList {
+		if !contains(oldList, s) && !contains(result, "added `"+s+"`") {
+			result = append(result, "added `"+s+"`")
+		}
+	}
+	for _, s := range oldList {
+		if !contains(newList, s) && !contains(result, "removed `"+s+"`") {
+			result = append(result, "removed `"+s+"`")
+		}
+	}
+	return result
+}
+
+func writeDiffSection(sb *strings.Builder, title string, changes []string) {
+	if len(changes) == 0 {
+		return
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(sb, "## %s\n\n", title)
+	for _, c := range changes {
+		fmt.Fprintf(sb, "- %s\n", c)
+	}
+}
@@ -0,0 +1,90 @@
+package source_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_DiffQueries(t *testing.T) {
+	tests := map[string]struct {
+		oldSQL string
+		newSQL string
+
+		expectMarkedStrings []lsp.MarkedString
+	}{
+		"only formatting": {
+			oldSQL: "SELECT id FROM `project.dataset.table` WHERE id > 0 AND name = 'a'",
+			newSQL: "select id\nfrom `project.dataset.table`\nwhere name = 'a'\n  and id>0",
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value:    "No semantic changes.\n",
+				},
+			},
+		},
+		"columns and filters": {
+			oldSQL: "SELECT id FROM `project.dataset.table` WHERE id > 0 AND name = 'a'",
+			newSQL: "SELECT id, name FROM `project.dataset.table` WHERE name = 'a'",
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value:    "## Output columns\n\n- added `name` STRING\n\n## Filters\n\n- removed `WHERE id > 0`\n",
+				},
+			},
+		},
+		"tables": {
+			oldSQL: "WITH t AS (SELECT * FROM `project.dataset.table`)\nSELECT id FROM t",
+			newSQL: "WITH t AS (SELECT * FROM `project.dataset.table2`)\nSELECT id FROM t",
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value:    "## Tables\n\n- added `project.dataset.table2`\n- removed `project.dataset.table`\n",
+				},
+			},
+		},
+		"failed to analyze": {
+			oldSQL: "SELECT id FROM `project.dataset.table`",
+			newSQL: "SELECT id FROM `project.dataset.unknown`",
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value:    "## Tables\n\n- added `project.dataset.unknown`\n- removed `project.dataset.table`\n\nThe output columns are not compared because the query failed to be analyzed.\n",
+				},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetDefaultProject().Return("project").MinTimes(0)
+			schema := bq.Schema{
+				{Name: "id", Type: bq.IntegerFieldType},
+				{Name: "name", Type: bq.StringFieldType},
+			}
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{Schema: schema}, nil).MinTimes(0)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table2").Return(&bq.TableMetadata{Schema: schema}, nil).MinTimes(0)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "unknown").Return(nil, errors.New("not found")).MinTimes(0)
+			bqClient.EXPECT().ListTables(gomock.Any(), "project", "dataset").Return(nil, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			got, err := p.DiffQueries(context.Background(), tt.oldSQL, tt.newSQL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectMarkedStrings, got); diff != "" {
+				t.Errorf("project.DiffQueries result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"slices"
 	"strings"
 
 	"cloud.google.com/go/bigquery"
@@ -21,20 +22,60 @@ import (
 func (p *Project) TermDocument(uri string, position lsp.Position) ([]lsp.MarkedString, error) {
 	ctx := context.Background()
 	sql := p.cache.Get(uri)
-	parsedFile := p.analyzer.ParseFile(uri, sql.RawText)
+	parsedFile := p.parseFile(uri, sql)
+	// the SQL of EXECUTE IMMEDIATE is hovered as the file.
+	if embedded, pos, ok := parsedFile.FindEmbeddedFile(position); ok {
+		parsedFile, position = embedded, pos
+	}
 
 	termOffset := parsedFile.TermOffset(position)
+	if result, ok := p.termDocumentForWithClauseEntry(termOffset, parsedFile); ok {
+		return result, nil
+	}
+
+	if result, ok := p.termDocumentForCall(ctx, termOffset, parsedFile); ok {
+		return result, nil
+	}
+
+	if result, ok := p.termDocumentForVariable(parsedFile, position); ok {
+		return result, nil
+	}
+
+	if result, ok := p.termDocumentForSelectKeyword(termOffset, parsedFile); ok {
+		return result, nil
+	}
+
+	if result, ok := p.termDocumentForJoinKeyword(termOffset, parsedFile); ok {
+		return result, nil
+	}
+
+	if result, ok := p.termDocumentForOrdinal(termOffset, parsedFile); ok {
+		return result, nil
+	}
+
+	if result, ok := p.termDocumentForConstant(termOffset, parsedFile); ok {
+		return result, nil
+	}
+
+	if result, ok := p.termDocumentForTableAlias(ctx, termOffset, parsedFile); ok {
+		return result, nil
+	}
+
 	targetNode, ok := file.SearchAstNode[*ast.PathExpressionNode](parsedFile.Node, termOffset)
 	if !ok {
 		p.logger.Debug("not found target node")
 		return nil, nil
 	}
 
+	if result, ok := p.termDocumentForRoutineTable(ctx, targetNode); ok {
+		return result, nil
+	}
+
 	output, ok := parsedFile.FindTargetAnalyzeOutput(termOffset)
 	if !ok {
 		// If not found analyze output, lookup table metadata from ast node.
 		if targetNode, ok := file.LookupNode[*ast.TablePathExpressionNode](targetNode); ok {
-			result, ok := p.termDocumentFromAstNode(ctx, targetNode)
+			result, ok := p.termDocumentFromAstNode(ctx, targetNode, parsedFile)
 			if ok {
 				return result, nil
 			}
@@ -51,9 +92,33 @@ func (p *Project) TermDocument(uri string, position lsp.Position) ([]lsp.MarkedS
 		}
 	}
 
+	if tvfNode, ok := file.LookupNode[*ast.TVFNode](targetNode); ok {
+		name := createNameFromPathExpressionNode(tvfNode.Name())
+		routine, err := p.analyzer.GetRoutineMetadataFromPath(ctx, name)
+		if err == nil {
+			return buildRoutineMarkedString(name, routine), nil
+		}
+	}
+
 	if node, ok := file.SearchResolvedAstNode[*rast.FunctionCallNode](output, termOffset); ok {
 		builtinFunction, ok := function.FindBuiltInFunction(node.Function().Name())
 		if !ok {
+			if decl, ok := parsedFile.FindTempFunctionDeclaration(node.Function().Name()); ok {
+				if sql, ok := parsedFile.ExtractSQL(decl.ParseLocationRange()); ok {
+					return []lsp.MarkedString{
+						{
+							Language: "sql",
+							Value:    sql,
+						},
+					}, nil
+				}
+			}
+
+			routine, err := p.analyzer.GetRoutineMetadataFromPath(ctx, node.Function().Name())
+			if err == nil {
+				return buildRoutineMarkedString(node.Function().Name(), routine), nil
+			}
+
 			sigs := make([]string, 0, len(node.Function().Signatures()))
 			for _, sig := range node.Function().Signatures() {
 				sigs = append(sigs, sig.DebugString(node.Function().SQLName(), true))
@@ -77,10 +142,21 @@ func (p *Project) TermDocument(uri string, position lsp.Position) ([]lsp.MarkedS
 				Value:    sql,
 			})
 		}
+		if folded, ok := constantMarkedString(parsedFile, node); ok {
+			result = append(folded, result...)
+		}
 		return result, nil
 	}
 
 	if node, ok := file.SearchResolvedAstNode[*rast.GetStructFieldNode](output, termOffset); ok {
+		if field, ok := p.lookupStructFieldSchema(ctx, output, node); ok {
+			return []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value:    createBigQueryFieldYamlString(field, 0),
+				},
+			}, nil
+		}
 		return []lsp.MarkedString{
 			{
 				Language: "markdown",
@@ -89,6 +165,15 @@ func (p *Project) TermDocument(uri string, position lsp.Position) ([]lsp.MarkedS
 		}, nil
 	}
 
+	if node, ok := file.SearchResolvedAstNode[*rast.GetJsonFieldNode](output, termOffset); ok && !containsOffset(node.Expr(), termOffset) {
+		return []lsp.MarkedString{
+			{
+				Language: "yaml",
+				Value:    fmt.Sprintf("- name: %s\n  type: JSON\n", jsonFieldPath(node)),
+			},
+		}, nil
+	}
+
 	if term, ok := file.SearchResolvedAstNode[*rast.ColumnRefNode](output, termOffset); ok {
 		column := term.Column()
 		if column == nil {
@@ -116,6 +201,16 @@ func (p *Project) TermDocument(uri string, position lsp.Position) ([]lsp.MarkedS
 				}, nil
 			}
 		}
+
+		// pseudo columns like _TABLE_SUFFIX are not included in the schema
+		if file.IsPseudoColumn(column.Name()) {
+			return []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value:    createColumnYamlString(column),
+				},
+			}, nil
+		}
 	}
 
 	if selectColumnNode, ok := file.LookupNode[*ast.SelectColumnNode](targetNode); ok {
@@ -149,7 +244,7 @@ func (p *Project) TermDocument(uri string, position lsp.Position) ([]lsp.MarkedS
 	return nil, nil
 }
 
-func (p *Project) termDocumentFromAstNode(ctx context.Context, targetNode *ast.TablePathExpressionNode) ([]lsp.MarkedString, bool) {
+func (p *Project) termDocumentFromAstNode(ctx context.Context, targetNode *ast.TablePathExpressionNode, parsedFile file.ParsedFile) ([]lsp.MarkedString, bool) {
 	name, ok := file.CreateTableNameFromTablePathExpressionNode(targetNode)
 	if !ok {
 		return nil, false
@@ -164,7 +259,10 @@ func (p *Project) termDocumentFromAstNode(ctx context.Context, targetNode *ast.T
 	if err != nil {
 		return nil, false
 	}
-	return result, true
+	result = p.appendRowAccessPolicies(ctx, targetTable, result)
+	result = p.appendMaterializedView(targetTable, result)
+	result = appendSnapshot(targetNode, parsedFile, result)
+	return p.appendHoverPreview(ctx, targetTable, result), true
 }
 
 func (p *Project) termDocumentForInputScan(ctx context.Context, termOffset int, targetNode *ast.TablePathExpressionNode, output *zetasql.AnalyzerOutput, parsedFile file.ParsedFile) ([]lsp.MarkedString, bool) {
@@ -192,51 +290,320 @@ func (p *Project) termDocumentForInputScan(ctx context.Context, termOffset int,
 			return nil, false
 		}
 		if len(result) > 0 {
-			return result, true
+			return appendSnapshot(targetNode, parsedFile, result), true
 		}
 	case *rast.WithRefScanNode:
-		withEntries := file.ListResolvedAstNode[*rast.WithEntryNode](output)
-		if len(withEntries) == 0 {
-			p.logger.Debug("not found with entries")
-			return nil, false
-		}
+		return p.withEntryMarkedString(output, name, parsedFile)
+	}
+	return nil, false
+}
 
-		result := []lsp.MarkedString{
-			{
-				Language: "yaml",
-				Value:    createColumnListYamlString(node.ColumnList()),
-			},
-		}
-		for _, entry := range withEntries {
-			if entry.WithQueryName() == name {
-				subQuery := entry.WithSubquery()
-				switch n := subQuery.(type) {
-				case *rast.ProjectScanNode:
-					sql, ok := parsedFile.ExtractSQL(n.ParseLocationRange())
-					if !ok {
-						break
-					}
-
-					sql = fmt.Sprintf("WITH %s AS (\n%s\n)", entry.WithQueryName(), sql)
+// termDocumentForWithClauseEntry shows the output schema of the WITH query when the term is its name.
+func (p *Project) termDocumentForWithClauseEntry(termOffset int, parsedFile file.ParsedFile) ([]lsp.MarkedString, bool) {
+	entry, ok := file.SearchAstNode[*ast.WithClauseEntryNode](parsedFile.Node, termOffset)
+	if !ok {
+		return nil, false
+	}
+
+	lRange := entry.Alias().ParseLocationRange()
+	if lRange == nil || termOffset < lRange.Start().ByteOffset() || lRange.End().ByteOffset() < termOffset {
+		return nil, false
+	}
+
+	output, ok := parsedFile.FindTargetAnalyzeOutput(termOffset)
+	if !ok {
+		p.logger.Debug("not found target analyze output")
+		return nil, false
+	}
+	return p.withEntryMarkedString(output, entry.Alias().Name(), parsedFile)
+}
+
+// withEntryMarkedString renders the resolved output columns of the WITH query and its SQL.
+func (p *Project) withEntryMarkedString(output *zetasql.AnalyzerOutput, name string, parsedFile file.ParsedFile) ([]lsp.MarkedString, bool) {
+	for _, withScan := range file.ListResolvedAstNode[*rast.WithScanNode](output) {
+		for _, entry := range withScan.WithEntryList() {
+			if entry.WithQueryName() != name {
+				continue
+			}
+
+			subQuery := entry.WithSubquery()
+			result := []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value:    createColumnListYamlString(subQuery.ColumnList()),
+				},
+			}
+			if n, ok := subQuery.(*rast.ProjectScanNode); ok {
+				if sql, ok := parsedFile.ExtractSQL(n.ParseLocationRange()); ok {
 					result = append(result, lsp.MarkedString{
 						Language: "sql",
-						Value:    sql,
+						Value:    fmt.Sprintf("WITH %s AS (\n%s\n)", name, sql),
 					})
 				}
 			}
+			return result, true
 		}
-		return result, true
 	}
+
+	p.logger.Debug("not found with entries")
 	return nil, false
 }
 
+// termDocumentForTableAlias shows the table metadata when the term is a table alias,
+// e.g. `t` in `FROM project.dataset.table t` or in `t.column`.
+func (p *Project) termDocumentForTableAlias(ctx context.Context, termOffset int, parsedFile file.ParsedFile) ([]lsp.MarkedString, bool) {
+	if aliasNode, ok := file.SearchAstNode[*ast.AliasNode](parsedFile.Node, termOffset); ok {
+		tablePathNode, ok := aliasNode.Parent().(*ast.TablePathExpressionNode)
+		if !ok {
+			return nil, false
+		}
+		return p.termDocumentFromAstNode(ctx, tablePathNode, parsedFile)
+	}
+
+	pathNode, ok := file.SearchAstNode[*ast.PathExpressionNode](parsedFile.Node, termOffset)
+	if !ok || len(pathNode.Names()) < 2 {
+		return nil, false
+	}
+	if _, ok := file.LookupNode[*ast.TablePathExpressionNode](pathNode); ok {
+		return nil, false
+	}
+
+	// only the first name of the path can be the alias
+	aliasName := pathNode.Names()[0]
+	lRange := aliasName.ParseLocationRange()
+	if lRange == nil || termOffset < lRange.Start().ByteOffset() || lRange.End().ByteOffset() < termOffset {
+		return nil, false
+	}
+
+	output, ok := parsedFile.FindTargetAnalyzeOutput(termOffset)
+	if !ok {
+		return nil, false
+	}
+	targetScanNode, ok := getMostNarrowScanNode(termOffset, output.Statement())
+	if !ok {
+		return nil, false
+	}
+	scanNode, ok := p.findInputScan(aliasName.Name(), targetScanNode)
+	if !ok {
+		return nil, false
+	}
+	tableScanNode, ok := scanNode.(*rast.TableScanNode)
+	if !ok || tableScanNode.Alias() != aliasName.Name() {
+		return nil, false
+	}
+
+	result, err := p.createTableMarkedString(ctx, tableScanNode)
+	if err != nil || len(result) == 0 {
+		return nil, false
+	}
+	return result, true
+}
+
 func (p *Project) createTableMarkedString(ctx context.Context, node *rast.TableScanNode) ([]lsp.MarkedString, error) {
 	targetTable, err := p.analyzer.GetTableMetadataFromPath(ctx, node.Table().Name())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get table metadata: %w", err)
 	}
 
-	return buildBigQueryTableMetadataMarkedString(targetTable)
+	result, err := buildBigQueryTableMetadataMarkedString(targetTable)
+	if err != nil {
+		return nil, err
+	}
+	result = p.appendRowAccessPolicies(ctx, targetTable, result)
+	result = p.appendMaterializedView(targetTable, result)
+	return p.appendHoverPreview(ctx, targetTable, result), nil
+}
+
+// appendRowAccessPolicies appends the row access policies of the table to the table info.
+// Because the rows of the query result are filtered by them, it is useful to know their existence.
+func (p *Project) appendRowAccessPolicies(ctx context.Context, metadata *bigquery.TableMetadata, result []lsp.MarkedString) []lsp.MarkedString {
+	if metadata.Type != bigquery.RegularTable || len(result) == 0 {
+		return result
+	}
+	projectID, datasetID, tableID, ok := extractTableIDsFromMedatada(metadata)
+	if !ok {
+		return result
+	}
+
+	policies, err := p.bqClient.ListRowAccessPolicies(ctx, projectID, datasetID, tableID)
+	if err != nil {
+		p.logger.Debugf("failed to list row access policies: %v", err)
+		return result
+	}
+	if len(policies) == 0 {
+		return result
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(result[0].Value)
+	sb.WriteString("\n### Row access policies\n\n")
+	for _, policy := range policies {
+		sb.WriteString(fmt.Sprintf("* %s: `%s`\n", policy.ID, policy.FilterPredicate))
+	}
+	result[0].Value = sb.String()
+	return result
+}
+
+// appendMaterializedView appends the refresh info and the base tables of the materialized view to the table info.
+// The result of the materialized view can be older than the base tables, which depends on them.
+func (p *Project) appendMaterializedView(metadata *bigquery.TableMetadata, result []lsp.MarkedString) []lsp.MarkedString {
+	mv := metadata.MaterializedView
+	if mv == nil || len(result) == 0 {
+		return result
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(result[0].Value)
+	sb.WriteString("\n### Materialized view\n\n")
+	sb.WriteString(fmt.Sprintf("* Enable refresh: %t\n", mv.EnableRefresh))
+	if mv.EnableRefresh && mv.RefreshInterval > 0 {
+		sb.WriteString(fmt.Sprintf("* Refresh interval: %s\n", mv.RefreshInterval))
+	}
+	if mv.MaxStaleness != nil {
+		sb.WriteString(fmt.Sprintf("* Max staleness: %s\n", mv.MaxStaleness.ToDuration()))
+	}
+	if !mv.LastRefreshTime.IsZero() {
+		sb.WriteString(fmt.Sprintf("* Last refreshed: %s\n", mv.LastRefreshTime.Format("2006-01-02 15:04:05")))
+	}
+
+	if mv.Query != "" {
+		parsedFile := p.analyzer.ParseFileWithoutAnalysis("", mv.Query)
+		names := file.ReferencedTableNames(parsedFile.Node)
+		slices.Sort(names)
+		names = slices.Compact(names)
+		if len(names) > 0 {
+			sb.WriteString("* Base tables:\n")
+			for _, name := range names {
+				sb.WriteString(fmt.Sprintf("  * %s\n", name))
+			}
+		}
+	}
+	result[0].Value = sb.String()
+	return result
+}
+
+// appendSnapshot appends the timestamp of `FOR SYSTEM_TIME AS OF` to the table info.
+func appendSnapshot(targetNode *ast.TablePathExpressionNode, parsedFile file.ParsedFile, result []lsp.MarkedString) []lsp.MarkedString {
+	snapshot, ok := parsedFile.TableSnapshot(targetNode)
+	if !ok || len(result) == 0 {
+		return result
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(result[0].Value)
+	sb.WriteString("\n### Snapshot\n\n")
+	sb.WriteString(fmt.Sprintf("* FOR SYSTEM_TIME AS OF: `%s`\n", snapshot.Expression))
+	if !snapshot.Timestamp.IsZero() {
+		sb.WriteString(fmt.Sprintf("* Timestamp: %s\n", snapshot.Timestamp.Format("2006-01-02 15:04:05 MST")))
+	}
+	result[0].Value = sb.String()
+	return result
+}
+
+// appendHoverPreview appends the first rows of the table when hover_preview_rows is set.
+func (p *Project) appendHoverPreview(ctx context.Context, metadata *bigquery.TableMetadata, result []lsp.MarkedString) []lsp.MarkedString {
+	if p.hoverPreviewRows <= 0 || metadata.Type != bigquery.RegularTable {
+		return result
+	}
+
+	preview, err := p.previewTableMarkdown(ctx, metadata, p.hoverPreviewRows)
+	if err != nil {
+		p.logger.Debugf("failed to preview table: %v", err)
+		return result
+	}
+	return append(result, lsp.MarkedString{
+		Language: "markdown",
+		Value:    "### Preview\n\n" + preview,
+	})
+}
+
+// jsonFieldPath returns the path of the JSON field access like `payload.user.name`.
+func jsonFieldPath(node *rast.GetJsonFieldNode) string {
+	names := []string{node.FieldName()}
+	expr := node.Expr()
+	for {
+		switch e := expr.(type) {
+		case *rast.GetJsonFieldNode:
+			names = append([]string{e.FieldName()}, names...)
+			expr = e.Expr()
+			continue
+		case *rast.ColumnRefNode:
+			names = append([]string{e.Column().Name()}, names...)
+		}
+		return strings.Join(names, ".")
+	}
+}
+
+func containsOffset(node rast.Node, offset int) bool {
+	loc := node.ParseLocationRange()
+	return loc != nil && loc.Start().ByteOffset() <= offset && offset <= loc.End().ByteOffset()
+}
+
+// lookupStructFieldSchema finds the RECORD schema of the field accessed by node.
+// The field path is resolved back to the table column, following UNNEST when the struct is an array element.
+func (p *Project) lookupStructFieldSchema(ctx context.Context, output *zetasql.AnalyzerOutput, node *rast.GetStructFieldNode) (*bigquery.FieldSchema, bool) {
+	names := make([]string, 0)
+	var expr rast.ExprNode = node
+	for {
+		switch n := expr.(type) {
+		case *rast.GetStructFieldNode:
+			typ := n.Expr().Type()
+			if !typ.IsStruct() {
+				return nil, false
+			}
+			fields := typ.AsStruct().Fields()
+			if n.FieldIdx() < 0 || len(fields) <= n.FieldIdx() {
+				return nil, false
+			}
+			names = append([]string{fields[n.FieldIdx()].Name()}, names...)
+			expr = n.Expr()
+		case *rast.ColumnRefNode:
+			column := n.Column()
+			if column == nil {
+				return nil, false
+			}
+
+			if arrayExpr, ok := findUnnestedArrayExpr(output, column); ok {
+				expr = arrayExpr
+				continue
+			}
+
+			tableMetadata, err := p.analyzer.GetTableMetadataFromPath(ctx, column.TableName())
+			if err != nil {
+				return nil, false
+			}
+			return lookupFieldSchema(tableMetadata.Schema, append([]string{column.Name()}, names...))
+		default:
+			return nil, false
+		}
+	}
+}
+
+// findUnnestedArrayExpr returns the array expression when column is the element column of UNNEST.
+func findUnnestedArrayExpr(output *zetasql.AnalyzerOutput, column *rast.Column) (rast.ExprNode, bool) {
+	for _, n := range file.ListResolvedAstNode[*rast.ArrayScanNode](output) {
+		if n.ElementColumn().ColumnID() == column.ColumnID() {
+			return n.ArrayExpr(), true
+		}
+	}
+	return nil, false
+}
+
+func lookupFieldSchema(schema bigquery.Schema, names []string) (*bigquery.FieldSchema, bool) {
+	if len(names) == 0 {
+		return nil, false
+	}
+
+	for _, f := range schema {
+		if f.Name != names[0] {
+			continue
+		}
+		if len(names) == 1 {
+			return f, true
+		}
+		return lookupFieldSchema(f.Schema, names[1:])
+	}
+	return nil, false
 }
 
 func (p *Project) getSelectColumnNodeToAnalyzedOutputCoumnNode(output *zetasql.AnalyzerOutput, column *ast.SelectColumnNode, termOffset int) (*rast.Column, error) {
@@ -395,6 +762,109 @@ func getSelectColumnName(targetNode *ast.SelectColumnNode) (string, bool) {
 	return strings.Join(names, "."), true
 }
 
+func createNameFromPathExpressionNode(node *ast.PathExpressionNode) string {
+	names := make([]string, len(node.Names()))
+	for i, n := range node.Names() {
+		names[i] = n.Name()
+	}
+	return strings.Join(names, ".")
+}
+
+// termDocumentForRoutineTable shows the persistent table function whose call is analyzed as the table of file.RoutineTableName.
+func (p *Project) termDocumentForRoutineTable(ctx context.Context, targetNode *ast.PathExpressionNode) ([]lsp.MarkedString, bool) {
+	tableNode, ok := file.LookupNode[*ast.TablePathExpressionNode](targetNode)
+	if !ok {
+		return nil, false
+	}
+	if tableNode.PathExpr() == nil {
+		return nil, false
+	}
+	routine, ok := file.RoutineOfTable(createNameFromPathExpressionNode(tableNode.PathExpr()))
+	if !ok {
+		return nil, false
+	}
+	metadata, err := p.analyzer.GetRoutineMetadataFromPath(ctx, routine)
+	if err != nil {
+		return nil, false
+	}
+	return buildRoutineMarkedString(routine, metadata), true
+}
+
+func buildRoutineMarkedString(name string, routine *bigquery.RoutineMetadata) []lsp.MarkedString {
+	args := make([]string, len(routine.Arguments))
+	for i, arg := range routine.Arguments {
+		typ := "ANY TYPE"
+		if arg.DataType != nil {
+			typ = standardSQLDataTypeString(arg.DataType)
+		}
+		args[i] = fmt.Sprintf("%s %s", arg.Name, typ)
+		// the arguments of the procedure can be OUT or INOUT
+		if arg.Mode == "OUT" || arg.Mode == "INOUT" {
+			args[i] = fmt.Sprintf("%s %s", arg.Mode, args[i])
+		}
+	}
+	signature := fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+	if routine.ReturnType != nil {
+		signature += " -> " + standardSQLDataTypeString(routine.ReturnType)
+	}
+	if routine.ReturnTableType != nil {
+		columns := make([]string, len(routine.ReturnTableType.Columns))
+		for i, c := range routine.ReturnTableType.Columns {
+			columns[i] = fmt.Sprintf("%s %s", c.Name, standardSQLDataTypeString(c.Type))
+		}
+		signature += fmt.Sprintf(" -> TABLE<%s>", strings.Join(columns, ", "))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s\n\n", name))
+	sb.WriteString(fmt.Sprintf("`%s`\n", signature))
+	if routine.Description != "" {
+		sb.WriteString(fmt.Sprintf("\n%s\n", routine.Description))
+	}
+
+	result := []lsp.MarkedString{
+		{
+			Language: "markdown",
+			Value:    sb.String(),
+		},
+	}
+	if routine.Body != "" {
+		language := "sql"
+		if routine.Language == "JAVASCRIPT" {
+			language = "javascript"
+		}
+		result = append(result, lsp.MarkedString{
+			Language: language,
+			Value:    routine.Body,
+		})
+	}
+	return result
+}
+
+func standardSQLDataTypeString(typ *bigquery.StandardSQLDataType) string {
+	if typ == nil {
+		return ""
+	}
+
+	switch typ.TypeKind {
+	case "ARRAY":
+		return fmt.Sprintf("ARRAY<%s>", standardSQLDataTypeString(typ.ArrayElementType))
+	case "STRUCT":
+		if typ.StructType == nil {
+			return "STRUCT<>"
+		}
+		fields := make([]string, len(typ.StructType.Fields))
+		for i, f := range typ.StructType.Fields {
+			fields[i] = fmt.Sprintf("%s %s", f.Name, standardSQLDataTypeString(f.Type))
+		}
+		return fmt.Sprintf("STRUCT<%s>", strings.Join(fields, ", "))
+	case "RANGE":
+		return fmt.Sprintf("RANGE<%s>", standardSQLDataTypeString(typ.RangeElementType))
+	default:
+		return typ.TypeKind
+	}
+}
+
 func createColumnListYamlString(columnLists []*rast.Column) string {
 	markdownBuilder := &strings.Builder{}
 	for _, column := range columnLists {
@@ -419,7 +889,7 @@ func createBigQueryFieldYamlString(field *bigquery.FieldSchema, depth int) strin
 	indent := strings.Repeat("  ", depth)
 	builder := &strings.Builder{}
 	builder.WriteString(fmt.Sprintf("%s- name: %s\n", indent, field.Name))
-	builder.WriteString(fmt.Sprintf("%s  type: %s\n", indent, field.Type))
+	builder.WriteString(fmt.Sprintf("%s  type: %s\n", indent, file.FieldTypeString(field)))
 
 	if field.Repeated {
 		builder.WriteString(fmt.Sprintf("%s  mode: REPEATED\n", indent))
@@ -431,6 +901,13 @@ func createBigQueryFieldYamlString(field *bigquery.FieldSchema, depth int) strin
 		builder.WriteString(fmt.Sprintf("%s  description: %s\n", indent, field.Description))
 	}
 
+	if field.PolicyTags != nil && len(field.PolicyTags.Names) > 0 {
+		builder.WriteString(fmt.Sprintf("%s  policy_tags:\n", indent))
+		for _, name := range field.PolicyTags.Names {
+			builder.WriteString(fmt.Sprintf("%s    - %s\n", indent, name))
+		}
+	}
+
 	if len(field.Schema) > 0 {
 		builder.WriteString(createBigQuerySchemaYamlString(field.Schema, depth+1))
 	}
@@ -449,9 +926,12 @@ func buildBigQueryTableMetadataMarkedString(metadata *bigquery.TableMetadata) ([
 
 	sb.WriteString("\n### Table info\n\n")
 
-	sb.WriteString(fmt.Sprintf("* Created: %s\n", metadata.CreationTime.Format("2006-01-02 15:04:05")))
-	// If cache the metadata, we should delete last modified time because it is confusing.
-	sb.WriteString(fmt.Sprintf("* Last modified: %s\n", metadata.LastModifiedTime.Format("2006-01-02 15:04:05")))
+	// built-in views like INFORMATION_SCHEMA don't have the creation time.
+	if !metadata.CreationTime.IsZero() {
+		sb.WriteString(fmt.Sprintf("* Created: %s\n", metadata.CreationTime.Format("2006-01-02 15:04:05")))
+		// If cache the metadata, we should delete last modified time because it is confusing.
+		sb.WriteString(fmt.Sprintf("* Last modified: %s\n", metadata.LastModifiedTime.Format("2006-01-02 15:04:05")))
+	}
 
 	if !metadata.ExpirationTime.IsZero() {
 		sb.WriteString(fmt.Sprintf("* Expired: %s\n", metadata.ExpirationTime.Format("2006-01-02 15:04:05")))
@@ -473,6 +953,14 @@ func buildBigQueryTableMetadataMarkedString(metadata *bigquery.TableMetadata) ([
 		}
 	}
 
+	writeBaseTableInfo(&sb, metadata)
+
+	writePartitionInfo(&sb, metadata)
+
+	if metadata.ExternalDataConfig != nil {
+		writeExternalDataConfig(&sb, metadata.ExternalDataConfig)
+	}
+
 	sb.WriteString("\n### Storage info\n\n")
 
 	p := message.NewPrinter(language.English)
@@ -481,10 +969,10 @@ func buildBigQueryTableMetadataMarkedString(metadata *bigquery.TableMetadata) ([
 
 	projectID, datasetID, tableID, ok := extractTableIDsFromMedatada(metadata)
 	if ok {
-		sb.WriteString(fmt.Sprintf("\n[Docs](https://console.cloud.google.com/bigquery?project=%[1]s&ws=!1m5!1m4!4m3!1s%[1]s!2s%[2]s!3s%[3]s)\n", projectID, datasetID, tableID))
+		sb.WriteString(fmt.Sprintf("\n[Docs](%s)\n", tableConsoleURL(projectID, datasetID, tableID)))
 	}
 
-	return []lsp.MarkedString{
+	result := []lsp.MarkedString{
 		{
 			Language: "markdown",
 			Value:    sb.String(),
@@ -493,7 +981,103 @@ func buildBigQueryTableMetadataMarkedString(metadata *bigquery.TableMetadata) ([
 			Language: "yaml",
 			Value:    createBigQuerySchemaYamlString(metadata.Schema, 0),
 		},
-	}, nil
+	}
+
+	if query, ok := viewQuery(metadata); ok {
+		result = append(result, lsp.MarkedString{
+			Language: "sql",
+			Value:    query,
+		})
+	}
+
+	return result, nil
+}
+
+// writeBaseTableInfo writes the base table of the table snapshot or the table clone.
+func writeBaseTableInfo(sb *strings.Builder, metadata *bigquery.TableMetadata) {
+	if sd := metadata.SnapshotDefinition; sd != nil {
+		if base := sd.BaseTableReference; base != nil {
+			sb.WriteString(fmt.Sprintf("* Snapshot of: %s.%s.%s\n", base.ProjectID, base.DatasetID, base.TableID))
+		}
+		if !sd.SnapshotTime.IsZero() {
+			sb.WriteString(fmt.Sprintf("* Snapshot time: %s\n", sd.SnapshotTime.Format("2006-01-02 15:04:05")))
+		}
+		sb.WriteString("* Read-only: DML can't modify the table snapshot\n")
+	}
+	if cd := metadata.CloneDefinition; cd != nil {
+		if base := cd.BaseTableReference; base != nil {
+			sb.WriteString(fmt.Sprintf("* Clone of: %s.%s.%s\n", base.ProjectID, base.DatasetID, base.TableID))
+		}
+		if !cd.CloneTime.IsZero() {
+			sb.WriteString(fmt.Sprintf("* Clone time: %s\n", cd.CloneTime.Format("2006-01-02 15:04:05")))
+		}
+	}
+}
+
+func writePartitionInfo(sb *strings.Builder, metadata *bigquery.TableMetadata) {
+	if tp := metadata.TimePartitioning; tp != nil {
+		field := tp.Field
+		if field == "" {
+			field = "_PARTITIONTIME"
+		}
+		sb.WriteString(fmt.Sprintf("* Partitioned by: %s (%s)\n", field, tp.Type))
+		if tp.Expiration > 0 {
+			sb.WriteString(fmt.Sprintf("* Partition expiration: %s\n", tp.Expiration))
+		}
+	}
+
+	if rp := metadata.RangePartitioning; rp != nil {
+		sb.WriteString(fmt.Sprintf("* Partitioned by: %s", rp.Field))
+		if r := rp.Range; r != nil {
+			sb.WriteString(fmt.Sprintf(" (RANGE %d to %d, interval %d)", r.Start, r.End, r.Interval))
+		}
+		sb.WriteString("\n")
+	}
+
+	if metadata.RequirePartitionFilter {
+		sb.WriteString("* Require partition filter: true\n")
+	}
+
+	if metadata.Clustering != nil && len(metadata.Clustering.Fields) > 0 {
+		sb.WriteString(fmt.Sprintf("* Clustered by: %s\n", strings.Join(metadata.Clustering.Fields, ", ")))
+	}
+}
+
+func writeExternalDataConfig(sb *strings.Builder, config *bigquery.ExternalDataConfig) {
+	sb.WriteString("\n### External data configuration\n\n")
+	sb.WriteString(fmt.Sprintf("* Source format: %s\n", config.SourceFormat))
+
+	if len(config.SourceURIs) > 0 {
+		sb.WriteString("* Source URIs:\n")
+		for _, uri := range config.SourceURIs {
+			sb.WriteString(fmt.Sprintf("  * %s\n", uri))
+		}
+	}
+
+	if config.ConnectionID != "" {
+		sb.WriteString(fmt.Sprintf("* Connection: %s\n", config.ConnectionID))
+	}
+
+	if hp := config.HivePartitioningOptions; hp != nil {
+		sb.WriteString(fmt.Sprintf("* Hive partitioning: %s\n", hp.Mode))
+		if hp.SourceURIPrefix != "" {
+			sb.WriteString(fmt.Sprintf("  * Source URI prefix: %s\n", hp.SourceURIPrefix))
+		}
+		if hp.RequirePartitionFilter {
+			sb.WriteString("  * Require partition filter: true\n")
+		}
+	}
+}
+
+// viewQuery returns the SQL which defines the view or the materialized view.
+func viewQuery(metadata *bigquery.TableMetadata) (string, bool) {
+	if metadata.ViewQuery != "" {
+		return metadata.ViewQuery, true
+	}
+	if metadata.MaterializedView != nil && metadata.MaterializedView.Query != "" {
+		return metadata.MaterializedView.Query, true
+	}
+	return "", false
 }
 
 func bytesConvert(bytes int64) string {
@@ -9,9 +9,11 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/kitagry/bqls/langserver/internal/bigquery"
 	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
 	"github.com/kitagry/bqls/langserver/internal/lsp"
 	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
 	"github.com/kitagry/bqls/langserver/internal/source/helper"
 	"github.com/sirupsen/logrus"
 )
@@ -19,8 +21,9 @@ import (
 func TestProject_TermDocument(t *testing.T) {
 	tests := map[string]struct {
 		// prepare
-		files           map[string]string
-		bqTableMetadata *bq.TableMetadata
+		files               map[string]string
+		bqTableMetadata     *bq.TableMetadata
+		bqRowAccessPolicies []bigquery.RowAccessPolicy
 
 		// output
 		expectMarkedStrings []lsp.MarkedString
@@ -65,6 +68,230 @@ table description
 					Value: `- name: name
   type: STRING
   description: name description
+`,
+				},
+			},
+		},
+		"hover table with policy tags and row access policies": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM |`project.dataset.table`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:           "project:dataset.table",
+				Type:             bq.RegularTable,
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				Schema: bq.Schema{
+					{
+						Name: "email",
+						Type: bq.StringFieldType,
+						PolicyTags: &bq.PolicyTagList{
+							Names: []string{"projects/project/locations/us/taxonomies/1/policyTags/2"},
+						},
+					},
+				},
+			},
+			bqRowAccessPolicies: []bigquery.RowAccessPolicy{
+				{ID: "us_only", FilterPredicate: "region = 'US'"},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value: `## project:dataset.table
+
+### Table info
+
+* Created: 2023-06-17 00:00:00
+* Last modified: 2023-06-17 00:00:00
+
+### Storage info
+
+* Number of rows: 0
+* Total logical bytes: 0 bytes
+
+[Docs](https://console.cloud.google.com/bigquery?project=project&ws=!1m5!1m4!4m3!1sproject!2sdataset!3stable)
+
+### Row access policies
+
+* us_only: ` + "`region = 'US'`" + `
+`,
+				},
+				{
+					Language: "yaml",
+					Value: `- name: email
+  type: STRING
+  policy_tags:
+    - projects/project/locations/us/taxonomies/1/policyTags/2
+`,
+				},
+			},
+		},
+		"hover materialized view": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM |`project.dataset.mv`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:           "project.dataset.mv",
+				Type:             bq.MaterializedView,
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				MaterializedView: &bq.MaterializedViewDefinition{
+					EnableRefresh:   true,
+					RefreshInterval: 30 * time.Minute,
+					LastRefreshTime: time.Date(2023, 6, 17, 1, 0, 0, 0, time.UTC),
+					Query:           "SELECT name, COUNT(*) AS cnt FROM `project.dataset.source` GROUP BY name",
+				},
+				Schema: bq.Schema{
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value: `## project.dataset.mv
+
+### Table info
+
+* Created: 2023-06-17 00:00:00
+* Last modified: 2023-06-17 00:00:00
+
+### Storage info
+
+* Number of rows: 0
+* Total logical bytes: 0 bytes
+
+### Materialized view
+
+* Enable refresh: true
+* Refresh interval: 30m0s
+* Last refreshed: 2023-06-17 01:00:00
+* Base tables:
+  * project.dataset.source
+`,
+				},
+				{
+					Language: "yaml",
+					Value: `- name: name
+  type: STRING
+`,
+				},
+				{
+					Language: "sql",
+					Value:    "SELECT name, COUNT(*) AS cnt FROM `project.dataset.source` GROUP BY name",
+				},
+			},
+		},
+		"hover table snapshot": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM |`project.dataset.snapshot`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:           "project.dataset.snapshot",
+				Type:             bq.Snapshot,
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				SnapshotDefinition: &bq.SnapshotDefinition{
+					BaseTableReference: &bq.Table{ProjectID: "project", DatasetID: "dataset", TableID: "table"},
+					SnapshotTime:       time.Date(2023, 6, 16, 0, 0, 0, 0, time.UTC),
+				},
+				Schema: bq.Schema{
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value: `## project.dataset.snapshot
+
+### Table info
+
+* Created: 2023-06-17 00:00:00
+* Last modified: 2023-06-17 00:00:00
+* Snapshot of: project.dataset.table
+* Snapshot time: 2023-06-16 00:00:00
+* Read-only: DML can't modify the table snapshot
+
+### Storage info
+
+* Number of rows: 0
+* Total logical bytes: 0 bytes
+`,
+				},
+				{
+					Language: "yaml",
+					Value: `- name: name
+  type: STRING
+`,
+				},
+			},
+		},
+		"hover JSON field": {
+			files: map[string]string{
+				"file1.sql": "SELECT payload.user.na|me FROM `project.dataset.table`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "payload",
+						Type: bq.JSONFieldType,
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value: `- name: payload.user.name
+  type: JSON
+`,
+				},
+			},
+		},
+		"hover table with FOR SYSTEM_TIME AS OF": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM |`project.dataset.table` FOR SYSTEM_TIME AS OF TIMESTAMP '2023-06-18 00:00:00+09'",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:           "project.dataset.table",
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				Schema: bq.Schema{
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value: `## project.dataset.table
+
+### Table info
+
+* Created: 2023-06-17 00:00:00
+* Last modified: 2023-06-17 00:00:00
+
+### Storage info
+
+* Number of rows: 0
+* Total logical bytes: 0 bytes
+
+### Snapshot
+
+* FOR SYSTEM_TIME AS OF: ` + "`TIMESTAMP '2023-06-18 00:00:00+09'`" + `
+* Timestamp: 2023-06-17 15:00:00 UTC
+`,
+				},
+				{
+					Language: "yaml",
+					Value: `- name: name
+  type: STRING
 `,
 				},
 			},
@@ -137,13 +364,193 @@ table description
 					Value: `- name: name
   type: STRING
   description: name description
+`,
+				},
+			},
+		},
+		"hover RANGE and BIGNUMERIC columns": {
+			files: map[string]string{
+				"file1.sql": "SELECT amount, |period FROM `project.dataset.table`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:           "project.dataset.table",
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				Schema: bq.Schema{
+					{
+						Name:      "amount",
+						Type:      bq.BigNumericFieldType,
+						Precision: 76,
+						Scale:     38,
+					},
+					{
+						Name:             "period",
+						Type:             bq.RangeFieldType,
+						RangeElementType: &bq.RangeElementType{Type: bq.DateFieldType},
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value: `- name: period
+  type: RANGE<DATE>
+`,
+				},
+			},
+		},
+		"hover column in EXECUTE IMMEDIATE": {
+			files: map[string]string{
+				"file1.sql": "EXECUTE IMMEDIATE 'SELECT id, |name FROM `project.dataset.table`'",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:           "project.dataset.table",
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name:        "name",
+						Type:        bq.StringFieldType,
+						Description: "name description",
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value: `- name: name
+  type: STRING
+  description: name description
+`,
+				},
+			},
+		},
+		"hover column in Python": {
+			files: map[string]string{
+				"query.py": "rows = client.query(\"SELECT id, |name FROM `project.dataset.table`\").result()",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:           "project.dataset.table",
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name:        "name",
+						Type:        bq.StringFieldType,
+						Description: "name description",
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value: `- name: name
+  type: STRING
+  description: name description
 `,
 				},
 			},
 		},
 		"hover column with alias": {
 			files: map[string]string{
-				"file1.sql": "SELECT |name AS alias_name FROM `project.dataset.table`",
+				"file1.sql": "SELECT |name AS alias_name FROM `project.dataset.table`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:           "project.dataset.table",
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				Schema: bq.Schema{
+					{
+						Name:        "name",
+						Type:        bq.StringFieldType,
+						Description: "name description",
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value: `- name: name
+  type: STRING
+  description: name description
+`,
+				},
+			},
+		},
+		"hover column with table alias": {
+			files: map[string]string{
+				"file1.sql": "SELECT a.|name FROM `project.dataset.table` AS a",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID: "project.dataset.table",
+				Schema: bq.Schema{
+					{
+						Name:        "name",
+						Type:        bq.StringFieldType,
+						Description: "name description",
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value: `- name: name
+  type: STRING
+  description: name description
+`,
+				},
+			},
+		},
+		"hover table alias": {
+			files: map[string]string{
+				"file1.sql": "SELECT t.user_id FROM `project.dataset.table` |t",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:           "project.dataset.table",
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				Schema: bq.Schema{
+					{
+						Name: "user_id",
+						Type: bq.StringFieldType,
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value: `## project.dataset.table
+
+### Table info
+
+* Created: 2023-06-17 00:00:00
+* Last modified: 2023-06-17 00:00:00
+
+### Storage info
+
+* Number of rows: 0
+* Total logical bytes: 0 bytes
+`,
+				},
+				{
+					Language: "yaml",
+					Value: `- name: user_id
+  type: STRING
+`,
+				},
+			},
+		},
+		"hover table alias in column reference": {
+			files: map[string]string{
+				"file1.sql": "SELECT |t.user_id FROM `project.dataset.table` t",
 			},
 			bqTableMetadata: &bq.TableMetadata{
 				FullID:           "project.dataset.table",
@@ -151,65 +558,94 @@ table description
 				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
 				Schema: bq.Schema{
 					{
-						Name:        "name",
-						Type:        bq.StringFieldType,
-						Description: "name description",
+						Name: "user_id",
+						Type: bq.StringFieldType,
 					},
 				},
 			},
 			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value: `## project.dataset.table
+
+### Table info
+
+* Created: 2023-06-17 00:00:00
+* Last modified: 2023-06-17 00:00:00
+
+### Storage info
+
+* Number of rows: 0
+* Total logical bytes: 0 bytes
+`,
+				},
 				{
 					Language: "yaml",
-					Value: `- name: name
+					Value: `- name: user_id
   type: STRING
-  description: name description
 `,
 				},
 			},
 		},
-		"hover column with table alias": {
+		"hover column unnest record": {
 			files: map[string]string{
-				"file1.sql": "SELECT a.|name FROM `project.dataset.table` AS a",
+				"file1.sql": "SELECT param.|key FROM `project.dataset.table`, UNNEST(params) AS param",
 			},
 			bqTableMetadata: &bq.TableMetadata{
 				FullID: "project.dataset.table",
 				Schema: bq.Schema{
 					{
-						Name:        "name",
-						Type:        bq.StringFieldType,
-						Description: "name description",
+						Name:     "params",
+						Type:     bq.RecordFieldType,
+						Repeated: true,
+						Schema: bq.Schema{
+							{
+								Name:        "key",
+								Type:        bq.StringFieldType,
+								Required:    true,
+								Description: "key description",
+							},
+							{
+								Name: "value",
+								Type: bq.StringFieldType,
+							},
+						},
 					},
 				},
 			},
 			expectMarkedStrings: []lsp.MarkedString{
 				{
 					Language: "yaml",
-					Value: `- name: name
+					Value: `- name: key
   type: STRING
-  description: name description
+  mode: REQUIRED
+  description: key description
 `,
 				},
 			},
 		},
-		"hover column unnest record": {
+		"hover nested record field": {
 			files: map[string]string{
-				"file1.sql": "SELECT param.|key FROM `project.dataset.table`, UNNEST(params) AS param",
+				"file1.sql": "SELECT record.child.|grandchild FROM `project.dataset.table`",
 			},
 			bqTableMetadata: &bq.TableMetadata{
 				FullID: "project.dataset.table",
 				Schema: bq.Schema{
 					{
-						Name:     "params",
-						Type:     bq.RecordFieldType,
-						Repeated: true,
+						Name: "record",
+						Type: bq.RecordFieldType,
 						Schema: bq.Schema{
 							{
-								Name: "key",
-								Type: bq.StringFieldType,
-							},
-							{
-								Name: "value",
-								Type: bq.StringFieldType,
+								Name: "child",
+								Type: bq.RecordFieldType,
+								Schema: bq.Schema{
+									{
+										Name:        "grandchild",
+										Type:        bq.IntegerFieldType,
+										Repeated:    true,
+										Description: "grandchild description",
+									},
+								},
 							},
 						},
 					},
@@ -217,8 +653,12 @@ table description
 			},
 			expectMarkedStrings: []lsp.MarkedString{
 				{
-					Language: "markdown",
-					Value:    "STRING",
+					Language: "yaml",
+					Value: `- name: grandchild
+  type: INTEGER
+  mode: REPEATED
+  description: grandchild description
+`,
 				},
 			},
 		},
@@ -421,6 +861,58 @@ table description
 				},
 			},
 		},
+		"hover WITH clause definition name": {
+			files: map[string]string{
+				"file1.sql": "WITH |data AS (SELECT id, CAST(id AS STRING) AS name FROM `project.dataset.table`)\nSELECT * FROM data",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID: "project.dataset.table",
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value: `- name: id
+  type: INT64
+- name: name
+  type: STRING
+`,
+				},
+				{
+					Language: "sql",
+					Value:    "WITH data AS (\nSELECT id, CAST(id AS STRING) AS name FROM `project.dataset.table`\n)",
+				},
+			},
+		},
+		"hover SELECT keyword shows the output schema": {
+			files: map[string]string{
+				"file1.sql": "WITH data AS (SELECT id FROM `project.dataset.table`)\nSEL|ECT id, CAST(id AS STRING) AS name FROM data",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID: "project.dataset.table",
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value: `- name: id
+  type: INT64
+- name: name
+  type: STRING
+`,
+				},
+			},
+		},
 		"hover with declaration": {
 			files: map[string]string{
 				"file1.sql": "DECLARE target_id INT64 DEFAULT 1;\nWITH data AS (SELECT id FROM `project.dataset.table`)\nSELECT * FROM data| WHERE id = target_id",
@@ -448,6 +940,208 @@ table description
 				},
 			},
 		},
+		"hover partitioned table": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM |`project.dataset.table`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:           "project.dataset.table",
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				Schema: bq.Schema{
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+				TimePartitioning: &bq.TimePartitioning{
+					Type: bq.DayPartitioningType,
+				},
+				RequirePartitionFilter: true,
+				Clustering: &bq.Clustering{
+					Fields: []string{"name"},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value: `## project.dataset.table
+
+### Table info
+
+* Created: 2023-06-17 00:00:00
+* Last modified: 2023-06-17 00:00:00
+* Partitioned by: _PARTITIONTIME (DAY)
+* Require partition filter: true
+* Clustered by: name
+
+### Storage info
+
+* Number of rows: 0
+* Total logical bytes: 0 bytes
+`,
+				},
+				{
+					Language: "yaml",
+					Value: `- name: name
+  type: STRING
+`,
+				},
+			},
+		},
+		"hover partition pseudo column": {
+			files: map[string]string{
+				"file1.sql": "SELECT name FROM `project.dataset.table` WHERE |_PARTITIONDATE = '2024-01-01'",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID: "project.dataset.table",
+				Schema: bq.Schema{
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+				TimePartitioning: &bq.TimePartitioning{
+					Type: bq.DayPartitioningType,
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value:    "- name: _PARTITIONDATE\n  type: DATE\n",
+				},
+			},
+		},
+		"hover external table": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM |`project.dataset.table`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:           "project.dataset.table",
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				Schema: bq.Schema{
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+				ExternalDataConfig: &bq.ExternalDataConfig{
+					SourceFormat: bq.Parquet,
+					SourceURIs:   []string{"gs://bucket/table/*"},
+					HivePartitioningOptions: &bq.HivePartitioningOptions{
+						Mode:            bq.AutoHivePartitioningMode,
+						SourceURIPrefix: "gs://bucket/table/",
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value: `## project.dataset.table
+
+### Table info
+
+* Created: 2023-06-17 00:00:00
+* Last modified: 2023-06-17 00:00:00
+
+### External data configuration
+
+* Source format: PARQUET
+* Source URIs:
+  * gs://bucket/table/*
+* Hive partitioning: AUTO
+  * Source URI prefix: gs://bucket/table/
+
+### Storage info
+
+* Number of rows: 0
+* Total logical bytes: 0 bytes
+`,
+				},
+				{
+					Language: "yaml",
+					Value: `- name: name
+  type: STRING
+`,
+				},
+			},
+		},
+		"hover view": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM |`project.dataset.view`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				FullID:           "project.dataset.view",
+				Type:             bq.ViewTable,
+				ViewQuery:        "SELECT name FROM `project.dataset.table`",
+				CreationTime:     time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				LastModifiedTime: time.Date(2023, 6, 17, 0, 0, 0, 0, time.UTC),
+				Schema: bq.Schema{
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value: `## project.dataset.view
+
+### Table info
+
+* Created: 2023-06-17 00:00:00
+* Last modified: 2023-06-17 00:00:00
+
+### Storage info
+
+* Number of rows: 0
+* Total logical bytes: 0 bytes
+`,
+				},
+				{
+					Language: "yaml",
+					Value: `- name: name
+  type: STRING
+`,
+				},
+				{
+					Language: "sql",
+					Value:    "SELECT name FROM `project.dataset.table`",
+				},
+			},
+		},
+		"hover temp function": {
+			files: map[string]string{
+				"file1.sql": "CREATE TEMP FUNCTION add_one(x INT64) RETURNS INT64 AS (x + 1);\nSELECT add_|one(1)",
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "sql",
+					Value:    "CREATE TEMP FUNCTION add_one(x INT64) RETURNS INT64 AS (x + 1)",
+				},
+			},
+		},
+		"hover script variable": {
+			files: map[string]string{
+				"file1.sql": "DECLARE target_id INT64 DEFAULT 10;\nSELECT * FROM `project.dataset.table` WHERE id = target_|id",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "sql",
+					Value:    "DECLARE target_id INT64 DEFAULT 10",
+				},
+			},
+		},
 	}
 
 	for n, tt := range tests {
@@ -455,9 +1149,13 @@ table description
 			ctrl := gomock.NewController(t)
 			bqClient := mock_bigquery.NewMockClient(ctrl)
 			bqClient.EXPECT().GetTableMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.bqTableMetadata, nil).MinTimes(0)
+			bqClient.EXPECT().ListRowAccessPolicies(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.bqRowAccessPolicies, nil).MinTimes(0)
 			logger := logrus.New()
 			logger.SetLevel(logrus.DebugLevel)
 			p := source.NewProjectWithBQClient("/", bqClient, logger)
+			embeddedSQLOption := file.DefaultEmbeddedSQLOption()
+			embeddedSQLOption.Enabled = true
+			p.SetEmbeddedSQLOption(embeddedSQLOption)
 
 			files, path, position, err := helper.GetLspPosition(tt.files)
 			if err != nil {
@@ -0,0 +1,79 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// StatementEstimate is the dry-run result of a statement.
+type StatementEstimate struct {
+	Position            lsp.Position
+	Query               string
+	TotalBytesProcessed int64
+	Err                 error
+}
+
+// EstimateStatements dry-runs each top-level statement of the file.
+// The variable declarations before the statement are prepended to it, so that the statement can refer to the variables.
+func (p *Project) EstimateStatements(ctx context.Context, path, src string) ([]StatementEstimate, error) {
+	parsedFile := p.analyzer.ParseFileWithoutAnalysis(path, src)
+	if len(parsedFile.Errors) > 0 {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, parsedFile.Errors[0])
+	}
+
+	declarations := make([]string, 0)
+	result := make([]StatementEstimate, 0)
+	for _, stmt := range topLevelStatements(parsedFile.Node) {
+		loc := stmt.ParseLocationRange()
+		if loc == nil {
+			continue
+		}
+		query := src[loc.Start().ByteOffset():loc.End().ByteOffset()]
+		if stmt.Kind() == ast.VariableDeclaration {
+			declarations = append(declarations, query)
+			continue
+		}
+
+		rng, _ := parsedFile.PositionRange(loc)
+		estimate := StatementEstimate{
+			Position: rng.Start,
+			Query:    query,
+		}
+
+		dryrun := true
+		job, err := p.bqClient.Run(ctx, strings.Join(append(declarations, query), ";\n"), dryrun)
+		if err != nil {
+			estimate.Err = err
+		} else if status := job.LastStatus(); status != nil {
+			estimate.Err = status.Err()
+			if status.Statistics != nil {
+				estimate.TotalBytesProcessed = status.Statistics.TotalBytesProcessed
+			}
+		}
+		result = append(result, estimate)
+	}
+	return result, nil
+}
+
+// topLevelStatements returns the statements which are not nested in another statement like BEGIN...END.
+func topLevelStatements(node ast.ScriptNode) []ast.StatementNode {
+	result := make([]ast.StatementNode, 0)
+	lastEnd := -1
+	ast.Walk(node, func(n ast.Node) error {
+		if n == nil || !n.IsStatement() {
+			return nil
+		}
+		loc := n.ParseLocationRange()
+		if loc == nil || loc.Start().ByteOffset() < lastEnd {
+			return nil
+		}
+		result = append(result, n)
+		lastEnd = loc.End().ByteOffset()
+		return nil
+	})
+	return result
+}
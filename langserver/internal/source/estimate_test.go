@@ -0,0 +1,55 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_EstimateStatements(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+
+	queries := make([]string, 0)
+	bqClient.EXPECT().Run(gomock.Any(), gomock.Any(), true).DoAndReturn(
+		func(ctx context.Context, q string, dryrun bool) (bigquery.BigqueryJob, error) {
+			queries = append(queries, q)
+			job := mock_bigquery.NewMockBigqueryJob(ctrl)
+			job.EXPECT().LastStatus().Return(&bq.JobStatus{
+				State:      bq.Done,
+				Statistics: &bq.JobStatistics{TotalBytesProcessed: int64(len(queries) * 100)},
+			}).MinTimes(0)
+			return job, nil
+		}).MinTimes(0)
+	p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+	src := "DECLARE x INT64 DEFAULT 1;\nSELECT x;\nSELECT 2"
+	got, err := p.EstimateStatements(context.Background(), "file1.sql", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []source.StatementEstimate{
+		{Position: lsp.Position{Line: 1, Character: 0}, Query: "SELECT x", TotalBytesProcessed: 100},
+		{Position: lsp.Position{Line: 2, Character: 0}, Query: "SELECT 2", TotalBytesProcessed: 200},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("EstimateStatements result diff (-want, +got)\n%s", diff)
+	}
+
+	wantQueries := []string{
+		"DECLARE x INT64 DEFAULT 1;\nSELECT x",
+		"DECLARE x INT64 DEFAULT 1;\nSELECT 2",
+	}
+	if diff := cmp.Diff(wantQueries, queries); diff != "" {
+		t.Errorf("dry-run queries diff (-want, +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,851 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-zetasql/ast"
+	rast "github.com/goccy/go-zetasql/resolved_ast"
+	"github.com/goccy/go-zetasql/types"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// EvaluateExpression evaluates the scalar expression at rng locally, which avoids the billable query for the quick check.
+// go-zetasql v0.5.5 doesn't expose the evaluator of ZetaSQL, so the constants are folded by evaluateConstant of bqls.
+// The expression is analyzed alone as `SELECT expression`, so it can't refer to the tables and the columns.
+// It returns the value as the literal and its type.
+func (p *Project) EvaluateExpression(ctx context.Context, uri string, rng lsp.Range) (string, string, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return "", "", fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+
+	start := parsedFile.SrcOffset(rng.Start)
+	end := parsedFile.SrcOffset(rng.End)
+	if start >= end || end > len(parsedFile.Src) {
+		return "", "", fmt.Errorf("the range is empty")
+	}
+
+	expression := p.analyzer.ParseFile(ctx, "", "SELECT "+strings.TrimSpace(parsedFile.Src[start:end]))
+	for _, err := range expression.Errors {
+		if err.Severity == 0 || err.Severity == lsp.Error {
+			return "", "", fmt.Errorf("failed to analyze the expression: %s", err.Msg)
+		}
+	}
+	if len(expression.RNode) != 1 || expression.RNode[0] == nil {
+		return "", "", fmt.Errorf("the selection is not a scalar expression")
+	}
+	columns := file.ListResolvedAstNode[*rast.ComputedColumnNode](expression.RNode[0])
+	if len(columns) == 0 {
+		return "", "", fmt.Errorf("the selection is not a scalar expression")
+	}
+	// the column of the outermost SELECT precedes the columns of the subqueries in it
+	value, err := evaluateConstant(expression, columns[0].Expr())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to evaluate the expression: %w", err)
+	}
+	return value.String(), value.typ, nil
+}
+
+// datePartNames are the date parts which are passed to the date functions as the keywords like DAY of `DATE_DIFF(a, b, DAY)`.
+var datePartNames = map[string]struct{}{
+	"MICROSECOND": {}, "MILLISECOND": {}, "SECOND": {}, "MINUTE": {}, "HOUR": {},
+	"DAY": {}, "DAYOFWEEK": {}, "DAYOFYEAR": {}, "WEEK": {}, "ISOWEEK": {},
+	"MONTH": {}, "QUARTER": {}, "YEAR": {}, "ISOYEAR": {}, "DATE": {}, "TIME": {},
+}
+
+// isConstantExpression reports whether rng selects an expression of the document which evaluateConstant can fold.
+// The expression may consist only of the literals, CAST and the operators and the functions which evaluateFunction implements.
+// Only the syntax is checked, so the expression may still fail to be evaluated by EvaluateExpression.
+func isConstantExpression(parsedFile file.ParsedFile, rng lsp.Range) bool {
+	if parsedFile.Node == nil {
+		return false
+	}
+	start := parsedFile.SrcOffset(rng.Start)
+	end := parsedFile.SrcOffset(rng.End)
+	if start >= end || end > len(parsedFile.Src) {
+		return false
+	}
+	// the spaces around the selection are trimmed as EvaluateExpression does
+	text := parsedFile.Src[start:end]
+	start += len(text) - len(strings.TrimLeft(text, " \t\r\n"))
+	end -= len(text) - len(strings.TrimRight(text, " \t\r\n"))
+
+	var expr ast.ExpressionNode
+	ast.Walk(parsedFile.Node, func(n ast.Node) error {
+		node, ok := n.(ast.ExpressionNode)
+		if !ok || expr != nil {
+			return nil
+		}
+		if lRange := node.ParseLocationRange(); lRange != nil && lRange.Start().ByteOffset() == start && lRange.End().ByteOffset() == end {
+			expr = node
+		}
+		return nil
+	})
+	if expr == nil {
+		return false
+	}
+
+	constant := true
+	ast.Walk(expr, func(n ast.Node) error {
+		if !isEvaluableNode(n) {
+			constant = false
+		}
+		return nil
+	})
+	return constant
+}
+
+// isEvaluableNode reports whether evaluateConstant can fold the node of the expression.
+func isEvaluableNode(n ast.Node) bool {
+	switch node := n.(type) {
+	case *ast.IntLiteralNode, *ast.FloatLiteralNode, *ast.StringLiteralNode, *ast.BooleanLiteralNode, *ast.NullLiteralNode,
+		*ast.DateOrTimeLiteralNode, *ast.AndExprNode, *ast.OrExprNode, *ast.IntervalExprNode, *ast.SimpleTypeNode, *ast.IdentifierNode:
+		return true
+	case *ast.CastExpressionNode:
+		// SAFE_CAST returns NULL for the value which castConstant fails to cast.
+		return !node.IsSafeCast()
+	case *ast.BinaryExpressionNode:
+		_, ok := evaluableBinaryOps[node.Op()]
+		return ok && !node.IsNot()
+	case *ast.UnaryExpressionNode:
+		return node.Op() == ast.MinusUnaryOp || node.Op() == ast.NotUnaryOp
+	case *ast.FunctionCallNode:
+		names := node.Function().Names()
+		if len(names) != 1 || node.HasModifiers() {
+			return false
+		}
+		_, ok := evaluableFunctions[strings.ToLower(names[0].Name())]
+		return ok
+	case *ast.PathExpressionNode:
+		// the name of the type of CAST isn't a column
+		if _, ok := node.Parent().(*ast.SimpleTypeNode); ok {
+			return true
+		}
+		return isFunctionNameOrDatePart(node)
+	}
+	// the other nodes like the columns, the parameters and the subqueries can't be folded
+	return false
+}
+
+// evaluableBinaryOps are the binary operators which evaluateFunction implements.
+var evaluableBinaryOps = map[ast.BinaryOp]struct{}{
+	ast.PlusOp: {}, ast.MinusOp: {}, ast.MultiplyOp: {}, ast.DivideOp: {},
+	ast.EqOp: {}, ast.NeOp: {}, ast.Ne2Op: {}, ast.LtOp: {}, ast.LeOp: {}, ast.GtOp: {}, ast.GeOp: {},
+}
+
+// evaluableFunctions are the functions which evaluateFunction implements, by the name of their calls.
+var evaluableFunctions = map[string]struct{}{
+	"concat": {}, "upper": {}, "lower": {}, "trim": {}, "length": {}, "date": {},
+	"date_add": {}, "date_sub": {}, "datetime_add": {}, "datetime_sub": {}, "timestamp_add": {}, "timestamp_sub": {},
+	"date_diff": {}, "date_trunc": {},
+}
+
+// isFunctionNameOrDatePart reports whether the path is the name of the function call or its date part argument, which isn't a column.
+func isFunctionNameOrDatePart(path *ast.PathExpressionNode) bool {
+	call, ok := path.Parent().(*ast.FunctionCallNode)
+	if !ok {
+		return false
+	}
+	if function := call.Function(); function != nil && function.ParseLocationRange() != nil && path.ParseLocationRange() != nil &&
+		function.ParseLocationRange().Start().ByteOffset() == path.ParseLocationRange().Start().ByteOffset() {
+		return true
+	}
+	names := path.Names()
+	if len(names) != 1 {
+		return false
+	}
+	_, ok = datePartNames[strings.ToUpper(names[0].Name())]
+	return ok
+}
+
+// termDocumentForConstant shows the folded value when the term is the operator or the literal of the constant expression like `DATE '2024-01-01' + 3`.
+// The function name is hovered with its documentation, which shows the folded value too.
+func (p *Project) termDocumentForConstant(termOffset int, parsedFile file.ParsedFile) ([]lsp.MarkedString, bool) {
+	if _, ok := file.SearchAstNode[*ast.PathExpressionNode](parsedFile.Node, termOffset); ok {
+		return nil, false
+	}
+	output, ok := parsedFile.FindTargetAnalyzeOutput(termOffset)
+	if !ok {
+		return nil, false
+	}
+	call, ok := file.SearchResolvedAstNode[*rast.FunctionCallNode](output, termOffset)
+	if !ok {
+		return nil, false
+	}
+	return constantMarkedString(parsedFile, call)
+}
+
+func constantMarkedString(parsedFile file.ParsedFile, expr rast.ExprNode) ([]lsp.MarkedString, bool) {
+	value, err := evaluateConstant(parsedFile, expr)
+	if err != nil {
+		return nil, false
+	}
+	return []lsp.MarkedString{
+		{
+			Language: "sql",
+			Value:    value.String(),
+		},
+	}, true
+}
+
+// constantValue is the value of the constant expression folded by bqls.
+// The value is int64, float64, bool, string or time.Time, and nil is NULL.
+// DATE and DATETIME are stored as the time in UTC.
+type constantValue struct {
+	typ   string
+	value any
+}
+
+// String returns the value as the literal of BigQuery.
+func (v constantValue) String() string {
+	switch x := v.value.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		s := strconv.FormatFloat(x, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eInN") {
+			s += ".0"
+		}
+		return s
+	case bool:
+		if x {
+			return "TRUE"
+		}
+		return "FALSE"
+	case string:
+		return strconv.Quote(x)
+	case time.Time:
+		return fmt.Sprintf("%s '%s'", v.typ, formatTime(v.typ, x))
+	}
+	return fmt.Sprint(v.value)
+}
+
+func formatTime(typ string, t time.Time) string {
+	switch typ {
+	case "DATE":
+		return t.Format("2006-01-02")
+	case "DATETIME":
+		return t.Format("2006-01-02 15:04:05.999999")
+	default:
+		return t.UTC().Format("2006-01-02 15:04:05.999999-07")
+	}
+}
+
+// evaluateConstant folds the constant expression into its value.
+// It fails when the expression refers to the columns, the parameters or the functions which bqls can't evaluate like CURRENT_DATE.
+func evaluateConstant(parsedFile file.ParsedFile, expr rast.ExprNode) (constantValue, error) {
+	typ := expr.Type().TypeName(types.ProductExternal)
+	switch node := expr.(type) {
+	case *rast.LiteralNode:
+		text, ok := parsedFile.ExtractSQL(node.ParseLocationRange())
+		if !ok {
+			return constantValue{}, fmt.Errorf("failed to find the literal")
+		}
+		return parseLiteral(typ, text)
+	case *rast.CastNode:
+		value, err := evaluateConstant(parsedFile, node.Expr())
+		if err != nil {
+			return constantValue{}, err
+		}
+		return castConstant(value, typ)
+	case *rast.FunctionCallNode:
+		name := node.Function().Name()
+		args := make([]constantValue, 0, len(node.ArgumentList()))
+		var part string
+		for _, arg := range node.ArgumentList() {
+			// the date part like DAY is the enum, which is read from the text of the call.
+			// The part which isn't a single keyword like WEEK(MONDAY) is left unknown, so that the function isn't evaluated.
+			if strings.Contains(arg.Type().TypeName(types.ProductExternal), "DateTimestampPart") {
+				if text, ok := parsedFile.ExtractSQL(node.ParseLocationRange()); ok {
+					part = datePart(text)
+				}
+				continue
+			}
+			value, err := evaluateConstant(parsedFile, arg)
+			if err != nil {
+				return constantValue{}, err
+			}
+			args = append(args, value)
+		}
+		return evaluateFunction(name, typ, args, part)
+	}
+	return constantValue{}, fmt.Errorf("%s is not a constant", typ)
+}
+
+// datePart returns the last argument of the call like DAY of `DATE_ADD(d, INTERVAL 1 DAY)`.
+// It returns the empty string when the argument isn't a single keyword like `WEEK(MONDAY)`.
+func datePart(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasSuffix(text, ")") {
+		return ""
+	}
+	text = strings.TrimSpace(strings.TrimSuffix(text, ")"))
+	i := strings.LastIndexFunc(text, func(r rune) bool {
+		return r >= 0x80 || !isIdentifierByte(byte(r))
+	})
+	word := text[i+1:]
+	if word == "" || (i >= 0 && text[i] == '(') {
+		return ""
+	}
+	return strings.ToUpper(word)
+}
+
+func parseLiteral(typ, text string) (constantValue, error) {
+	text = strings.TrimSpace(text)
+	if strings.EqualFold(text, "NULL") {
+		return constantValue{typ: typ}, nil
+	}
+	switch typ {
+	case "INT64":
+		i, err := parseInt(text)
+		if err != nil {
+			return constantValue{}, err
+		}
+		return constantValue{typ: typ, value: i}, nil
+	case "FLOAT64":
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return constantValue{}, err
+		}
+		return constantValue{typ: typ, value: f}, nil
+	case "BOOL":
+		return constantValue{typ: typ, value: strings.EqualFold(text, "TRUE")}, nil
+	case "STRING":
+		s, err := unquoteLiteral(text)
+		if err != nil {
+			return constantValue{}, err
+		}
+		return constantValue{typ: typ, value: s}, nil
+	case "DATE", "DATETIME", "TIMESTAMP":
+		s, err := unquoteLiteral(text)
+		if err != nil {
+			return constantValue{}, err
+		}
+		t, err := parseTime(typ, s)
+		if err != nil {
+			return constantValue{}, err
+		}
+		return constantValue{typ: typ, value: t}, nil
+	}
+	return constantValue{}, fmt.Errorf("%s is not supported", typ)
+}
+
+// parseInt parses the decimal and the hexadecimal like 0x1F.
+func parseInt(text string) (int64, error) {
+	sign := ""
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		sign, text = text[:1], text[1:]
+	}
+	if strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X") {
+		return strconv.ParseInt(sign+text[2:], 16, 64)
+	}
+	return strconv.ParseInt(sign+text, 10, 64)
+}
+
+// unquoteLiteral returns the content of the quoted string like 'a', """a""" and r'a'.
+// The prefix like DATE of `DATE '2024-01-01'` is skipped.
+func unquoteLiteral(text string) (string, error) {
+	i := strings.IndexAny(text, `'"`)
+	if i < 0 {
+		return "", fmt.Errorf("%s is not quoted", text)
+	}
+	raw := strings.EqualFold(strings.TrimSpace(text[:i]), "R")
+	body, quote := text[i:], text[i:i+1]
+	switch {
+	case len(body) >= 6 && strings.HasPrefix(body, strings.Repeat(quote, 3)) && strings.HasSuffix(body, strings.Repeat(quote, 3)):
+		body = body[3 : len(body)-3]
+	case len(body) >= 2 && strings.HasSuffix(body, quote):
+		body = body[1 : len(body)-1]
+	default:
+		return "", fmt.Errorf("%s is not quoted", text)
+	}
+	if raw {
+		return body, nil
+	}
+
+	var sb strings.Builder
+	for len(body) > 0 {
+		if len(body) > 1 && body[0] == '\\' && strings.IndexByte("'\"`?", body[1]) >= 0 {
+			sb.WriteByte(body[1])
+			body = body[2:]
+			continue
+		}
+		r, multibyte, tail, err := strconv.UnquoteChar(body, 0)
+		if err != nil {
+			return "", err
+		}
+		if multibyte {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte(byte(r))
+		}
+		body = tail
+	}
+	return sb.String(), nil
+}
+
+func parseTime(typ, s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	layouts := []string{"2006-01-02"}
+	switch typ {
+	case "DATETIME":
+		layouts = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+	case "TIMESTAMP":
+		s = strings.TrimSuffix(s, " UTC")
+		layouts = []string{
+			"2006-01-02 15:04:05Z07:00", "2006-01-02T15:04:05Z07:00", "2006-01-02 15:04:05-07",
+			"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02",
+		}
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("failed to parse %s %s", typ, s)
+}
+
+func castConstant(v constantValue, typ string) (constantValue, error) {
+	if v.value == nil || v.typ == typ {
+		return constantValue{typ: typ, value: v.value}, nil
+	}
+	switch x := v.value.(type) {
+	case int64:
+		switch typ {
+		case "FLOAT64":
+			return constantValue{typ: typ, value: float64(x)}, nil
+		case "STRING":
+			return constantValue{typ: typ, value: strconv.FormatInt(x, 10)}, nil
+		case "BOOL":
+			return constantValue{typ: typ, value: x != 0}, nil
+		}
+	case float64:
+		switch typ {
+		case "INT64":
+			r := math.Round(x)
+			if math.IsNaN(r) || r < math.MinInt64 || r >= math.MaxInt64 {
+				return constantValue{}, fmt.Errorf("int64 overflow: %v", x)
+			}
+			return constantValue{typ: typ, value: int64(r)}, nil
+		case "STRING":
+			// BigQuery writes inf and nan in lower case, which differs from Go.
+			if math.IsInf(x, 0) || math.IsNaN(x) {
+				break
+			}
+			return constantValue{typ: typ, value: strconv.FormatFloat(x, 'g', -1, 64)}, nil
+		}
+	case bool:
+		switch typ {
+		case "INT64":
+			if x {
+				return constantValue{typ: typ, value: int64(1)}, nil
+			}
+			return constantValue{typ: typ, value: int64(0)}, nil
+		case "STRING":
+			return constantValue{typ: typ, value: strconv.FormatBool(x)}, nil
+		}
+	case string:
+		// The strings which Go and BigQuery may parse differently like the spaces and the hexadecimal floats are not evaluated.
+		if x != strings.TrimSpace(x) {
+			break
+		}
+		switch typ {
+		case "INT64":
+			i, err := parseInt(x)
+			if err != nil {
+				return constantValue{}, err
+			}
+			return constantValue{typ: typ, value: i}, nil
+		case "FLOAT64":
+			if strings.ContainsAny(x, "xX_") || strings.Contains(strings.ToLower(x), "infinity") {
+				break
+			}
+			f, err := strconv.ParseFloat(x, 64)
+			if err != nil {
+				return constantValue{}, err
+			}
+			return constantValue{typ: typ, value: f}, nil
+		case "BOOL":
+			// BigQuery accepts only true and false, unlike strconv.ParseBool which accepts 1 and t.
+			switch strings.ToLower(x) {
+			case "true":
+				return constantValue{typ: typ, value: true}, nil
+			case "false":
+				return constantValue{typ: typ, value: false}, nil
+			}
+			return constantValue{}, fmt.Errorf("bad bool value: %s", x)
+		case "DATE", "DATETIME", "TIMESTAMP":
+			t, err := parseTime(typ, x)
+			if err != nil {
+				return constantValue{}, err
+			}
+			return constantValue{typ: typ, value: t}, nil
+		}
+	case time.Time:
+		switch typ {
+		case "STRING":
+			// the fractional seconds may be written with the different number of digits from BigQuery.
+			if x.Nanosecond() != 0 {
+				break
+			}
+			return constantValue{typ: typ, value: formatTime(v.typ, x)}, nil
+		case "DATE":
+			return constantValue{typ: typ, value: startOfDay(x)}, nil
+		case "DATETIME", "TIMESTAMP":
+			return constantValue{typ: typ, value: x}, nil
+		}
+	}
+	return constantValue{}, fmt.Errorf("CAST from %s to %s is not supported", v.typ, typ)
+}
+
+// evaluateFunction evaluates the function of the resolved AST like `$add` for `+`.
+// part is the date part like DAY of the date functions.
+// The functions and the operators which are added here should be added to evaluableFunctions and evaluableBinaryOps too.
+func evaluateFunction(name, typ string, args []constantValue, part string) (constantValue, error) {
+	switch name {
+	case "$and", "$or":
+		return evaluateLogical(name, typ, args), nil
+	}
+	for _, arg := range args {
+		if arg.value == nil {
+			return constantValue{typ: typ}, nil
+		}
+	}
+
+	switch name {
+	case "$add", "$subtract", "$multiply", "$divide":
+		if len(args) != 2 {
+			break
+		}
+		return evaluateArithmetic(name, typ, args[0], args[1])
+	case "$unary_minus":
+		switch x := args[0].value.(type) {
+		case int64:
+			if x == math.MinInt64 {
+				return constantValue{}, fmt.Errorf("int64 overflow: -%d", x)
+			}
+			return constantValue{typ: typ, value: -x}, nil
+		case float64:
+			return constantValue{typ: typ, value: -x}, nil
+		}
+	case "$not":
+		if b, ok := args[0].value.(bool); ok {
+			return constantValue{typ: typ, value: !b}, nil
+		}
+	case "$equal", "$not_equal", "$less", "$less_or_equal", "$greater", "$greater_or_equal":
+		if len(args) != 2 {
+			break
+		}
+		c, err := compareConstants(args[0], args[1])
+		if err != nil {
+			return constantValue{}, err
+		}
+		result := map[string]bool{
+			"$equal":            c == 0,
+			"$not_equal":        c != 0,
+			"$less":             c < 0,
+			"$less_or_equal":    c <= 0,
+			"$greater":          c > 0,
+			"$greater_or_equal": c >= 0,
+		}[name]
+		return constantValue{typ: typ, value: result}, nil
+	case "concat":
+		var sb strings.Builder
+		for _, arg := range args {
+			s, ok := arg.value.(string)
+			if !ok {
+				return constantValue{}, fmt.Errorf("CONCAT of %s is not supported", arg.typ)
+			}
+			sb.WriteString(s)
+		}
+		return constantValue{typ: typ, value: sb.String()}, nil
+	case "upper", "lower", "trim", "length":
+		s, ok := args[0].value.(string)
+		if !ok || len(args) != 1 {
+			break
+		}
+		switch name {
+		case "upper":
+			return constantValue{typ: typ, value: strings.ToUpper(s)}, nil
+		case "lower":
+			return constantValue{typ: typ, value: strings.ToLower(s)}, nil
+		case "trim":
+			return constantValue{typ: typ, value: strings.TrimSpace(s)}, nil
+		default:
+			return constantValue{typ: typ, value: int64(len([]rune(s)))}, nil
+		}
+	case "date":
+		if len(args) != 3 {
+			break
+		}
+		y, yok := args[0].value.(int64)
+		m, mok := args[1].value.(int64)
+		d, dok := args[2].value.(int64)
+		if !yok || !mok || !dok {
+			break
+		}
+		t := time.Date(int(y), time.Month(m), int(d), 0, 0, 0, 0, time.UTC)
+		if t.Year() != int(y) || t.Month() != time.Month(m) || t.Day() != int(d) {
+			return constantValue{}, fmt.Errorf("invalid date: %d-%d-%d", y, m, d)
+		}
+		return constantValue{typ: typ, value: t}, nil
+	case "date_add", "date_sub", "datetime_add", "datetime_sub", "timestamp_add", "timestamp_sub":
+		if len(args) != 2 {
+			break
+		}
+		t, tok := args[0].value.(time.Time)
+		n, nok := args[1].value.(int64)
+		if !tok || !nok {
+			break
+		}
+		if strings.HasSuffix(name, "_sub") {
+			n = -n
+		}
+		result, err := addTime(t, n, part)
+		if err != nil {
+			return constantValue{}, err
+		}
+		return constantValue{typ: typ, value: result}, nil
+	case "date_diff":
+		if len(args) != 2 {
+			break
+		}
+		a, aok := args[0].value.(time.Time)
+		b, bok := args[1].value.(time.Time)
+		if !aok || !bok {
+			break
+		}
+		diff, err := diffDate(a, b, part)
+		if err != nil {
+			return constantValue{}, err
+		}
+		return constantValue{typ: typ, value: diff}, nil
+	case "date_trunc":
+		if len(args) != 1 {
+			break
+		}
+		if t, ok := args[0].value.(time.Time); ok {
+			result, err := truncateTime(t, part)
+			if err != nil {
+				return constantValue{}, err
+			}
+			return constantValue{typ: typ, value: result}, nil
+		}
+	}
+	return constantValue{}, fmt.Errorf("%s can't be evaluated", strings.TrimPrefix(name, "$"))
+}
+
+// evaluateLogical evaluates AND and OR, whose result can be known with NULL like `FALSE AND NULL`.
+func evaluateLogical(name, typ string, args []constantValue) constantValue {
+	short := name == "$or"
+	hasNull := false
+	for _, arg := range args {
+		b, ok := arg.value.(bool)
+		if !ok {
+			hasNull = true
+			continue
+		}
+		if b == short {
+			return constantValue{typ: typ, value: short}
+		}
+	}
+	if hasNull {
+		return constantValue{typ: typ}
+	}
+	return constantValue{typ: typ, value: !short}
+}
+
+func evaluateArithmetic(name, typ string, a, b constantValue) (constantValue, error) {
+	// DATE + INT64 and DATE - INT64 add the days
+	if t, ok := a.value.(time.Time); ok {
+		if n, ok := b.value.(int64); ok && name != "$multiply" && name != "$divide" {
+			if name == "$subtract" {
+				n = -n
+			}
+			return constantValue{typ: typ, value: t.AddDate(0, 0, int(n))}, nil
+		}
+	}
+	if t, ok := b.value.(time.Time); ok && name == "$add" {
+		if n, ok := a.value.(int64); ok {
+			return constantValue{typ: typ, value: t.AddDate(0, 0, int(n))}, nil
+		}
+	}
+
+	x, xok := a.value.(int64)
+	y, yok := b.value.(int64)
+	if xok && yok && name != "$divide" {
+		var r int64
+		switch name {
+		case "$add":
+			r = x + y
+			if (x > 0 && y > 0 && r < 0) || (x < 0 && y < 0 && r >= 0) {
+				return constantValue{}, fmt.Errorf("int64 overflow: %d + %d", x, y)
+			}
+		case "$subtract":
+			r = x - y
+			if (x >= 0 && y < 0 && r < 0) || (x < 0 && y > 0 && r >= 0) {
+				return constantValue{}, fmt.Errorf("int64 overflow: %d - %d", x, y)
+			}
+		case "$multiply":
+			r = x * y
+			if x != 0 && (r/x != y || (x == -1 && y == math.MinInt64)) {
+				return constantValue{}, fmt.Errorf("int64 overflow: %d * %d", x, y)
+			}
+		}
+		return constantValue{typ: typ, value: r}, nil
+	}
+
+	f, fok := toFloat(a)
+	g, gok := toFloat(b)
+	if !fok || !gok {
+		return constantValue{}, fmt.Errorf("%s of %s and %s can't be evaluated", strings.TrimPrefix(name, "$"), a.typ, b.typ)
+	}
+	switch name {
+	case "$add":
+		return constantValue{typ: typ, value: f + g}, nil
+	case "$subtract":
+		return constantValue{typ: typ, value: f - g}, nil
+	case "$multiply":
+		return constantValue{typ: typ, value: f * g}, nil
+	default:
+		if g == 0 {
+			return constantValue{}, fmt.Errorf("division by zero: %v / %v", f, g)
+		}
+		return constantValue{typ: typ, value: f / g}, nil
+	}
+}
+
+func toFloat(v constantValue) (float64, bool) {
+	switch x := v.value.(type) {
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+func compareConstants(a, b constantValue) (int, error) {
+	if x, ok := a.value.(int64); ok {
+		if y, ok := b.value.(int64); ok {
+			switch {
+			case x < y:
+				return -1, nil
+			case x > y:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	if f, ok := toFloat(a); ok {
+		if g, ok := toFloat(b); ok {
+			switch {
+			case f < g:
+				return -1, nil
+			case f > g:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	switch x := a.value.(type) {
+	case string:
+		if y, ok := b.value.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	case bool:
+		if y, ok := b.value.(bool); ok {
+			switch {
+			case x == y:
+				return 0, nil
+			case y:
+				return -1, nil
+			}
+			return 1, nil
+		}
+	case time.Time:
+		if y, ok := b.value.(time.Time); ok {
+			return x.Compare(y), nil
+		}
+	}
+	return 0, fmt.Errorf("%s and %s can't be compared", a.typ, b.typ)
+}
+
+// addTime adds n parts to t. The month is clamped to its last day like `DATE_ADD(DATE '2024-01-31', INTERVAL 1 MONTH)` is 2024-02-29.
+func addTime(t time.Time, n int64, part string) (time.Time, error) {
+	switch part {
+	case "MICROSECOND":
+		return t.Add(time.Duration(n) * time.Microsecond), nil
+	case "MILLISECOND":
+		return t.Add(time.Duration(n) * time.Millisecond), nil
+	case "SECOND":
+		return t.Add(time.Duration(n) * time.Second), nil
+	case "MINUTE":
+		return t.Add(time.Duration(n) * time.Minute), nil
+	case "HOUR":
+		return t.Add(time.Duration(n) * time.Hour), nil
+	case "DAY":
+		return t.AddDate(0, 0, int(n)), nil
+	case "WEEK":
+		return t.AddDate(0, 0, int(n)*7), nil
+	case "MONTH":
+		return addMonths(t, int(n)), nil
+	case "QUARTER":
+		return addMonths(t, int(n)*3), nil
+	case "YEAR":
+		return addMonths(t, int(n)*12), nil
+	}
+	return time.Time{}, fmt.Errorf("date part %s is not supported", part)
+}
+
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+func diffDate(a, b time.Time, part string) (int64, error) {
+	switch part {
+	case "DAY":
+		return int64(startOfDay(a).Sub(startOfDay(b)).Hours() / 24), nil
+	case "MONTH":
+		return int64((a.Year()*12 + int(a.Month())) - (b.Year()*12 + int(b.Month()))), nil
+	case "QUARTER":
+		return int64((a.Year()*4 + (int(a.Month())-1)/3) - (b.Year()*4 + (int(b.Month())-1)/3)), nil
+	case "YEAR":
+		return int64(a.Year() - b.Year()), nil
+	}
+	return 0, fmt.Errorf("date part %s is not supported", part)
+}
+
+// truncateTime truncates t to the beginning of the part. WEEK begins on Sunday.
+// The other parts like ISOWEEK, ISOYEAR and HOUR are not supported.
+func truncateTime(t time.Time, part string) (time.Time, error) {
+	day := startOfDay(t)
+	switch part {
+	case "DAY":
+		return day, nil
+	case "WEEK":
+		return day.AddDate(0, 0, -int(day.Weekday())), nil
+	case "MONTH":
+		return day.AddDate(0, 0, 1-day.Day()), nil
+	case "QUARTER":
+		return time.Date(t.Year(), t.Month()-(t.Month()-1)%3, 1, 0, 0, 0, 0, t.Location()), nil
+	case "YEAR":
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location()), nil
+	}
+	return time.Time{}, fmt.Errorf("date part %s is not supported", part)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
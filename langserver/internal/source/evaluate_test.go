@@ -0,0 +1,192 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_TermDocumentForConstant(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+		// the documentation of the function follows the folded value
+		onlyFirst bool
+
+		expectMarkedStrings []lsp.MarkedString
+	}{
+		"date arithmetic": {
+			files: map[string]string{
+				"file1.sql": "SELECT DATE '2024-01-01' |+ 3",
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "sql",
+					Value:    "DATE '2024-01-04'",
+				},
+			},
+		},
+		"innermost expression": {
+			files: map[string]string{
+				"file1.sql": "SELECT 1 + 2 |* 3",
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "sql",
+					Value:    "6",
+				},
+			},
+		},
+		"function name": {
+			files: map[string]string{
+				"file1.sql": "SELECT DATE_|ADD(DATE '2024-01-31', INTERVAL 1 MONTH)",
+			},
+			onlyFirst: true,
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "sql",
+					Value:    "DATE '2024-02-29'",
+				},
+			},
+		},
+		"not constant": {
+			files: map[string]string{
+				"file1.sql": "SELECT CURRENT_DATE() |+ 3",
+			},
+			expectMarkedStrings: nil,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.TermDocument(path, position)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.onlyFirst && len(got) > 0 {
+				got = got[:1]
+			}
+			if diff := cmp.Diff(tt.expectMarkedStrings, got); diff != "" {
+				t.Errorf("project.TermDocument result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProject_EvaluateExpression(t *testing.T) {
+	tests := map[string]struct {
+		file string
+		rng  lsp.Range
+
+		expectValue string
+		expectType  string
+		expectErr   bool
+	}{
+		"date function": {
+			file:        "SELECT DATE_DIFF(DATE '2024-03-01', DATE '2024-01-01', DAY) AS days",
+			rng:         lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 59}},
+			expectValue: "60",
+			expectType:  "INT64",
+		},
+		"string function": {
+			file:        "SELECT CONCAT('a', 'b')",
+			rng:         lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 23}},
+			expectValue: `"ab"`,
+			expectType:  "STRING",
+		},
+		"date_trunc month": {
+			file:        "SELECT DATE_TRUNC(DATE '2024-03-15', MONTH)",
+			rng:         lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 43}},
+			expectValue: `DATE '2024-03-01'`,
+			expectType:  "DATE",
+		},
+		"date_trunc isoweek is not evaluated": {
+			file:      "SELECT DATE_TRUNC(DATE '2024-03-15', ISOWEEK)",
+			rng:       lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 45}},
+			expectErr: true,
+		},
+		"date_trunc isoyear is not evaluated": {
+			file:      "SELECT DATE_TRUNC(DATE '2024-03-15', ISOYEAR)",
+			rng:       lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 45}},
+			expectErr: true,
+		},
+		"date_trunc week with the weekday is not evaluated": {
+			file:      "SELECT DATE_TRUNC(DATE '2024-03-15', WEEK(MONDAY))",
+			rng:       lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 50}},
+			expectErr: true,
+		},
+		"cast string to bool": {
+			file:        "SELECT CAST('TRUE' AS BOOL)",
+			rng:         lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 27}},
+			expectValue: "TRUE",
+			expectType:  "BOOL",
+		},
+		"cast numeric string to bool": {
+			file:      "SELECT CAST('1' AS BOOL)",
+			rng:       lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 24}},
+			expectErr: true,
+		},
+		"cast string with spaces to int64": {
+			file:      "SELECT CAST(' 1' AS INT64)",
+			rng:       lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 26}},
+			expectErr: true,
+		},
+		"column reference": {
+			file:      "SELECT id + 1 FROM `project.dataset.table`",
+			rng:       lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 13}},
+			expectErr: true,
+		},
+		"non-deterministic function": {
+			file:      "SELECT CURRENT_DATE()",
+			rng:       lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 21}},
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "id", Type: bq.IntegerFieldType},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+			p.UpdateFile("file1.sql", tt.file, 1)
+
+			value, typ, err := p.EvaluateExpression(context.Background(), "file1.sql", tt.rng)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("EvaluateExpression should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != tt.expectValue || typ != tt.expectType {
+				t.Errorf("EvaluateExpression got %s (%s), want %s (%s)", value, typ, tt.expectValue, tt.expectType)
+			}
+		})
+	}
+}
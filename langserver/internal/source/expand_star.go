@@ -0,0 +1,114 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	rast "github.com/goccy/go-zetasql/resolved_ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// ExpandStar returns the edit which replaces `*` or `t.*` at position with the columns it selects.
+// The columns are taken from the output of the statement, so the star should be in the SELECT list of the outermost query.
+func (p *Project) ExpandStar(uri string, position lsp.Position) ([]lsp.TextEdit, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+	if parsedFile.Node == nil {
+		return nil, fmt.Errorf("failed to parse document %s", uri)
+	}
+
+	termOffset := parsedFile.TermOffset(position)
+	selectColumn, ok := file.SearchAstNode[*ast.SelectColumnNode](parsedFile.Node, termOffset)
+	if !ok {
+		return nil, fmt.Errorf("star is not found at the position")
+	}
+	switch selectColumn.Expression().(type) {
+	case *ast.StarNode, *ast.DotStarNode:
+	default:
+		// the star with EXCEPT or REPLACE is kept because its columns are chosen explicitly
+		return nil, fmt.Errorf("star is not found at the position")
+	}
+	selectList, ok := selectColumn.Parent().(*ast.SelectListNode)
+	if !ok {
+		return nil, fmt.Errorf("failed to find the SELECT list")
+	}
+
+	stmt, ok := parsedFile.FindTargetStatementNode(termOffset)
+	if !ok {
+		return nil, fmt.Errorf("failed to find the statement")
+	}
+	queryStmt, ok := stmt.(*ast.QueryStatementNode)
+	if !ok {
+		return nil, fmt.Errorf("only the star in SELECT statement can be expanded")
+	}
+	selectNode, ok := queryStmt.Query().QueryExpr().(*ast.SelectNode)
+	if !ok || selectNode.SelectList().ParseLocationRange().Start().ByteOffset() != selectList.ParseLocationRange().Start().ByteOffset() {
+		return nil, fmt.Errorf("only the star in the outermost query can be expanded")
+	}
+
+	output, ok := parsedFile.FindTargetAnalyzeOutput(termOffset)
+	if !ok {
+		return nil, fmt.Errorf("failed to analyze the statement")
+	}
+	resolvedStmt, ok := output.Statement().(*rast.QueryStmtNode)
+	if !ok {
+		return nil, fmt.Errorf("failed to analyze the statement")
+	}
+
+	// each item other than the star has one output column
+	items := selectList.Columns()
+	index := -1
+	for i, item := range items {
+		if item.ParseLocationRange().Start().ByteOffset() == selectColumn.ParseLocationRange().Start().ByteOffset() {
+			index = i
+			continue
+		}
+		if isStarColumn(item) {
+			return nil, fmt.Errorf("the SELECT list which has multiple stars can't be expanded")
+		}
+	}
+	outputColumns := resolvedStmt.OutputColumnList()
+	starColumnCount := len(outputColumns) - (len(items) - 1)
+	if index < 0 || starColumnCount <= 0 {
+		return nil, fmt.Errorf("failed to find the columns of the star")
+	}
+
+	prefix := ""
+	if dotStar, ok := selectColumn.Expression().(*ast.DotStarNode); ok {
+		expr, ok := parsedFile.ExtractSQL(dotStar.Expr().ParseLocationRange())
+		if !ok {
+			return nil, fmt.Errorf("failed to find the expression of the star")
+		}
+		prefix = expr + "."
+	}
+
+	names := make([]string, 0, starColumnCount)
+	seen := make(map[string]struct{}, starColumnCount)
+	for _, c := range outputColumns[index : index+starColumnCount] {
+		// the same name from the joined tables is ambiguous without the table name
+		if _, ok := seen[c.Name()]; ok {
+			return nil, fmt.Errorf("%s is selected from multiple tables", c.Name())
+		}
+		seen[c.Name()] = struct{}{}
+		names = append(names, prefix+c.Name())
+	}
+
+	rng, ok := parsedFile.PositionRange(selectColumn.Expression().ParseLocationRange())
+	if !ok {
+		return nil, fmt.Errorf("failed to find the range of the star")
+	}
+	return []lsp.TextEdit{{Range: rng, NewText: strings.Join(names, ", ")}}, nil
+}
+
+func isStarColumn(column *ast.SelectColumnNode) bool {
+	switch column.Expression().(type) {
+	case *ast.StarNode, *ast.DotStarNode, *ast.StarWithModifiersNode, *ast.DotStarWithModifiersNode:
+		return true
+	}
+	return false
+}
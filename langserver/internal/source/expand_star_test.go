@@ -0,0 +1,94 @@
+package source_test
+
+import (
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_ExpandStar(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+
+		expectEdits []lsp.TextEdit
+		expectErr   bool
+	}{
+		"expand star": {
+			files: map[string]string{
+				"file1.sql": "SELECT |* FROM `project.dataset.table`",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 8}},
+					NewText: "id, name",
+				},
+			},
+		},
+		"expand table star with other columns": {
+			files: map[string]string{
+				"file1.sql": "SELECT 1 AS one, t.|*, 2 AS two FROM `project.dataset.table` AS t",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 17}, End: lsp.Position{Line: 0, Character: 20}},
+					NewText: "t.id, t.name",
+				},
+			},
+		},
+		"star in subquery": {
+			files: map[string]string{
+				"file1.sql": "SELECT id FROM (SELECT |* FROM `project.dataset.table`)",
+			},
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.ExpandStar(path, position)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("ExpandStar should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectEdits, got); diff != "" {
+				t.Errorf("project.ExpandStar result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kitagry/bqls/langserver/internal/cache"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// ExportSchemas writes the schemas of all tables referenced in the workspace into outputDir
+// with the offline catalog format(`{outputDir}/{project}/{dataset}/{table}.json`).
+// It returns the paths of the written files.
+func (p *Project) ExportSchemas(ctx context.Context, outputDir string) ([]string, error) {
+	srcs, err := p.workspaceSQLFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	tableNames := make(map[string]struct{})
+	for path, sql := range srcs {
+		parsedFile := p.parseFile(path, sql)
+		for _, name := range file.ReferencedTableNames(parsedFile.Node) {
+			tableNames[name] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(tableNames))
+	for name := range tableNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		// wildcard tables and INFORMATION_SCHEMA views don't have their own schema files.
+		if strings.Contains(name, "*") || strings.Contains(strings.ToUpper(name), "INFORMATION_SCHEMA") {
+			continue
+		}
+
+		metadata, err := p.analyzer.GetTableMetadataFromPath(ctx, name)
+		if err != nil {
+			p.logger.Debugf("failed to get table metadata(%s): %v", name, err)
+			continue
+		}
+
+		projectID, datasetID, tableID, ok := extractTableIDsFromMedatada(metadata)
+		if !ok {
+			continue
+		}
+
+		b, err := metadata.Schema.ToJSONFields()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert schema(%s): %w", name, err)
+		}
+
+		path := filepath.Join(outputDir, projectID, datasetID, tableID+".json")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write schema file: %w", err)
+		}
+		result = append(result, path)
+	}
+
+	return result, nil
+}
+
+// workspaceSQLFiles returns the snapshots of the .sql files under the rootPath.
+// Opened files are read from the cache because they may not be saved,
+// and the other files are read from disk only when they are modified after the last read.
+func (p *Project) workspaceSQLFiles() (map[string]*cache.SQL, error) {
+	if p.rootPath == "" {
+		return nil, fmt.Errorf("root path is not set")
+	}
+
+	result := make(map[string]*cache.SQL)
+	err := filepath.WalkDir(p.rootPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != p.rootPath && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".sql" {
+			return nil
+		}
+
+		if sql := p.cache.Get(path); sql != nil {
+			result[path] = sql
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sql, err := p.cache.GetFile(path, cache.FileStamp{ModTime: info.ModTime(), Size: info.Size()}, func() (string, error) {
+			b, err := os.ReadFile(path)
+			return string(b), err
+		})
+		if err != nil {
+			return err
+		}
+		result[path] = sql
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", p.rootPath, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package source_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_ExportSchemas(t *testing.T) {
+	rootPath := t.TempDir()
+	files := map[string]string{
+		"query.sql":        "WITH data AS (SELECT * FROM `project.dataset.table`)\nSELECT * FROM data",
+		"nested/query.sql": "SELECT * FROM `project.dataset.table`",
+		".hidden/skip.sql": "SELECT * FROM `project.dataset.hidden`",
+	}
+	for path, content := range files {
+		path = filepath.Join(rootPath, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	schema := bq.Schema{
+		{
+			Name:        "id",
+			Type:        bq.IntegerFieldType,
+			Description: "id description",
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+		FullID: "project:dataset.table",
+		Schema: schema,
+	}, nil).MinTimes(1)
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+	p := source.NewProjectWithBQClient(rootPath, bqClient, logger)
+
+	outputDir := filepath.Join(t.TempDir(), "schemas")
+	got, err := p.ExportSchemas(context.Background(), outputDir)
+	if err != nil {
+		t.Fatalf("failed to ExportSchemas: %v", err)
+	}
+
+	expectFile := filepath.Join(outputDir, "project", "dataset", "table.json")
+	if diff := cmp.Diff([]string{expectFile}, got); diff != "" {
+		t.Errorf("ExportSchemas result diff (-expect, +got)\n%s", diff)
+	}
+
+	b, err := os.ReadFile(expectFile)
+	if err != nil {
+		t.Fatalf("failed to read schema file: %v", err)
+	}
+	gotSchema, err := bq.SchemaFromJSON(b)
+	if err != nil {
+		t.Fatalf("failed to parse schema file: %v", err)
+	}
+	if diff := cmp.Diff(schema, gotSchema, cmpopts.IgnoreUnexported(bq.FieldSchema{})); diff != "" {
+		t.Errorf("schema file diff (-expect, +got)\n%s", diff)
+	}
+}
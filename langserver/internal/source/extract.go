@@ -0,0 +1,210 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// DefaultCTEName is used when the name of the extracted WITH query is not given.
+const DefaultCTEName = "subquery"
+
+// ExtractSubqueryToCTE returns the edits which lift the subquery in FROM clause containing rng into the WITH clause of the statement.
+// The subquery is replaced with the reference to the WITH query named name.
+func (p *Project) ExtractSubqueryToCTE(uri string, rng lsp.Range, name string) ([]lsp.TextEdit, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+	if parsedFile.Node == nil {
+		return nil, fmt.Errorf("failed to parse document %s", uri)
+	}
+
+	subquery, queryStmt, err := subqueryToExtract(parsedFile, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	queryRange, body, ok := subqueryBody(parsedFile, subquery.Subquery())
+	if !ok {
+		return nil, fmt.Errorf("failed to find the subquery body")
+	}
+
+	var entries []*ast.WithClauseEntryNode
+	if withClause := queryStmt.Query().WithClause(); withClause != nil {
+		entries = withClause.With()
+	}
+	if name == "" {
+		name = uniqueCTEName(entries)
+	}
+	definition := fmt.Sprintf("%s AS (\n%s\n)", name, indentBody(body, "  "))
+
+	insertEdit, ok := insertCTEEdit(parsedFile, queryStmt, entries, subquery, definition)
+	if !ok {
+		return nil, fmt.Errorf("failed to find the position to insert the WITH query")
+	}
+
+	return []lsp.TextEdit{
+		insertEdit,
+		{Range: queryRange, NewText: name},
+	}, nil
+}
+
+// subqueryToExtract finds the subquery in FROM clause containing rng and its SELECT statement.
+func subqueryToExtract(parsedFile file.ParsedFile, rng lsp.Range) (*ast.TableSubqueryNode, *ast.QueryStatementNode, error) {
+	subquery, ok := findSubqueryInRange(parsedFile, rng)
+	if !ok {
+		return nil, nil, fmt.Errorf("subquery is not found in the selection")
+	}
+
+	stmt, ok := parsedFile.FindTargetStatementNode(subquery.ParseLocationRange().Start().ByteOffset())
+	if !ok {
+		return nil, nil, fmt.Errorf("failed to find the statement of the subquery")
+	}
+	queryStmt, ok := stmt.(*ast.QueryStatementNode)
+	if !ok {
+		return nil, nil, fmt.Errorf("only the subquery in SELECT statement can be extracted")
+	}
+	return subquery, queryStmt, nil
+}
+
+// findSubqueryInRange finds the innermost subquery in FROM clause which contains rng.
+func findSubqueryInRange(parsedFile file.ParsedFile, rng lsp.Range) (*ast.TableSubqueryNode, bool) {
+	var result *ast.TableSubqueryNode
+	for _, node := range file.ListAstNode[*ast.TableSubqueryNode](parsedFile.Node) {
+		nodeRange, ok := parsedFile.PositionRange(node.ParseLocationRange())
+		if !ok || !rangeContains(nodeRange, rng) {
+			continue
+		}
+		// ListAstNode returns the parent before its children
+		result = node
+	}
+	return result, result != nil
+}
+
+// subqueryBody returns the range of the subquery including its parentheses and the query inside them.
+func subqueryBody(parsedFile file.ParsedFile, query *ast.QueryNode) (lsp.Range, string, bool) {
+	if query == nil {
+		return lsp.Range{}, "", false
+	}
+	queryRange, ok := parsedFile.PositionRange(query.ParseLocationRange())
+	if !ok {
+		return lsp.Range{}, "", false
+	}
+	start := parsedFile.SrcOffset(queryRange.Start)
+	end := parsedFile.SrcOffset(queryRange.End)
+	src := parsedFile.Src
+
+	// the location of the query may not contain the parentheses
+	if !strings.HasPrefix(src[start:end], "(") {
+		open := strings.LastIndex(strings.TrimRight(src[:start], " \t\n"), "(")
+		closing := strings.Index(src[end:], ")")
+		if open < 0 || closing < 0 || strings.TrimSpace(src[open+1:start]) != "" || strings.TrimSpace(src[end:end+closing]) != "" {
+			return lsp.Range{}, "", false
+		}
+		start, end = open, end+closing+1
+	}
+	body := strings.TrimSpace(src[start+1 : end-1])
+
+	startPos, ok := parsedFile.SrcPosition(start)
+	if !ok {
+		return lsp.Range{}, "", false
+	}
+	endPos, ok := parsedFile.SrcPosition(end)
+	if !ok {
+		return lsp.Range{}, "", false
+	}
+	return lsp.Range{Start: startPos, End: endPos}, body, true
+}
+
+// insertCTEEdit inserts the definition before the WITH query which contains the subquery, or after the last WITH query.
+// When the statement has no WITH clause, it is added at the beginning of the statement.
+func insertCTEEdit(parsedFile file.ParsedFile, stmt *ast.QueryStatementNode, entries []*ast.WithClauseEntryNode, subquery *ast.TableSubqueryNode, definition string) (lsp.TextEdit, bool) {
+	if len(entries) == 0 {
+		stmtRange, ok := parsedFile.PositionRange(stmt.ParseLocationRange())
+		if !ok {
+			return lsp.TextEdit{}, false
+		}
+		return lsp.TextEdit{
+			Range:   lsp.Range{Start: stmtRange.Start, End: stmtRange.Start},
+			NewText: "WITH " + definition + "\n",
+		}, true
+	}
+
+	subqueryRange, ok := parsedFile.PositionRange(subquery.ParseLocationRange())
+	if !ok {
+		return lsp.TextEdit{}, false
+	}
+	for _, entry := range entries {
+		entryRange, ok := parsedFile.PositionRange(entry.ParseLocationRange())
+		if !ok {
+			return lsp.TextEdit{}, false
+		}
+		if !rangeContains(entryRange, subqueryRange) {
+			continue
+		}
+		// keep the following WITH query at the same column
+		return lsp.TextEdit{
+			Range:   lsp.Range{Start: entryRange.Start, End: entryRange.Start},
+			NewText: definition + ",\n" + strings.Repeat(" ", entryRange.Start.Character),
+		}, true
+	}
+
+	lastRange, ok := parsedFile.PositionRange(entries[len(entries)-1].ParseLocationRange())
+	if !ok {
+		return lsp.TextEdit{}, false
+	}
+	return lsp.TextEdit{
+		Range:   lsp.Range{Start: lastRange.End, End: lastRange.End},
+		NewText: ",\n" + definition,
+	}, true
+}
+
+// uniqueCTEName returns DefaultCTEName with the suffix which doesn't conflict with the existing WITH queries.
+func uniqueCTEName(entries []*ast.WithClauseEntryNode) string {
+	names := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		names[strings.ToLower(entry.Alias().Name())] = struct{}{}
+	}
+
+	name := DefaultCTEName
+	for i := 2; ; i++ {
+		if _, ok := names[name]; !ok {
+			return name
+		}
+		name = fmt.Sprintf("%s_%d", DefaultCTEName, i)
+	}
+}
+
+// indentBody indents the query by indent keeping the relative indent of its lines.
+func indentBody(body string, indent string) string {
+	lines := strings.Split(body, "\n")
+
+	// the first line has no indent because it is trimmed
+	minIndent := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if minIndent < 0 || lineIndent < minIndent {
+			minIndent = lineIndent
+		}
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+			continue
+		}
+		if i > 0 && minIndent > 0 {
+			line = line[minIndent:]
+		}
+		lines[i] = indent + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,100 @@
+package source_test
+
+import (
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_ExtractSubqueryToCTE(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+		name  string
+
+		expectEdits []lsp.TextEdit
+		expectErr   bool
+	}{
+		"add WITH clause": {
+			files: map[string]string{
+				"file1.sql": "SELECT id FROM (SELECT id FROM `project.dataset.table` WHE|RE id > 0) AS t",
+			},
+			name: "positive",
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 0}, End: lsp.Position{Line: 0, Character: 0}},
+					NewText: "WITH positive AS (\n  SELECT id FROM `project.dataset.table` WHERE id > 0\n)\n",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 15}, End: lsp.Position{Line: 0, Character: 68}},
+					NewText: "positive",
+				},
+			},
+		},
+		"append to WITH clause with unique name": {
+			files: map[string]string{
+				"file1.sql": "WITH subquery AS (SELECT 1 AS id)\nSELECT id FROM (SELECT id FROM sub|query) AS t",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 33}, End: lsp.Position{Line: 0, Character: 33}},
+					NewText: ",\nsubquery_2 AS (\n  SELECT id FROM subquery\n)",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 1, Character: 15}, End: lsp.Position{Line: 1, Character: 40}},
+					NewText: "subquery_2",
+				},
+			},
+		},
+		"no subquery": {
+			files: map[string]string{
+				"file1.sql": "SELECT i|d FROM `project.dataset.table`",
+			},
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.ExtractSubqueryToCTE(path, lsp.Range{Start: position, End: position}, tt.name)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("ExtractSubqueryToCTE should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectEdits, got); diff != "" {
+				t.Errorf("project.ExtractSubqueryToCTE result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -3,14 +3,21 @@ package file
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	bq "cloud.google.com/go/bigquery"
 	"github.com/goccy/go-zetasql"
 	"github.com/goccy/go-zetasql/ast"
+	rast "github.com/goccy/go-zetasql/resolved_ast"
 	"github.com/goccy/go-zetasql/types"
 	"github.com/kitagry/bqls/langserver/internal/bigquery"
+	"github.com/kitagry/bqls/langserver/internal/cache"
 	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/metrics"
 	"github.com/kitagry/bqls/langserver/internal/source/helper"
 	"github.com/sirupsen/logrus"
 )
@@ -19,32 +26,102 @@ type Analyzer struct {
 	logger   *logrus.Logger
 	bqClient bigquery.Client
 	catalog  *Catalog
+
+	// options are replaced as a whole while the files are analyzed by the other goroutines.
+	options     atomic.Pointer[analyzerOptions]
+	optionsLock sync.Mutex
 }
 
+// analyzerOptions are the options which can be changed after the analyzer is created.
+// The value isn't modified after it is stored, so that an analysis reads the consistent options without the lock.
+type analyzerOptions struct {
+	language    LanguageOption
+	embeddedSQL EmbeddedSQLOption
+
+	// statements has the analyses of the statements of each document, which depend on the language option and the catalog.
+	statements *cache.LRU[string, cachedStatements]
+}
+
+// cachedStatements are the analyses of the statements in the last analysis of a document keyed by statementCacheKey.
+// Only the last analysis is kept, so that the cache doesn't grow while the document is edited.
+type cachedStatements map[string]*zetasql.AnalyzerOutput
+
 func NewAnalyzer(logger *logrus.Logger, bqClient bigquery.Client) *Analyzer {
 	catalog := NewCatalog(bqClient)
 
-	return &Analyzer{
+	a := &Analyzer{
 		logger:   logger,
 		bqClient: bqClient,
 		catalog:  catalog,
 	}
+	a.options.Store(&analyzerOptions{
+		language:    DefaultLanguageOption(),
+		embeddedSQL: DefaultEmbeddedSQLOption(),
+		statements:  cache.NewLRU[string, cachedStatements](maxCachedDocuments),
+	})
+	return a
+}
+
+// updateOptions replaces the options with the copy modified by update.
+func (a *Analyzer) updateOptions(update func(o *analyzerOptions)) {
+	a.optionsLock.Lock()
+	defer a.optionsLock.Unlock()
+
+	o := *a.options.Load()
+	update(&o)
+	a.options.Store(&o)
+}
+
+// SetLanguageOption replaces the language option of the analyzer. It can be called while the files are analyzed.
+// When the option is invalid like the unknown reserved keyword, the option isn't changed.
+func (a *Analyzer) SetLanguageOption(option LanguageOption) error {
+	if _, err := newLanguageOptions(option); err != nil {
+		return err
+	}
+	a.updateOptions(func(o *analyzerOptions) {
+		o.language = option
+		// The analyses of the statements depend on the language option.
+		o.statements = cache.NewLRU[string, cachedStatements](maxCachedDocuments)
+	})
+	return nil
+}
+
+// ClearStatementCache drops the analyses of the statements, e.g. after the schema of a table is changed.
+func (a *Analyzer) ClearStatementCache() {
+	a.updateOptions(func(o *analyzerOptions) {
+		o.statements = cache.NewLRU[string, cachedStatements](maxCachedDocuments)
+	})
+}
+
+// StatementCacheStats returns the statistics of the cache of the analyzed statements.
+func (a *Analyzer) StatementCacheStats() cache.Stats {
+	return a.options.Load().statements.Stats()
 }
 
 func (a *Analyzer) langOpt() (*zetasql.LanguageOptions, error) {
+	return newLanguageOptions(a.options.Load().language)
+}
+
+func newLanguageOptions(option LanguageOption) (*zetasql.LanguageOptions, error) {
 	langOpt := zetasql.NewLanguageOptions()
 	langOpt.SetNameResolutionMode(zetasql.NameResolutionDefault)
-	langOpt.SetProductMode(types.ProductExternal)
+	langOpt.SetProductMode(option.ProductMode)
 	langOpt.EnableMaximumLanguageFeatures()
-	langOpt.EnableLanguageFeature(zetasql.FeatureV13AllowDashesInTableName)
-	langOpt.EnableLanguageFeature(zetasql.FeatureV13Qualify)
-	langOpt.EnableLanguageFeature(zetasql.FeatureV13ScriptLabel)
-	langOpt.EnableLanguageFeature(zetasql.FeatureAnalyticFunctions)
+	for _, feature := range option.EnabledFeatures {
+		langOpt.EnableLanguageFeature(feature)
+	}
+	if len(option.DisabledFeatures) > 0 {
+		features := slices.DeleteFunc(langOpt.EnabledLanguageFeatures(), func(feature zetasql.LanguageFeature) bool {
+			return slices.Contains(option.DisabledFeatures, feature)
+		})
+		langOpt.SetEnabledLanguageFeatures(features)
+	}
 	langOpt.SetSupportsAllStatementKinds()
 	langOpt.EnableAllReservableKeywords(true)
-	err := langOpt.EnableReservableKeyword("QUALIFY", true)
-	if err != nil {
-		return nil, err
+	for _, keyword := range option.ReservedKeywords {
+		if err := langOpt.EnableReservableKeyword(strings.ToUpper(keyword), true); err != nil {
+			return nil, fmt.Errorf("failed to reserve keyword %s: %w", keyword, err)
+		}
 	}
 	return langOpt, nil
 }
@@ -57,6 +134,12 @@ func (a *Analyzer) AnalyzeStatement(rawText string, stmt ast.StatementNode, cata
 	opts := zetasql.NewAnalyzerOptions()
 	opts.SetLanguage(langOpt)
 	opts.SetAllowUndeclaredParameters(true)
+	// the parameters of the scheduled queries
+	for name, typ := range scheduledQueryParameters {
+		if err := opts.AddQueryParameter(name, typ); err != nil {
+			return nil, err
+		}
+	}
 	opts.SetErrorMessageMode(zetasql.ErrorMessageOneLine)
 	opts.SetParseLocationRecordType(zetasql.ParseLocationRecordCodeSearch)
 	return zetasql.AnalyzeStatementFromParserAST(rawText, stmt, catalog, opts)
@@ -72,11 +155,42 @@ func (a *Analyzer) parseScript(src string) (ast.ScriptNode, error) {
 	return zetasql.ParseScript(src, opts, zetasql.ErrorMessageOneLine)
 }
 
-func (a *Analyzer) ParseFile(uri string, src string) ParsedFile {
+// ParseFileWithoutAnalysis only parses the src and doesn't resolve the tables.
+// It is much cheaper than ParseFile, and used when only the syntax tree is needed.
+func (a *Analyzer) ParseFileWithoutAnalysis(uri string, src string) ParsedFile {
+	node, err := a.parseScript(src)
+	if err != nil {
+		return ParsedFile{URI: uri, Src: src, Errors: []Error{parseZetaSQLError(err)}}
+	}
+	return ParsedFile{URI: uri, Src: src, Node: node}
+}
+
+// ParseFile analyzes the src. The tables and the routines are fetched with ctx.
+func (a *Analyzer) ParseFile(ctx context.Context, uri string, src string) ParsedFile {
+	if language, ok := hostLanguageOf(uri); ok && a.options.Load().embeddedSQL.Enabled {
+		return a.parseHostFile(ctx, uri, src, language)
+	}
+	return a.parseSQLFile(ctx, uri, src)
+}
+
+func (a *Analyzer) parseSQLFile(ctx context.Context, uri string, src string) ParsedFile {
+	start := time.Now()
+	defer func() {
+		metrics.Default.ObserveAnalysis(time.Since(start))
+	}()
+
 	fixedSrc, errs, fixOffsets := fixDot(src)
+	// The cache is taken once, so that the analyses are not stored into the cache of the language option replaced meanwhile.
+	statements := a.options.Load().statements
+	previous, _ := statements.Get(uri)
+	current := make(cachedStatements)
+	if uri != "" {
+		defer statements.Put(uri, current)
+	}
 
 	var node ast.ScriptNode
-	rnode := make([]*zetasql.AnalyzerOutput, 0)
+	var catalog *Catalog
+	var rnode []*zetasql.AnalyzerOutput
 	for _retry := 0; _retry < 10; _retry++ {
 		var err error
 		var fo []FixOffset
@@ -95,6 +209,11 @@ func (a *Analyzer) ParseFile(uri string, src string) ParsedFile {
 				fixOffsets = append(fixOffsets, fo...)
 				continue
 			}
+			// While the statement is being edited, the other statements are still analyzed.
+			if recoveredSrc, ok := blankStatement(fixedSrc, positionToByteOffset(fixedSrc, pErr.Position)); ok {
+				fixedSrc = recoveredSrc
+				continue
+			}
 		}
 
 		stmts := make([]ast.StatementNode, 0)
@@ -108,7 +227,10 @@ func (a *Analyzer) ParseFile(uri string, src string) ParsedFile {
 			return nil
 		})
 
-		catalog := a.catalog.Clone()
+		catalog = a.catalog.Clone(ctx)
+		// rnode has an entry for each analyzed statement even when its analysis fails,
+		// so that an error of a statement doesn't shift the analyses of the following statements.
+		rnode = make([]*zetasql.AnalyzerOutput, 0, len(stmts))
 		declarationMap := make(map[string]string)
 		for _, s := range stmts {
 			if s.Kind() == ast.VariableDeclaration {
@@ -124,23 +246,60 @@ func (a *Analyzer) ParseFile(uri string, src string) ParsedFile {
 				continue
 			}
 
+			if s.Kind() == ast.ExecuteImmediateStatement {
+				// the SQL is analyzed as the embedded file after the analysis of the file.
+				continue
+			}
+
+			if s.Kind() == ast.CreateProcedureStatement {
+				node := s.(*ast.CreateProcedureStatementNode)
+				// the parameters are referred as the script variables in the body.
+				for _, parameter := range node.Parameters().ParameterEntries() {
+					dummyValue, err := getDummyValueForTypeNode(parameter.Type())
+					if err != nil {
+						a.logger.Debug("failed to get default value for parameter", err)
+						continue
+					}
+					declarationMap[parameter.Name().Name()] = dummyValue
+				}
+				if procedure, ok := a.createProcedure(node); ok {
+					catalog.AddProcedureWithName(pathName(node.Name()), procedure)
+				}
+			}
+
 			if s.Kind() == ast.CreateFunctionStatement {
 				node := s.(*ast.CreateFunctionStatementNode)
-				newFunc, err := a.createFunctionTypes(node, fixedSrc)
+				newFunc, err := a.createFunctionTypes(node, fixedSrc, catalog)
 				if err != nil {
 					errs = append(errs, *err)
+					rnode = append(rnode, nil)
 					continue
 				}
 
-				name := ""
-				for _, n := range node.FunctionDeclaration().Name().Names() {
-					name += n.Name()
+				if newFunc != nil {
+					name := ""
+					for _, n := range node.FunctionDeclaration().Name().Names() {
+						name += n.Name()
+					}
+					catalog.AddFunctionWithName(name, newFunc)
+				}
+			}
+
+			var cacheKey string
+			if loc := s.ParseLocationRange(); loc != nil {
+				cacheKey = statementCacheKey(fixedSrc, loc.End().ByteOffset())
+				if output, ok := previous[cacheKey]; ok {
+					current[cacheKey] = output
+					rnode = append(rnode, output)
+					continue
 				}
-				catalog.AddFunctionWithName(name, newFunc)
 			}
 
 			output, err := a.AnalyzeStatement(fixedSrc, s, catalog)
 			if err == nil {
+				if cacheKey != "" {
+					current[cacheKey] = output
+				}
 				rnode = append(rnode, output)
 				continue
 			}
@@ -151,6 +310,7 @@ func (a *Analyzer) ParseFile(uri string, src string) ParsedFile {
 			isNotExistInStructError := strings.Contains(pErr.Msg, "does not exist in STRUCT")
 			isNotFoundInsideError := strings.Contains(pErr.Msg, "not found inside")
 			isTableNotFoundError := strings.Contains(pErr.Msg, "Table not found: ")
+			isTVFNotFoundError := strings.Contains(pErr.Msg, "Table-valued function not found: ")
 
 			// add information to Error
 			switch {
@@ -166,6 +326,13 @@ func (a *Analyzer) ParseFile(uri string, src string) ParsedFile {
 				pErr.TermLength = len(table)
 				pErr.IncompleteColumnName = table
 			}
+			if isArrayScalarError(pErr.Msg) {
+				pErr.Code = ArrayScalarCode
+			}
+			if isJSONComparisonError(pErr.Msg) {
+				pErr.Msg += "; JSON can't be compared directly, so convert it with JSON_VALUE, JSON_QUERY or LAX_STRING"
+				pErr.Code = JSONComparisonCode
+			}
 
 			// fix src
 			skipError := false
@@ -189,6 +356,13 @@ func (a *Analyzer) ParseFile(uri string, src string) ParsedFile {
 				}
 			}
 
+			if isTVFNotFoundError {
+				fixedSrc, fo = fixRoutineTableValuedFunction(fixedSrc, s, pErr, catalog)
+				if len(fo) > 0 {
+					skipError = true
+				}
+			}
+
 			if !skipError {
 				errs = append(errs, pErr)
 			}
@@ -196,23 +370,37 @@ func (a *Analyzer) ParseFile(uri string, src string) ParsedFile {
 				fixOffsets = append(fixOffsets, fo...)
 				goto retry
 			}
+			rnode = append(rnode, nil)
 		}
 		break
 	retry:
 	}
 
-	return ParsedFile{
+	if catalog != nil {
+		errs = append(errs, externalTableErrors(fixedSrc, node, catalog)...)
+	}
+	errs = append(errs, implicitCoercionErrors(fixedSrc, rnode)...)
+	errs = append(errs, duplicateColumnErrors(fixedSrc, node)...)
+
+	result := ParsedFile{
 		URI:        uri,
 		Src:        src,
 		Node:       node,
 		RNode:      rnode,
 		FixOffsets: fixOffsets,
-		Errors:     errs,
 	}
+	result.EmbeddedFiles = a.executeImmediateFiles(ctx, result)
+	result.Errors = append(errs, embeddedFileErrors(src, result.EmbeddedFiles)...)
+	return result
 }
 
 func (a *Analyzer) GetTableMetadataFromPath(ctx context.Context, path string) (*bq.TableMetadata, error) {
+	if metadata, ok := informationSchemaTableMetadata(path); ok {
+		return metadata, nil
+	}
+
 	splitNode := strings.Split(path, ".")
+	splitNode[len(splitNode)-1] = trimPartitionDecorator(splitNode[len(splitNode)-1])
 
 	// validate id
 	for _, id := range splitNode {
@@ -231,7 +419,32 @@ func (a *Analyzer) GetTableMetadataFromPath(ctx context.Context, path string) (*
 	}
 }
 
-func (p *Analyzer) createFunctionTypes(node *ast.CreateFunctionStatementNode, sourceFile string) (*types.Function, *Error) {
+func (a *Analyzer) GetRoutineMetadataFromPath(ctx context.Context, path string) (*bq.RoutineMetadata, error) {
+	splitNode := strings.Split(path, ".")
+
+	// validate id
+	for _, id := range splitNode {
+		if id == "" {
+			return nil, fmt.Errorf("invalid path: %s", path)
+		}
+	}
+
+	switch len(splitNode) {
+	case 3:
+		return a.bqClient.GetRoutineMetadata(ctx, splitNode[0], splitNode[1], splitNode[2])
+	case 2:
+		return a.bqClient.GetRoutineMetadata(ctx, a.bqClient.GetDefaultProject(), splitNode[0], splitNode[1])
+	default:
+		return nil, fmt.Errorf("invalid path: %s", path)
+	}
+}
+
+func (p *Analyzer) createFunctionTypes(node *ast.CreateFunctionStatementNode, sourceFile string, catalog types.Catalog) (*types.Function, *Error) {
+	name := ""
+	for _, n := range node.FunctionDeclaration().Name().Names() {
+		name += n.Name()
+	}
+
 	argTypes := []*types.FunctionArgumentType{}
 	for _, parameter := range node.FunctionDeclaration().Parameters().ParameterEntries() {
 		typ, err := getTypeFromTypeNode(parameter.Type())
@@ -255,30 +468,97 @@ func (p *Analyzer) createFunctionTypes(node *ast.CreateFunctionStatementNode, so
 			return nil, nil
 		}
 	} else {
-		err := Error{
+		// When the return type is omitted, let the analyzer infer it from the function body.
+		output, err := p.AnalyzeStatement(sourceFile, node, catalog)
+		if err == nil {
+			if stmt, ok := output.Statement().(*rast.CreateFunctionStmtNode); ok {
+				return types.NewFunction([]string{name}, "", types.ScalarMode, []*types.FunctionSignature{stmt.Signature()}), nil
+			}
+		}
+
+		pErr := Error{
 			Msg:      "Currently, bqls does not support function without return type.",
 			Severity: lsp.Warning,
 		}
 		loc := node.ParseLocationRange()
 		if loc != nil {
-			err.Position = helper.IndexToPosition(sourceFile, loc.Start().ByteOffset())
-			err.TermLength = loc.End().ByteOffset() - loc.Start().ByteOffset()
+			pErr.Position = helper.IndexToPosition(sourceFile, loc.Start().ByteOffset())
+			pErr.TermLength = loc.End().ByteOffset() - loc.Start().ByteOffset()
 		}
-		return nil, &err
+		return nil, &pErr
 	}
 	opt := types.NewFunctionArgumentTypeOptions(types.RequiredArgumentCardinality)
 	retType := types.NewFunctionArgumentType(typ, opt)
 
 	sig := types.NewFunctionSignature(retType, argTypes)
 
-	name := ""
-	for _, n := range node.FunctionDeclaration().Name().Names() {
-		name += n.Name()
-	}
 	newFunc := types.NewFunction([]string{name}, "", types.ScalarMode, []*types.FunctionSignature{sig})
 	return newFunc, nil
 }
 
+// createProcedure creates the procedure of CREATE PROCEDURE, so that CALL in the script resolves it.
+func (a *Analyzer) createProcedure(node *ast.CreateProcedureStatementNode) (*types.Procedure, bool) {
+	argTypes := []*types.FunctionArgumentType{}
+	for _, parameter := range node.Parameters().ParameterEntries() {
+		typ, err := getTypeFromTypeNode(parameter.Type())
+		if err != nil {
+			a.logger.Debug("failed to get type from parameter ", err)
+			return nil, false
+		}
+		opt := types.NewFunctionArgumentTypeOptions(types.RequiredArgumentCardinality)
+		opt.SetArgumentName(parameter.Name().Name())
+		argTypes = append(argTypes, types.NewFunctionArgumentType(typ, opt))
+	}
+	return newProcedure(pathName(node.Name()), argTypes), true
+}
+
+// externalTableErrors reports the external tables as information,
+// because the query cost is based on the data read from the external source.
+func externalTableErrors(src string, node ast.ScriptNode, catalog *Catalog) []Error {
+	result := make([]Error, 0)
+	ast.Walk(node, func(n ast.Node) error {
+		tablePath, ok := n.(*ast.TablePathExpressionNode)
+		if !ok {
+			return nil
+		}
+
+		name, ok := CreateTableNameFromTablePathExpressionNode(tablePath)
+		if !ok {
+			return nil
+		}
+
+		metadata, ok := catalog.findTableMetadata(name)
+		if !ok || metadata.ExternalDataConfig == nil {
+			return nil
+		}
+
+		pErr := Error{
+			Msg:      fmt.Sprintf("%s is an external table. The query cost is based on the data read from %s.", name, externalDataSourceName(metadata.ExternalDataConfig)),
+			Severity: lsp.Information,
+		}
+		loc := tablePath.ParseLocationRange()
+		if loc != nil {
+			pErr.Position = helper.IndexToPosition(src, loc.Start().ByteOffset())
+			pErr.TermLength = loc.End().ByteOffset() - loc.Start().ByteOffset()
+		}
+		result = append(result, pErr)
+		return nil
+	})
+	return result
+}
+
+func externalDataSourceName(config *bq.ExternalDataConfig) string {
+	for _, uri := range config.SourceURIs {
+		switch {
+		case strings.HasPrefix(uri, "gs://"):
+			return "GCS"
+		case strings.HasPrefix(uri, "https://drive.google.com"):
+			return "Google Drive"
+		}
+	}
+	return "the external source"
+}
+
 func getDummyValueForDeclarationNode(node *ast.VariableDeclarationNode) (string, error) {
 	switch n := node.Type().(type) {
 	case *ast.ArrayTypeNode:
@@ -308,6 +588,21 @@ func getDummyValueForDeclarationNode(node *ast.VariableDeclarationNode) (string,
 	}
 }
 
+// getDummyValueForTypeNode returns the dummy value of the parameter which has the explicit type.
+func getDummyValueForTypeNode(node ast.TypeNode) (string, error) {
+	switch n := node.(type) {
+	case *ast.ArrayTypeNode:
+		return "[]", nil
+	case *ast.SimpleTypeNode:
+		if pen, ok := n.Child(0).(*ast.PathExpressionNode); ok {
+			if in, ok := pen.Child(0).(*ast.IdentifierNode); ok {
+				return getDummyValueForDeclarationIdentifierName(in.Name())
+			}
+		}
+	}
+	return "", fmt.Errorf("not implemented: %T", node)
+}
+
 func getDummyValueForDeclarationIdentifierName(name string) (string, error) {
 	switch name {
 	case "BOOL":
@@ -359,39 +654,42 @@ func getTypeFromTypeNode(node ast.TypeNode) (types.Type, error) {
 		for _, n := range names {
 			typeName += n.Name()
 		}
-
-		switch typeName {
-		case "INT64":
-			return types.Int64Type(), nil
-		case "FLOAT64":
-			return types.FloatType(), nil
-		case "BOOL":
-			return types.BoolType(), nil
-		case "STRING":
-			return types.StringType(), nil
-		case "BYTES":
-			return types.BytesType(), nil
-		case "DATE":
-			return types.DateType(), nil
-		case "DATETIME":
-			return types.DatetimeType(), nil
-		case "TIME":
-			return types.TimeType(), nil
-		case "TIMESTAMP":
-			return types.TimestampType(), nil
-		case "NUMERIC":
-			return types.NumericType(), nil
-		case "BIGNUMERIC":
-			return types.BigNumericType(), nil
-		case "GEOGRAPHY":
-			return types.GeographyType(), nil
-		case "INTERVAL":
-			return types.IntervalType(), nil
-		case "JSON":
-			return types.JsonType(), nil
-		default:
-			return nil, fmt.Errorf("not implemented: %s", typeName)
-		}
+		return typeNameToZetaSQLType(typeName)
 	}
 	return nil, fmt.Errorf("not implemented: %T", node)
 }
+
+func typeNameToZetaSQLType(typeName string) (types.Type, error) {
+	switch typeName {
+	case "INT64":
+		return types.Int64Type(), nil
+	case "FLOAT64":
+		return types.FloatType(), nil
+	case "BOOL":
+		return types.BoolType(), nil
+	case "STRING":
+		return types.StringType(), nil
+	case "BYTES":
+		return types.BytesType(), nil
+	case "DATE":
+		return types.DateType(), nil
+	case "DATETIME":
+		return types.DatetimeType(), nil
+	case "TIME":
+		return types.TimeType(), nil
+	case "TIMESTAMP":
+		return types.TimestampType(), nil
+	case "NUMERIC", "DECIMAL":
+		return types.NumericType(), nil
+	case "BIGNUMERIC", "BIGDECIMAL":
+		return types.BigNumericType(), nil
+	case "GEOGRAPHY":
+		return types.GeographyType(), nil
+	case "INTERVAL":
+		return types.IntervalType(), nil
+	case "JSON":
+		return types.JsonType(), nil
+	default:
+		return nil, fmt.Errorf("not implemented: %s", typeName)
+	}
+}
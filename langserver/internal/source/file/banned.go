@@ -0,0 +1,90 @@
+package file
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// BannedFunction is the function which the team doesn't want to use.
+type BannedFunction struct {
+	// Name is the name of the function like `CURRENT_TIMESTAMP` or `NET.HOST`. It is compared case insensitively.
+	Name string
+	// Pattern limits the reported calls to the ones whose SQL matches it. When it is nil, all the calls are reported.
+	Pattern *regexp.Regexp
+	// Message is the reason why the function is banned. When it is empty, the default message is used.
+	Message  string
+	Severity lsp.DiagnosticSeverity
+}
+
+// BannedFunctionErrors reports the calls of the banned functions.
+// The functions which can be called without parentheses like `CURRENT_TIMESTAMP` are also reported.
+func (p ParsedFile) BannedFunctionErrors(functions []BannedFunction) []Error {
+	result := make([]Error, 0)
+	if p.Node == nil || len(functions) == 0 {
+		return result
+	}
+	ast.Walk(p.Node, func(n ast.Node) error {
+		var name string
+		switch n := n.(type) {
+		case *ast.FunctionCallNode:
+			name = pathName(n.Function())
+		case *ast.PathExpressionNode:
+			if _, ok := n.Parent().(*ast.FunctionCallNode); ok || len(n.Names()) != 1 {
+				return nil
+			}
+			name = n.Names()[0].Name()
+			if !strings.HasPrefix(strings.ToUpper(name), "CURRENT_") {
+				return nil
+			}
+		default:
+			return nil
+		}
+
+		text, ok := p.ExtractSQL(n.ParseLocationRange())
+		if !ok {
+			return nil
+		}
+		for _, f := range functions {
+			if !strings.EqualFold(f.Name, name) {
+				continue
+			}
+			if f.Pattern != nil && !f.Pattern.MatchString(text) {
+				continue
+			}
+
+			rng, ok := p.PositionRange(n.ParseLocationRange())
+			if !ok {
+				return nil
+			}
+			msg := f.Message
+			if msg == "" {
+				msg = fmt.Sprintf("%s is banned in this project", strings.ToUpper(f.Name))
+			}
+			pErr := Error{
+				Msg:      msg,
+				Position: rng.Start,
+				Severity: f.Severity,
+				Code:     "banned-function",
+			}
+			if rng.Start.Line == rng.End.Line {
+				pErr.TermLength = rng.End.Character - rng.Start.Character
+			}
+			result = append(result, pErr)
+			return nil
+		}
+		return nil
+	})
+	return result
+}
+
+func pathName(path *ast.PathExpressionNode) string {
+	names := make([]string, 0, len(path.Names()))
+	for _, n := range path.Names() {
+		names = append(names, n.Name())
+	}
+	return strings.Join(names, ".")
+}
@@ -0,0 +1,100 @@
+package file_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParsedFile_BannedFunctionErrors(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectErrs []file.Error
+	}{
+		"function call": {
+			file: "SELECT id FROM `project.dataset.table` WHERE DATE(ts) = current_date()",
+			expectErrs: []file.Error{
+				{
+					Msg:        "Use @run_date",
+					Position:   lsp.Position{Line: 0, Character: 56},
+					TermLength: 14,
+					Severity:   lsp.Error,
+					Code:       "banned-function",
+				},
+			},
+		},
+		"function without parentheses": {
+			file: "SELECT CURRENT_TIMESTAMP AS ts",
+			expectErrs: []file.Error{
+				{
+					Msg:        "CURRENT_TIMESTAMP is banned in this project",
+					Position:   lsp.Position{Line: 0, Character: 7},
+					TermLength: 17,
+					Severity:   lsp.Warning,
+					Code:       "banned-function",
+				},
+			},
+		},
+		"pattern": {
+			file: "SELECT FORMAT_DATE('%Y-%m-%d', DATE(ts)), FORMAT_DATE('%Y%m', DATE(ts)) FROM `project.dataset.table`",
+			expectErrs: []file.Error{
+				{
+					Msg:        "FORMAT_DATE is banned in this project",
+					Position:   lsp.Position{Line: 0, Character: 7},
+					TermLength: 33,
+					Severity:   lsp.Warning,
+					Code:       "banned-function",
+				},
+			},
+		},
+		"column is not reported": {
+			file:       "SELECT name FROM `project.dataset.table`",
+			expectErrs: []file.Error{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+					{
+						Name: "ts",
+						Type: bq.TimestampFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+
+			got := parsedFile.BannedFunctionErrors([]file.BannedFunction{
+				{Name: "CURRENT_DATE", Message: "Use @run_date", Severity: lsp.Error},
+				{Name: "CURRENT_TIMESTAMP", Severity: lsp.Warning},
+				{Name: "FORMAT_DATE", Pattern: regexp.MustCompile(`'%Y-%m-%d'`), Severity: lsp.Warning},
+				{Name: "NAME", Severity: lsp.Warning},
+			})
+			if diff := cmp.Diff(tt.expectErrs, got); diff != "" {
+				t.Errorf("BannedFunctionErrors result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
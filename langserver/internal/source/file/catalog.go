@@ -17,10 +17,18 @@ const (
 )
 
 type Catalog struct {
-	catalog      *types.SimpleCatalog
-	bqClient     bigquery.Client
-	tableMetaMap map[string]*bq.TableMetadata
-	mu           *sync.Mutex
+	// ctx is the context of the analysis which uses the catalog, because the analyzer looks up the tables and the routines without it.
+	ctx            context.Context
+	catalog        *types.SimpleCatalog
+	bqClient       bigquery.Client
+	tableMetaMap   map[string]*bq.TableMetadata
+	routineMetaMap map[string]*bq.RoutineMetadata
+	functions      map[string]*types.Function
+	procedures     map[string]*types.Procedure
+	mu             *sync.Mutex
+
+	// routineErrors has the errors of the routines which failed to be fetched, so that they are not fetched again.
+	routineErrors map[string]error
 }
 
 var _ types.Catalog = (*Catalog)(nil)
@@ -29,21 +37,32 @@ func NewCatalog(bqClient bigquery.Client) *Catalog {
 	catalog := types.NewSimpleCatalog(catalogName)
 	catalog.AddZetaSQLBuiltinFunctions(nil)
 	return &Catalog{
-		catalog:      catalog,
-		bqClient:     bqClient,
-		tableMetaMap: make(map[string]*bq.TableMetadata),
-		mu:           &sync.Mutex{},
+		ctx:            context.Background(),
+		catalog:        catalog,
+		bqClient:       bqClient,
+		tableMetaMap:   make(map[string]*bq.TableMetadata),
+		routineMetaMap: make(map[string]*bq.RoutineMetadata),
+		routineErrors:  make(map[string]error),
+		functions:      make(map[string]*types.Function),
+		procedures:     make(map[string]*types.Procedure),
+		mu:             &sync.Mutex{},
 	}
 }
 
-func (c *Catalog) Clone() *Catalog {
+// Clone returns the empty catalog which fetches the tables and the routines with ctx.
+func (c *Catalog) Clone(ctx context.Context) *Catalog {
 	catalog := types.NewSimpleCatalog(catalogName)
 	catalog.AddZetaSQLBuiltinFunctions(nil)
 	return &Catalog{
-		catalog:      catalog,
-		bqClient:     c.bqClient,
-		tableMetaMap: make(map[string]*bq.TableMetadata),
-		mu:           &sync.Mutex{},
+		ctx:            ctx,
+		catalog:        catalog,
+		bqClient:       c.bqClient,
+		tableMetaMap:   make(map[string]*bq.TableMetadata),
+		routineMetaMap: make(map[string]*bq.RoutineMetadata),
+		routineErrors:  make(map[string]error),
+		functions:      make(map[string]*types.Function),
+		procedures:     make(map[string]*types.Procedure),
+		mu:             &sync.Mutex{},
 	}
 }
 
@@ -52,6 +71,10 @@ func (c *Catalog) FullName() string {
 }
 
 func (c *Catalog) FindTable(path []string) (types.Table, error) {
+	if routine, ok := RoutineOfTable(strings.Join(path, ".")); ok {
+		return c.findRoutineTable(routine)
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -70,18 +93,9 @@ func (c *Catalog) FindTable(path []string) (types.Table, error) {
 }
 
 func (c *Catalog) addTable(path []string) error {
-	tableSep := strings.Split(strings.Join(path, "."), ".")
-	var metadata *bq.TableMetadata
-	var err error
-	if len(tableSep) == 3 {
-		metadata, err = c.bqClient.GetTableMetadata(context.Background(), tableSep[0], tableSep[1], tableSep[2])
-	} else if len(tableSep) == 2 {
-		metadata, err = c.bqClient.GetTableMetadata(context.Background(), c.bqClient.GetDefaultProject(), tableSep[0], tableSep[1])
-	} else {
-		return fmt.Errorf("unknown table: %s", strings.Join(path, "."))
-	}
+	metadata, err := c.getTableMetadata(path)
 	if err != nil {
-		return fmt.Errorf("failed to get schema: %w", err)
+		return err
 	}
 
 	tableName := strings.Join(path, ".")
@@ -89,15 +103,19 @@ func (c *Catalog) addTable(path []string) error {
 	schema := metadata.Schema
 	columns := make([]types.Column, len(schema))
 	for i, field := range schema {
-		typ, err := bigqueryTypeToZetaSQLType(field.Type, field.Repeated, field.Schema)
+		typ, err := bigqueryTypeToZetaSQLType(field)
 		if err != nil {
 			return fmt.Errorf("failed to convert type(%s): %w", field.Name, err)
 		}
 		columns[i] = types.NewSimpleColumn(tableName, field.Name, typ)
 	}
 
-	if metadata.TimePartitioning != nil {
+	// ingestion-time partitioned tables have the pseudo columns instead of the partitioning column.
+	if tp := metadata.TimePartitioning; tp != nil && tp.Field == "" {
 		columns = append(columns, types.NewSimpleColumn(tableName, "_PARTITIONTIME", types.TimestampType()))
+		if tp.Type == "" || tp.Type == bq.DayPartitioningType {
+			columns = append(columns, types.NewSimpleColumn(tableName, "_PARTITIONDATE", types.DateType()))
+		}
 	}
 
 	if isWildCardTable(path) {
@@ -110,13 +128,44 @@ func (c *Catalog) addTable(path []string) error {
 	return nil
 }
 
-func bigqueryTypeToZetaSQLType(typ bq.FieldType, isRepeated bool, schema bq.Schema) (types.Type, error) {
-	result, err := literalBigqueryTypeToZetaSQLType(typ, schema)
+// findTableMetadata returns the metadata of the table which has already been added to the catalog.
+func (c *Catalog) findTableMetadata(name string) (*bq.TableMetadata, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	metadata, ok := c.tableMetaMap[name]
+	return metadata, ok
+}
+
+func (c *Catalog) getTableMetadata(path []string) (*bq.TableMetadata, error) {
+	if metadata, ok := informationSchemaTableMetadata(strings.Join(path, ".")); ok {
+		return metadata, nil
+	}
+
+	tableSep := strings.Split(strings.Join(path, "."), ".")
+	tableSep[len(tableSep)-1] = trimPartitionDecorator(tableSep[len(tableSep)-1])
+	var metadata *bq.TableMetadata
+	var err error
+	if len(tableSep) == 3 {
+		metadata, err = c.bqClient.GetTableMetadata(c.ctx, tableSep[0], tableSep[1], tableSep[2])
+	} else if len(tableSep) == 2 {
+		metadata, err = c.bqClient.GetTableMetadata(c.ctx, c.bqClient.GetDefaultProject(), tableSep[0], tableSep[1])
+	} else {
+		return nil, fmt.Errorf("unknown table: %s", strings.Join(path, "."))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema: %w", err)
+	}
+	return metadata, nil
+}
+
+func bigqueryTypeToZetaSQLType(field *bq.FieldSchema) (types.Type, error) {
+	result, err := literalBigqueryTypeToZetaSQLType(field)
 	if err != nil {
 		return nil, err
 	}
 
-	if isRepeated {
+	if field.Repeated {
 		result, err = types.NewArrayType(result)
 		if err != nil {
 			return nil, fmt.Errorf("Failed to create array type: %w", err)
@@ -127,8 +176,8 @@ func bigqueryTypeToZetaSQLType(typ bq.FieldType, isRepeated bool, schema bq.Sche
 	return result, nil
 }
 
-func literalBigqueryTypeToZetaSQLType(typ bq.FieldType, schema bq.Schema) (types.Type, error) {
-	switch typ {
+func literalBigqueryTypeToZetaSQLType(field *bq.FieldSchema) (types.Type, error) {
+	switch field.Type {
 	case bq.StringFieldType:
 		return types.StringType(), nil
 	case bq.IntegerFieldType:
@@ -154,13 +203,13 @@ func literalBigqueryTypeToZetaSQLType(typ bq.FieldType, schema bq.Schema) (types
 	case bq.BigNumericFieldType:
 		return types.BigNumericType(), nil
 	case bq.RecordFieldType:
-		fields := make([]*types.StructField, len(schema))
-		for i, field := range schema {
-			typ, err := bigqueryTypeToZetaSQLType(field.Type, field.Repeated, field.Schema)
+		fields := make([]*types.StructField, len(field.Schema))
+		for i, f := range field.Schema {
+			typ, err := bigqueryTypeToZetaSQLType(f)
 			if err != nil {
-				return nil, fmt.Errorf("failed to convert type(%s): %w", field.Name, err)
+				return nil, fmt.Errorf("failed to convert type(%s): %w", f.Name, err)
 			}
-			fields[i] = types.NewStructField(field.Name, typ)
+			fields[i] = types.NewStructField(f.Name, typ)
 		}
 		st, err := types.NewStructType(fields)
 		if err != nil {
@@ -171,9 +220,63 @@ func literalBigqueryTypeToZetaSQLType(typ bq.FieldType, schema bq.Schema) (types
 		return types.IntervalType(), nil
 	case bq.JSONFieldType:
 		return types.JsonType(), nil
+	case bq.RangeFieldType:
+		return rangeTypeToZetaSQLType(field.RangeElementType)
 	default:
-		return nil, fmt.Errorf("unsupported type: %v", typ)
+		return nil, fmt.Errorf("unsupported type: %v", field.Type)
+	}
+}
+
+// rangeTypeToZetaSQLType approximates RANGE<T> with STRUCT<start T, end T>, because the analyzer doesn't support RANGE type.
+// This keeps the table which has RANGE columns available, and the bounds can be referred as the fields.
+func rangeTypeToZetaSQLType(elem *bq.RangeElementType) (types.Type, error) {
+	if elem == nil {
+		return nil, fmt.Errorf("range element type is not specified")
 	}
+	typ, err := literalBigqueryTypeToZetaSQLType(&bq.FieldSchema{Type: elem.Type})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert range element type: %w", err)
+	}
+	return types.NewStructType([]*types.StructField{
+		types.NewStructField("start", typ),
+		types.NewStructField("end", typ),
+	})
+}
+
+// FieldTypeString returns the type of the field like `RANGE<DATE>` or `NUMERIC(10, 2)`.
+func FieldTypeString(field *bq.FieldSchema) string {
+	switch field.Type {
+	case bq.RangeFieldType:
+		if field.RangeElementType != nil {
+			return fmt.Sprintf("RANGE<%s>", field.RangeElementType.Type)
+		}
+	case bq.NumericFieldType, bq.BigNumericFieldType:
+		if field.Precision > 0 {
+			return fmt.Sprintf("%s(%d, %d)", field.Type, field.Precision, field.Scale)
+		}
+	case bq.StringFieldType, bq.BytesFieldType:
+		if field.MaxLength > 0 {
+			return fmt.Sprintf("%s(%d)", field.Type, field.MaxLength)
+		}
+	}
+	return string(field.Type)
+}
+
+// trimPartitionDecorator removes the partition decorator like `table$20240101`.
+func trimPartitionDecorator(tableID string) string {
+	if i := strings.Index(tableID, "$"); i >= 0 {
+		return tableID[:i]
+	}
+	return tableID
+}
+
+// IsPseudoColumn reports whether the column is the pseudo column of BigQuery, which is not included in the table schema.
+func IsPseudoColumn(name string) bool {
+	switch strings.ToUpper(name) {
+	case "_PARTITIONTIME", "_PARTITIONDATE", "_TABLE_SUFFIX", "_FILE_NAME":
+		return true
+	}
+	return false
 }
 
 func isWildCardTable(path []string) bool {
@@ -195,15 +298,206 @@ func (c *Catalog) AddFunctionWithName(name string, fn *types.Function) {
 }
 
 func (c *Catalog) FindFunction(path []string) (*types.Function, error) {
-	return c.catalog.FindFunction(path)
+	fn, err := c.catalog.FindFunction(path)
+	if err == nil {
+		return fn, nil
+	}
+
+	name := strings.Join(path, ".")
+	c.mu.Lock()
+	fn, ok := c.functions[name]
+	c.mu.Unlock()
+	if ok {
+		return fn, nil
+	}
+
+	metadata, rErr := c.getRoutineMetadata(c.ctx, path)
+	if rErr != nil {
+		return nil, errors.Join(err, rErr)
+	}
+	if metadata.Type != "SCALAR_FUNCTION" {
+		return nil, err
+	}
+
+	fn, rErr = newRoutineFunction(name, metadata)
+	if rErr != nil {
+		return nil, errors.Join(err, rErr)
+	}
+	c.mu.Lock()
+	c.functions[name] = fn
+	c.mu.Unlock()
+	return fn, nil
 }
 
+// FindTableValuedFunction finds the table functions created in the catalog.
+// The calls of the persistent table functions are analyzed as the tables of RoutineTableName.
 func (c *Catalog) FindTableValuedFunction(path []string) (types.TableValuedFunction, error) {
 	return c.catalog.FindTableValuedFunction(path)
 }
 
+// getRoutineMetadata fetches the persistent routine from the BigQuery routines catalog.
+// path should be `dataset.routine` or `project.dataset.routine`.
+// The lock is not held while the API is called, so that the lookups of the other names are not blocked.
+func (c *Catalog) getRoutineMetadata(ctx context.Context, path []string) (*bq.RoutineMetadata, error) {
+	name := strings.Join(path, ".")
+	c.mu.Lock()
+	metadata, ok := c.routineMetaMap[name]
+	routineErr, failed := c.routineErrors[name]
+	c.mu.Unlock()
+	if ok {
+		return metadata, nil
+	}
+	if failed {
+		return nil, routineErr
+	}
+
+	routineSep := strings.Split(name, ".")
+	var err error
+	if len(routineSep) == 3 {
+		metadata, err = c.bqClient.GetRoutineMetadata(ctx, routineSep[0], routineSep[1], routineSep[2])
+	} else if len(routineSep) == 2 {
+		metadata, err = c.bqClient.GetRoutineMetadata(ctx, c.bqClient.GetDefaultProject(), routineSep[0], routineSep[1])
+	} else {
+		return nil, fmt.Errorf("unknown routine: %s", name)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		err = fmt.Errorf("failed to get routine: %w", err)
+		c.routineErrors[name] = err
+		return nil, err
+	}
+	c.routineMetaMap[name] = metadata
+	return metadata, nil
+}
+
+func newRoutineFunction(name string, metadata *bq.RoutineMetadata) (*types.Function, error) {
+	argTypes, err := routineArgumentTypes(metadata.Arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata.ReturnType == nil {
+		return nil, fmt.Errorf("routine %s doesn't have an explicit return type", name)
+	}
+	typ, err := standardSQLDataTypeToZetaSQLType(metadata.ReturnType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert return type: %w", err)
+	}
+	opt := types.NewFunctionArgumentTypeOptions(types.RequiredArgumentCardinality)
+	retType := types.NewFunctionArgumentType(typ, opt)
+
+	sig := types.NewFunctionSignature(retType, argTypes)
+	return types.NewFunction([]string{name}, "", types.ScalarMode, []*types.FunctionSignature{sig}), nil
+}
+
+func routineArgumentTypes(arguments []*bq.RoutineArgument) ([]*types.FunctionArgumentType, error) {
+	argTypes := make([]*types.FunctionArgumentType, 0, len(arguments))
+	for _, arg := range arguments {
+		if arg.Kind == "ANY_TYPE" {
+			return nil, fmt.Errorf("ANY TYPE argument(%s) is not supported", arg.Name)
+		}
+		typ, err := standardSQLDataTypeToZetaSQLType(arg.DataType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert argument type(%s): %w", arg.Name, err)
+		}
+		opt := types.NewFunctionArgumentTypeOptions(types.RequiredArgumentCardinality)
+		opt.SetArgumentName(arg.Name)
+		argTypes = append(argTypes, types.NewFunctionArgumentType(typ, opt))
+	}
+	return argTypes, nil
+}
+
+func standardSQLDataTypeToZetaSQLType(typ *bq.StandardSQLDataType) (types.Type, error) {
+	if typ == nil {
+		return nil, fmt.Errorf("type is not specified")
+	}
+
+	switch typ.TypeKind {
+	case "ARRAY":
+		elem, err := standardSQLDataTypeToZetaSQLType(typ.ArrayElementType)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewArrayType(elem)
+	case "STRUCT":
+		if typ.StructType == nil {
+			return nil, fmt.Errorf("struct type doesn't have fields")
+		}
+		fields := make([]*types.StructField, len(typ.StructType.Fields))
+		for i, field := range typ.StructType.Fields {
+			fieldType, err := standardSQLDataTypeToZetaSQLType(field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert type(%s): %w", field.Name, err)
+			}
+			fields[i] = types.NewStructField(field.Name, fieldType)
+		}
+		return types.NewStructType(fields)
+	case "RANGE":
+		if typ.RangeElementType == nil {
+			return nil, fmt.Errorf("range type doesn't have the element type")
+		}
+		return rangeTypeToZetaSQLType(&bq.RangeElementType{Type: bq.FieldType(typ.RangeElementType.TypeKind)})
+	default:
+		return typeNameToZetaSQLType(typ.TypeKind)
+	}
+}
+
+// AddProcedureWithName adds the procedure created by CREATE PROCEDURE in the script.
+func (c *Catalog) AddProcedureWithName(name string, procedure *types.Procedure) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.procedures[name] = procedure
+}
+
 func (c *Catalog) FindProcedure(path []string) (*types.Procedure, error) {
-	return c.catalog.FindProcedure(path)
+	procedure, err := c.catalog.FindProcedure(path)
+	if err == nil {
+		return procedure, nil
+	}
+
+	name := strings.Join(path, ".")
+	c.mu.Lock()
+	procedure, ok := c.procedures[name]
+	c.mu.Unlock()
+	if ok {
+		return procedure, nil
+	}
+
+	metadata, rErr := c.getRoutineMetadata(c.ctx, path)
+	if rErr != nil {
+		return nil, errors.Join(err, rErr)
+	}
+	if metadata.Type != "PROCEDURE" {
+		return nil, err
+	}
+
+	procedure, rErr = newRoutineProcedure(name, metadata)
+	if rErr != nil {
+		return nil, errors.Join(err, rErr)
+	}
+	c.mu.Lock()
+	c.procedures[name] = procedure
+	c.mu.Unlock()
+	return procedure, nil
+}
+
+func newRoutineProcedure(name string, metadata *bq.RoutineMetadata) (*types.Procedure, error) {
+	argTypes, err := routineArgumentTypes(metadata.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	return newProcedure(name, argTypes), nil
+}
+
+// newProcedure creates the procedure which CALL resolves. The procedure returns nothing.
+func newProcedure(name string, argTypes []*types.FunctionArgumentType) *types.Procedure {
+	opt := types.NewFunctionArgumentTypeOptions(types.RequiredArgumentCardinality)
+	retType := types.NewTemplatedFunctionArgumentType(types.ArgTypeVoid, opt)
+	sig := types.NewFunctionSignature(retType, argTypes)
+	return types.NewProcedure([]string{name}, sig)
 }
 func (c *Catalog) FindType(path []string) (types.Type, error) { return c.catalog.FindType(path) }
 
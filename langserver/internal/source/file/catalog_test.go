@@ -85,8 +85,106 @@ func TestCatalog_AddTable(t *testing.T) {
 				return bqClient
 			},
 			expectTableName:   "project.dataset.table",
+			expectColumnNames: []string{"name", "_PARTITIONTIME", "_PARTITIONDATE"},
+		},
+		"hourly partitiontime table": {
+			path: []string{"project.dataset.table"},
+			createMockBigQuery: func(ctrl *gomock.Controller) bigquery.Client {
+				bqClient := mock_bigquery.NewMockClient(ctrl)
+				bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+					Schema: bq.Schema{
+						{
+							Name: "name",
+							Type: bq.StringFieldType,
+						},
+					},
+					TimePartitioning: &bq.TimePartitioning{
+						Type: bq.HourPartitioningType,
+					},
+				}, nil)
+				return bqClient
+			},
+			expectTableName:   "project.dataset.table",
 			expectColumnNames: []string{"name", "_PARTITIONTIME"},
 		},
+		"column partitioned table": {
+			path: []string{"project.dataset.table"},
+			createMockBigQuery: func(ctrl *gomock.Controller) bigquery.Client {
+				bqClient := mock_bigquery.NewMockClient(ctrl)
+				bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+					Schema: bq.Schema{
+						{
+							Name: "created_at",
+							Type: bq.TimestampFieldType,
+						},
+					},
+					TimePartitioning: &bq.TimePartitioning{
+						Type:  bq.DayPartitioningType,
+						Field: "created_at",
+					},
+				}, nil)
+				return bqClient
+			},
+			expectTableName:   "project.dataset.table",
+			expectColumnNames: []string{"created_at"},
+		},
+		"partition decorator": {
+			path: []string{"project.dataset.table$20240101"},
+			createMockBigQuery: func(ctrl *gomock.Controller) bigquery.Client {
+				bqClient := mock_bigquery.NewMockClient(ctrl)
+				bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+					Schema: bq.Schema{
+						{
+							Name: "name",
+							Type: bq.StringFieldType,
+						},
+					},
+				}, nil)
+				return bqClient
+			},
+			expectTableName:   "project.dataset.table$20240101",
+			expectColumnNames: []string{"name"},
+		},
+		"GEOGRAPHY, INTERVAL, RANGE and BIGNUMERIC columns": {
+			path: []string{"project.dataset.table"},
+			createMockBigQuery: func(ctrl *gomock.Controller) bigquery.Client {
+				bqClient := mock_bigquery.NewMockClient(ctrl)
+				bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+					Schema: bq.Schema{
+						{
+							Name: "location",
+							Type: bq.GeographyFieldType,
+						},
+						{
+							Name: "duration",
+							Type: bq.IntervalFieldType,
+						},
+						{
+							Name:             "period",
+							Type:             bq.RangeFieldType,
+							RangeElementType: &bq.RangeElementType{Type: bq.TimestampFieldType},
+						},
+						{
+							Name:      "amount",
+							Type:      bq.BigNumericFieldType,
+							Precision: 76,
+							Scale:     38,
+						},
+					},
+				}, nil)
+				return bqClient
+			},
+			expectTableName:   "project.dataset.table",
+			expectColumnNames: []string{"location", "duration", "period", "amount"},
+		},
+		"INFORMATION_SCHEMA view": {
+			path: []string{"region-us", "INFORMATION_SCHEMA", "SCHEMATA"},
+			createMockBigQuery: func(ctrl *gomock.Controller) bigquery.Client {
+				return mock_bigquery.NewMockClient(ctrl)
+			},
+			expectTableName:   "region-us.INFORMATION_SCHEMA.SCHEMATA",
+			expectColumnNames: []string{"catalog_name", "schema_name", "schema_owner", "creation_time", "last_modified_time", "location", "ddl", "default_collation_name"},
+		},
 	}
 
 	for n, tt := range tests {
@@ -144,3 +242,148 @@ func TestCatalog_shouldNotGetSchemaTwice(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestCatalog_FindFunction(t *testing.T) {
+	tests := map[string]struct {
+		path               []string
+		createMockBigQuery func(ctrl *gomock.Controller) bigquery.Client
+
+		expectError        bool
+		expectFunctionName string
+	}{
+		"Builtin function": {
+			path: []string{"abs"},
+			createMockBigQuery: func(ctrl *gomock.Controller) bigquery.Client {
+				return mock_bigquery.NewMockClient(ctrl)
+			},
+			expectFunctionName: "abs",
+		},
+		"Persistent UDF": {
+			path: []string{"dataset", "my_udf"},
+			createMockBigQuery: func(ctrl *gomock.Controller) bigquery.Client {
+				bqClient := mock_bigquery.NewMockClient(ctrl)
+				bqClient.EXPECT().GetDefaultProject().Return("project")
+				bqClient.EXPECT().GetRoutineMetadata(gomock.Any(), "project", "dataset", "my_udf").Return(&bq.RoutineMetadata{
+					Type: "SCALAR_FUNCTION",
+					Arguments: []*bq.RoutineArgument{
+						{
+							Name:     "x",
+							DataType: &bq.StandardSQLDataType{TypeKind: "INT64"},
+						},
+					},
+					ReturnType: &bq.StandardSQLDataType{TypeKind: "INT64"},
+					Body:       "x + 1",
+				}, nil)
+				return bqClient
+			},
+			expectFunctionName: "dataset.my_udf",
+		},
+		"Routine not found": {
+			path: []string{"project.dataset.my_udf"},
+			createMockBigQuery: func(ctrl *gomock.Controller) bigquery.Client {
+				bqClient := mock_bigquery.NewMockClient(ctrl)
+				bqClient.EXPECT().GetRoutineMetadata(gomock.Any(), "project", "dataset", "my_udf").Return(nil, fmt.Errorf("not found"))
+				return bqClient
+			},
+			expectError: true,
+		},
+		"Procedure is not a function": {
+			path: []string{"project.dataset.my_proc"},
+			createMockBigQuery: func(ctrl *gomock.Controller) bigquery.Client {
+				bqClient := mock_bigquery.NewMockClient(ctrl)
+				bqClient.EXPECT().GetRoutineMetadata(gomock.Any(), "project", "dataset", "my_proc").Return(&bq.RoutineMetadata{
+					Type: "PROCEDURE",
+				}, nil)
+				return bqClient
+			},
+			expectError: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			bqClient := tt.createMockBigQuery(mockCtrl)
+			catalog := file.NewCatalog(bqClient)
+
+			got, err := catalog.FindFunction(tt.path)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("error: got %v, want error %v", err, tt.expectError)
+			}
+			if err != nil {
+				return
+			}
+
+			if got.Name() != tt.expectFunctionName {
+				t.Errorf("functionName: got %s, want %s", got.Name(), tt.expectFunctionName)
+			}
+		})
+	}
+}
+
+func TestCatalog_FindRoutineTable(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	bqClient := mock_bigquery.NewMockClient(mockCtrl)
+	bqClient.EXPECT().GetRoutineMetadata(gomock.Any(), "project", "dataset", "my_tvf").Return(&bq.RoutineMetadata{
+		Type: "TABLE_VALUED_FUNCTION",
+		Arguments: []*bq.RoutineArgument{
+			{
+				Name:     "x",
+				DataType: &bq.StandardSQLDataType{TypeKind: "INT64"},
+			},
+		},
+		ReturnTableType: &bq.StandardSQLTableType{
+			Columns: []*bq.StandardSQLField{
+				{Name: "id", Type: &bq.StandardSQLDataType{TypeKind: "INT64"}},
+				{Name: "name", Type: &bq.StandardSQLDataType{TypeKind: "STRING"}},
+			},
+		},
+		Body: "SELECT id, name FROM dataset.table WHERE id = x",
+	}, nil).Times(1)
+	catalog := file.NewCatalog(bqClient)
+
+	for i := 0; i < 2; i++ {
+		got, err := catalog.FindTable([]string{file.RoutineTableName("project.dataset.my_tvf")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.NumColumns() != 2 {
+			t.Errorf("NumColumns: got %d, want 2", got.NumColumns())
+		}
+	}
+}
+
+func TestCatalog_FindFunctionCachesNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	bqClient.EXPECT().GetRoutineMetadata(gomock.Any(), "project", "dataset", "my_udf").Return(nil, fmt.Errorf("not found")).Times(1)
+	catalog := file.NewCatalog(bqClient)
+
+	if _, err := catalog.FindFunction([]string{"project.dataset.my_udf"}); err == nil {
+		t.Fatalf("FindFunction should return the error of the routine not found")
+	}
+	if _, err := catalog.FindProcedure([]string{"project.dataset.my_udf"}); err == nil {
+		t.Fatalf("FindProcedure should return the error of the routine not found")
+	}
+}
+
+func TestIsPseudoColumn(t *testing.T) {
+	tests := map[string]bool{
+		"_PARTITIONTIME": true,
+		"_partitiondate": true,
+		"_TABLE_SUFFIX":  true,
+		"_FILE_NAME":     true,
+		"_id":            false,
+		"name":           false,
+	}
+
+	for name, expect := range tests {
+		if got := file.IsPseudoColumn(name); got != expect {
+			t.Errorf("IsPseudoColumn(%s) expect %t, but got %t", name, expect, got)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package file
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-zetasql"
+	rast "github.com/goccy/go-zetasql/resolved_ast"
+	"github.com/goccy/go-zetasql/types"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+)
+
+// comparisonFunctions are the internal names of the comparison operators.
+var comparisonFunctions = map[string]struct{}{
+	"$equal":            {},
+	"$not_equal":        {},
+	"$less":             {},
+	"$less_or_equal":    {},
+	"$greater":          {},
+	"$greater_or_equal": {},
+}
+
+// implicitCoercionErrors reports the expressions which are implicitly coerced to the type of the other side in the comparisons,
+// e.g. INT64 compared with FLOAT64 loses the precision, and DATE compared with DATETIME is compared at midnight.
+// The literals are not reported because they are typed by the context without the coercion.
+func implicitCoercionErrors(src string, outputs []*zetasql.AnalyzerOutput) []Error {
+	result := make([]Error, 0)
+	for _, output := range outputs {
+		if output == nil {
+			continue
+		}
+		rast.Walk(output.Statement(), func(n rast.Node) error {
+			call, ok := n.(*rast.FunctionCallNode)
+			if !ok {
+				return nil
+			}
+			if _, ok := comparisonFunctions[call.Function().Name()]; !ok {
+				return nil
+			}
+
+			for _, arg := range call.ArgumentList() {
+				cast, ok := arg.(*rast.CastNode)
+				if !ok {
+					continue
+				}
+				expr := cast.Expr()
+				exprLoc := expr.ParseLocationRange()
+				if exprLoc == nil {
+					continue
+				}
+				// the explicit CAST has its own location
+				if castLoc := cast.ParseLocationRange(); castLoc != nil && castLoc.Start().ByteOffset() != exprLoc.Start().ByteOffset() {
+					continue
+				}
+
+				startOffset := exprLoc.Start().ByteOffset()
+				endOffset := exprLoc.End().ByteOffset()
+				result = append(result, Error{
+					Msg: fmt.Sprintf("%s is implicitly coerced from %s to %s in the comparison",
+						src[startOffset:endOffset], expr.Type().TypeName(types.ProductExternal), cast.Type().TypeName(types.ProductExternal)),
+					Position:   helper.IndexToPosition(src, startOffset),
+					TermLength: endOffset - startOffset,
+					Severity:   lsp.Warning,
+					Code:       "implicit-coercion",
+				})
+			}
+			return nil
+		})
+	}
+	return result
+}
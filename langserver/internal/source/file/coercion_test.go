@@ -0,0 +1,66 @@
+package file_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnalyzer_ParseFileWithImplicitCoercion(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectErrs []file.Error
+	}{
+		"INT64 compared with FLOAT64": {
+			file: "SELECT id FROM `project.dataset.table` WHERE id = score",
+			expectErrs: []file.Error{
+				{
+					Msg:        "id is implicitly coerced from INT64 to FLOAT64 in the comparison",
+					Position:   lsp.Position{Line: 0, Character: 45},
+					TermLength: 2,
+					Severity:   lsp.Warning,
+					Code:       "implicit-coercion",
+				},
+			},
+		},
+		"explicit CAST and literal are not reported": {
+			file:       "SELECT id FROM `project.dataset.table` WHERE CAST(id AS FLOAT64) = score AND id = 1",
+			expectErrs: []file.Error{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "score",
+						Type: bq.FloatFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+
+			if diff := cmp.Diff(tt.expectErrs, parsedFile.Errors, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ParseFile errors diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+)
+
+// DuplicateColumnMessage is the prefix of the error reported for the duplicate column names.
+const DuplicateColumnMessage = "Duplicate column name"
+
+// duplicateColumnErrors reports the items of the SELECT list whose names are already used by the previous items,
+// which breaks CREATE TABLE AS SELECT and the tools which read the result by the column names.
+// The columns selected by the star are not checked because their names are unknown without the analysis.
+func duplicateColumnErrors(src string, node ast.ScriptNode) []Error {
+	result := make([]Error, 0)
+	if node == nil {
+		return result
+	}
+	ast.Walk(node, func(n ast.Node) error {
+		selectList, ok := n.(*ast.SelectListNode)
+		if !ok {
+			return nil
+		}
+
+		seen := make(map[string]struct{})
+		for _, column := range selectList.Columns() {
+			name, ok := SelectColumnName(column)
+			if !ok {
+				continue
+			}
+			key := strings.ToLower(name)
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				continue
+			}
+
+			loc := column.ParseLocationRange()
+			if loc == nil {
+				continue
+			}
+			result = append(result, Error{
+				Msg:        fmt.Sprintf("%s %s in the SELECT list. Add a distinct alias.", DuplicateColumnMessage, name),
+				Position:   helper.IndexToPosition(src, loc.Start().ByteOffset()),
+				TermLength: loc.End().ByteOffset() - loc.Start().ByteOffset(),
+				Severity:   lsp.Warning,
+				Code:       "duplicate-column",
+			})
+		}
+		return nil
+	})
+	return result
+}
+
+// SelectColumnName returns the output column name of the item in the SELECT list.
+// It is false when the column is anonymous or selected by the star.
+func SelectColumnName(column *ast.SelectColumnNode) (string, bool) {
+	if alias := column.Alias(); alias != nil {
+		return alias.Identifier().Name(), true
+	}
+	path, ok := column.Expression().(*ast.PathExpressionNode)
+	if !ok {
+		return "", false
+	}
+	names := path.Names()
+	return names[len(names)-1].Name(), true
+}
@@ -0,0 +1,78 @@
+package file_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnalyzer_ParseFileWithDuplicateColumn(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectErrs []file.Error
+	}{
+		"same column from the joined tables": {
+			file: "SELECT a.id, b.id FROM `project.dataset.table` a JOIN `project.dataset.table` b USING (name)",
+			expectErrs: []file.Error{
+				{
+					Msg:        "Duplicate column name id in the SELECT list. Add a distinct alias.",
+					Position:   lsp.Position{Line: 0, Character: 13},
+					TermLength: 4,
+					Severity:   lsp.Warning,
+					Code:       "duplicate-column",
+				},
+			},
+		},
+		"alias is compared case insensitively": {
+			file: "SELECT id AS value, name AS VALUE FROM `project.dataset.table`",
+			expectErrs: []file.Error{
+				{
+					Msg:        "Duplicate column name VALUE in the SELECT list. Add a distinct alias.",
+					Position:   lsp.Position{Line: 0, Character: 20},
+					TermLength: 13,
+					Severity:   lsp.Warning,
+					Code:       "duplicate-column",
+				},
+			},
+		},
+		"distinct names are not reported": {
+			file:       "SELECT a.id, b.id AS b_id, COUNT(*), COUNT(*) FROM `project.dataset.table` a JOIN `project.dataset.table` b USING (name) GROUP BY 1, 2",
+			expectErrs: []file.Error{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+
+			if diff := cmp.Diff(tt.expectErrs, parsedFile.Errors, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ParseFile errors diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
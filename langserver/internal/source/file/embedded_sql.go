@@ -0,0 +1,202 @@
+package file
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// EmbeddedSQLOption configures the extraction of SQL from the string literals of Python and Go files.
+type EmbeddedSQLOption struct {
+	Enabled bool
+	// Markers are the comments like `bqls:sql` which mark the string literal in the same or the next line as SQL.
+	Markers []string
+	// CallSites are the functions like `query` whose first argument is SQL.
+	CallSites []string
+}
+
+// DefaultEmbeddedSQLOption marks the SQL with `bqls:sql`, and the argument of the BigQuery client libraries.
+func DefaultEmbeddedSQLOption() EmbeddedSQLOption {
+	return EmbeddedSQLOption{
+		Markers: []string{"bqls:sql"},
+		// `client.query` and `pandas.read_gbq` of Python, and `client.Query` of Go.
+		CallSites: []string{"query", "read_gbq", "Query"},
+	}
+}
+
+type hostLanguage int
+
+const (
+	hostLanguagePython hostLanguage = iota + 1
+	hostLanguageGo
+)
+
+func hostLanguageOf(uri string) (hostLanguage, bool) {
+	switch filepath.Ext(uri) {
+	case ".py":
+		return hostLanguagePython, true
+	case ".go":
+		return hostLanguageGo, true
+	default:
+		return 0, false
+	}
+}
+
+// SetEmbeddedSQLOption replaces the option of the extraction. It can be called while the files are analyzed.
+func (a *Analyzer) SetEmbeddedSQLOption(option EmbeddedSQLOption) {
+	a.updateOptions(func(o *analyzerOptions) {
+		o.embeddedSQL = option
+	})
+}
+
+// IsHostFile reports whether the SQL is extracted from the string literals of the file instead of analyzing it as SQL.
+func (a *Analyzer) IsHostFile(uri string) bool {
+	_, ok := hostLanguageOf(uri)
+	return ok && a.options.Load().embeddedSQL.Enabled
+}
+
+// parseHostFile analyzes the SQL in the string literals of the file.
+// The file itself isn't parsed, so only the errors and the embedded files are set.
+func (a *Analyzer) parseHostFile(ctx context.Context, uri string, src string, language hostLanguage) ParsedFile {
+	files := make([]EmbeddedFile, 0)
+	for _, literal := range scanStringLiterals(src, language) {
+		if !literal.mappable || strings.TrimSpace(literal.content) == "" {
+			continue
+		}
+		if !a.isMarkedLiteral(src, literal) && !a.isCallSiteLiteral(src, literal) {
+			continue
+		}
+		files = append(files, EmbeddedFile{
+			Offset:     literal.offset,
+			ParsedFile: a.parseSQLFile(ctx, uri, literal.content),
+		})
+	}
+	return ParsedFile{
+		URI:           uri,
+		Src:           src,
+		EmbeddedFiles: files,
+		Errors:        embeddedFileErrors(src, files),
+	}
+}
+
+// isMarkedLiteral reports whether the line of the literal or the previous line has the marker before the literal.
+func (a *Analyzer) isMarkedLiteral(src string, literal stringLiteral) bool {
+	lineStart := strings.LastIndex(src[:literal.start], "\n") + 1
+	prevLineStart := 0
+	if lineStart > 0 {
+		prevLineStart = strings.LastIndex(src[:lineStart-1], "\n") + 1
+	}
+	for _, marker := range a.options.Load().embeddedSQL.Markers {
+		if marker != "" && strings.Contains(src[prevLineStart:literal.start], marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCallSiteLiteral reports whether the literal is the first argument of the call site like `client.query("SELECT 1")`.
+func (a *Analyzer) isCallSiteLiteral(src string, literal stringLiteral) bool {
+	before := strings.TrimRight(src[:literal.start], " \t\r\n")
+	if !strings.HasSuffix(before, "(") {
+		return false
+	}
+	before = strings.TrimRight(strings.TrimSuffix(before, "("), " \t")
+	name := before[strings.LastIndexFunc(before, func(r rune) bool { return !isIdentifierRune(r) })+1:]
+	return name != "" && slices.Contains(a.options.Load().embeddedSQL.CallSites, name)
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
+
+// stringLiteral is the string literal of the host language.
+type stringLiteral struct {
+	// start is the offset of the literal including its prefix like `r`.
+	start int
+	// offset is the offset of the content.
+	offset  int
+	content string
+	// mappable is false when the content has escape sequences or is formatted at runtime,
+	// because the positions of the SQL can't be mapped to the file.
+	mappable bool
+}
+
+// scanStringLiterals lists the string literals of src, skipping the comments.
+func scanStringLiterals(src string, language hostLanguage) []stringLiteral {
+	result := make([]stringLiteral, 0)
+	for i := 0; i < len(src); {
+		switch {
+		case language == hostLanguagePython && src[i] == '#',
+			language == hostLanguageGo && strings.HasPrefix(src[i:], "//"):
+			i = skipTo(src, i, "\n")
+		case language == hostLanguageGo && strings.HasPrefix(src[i:], "/*"):
+			i = skipTo(src, i+2, "*/")
+		case language == hostLanguageGo && src[i] == '`':
+			end := strings.IndexByte(src[i+1:], '`')
+			if end < 0 {
+				return result
+			}
+			result = append(result, stringLiteral{start: i, offset: i + 1, content: src[i+1 : i+1+end], mappable: true})
+			i += end + 2
+		case src[i] == '"' || src[i] == '\'':
+			literal, next := scanQuotedLiteral(src, i, language)
+			if literal != nil {
+				result = append(result, *literal)
+			}
+			i = next
+		default:
+			i++
+		}
+	}
+	return result
+}
+
+// scanQuotedLiteral scans the literal which starts with the quote at i. It returns nil for the rune literal of Go.
+func scanQuotedLiteral(src string, i int, language hostLanguage) (*stringLiteral, int) {
+	quote := src[i : i+1]
+	if language == hostLanguagePython && strings.HasPrefix(src[i:], strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+
+	// the prefix like `r` or `f` of Python
+	start := i
+	for language == hostLanguagePython && start > 0 && start > i-2 && strings.ContainsRune("rRbBuUfF", rune(src[start-1])) {
+		start--
+	}
+	if start > 0 && isIdentifierRune(rune(src[start-1])) {
+		start = i
+	}
+	prefix := strings.ToLower(src[start:i])
+
+	offset := i + len(quote)
+	end := offset
+	for end < len(src) && !strings.HasPrefix(src[end:], quote) {
+		if src[end] == '\\' {
+			end++
+		} else if src[end] == '\n' && len(quote) == 1 {
+			break
+		}
+		end++
+	}
+	if end >= len(src) || src[end] == '\n' {
+		return nil, end
+	}
+
+	content := src[offset:end]
+	next := end + len(quote)
+	if language == hostLanguageGo && quote == "'" {
+		return nil, next
+	}
+	mappable := !strings.ContainsAny(prefix, "bf") && (strings.Contains(prefix, "r") || !strings.Contains(content, `\`))
+	return &stringLiteral{start: start, offset: offset, content: content, mappable: mappable}, next
+}
+
+// skipTo returns the offset after the first sep from i, or the end of src.
+func skipTo(src string, i int, sep string) int {
+	end := strings.Index(src[i:], sep)
+	if end < 0 {
+		return len(src)
+	}
+	return i + end + len(sep)
+}
@@ -0,0 +1,112 @@
+package file_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnalyzer_ParseFileWithEmbeddedSQL(t *testing.T) {
+	tests := map[string]struct {
+		uri  string
+		file string
+
+		expectedErrs          []file.Error
+		expectedEmbeddedFiles int
+	}{
+		"python": {
+			uri: "query.py",
+			file: `from google.cloud import bigquery
+
+client = bigquery.Client()
+# bqls:sql
+sql = """
+SELECT unknown FROM ` + "`project.dataset.table`" + `
+"""
+client.query("SELECT id FROM ` + "`project.dataset.table`" + `")
+client.query("SELECT\n1")
+name = "not sql"
+`,
+			expectedErrs: []file.Error{
+				{
+					Msg: "INVALID_ARGUMENT: Unrecognized name: unknown",
+					Position: lsp.Position{
+						Line:      5,
+						Character: 7,
+					},
+					TermLength:           7,
+					IncompleteColumnName: "unknown",
+				},
+			},
+			expectedEmbeddedFiles: 2,
+		},
+		"go": {
+			uri: "main.go",
+			file: "package main\n\n" +
+				"// bqls:sql\n" +
+				"const query = \"SELECT id FROM project.dataset.table\"\n\n" +
+				"func run() {\n" +
+				"\tclient.Query(`SELECT unknown FROM project.dataset.table`)\n" +
+				"}\n",
+			expectedErrs: []file.Error{
+				{
+					Msg: "INVALID_ARGUMENT: Unrecognized name: unknown",
+					Position: lsp.Position{
+						Line:      6,
+						Character: 22,
+					},
+					TermLength:           7,
+					IncompleteColumnName: "unknown",
+				},
+			},
+			expectedEmbeddedFiles: 2,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+			option := file.DefaultEmbeddedSQLOption()
+			option.Enabled = true
+			analyzer.SetEmbeddedSQLOption(option)
+
+			if !analyzer.IsHostFile(tt.uri) {
+				t.Fatalf("%s should be the host file", tt.uri)
+			}
+			got := analyzer.ParseFile(context.Background(), tt.uri, tt.file)
+			if diff := cmp.Diff(tt.expectedErrs, got.Errors, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ParseFile errors diff (-expect, +got)\n%s", diff)
+			}
+			if len(got.EmbeddedFiles) != tt.expectedEmbeddedFiles {
+				t.Errorf("expect %d embedded files, but got %d", tt.expectedEmbeddedFiles, len(got.EmbeddedFiles))
+			}
+		})
+	}
+}
+
+func TestAnalyzer_IsHostFileWhenDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	analyzer := file.NewAnalyzer(logrus.New(), mock_bigquery.NewMockClient(ctrl))
+
+	if analyzer.IsHostFile("query.py") {
+		t.Error("the embedded SQL should be disabled by default")
+	}
+}
@@ -0,0 +1,93 @@
+package file
+
+import (
+	"context"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// EmbeddedFile is the SQL of the string literal executed by EXECUTE IMMEDIATE.
+type EmbeddedFile struct {
+	// Offset is the byte offset of the SQL in the source of the outer file.
+	Offset int
+
+	ParsedFile
+}
+
+// FindEmbeddedFile returns the embedded file which contains pos and the position in it.
+func (p ParsedFile) FindEmbeddedFile(pos lsp.Position) (ParsedFile, lsp.Position, bool) {
+	offset := positionToByteOffset(p.Src, pos)
+	for _, e := range p.EmbeddedFiles {
+		if offset < e.Offset || e.Offset+len(e.Src) < offset {
+			continue
+		}
+		nestedPos, ok := byteOffsetToPosition(e.Src, offset-e.Offset)
+		if !ok {
+			continue
+		}
+		return e.ParsedFile, nestedPos, true
+	}
+	return ParsedFile{}, lsp.Position{}, false
+}
+
+// executeImmediateFiles analyzes the constant SQL of EXECUTE IMMEDIATE.
+// The SQL built at runtime, or which has escape sequences, is skipped because its positions can't be mapped to the file.
+func (a *Analyzer) executeImmediateFiles(ctx context.Context, parsedFile ParsedFile) []EmbeddedFile {
+	result := make([]EmbeddedFile, 0)
+	for _, node := range ListAstNode[*ast.ExecuteImmediateStatementNode](parsedFile.Node) {
+		literal, ok := node.SQL().(*ast.StringLiteralNode)
+		if !ok {
+			continue
+		}
+		image, ok := parsedFile.ExtractSQL(literal.ParseLocationRange())
+		if !ok {
+			continue
+		}
+		contentOffset, content, ok := stringLiteralContent(image)
+		if !ok || content != literal.Value() {
+			continue
+		}
+
+		result = append(result, EmbeddedFile{
+			Offset:     parsedFile.fixTermOFfsetForSQL(literal.ParseLocationRange().Start().ByteOffset()) + contentOffset,
+			ParsedFile: a.parseSQLFile(ctx, parsedFile.URI, content),
+		})
+	}
+	return result
+}
+
+// stringLiteralContent returns the content between the quotes of the string literal, which may be raw or triple-quoted.
+func stringLiteralContent(image string) (offset int, content string, ok bool) {
+	prefix := 0
+	if strings.HasPrefix(image, "r") || strings.HasPrefix(image, "R") {
+		prefix = 1
+	}
+	for _, quote := range []string{`"""`, `'''`, `"`, `'`} {
+		if len(image) < prefix+2*len(quote) {
+			continue
+		}
+		if strings.HasPrefix(image[prefix:], quote) && strings.HasSuffix(image, quote) {
+			offset = prefix + len(quote)
+			return offset, image[offset : len(image)-len(quote)], true
+		}
+	}
+	return 0, "", false
+}
+
+// embeddedFileErrors converts the positions of the errors in the embedded files into the ones of the outer file.
+func embeddedFileErrors(src string, files []EmbeddedFile) []Error {
+	result := make([]Error, 0)
+	for _, e := range files {
+		for _, err := range e.Errors {
+			pos, ok := byteOffsetToPosition(src, e.Offset+positionToByteOffset(e.Src, err.Position))
+			if !ok {
+				continue
+			}
+			err.Position = pos
+			result = append(result, err)
+		}
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package file_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnalyzer_ParseFileWithExecuteImmediate(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectedErrs          []file.Error
+		expectedEmbeddedFiles int
+	}{
+		"constant SQL": {
+			file:                  "EXECUTE IMMEDIATE 'SELECT id FROM `project.dataset.table`'",
+			expectedErrs:          []file.Error{},
+			expectedEmbeddedFiles: 1,
+		},
+		"error in triple-quoted SQL": {
+			file: "EXECUTE IMMEDIATE '''\nSELECT unknown FROM `project.dataset.table`\n'''",
+			expectedErrs: []file.Error{
+				{
+					Msg: "INVALID_ARGUMENT: Unrecognized name: unknown",
+					Position: lsp.Position{
+						Line:      1,
+						Character: 7,
+					},
+					TermLength:           7,
+					IncompleteColumnName: "unknown",
+				},
+			},
+			expectedEmbeddedFiles: 1,
+		},
+		"SQL built at runtime": {
+			file:                  "DECLARE table_name STRING DEFAULT 'table';\nEXECUTE IMMEDIATE CONCAT('SELECT * FROM `project.dataset.', table_name, '`')",
+			expectedErrs:          []file.Error{},
+			expectedEmbeddedFiles: 0,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			got := analyzer.ParseFile(context.Background(), "uri", tt.file)
+			if diff := cmp.Diff(tt.expectedErrs, got.Errors, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ParseFile errors diff (-expect, +got)\n%s", diff)
+			}
+			if len(got.EmbeddedFiles) != tt.expectedEmbeddedFiles {
+				t.Errorf("expect %d embedded files, but got %d", tt.expectedEmbeddedFiles, len(got.EmbeddedFiles))
+			}
+		})
+	}
+}
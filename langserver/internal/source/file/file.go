@@ -20,11 +20,14 @@ type ParsedFile struct {
 	Src string
 
 	Node ast.ScriptNode
-	// index is Node's statement order
+	// index is Node's statement order, and the entry is nil when the statement fails to be analyzed.
 	RNode []*zetasql.AnalyzerOutput
 
 	FixOffsets []FixOffset
 	Errors     []Error
+
+	// EmbeddedFiles are the SQL strings executed by EXECUTE IMMEDIATE.
+	EmbeddedFiles []EmbeddedFile
 }
 
 func (p ParsedFile) TermOffset(pos lsp.Position) int {
@@ -32,6 +35,21 @@ func (p ParsedFile) TermOffset(pos lsp.Position) int {
 	return p.fixTermOffsetForNode(termOffset)
 }
 
+// SrcOffset returns the byte offset of pos in the original source.
+func (p ParsedFile) SrcOffset(pos lsp.Position) int {
+	return positionToByteOffset(p.Src, pos)
+}
+
+// SrcPosition returns the position of the byte offset in the original source.
+func (p ParsedFile) SrcPosition(offset int) (lsp.Position, bool) {
+	return byteOffsetToPosition(p.Src, offset)
+}
+
+// ErrorOffset returns the byte offset of the error in the original source.
+func (p ParsedFile) ErrorOffset(err Error) int {
+	return p.fixTermOFfsetForSQL(positionToByteOffset(p.Src, err.Position))
+}
+
 func (p ParsedFile) fixTermOffsetForNode(termOffset int) int {
 	for _, fo := range p.FixOffsets {
 		if termOffset > fo.Offset+fo.Length {
@@ -61,6 +79,59 @@ func (p ParsedFile) fixTermOFfsetForSQL(termOffset int) int {
 	return termOffset
 }
 
+// PositionRange converts the location range of the node into the range of the original source.
+func (p ParsedFile) PositionRange(locationRange *types.ParseLocationRange) (lsp.Range, bool) {
+	if locationRange == nil {
+		return lsp.Range{}, false
+	}
+
+	start, ok := byteOffsetToPosition(p.Src, p.fixTermOFfsetForSQL(locationRange.Start().ByteOffset()))
+	if !ok {
+		return lsp.Range{}, false
+	}
+	end, ok := byteOffsetToPosition(p.Src, p.fixTermOFfsetForSQL(locationRange.End().ByteOffset()))
+	if !ok {
+		return lsp.Range{}, false
+	}
+	return lsp.Range{Start: start, End: end}, true
+}
+
+// FindTempFunctionDeclaration finds `CREATE TEMP FUNCTION` statement declared in the file.
+func (p ParsedFile) FindTempFunctionDeclaration(name string) (*ast.CreateFunctionStatementNode, bool) {
+	for _, node := range ListAstNode[*ast.CreateFunctionStatementNode](p.Node) {
+		funcName := ""
+		for _, n := range node.FunctionDeclaration().Name().Names() {
+			funcName += n.Name()
+		}
+		if strings.EqualFold(funcName, name) {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// FindProcedureDeclaration finds `CREATE PROCEDURE` statement of the procedure in the script.
+func (p ParsedFile) FindProcedureDeclaration(name string) (*ast.CreateProcedureStatementNode, bool) {
+	for _, node := range ListAstNode[*ast.CreateProcedureStatementNode](p.Node) {
+		if strings.EqualFold(pathName(node.Name()), name) {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// FindVariableDeclaration finds `DECLARE` statement of the script variable, and the identifier of the variable in it.
+func (p ParsedFile) FindVariableDeclaration(name string) (*ast.VariableDeclarationNode, *ast.IdentifierNode, bool) {
+	for _, node := range ListAstNode[*ast.VariableDeclarationNode](p.Node) {
+		for _, identifier := range node.VariableList().IdentifierList() {
+			if strings.EqualFold(identifier.Name(), name) {
+				return node, identifier, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
 func (p ParsedFile) FindTargetStatementNode(termOffset int) (ast.StatementNode, bool) {
 	stmts := make([]ast.StatementNode, 0)
 	ast.Walk(p.Node, func(n ast.Node) error {
@@ -77,6 +148,8 @@ func (p ParsedFile) FindTargetStatementNode(termOffset int) (ast.StatementNode,
 		return stmts[0], true
 	}
 
+	// the statement in the body of CREATE PROCEDURE or BEGIN...END follows the outer statement, so the last one is the innermost.
+	var result ast.StatementNode
 	for _, stmt := range stmts {
 		loc := stmt.ParseLocationRange()
 		if loc == nil {
@@ -85,11 +158,11 @@ func (p ParsedFile) FindTargetStatementNode(termOffset int) (ast.StatementNode,
 		startOffset := loc.Start().ByteOffset()
 		endOffset := loc.End().ByteOffset()
 		if startOffset <= termOffset && termOffset <= endOffset {
-			return stmt, true
+			result = stmt
 		}
 	}
 
-	return nil, false
+	return result, result != nil
 }
 
 func (p ParsedFile) findTargetStatementNodeIndex(termOffset int) (int, bool) {
@@ -101,7 +174,9 @@ func (p ParsedFile) findTargetStatementNodeIndex(termOffset int) (int, bool) {
 		// Currently VariableDeclarationNode can't be analyzed.
 		// So, skip it.
 		_, isVariableDeclaration := n.(*ast.VariableDeclarationNode)
-		if n.IsStatement() && !isVariableDeclaration {
+		// EXECUTE IMMEDIATE isn't analyzed, but its SQL is analyzed as the embedded file.
+		_, isExecuteImmediate := n.(*ast.ExecuteImmediateStatementNode)
+		if n.IsStatement() && !isVariableDeclaration && !isExecuteImmediate {
 			stmts = append(stmts, n)
 		}
 		return nil
@@ -111,6 +186,8 @@ func (p ParsedFile) findTargetStatementNodeIndex(termOffset int) (int, bool) {
 		return 0, true
 	}
 
+	// the innermost statement is used as FindTargetStatementNode.
+	index := -1
 	for i, stmt := range stmts {
 		loc := stmt.ParseLocationRange()
 		if loc == nil {
@@ -119,11 +196,11 @@ func (p ParsedFile) findTargetStatementNodeIndex(termOffset int) (int, bool) {
 		startOffset := loc.Start().ByteOffset()
 		endOffset := loc.End().ByteOffset()
 		if startOffset <= termOffset && termOffset <= endOffset {
-			return i, true
+			index = i
 		}
 	}
 
-	return -1, false
+	return index, index >= 0
 }
 
 func (p *ParsedFile) FindTargetAnalyzeOutput(termOffset int) (*zetasql.AnalyzerOutput, bool) {
@@ -132,7 +209,7 @@ func (p *ParsedFile) FindTargetAnalyzeOutput(termOffset int) (*zetasql.AnalyzerO
 		return nil, false
 	}
 
-	if index >= len(p.RNode) {
+	if index >= len(p.RNode) || p.RNode[index] == nil {
 		return nil, false
 	}
 
@@ -163,6 +240,8 @@ type Error struct {
 	TermLength           int
 	IncompleteColumnName string
 	Severity             lsp.DiagnosticSeverity
+	// Code is the name of the lint rule which reports the error.
+	Code string
 }
 
 func (e Error) Error() string {
@@ -1,6 +1,7 @@
 package file_test
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -584,6 +585,20 @@ func TestProject_ParseFile(t *testing.T) {
 			},
 			expectedErrs: []file.Error{},
 		},
+		"parse TABLESAMPLE statement": {
+			file: "SELECT city FROM `project.dataset.table` t1 TABLESAMPLE SYSTEM (10 PERCENT)",
+			bqTableMetadataMap: map[string]*bq.TableMetadata{
+				"project.dataset.table": {
+					Schema: bq.Schema{
+						{
+							Name: "city",
+							Type: bq.StringFieldType,
+						},
+					},
+				},
+			},
+			expectedErrs: []file.Error{},
+		},
 	}
 
 	for n, tt := range tests {
@@ -601,7 +616,7 @@ func TestProject_ParseFile(t *testing.T) {
 			logger.SetLevel(logrus.DebugLevel)
 
 			analyzer := file.NewAnalyzer(logger, bqClient)
-			got := analyzer.ParseFile("uri", tt.file)
+			got := analyzer.ParseFile(context.Background(), "uri", tt.file)
 			if diff := cmp.Diff(tt.expectedErrs, got.Errors, cmpopts.IgnoreUnexported()); diff != "" {
 				t.Errorf("ParseFile result diff (-expect, +got)\n%s", diff)
 			}
@@ -649,7 +664,7 @@ func TestProject_ParseFileWithIncompleteTable(t *testing.T) {
 			bqClient := tt.bigqueryClientMockFunc(t)
 			analyzer := file.NewAnalyzer(logger, bqClient)
 
-			got := analyzer.ParseFile("uri", tt.file)
+			got := analyzer.ParseFile(context.Background(), "uri", tt.file)
 			if diff := cmp.Diff(tt.expectedErrs, got.Errors, cmpopts.IgnoreUnexported()); diff != "" {
 				t.Errorf("ParseFile result diff (-expect, +got)\n%s", diff)
 			}
@@ -724,6 +739,34 @@ func TestAnalyzer_ParseFileWithDeclareStatement(t *testing.T) {
 			},
 			expectedErrs: []file.Error{},
 		},
+		"Parse external table": {
+			file: "SELECT * FROM `project.dataset.table`",
+			bqTableMetadataMap: map[string]*bq.TableMetadata{
+				"project.dataset.table": {
+					Schema: bq.Schema{
+						{
+							Name: "id",
+							Type: bq.IntegerFieldType,
+						},
+					},
+					ExternalDataConfig: &bq.ExternalDataConfig{
+						SourceFormat: bq.Parquet,
+						SourceURIs:   []string{"gs://bucket/path/*.parquet"},
+					},
+				},
+			},
+			expectedErrs: []file.Error{
+				{
+					Msg: "project.dataset.table is an external table. The query cost is based on the data read from GCS.",
+					Position: lsp.Position{
+						Line:      0,
+						Character: 14,
+					},
+					TermLength: 23,
+					Severity:   lsp.Information,
+				},
+			},
+		},
 		"Parse create tmp function statement with returns": {
 			file: "CREATE TEMP FUNCTION target_func(x INT64) RETURNS INT64 AS (x * 10);\n" +
 				"SELECT target_func(10);",
@@ -734,24 +777,7 @@ func TestAnalyzer_ParseFileWithDeclareStatement(t *testing.T) {
 			file: "CREATE TEMP FUNCTION target_func(x INT64) AS (x * 10);\n" +
 				"SELECT target_func(10);",
 			bqTableMetadataMap: map[string]*bq.TableMetadata{},
-			expectedErrs: []file.Error{
-				{
-					Msg: "Currently, bqls does not support function without return type.",
-					Position: lsp.Position{
-						Line:      0,
-						Character: 0,
-					},
-					TermLength: 53,
-					Severity:   lsp.Warning,
-				},
-				{
-					Msg: "INVALID_ARGUMENT: Function not found: target_func",
-					Position: lsp.Position{
-						Line:      1,
-						Character: 7,
-					},
-				},
-			},
+			expectedErrs:       []file.Error{},
 		},
 	}
 
@@ -770,7 +796,7 @@ func TestAnalyzer_ParseFileWithDeclareStatement(t *testing.T) {
 			logger.SetLevel(logrus.DebugLevel)
 			analyzer := file.NewAnalyzer(logger, bqClient)
 
-			got := analyzer.ParseFile("uri", tt.file)
+			got := analyzer.ParseFile(context.Background(), "uri", tt.file)
 			if diff := cmp.Diff(tt.expectedErrs, got.Errors, cmpopts.IgnoreUnexported()); diff != "" {
 				t.Errorf("ParseFile result diff (-expect, +got)\n%s", diff)
 			}
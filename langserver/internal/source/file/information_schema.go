@@ -0,0 +1,183 @@
+package file
+
+import (
+	"strings"
+
+	bq "cloud.google.com/go/bigquery"
+)
+
+const informationSchemaName = "INFORMATION_SCHEMA"
+
+// informationSchemaTableMetadata returns the built-in metadata of INFORMATION_SCHEMA views.
+// path should be like `region-us.INFORMATION_SCHEMA.JOBS` or `project.dataset.INFORMATION_SCHEMA.TABLES`.
+func informationSchemaTableMetadata(path string) (*bq.TableMetadata, bool) {
+	names := strings.Split(path, ".")
+	if len(names) < 2 || !strings.EqualFold(names[len(names)-2], informationSchemaName) {
+		return nil, false
+	}
+
+	viewName := strings.ToUpper(trimPartitionDecorator(names[len(names)-1]))
+	schema, ok := informationSchemaViews[viewName]
+	if !ok {
+		return nil, false
+	}
+
+	return &bq.TableMetadata{
+		Name:        viewName,
+		FullID:      path,
+		Description: "INFORMATION_SCHEMA." + viewName + " view",
+		Type:        bq.ViewTable,
+		Schema:      schema,
+	}, true
+}
+
+func stringFields(names ...string) bq.Schema {
+	schema := make(bq.Schema, len(names))
+	for i, name := range names {
+		schema[i] = &bq.FieldSchema{Name: name, Type: bq.StringFieldType}
+	}
+	return schema
+}
+
+func joinSchemas(schemas ...bq.Schema) bq.Schema {
+	result := make(bq.Schema, 0)
+	for _, schema := range schemas {
+		result = append(result, schema...)
+	}
+	return result
+}
+
+var tableReferenceSchema = stringFields("project_id", "dataset_id", "table_id")
+
+var jobsSchema = joinSchemas(
+	bq.Schema{
+		{Name: "creation_time", Type: bq.TimestampFieldType},
+		{Name: "project_id", Type: bq.StringFieldType},
+		{Name: "project_number", Type: bq.IntegerFieldType},
+		{Name: "user_email", Type: bq.StringFieldType},
+		{Name: "job_id", Type: bq.StringFieldType},
+		{Name: "job_type", Type: bq.StringFieldType},
+		{Name: "statement_type", Type: bq.StringFieldType},
+		{Name: "priority", Type: bq.StringFieldType},
+		{Name: "start_time", Type: bq.TimestampFieldType},
+		{Name: "end_time", Type: bq.TimestampFieldType},
+		{Name: "query", Type: bq.StringFieldType},
+		{Name: "state", Type: bq.StringFieldType},
+		{Name: "reservation_id", Type: bq.StringFieldType},
+		{Name: "total_bytes_processed", Type: bq.IntegerFieldType},
+		{Name: "total_bytes_billed", Type: bq.IntegerFieldType},
+		{Name: "total_slot_ms", Type: bq.IntegerFieldType},
+		{Name: "cache_hit", Type: bq.BooleanFieldType},
+		{Name: "parent_job_id", Type: bq.StringFieldType},
+		{Name: "destination_table", Type: bq.RecordFieldType, Schema: tableReferenceSchema},
+		{Name: "referenced_tables", Type: bq.RecordFieldType, Repeated: true, Schema: tableReferenceSchema},
+		{Name: "labels", Type: bq.RecordFieldType, Repeated: true, Schema: stringFields("key", "value")},
+		{Name: "error_result", Type: bq.RecordFieldType, Schema: stringFields("reason", "location", "debug_info", "message")},
+		{Name: "session_info", Type: bq.RecordFieldType, Schema: stringFields("session_id")},
+	},
+)
+
+var tablesSchema = joinSchemas(
+	stringFields("table_catalog", "table_schema", "table_name", "table_type", "is_insertable_into", "is_typed"),
+	bq.Schema{
+		{Name: "creation_time", Type: bq.TimestampFieldType},
+	},
+	stringFields("base_table_catalog", "base_table_schema", "base_table_name"),
+	bq.Schema{
+		{Name: "snapshot_time_ms", Type: bq.TimestampFieldType},
+	},
+	stringFields("ddl", "default_collation_name"),
+	bq.Schema{
+		{Name: "upsert_stream_apply_watermark", Type: bq.TimestampFieldType},
+	},
+)
+
+var columnsSchema = joinSchemas(
+	stringFields("table_catalog", "table_schema", "table_name", "column_name"),
+	bq.Schema{
+		{Name: "ordinal_position", Type: bq.IntegerFieldType},
+	},
+	stringFields("is_nullable", "data_type", "is_generated", "generation_expression", "is_stored", "is_hidden", "is_updatable", "is_system_defined", "is_partitioning_column"),
+	bq.Schema{
+		{Name: "clustering_ordinal_position", Type: bq.IntegerFieldType},
+	},
+	stringFields("collation_name", "column_default", "rounding_mode"),
+)
+
+var tableStorageSchema = joinSchemas(
+	stringFields("project_id"),
+	bq.Schema{
+		{Name: "project_number", Type: bq.IntegerFieldType},
+	},
+	stringFields("table_catalog", "table_schema", "table_name"),
+	bq.Schema{
+		{Name: "creation_time", Type: bq.TimestampFieldType},
+		{Name: "total_rows", Type: bq.IntegerFieldType},
+		{Name: "total_partitions", Type: bq.IntegerFieldType},
+		{Name: "total_logical_bytes", Type: bq.IntegerFieldType},
+		{Name: "active_logical_bytes", Type: bq.IntegerFieldType},
+		{Name: "long_term_logical_bytes", Type: bq.IntegerFieldType},
+		{Name: "current_physical_bytes", Type: bq.IntegerFieldType},
+		{Name: "total_physical_bytes", Type: bq.IntegerFieldType},
+		{Name: "active_physical_bytes", Type: bq.IntegerFieldType},
+		{Name: "long_term_physical_bytes", Type: bq.IntegerFieldType},
+		{Name: "time_travel_physical_bytes", Type: bq.IntegerFieldType},
+		{Name: "fail_safe_physical_bytes", Type: bq.IntegerFieldType},
+		{Name: "storage_last_modified_time", Type: bq.TimestampFieldType},
+		{Name: "deleted", Type: bq.BooleanFieldType},
+		{Name: "table_type", Type: bq.StringFieldType},
+	},
+)
+
+var partitionsSchema = joinSchemas(
+	stringFields("table_catalog", "table_schema", "table_name", "partition_id"),
+	bq.Schema{
+		{Name: "total_rows", Type: bq.IntegerFieldType},
+		{Name: "total_logical_bytes", Type: bq.IntegerFieldType},
+		{Name: "total_billable_bytes", Type: bq.IntegerFieldType},
+		{Name: "last_modified_time", Type: bq.TimestampFieldType},
+		{Name: "storage_tier", Type: bq.StringFieldType},
+	},
+)
+
+var schemataSchema = joinSchemas(
+	stringFields("catalog_name", "schema_name", "schema_owner"),
+	bq.Schema{
+		{Name: "creation_time", Type: bq.TimestampFieldType},
+		{Name: "last_modified_time", Type: bq.TimestampFieldType},
+	},
+	stringFields("location", "ddl", "default_collation_name"),
+)
+
+var routinesSchema = joinSchemas(
+	stringFields(
+		"specific_catalog", "specific_schema", "specific_name",
+		"routine_catalog", "routine_schema", "routine_name", "routine_type",
+		"data_type", "routine_body", "routine_definition", "external_language",
+		"is_deterministic", "security_type",
+	),
+	bq.Schema{
+		{Name: "created", Type: bq.TimestampFieldType},
+		{Name: "last_altered", Type: bq.TimestampFieldType},
+	},
+	stringFields("ddl"),
+)
+
+// informationSchemaViews is the schema of each INFORMATION_SCHEMA view.
+// Only the commonly used columns are defined.
+var informationSchemaViews = map[string]bq.Schema{
+	"JOBS":                 jobsSchema,
+	"JOBS_BY_USER":         jobsSchema,
+	"JOBS_BY_PROJECT":      jobsSchema,
+	"JOBS_BY_FOLDER":       jobsSchema,
+	"JOBS_BY_ORGANIZATION": jobsSchema,
+	"TABLES":               tablesSchema,
+	"COLUMNS":              columnsSchema,
+	"COLUMN_FIELD_PATHS":   stringFields("table_catalog", "table_schema", "table_name", "column_name", "field_path", "data_type", "description", "collation_name", "rounding_mode"),
+	"TABLE_OPTIONS":        stringFields("table_catalog", "table_schema", "table_name", "option_name", "option_type", "option_value"),
+	"TABLE_STORAGE":        tableStorageSchema,
+	"PARTITIONS":           partitionsSchema,
+	"SCHEMATA":             schemataSchema,
+	"VIEWS":                stringFields("table_catalog", "table_schema", "table_name", "view_definition", "check_option", "use_standard_sql"),
+	"ROUTINES":             routinesSchema,
+}
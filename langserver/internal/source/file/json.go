@@ -0,0 +1,12 @@
+package file
+
+import "regexp"
+
+// JSONComparisonCode is the code of the error which compares JSON with the other type like `payload.id = 'a'`.
+const JSONComparisonCode = "json-comparison"
+
+var jsonComparisonErrorRegex = regexp.MustCompile(`No matching signature for operator \S+ for argument types: (?:JSON, \w+|\w+, JSON)`)
+
+func isJSONComparisonError(msg string) bool {
+	return jsonComparisonErrorRegex.MatchString(msg)
+}
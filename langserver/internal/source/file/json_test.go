@@ -0,0 +1,71 @@
+package file_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnalyzer_ParseFileJSONComparison(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectCode string
+	}{
+		"compare JSON with STRING": {
+			file:       "SELECT id FROM `project.dataset.table` WHERE payload.name = 'a'",
+			expectCode: file.JSONComparisonCode,
+		},
+		"compare STRING with JSON": {
+			file:       "SELECT id FROM `project.dataset.table` WHERE 'a' = payload",
+			expectCode: file.JSONComparisonCode,
+		},
+		"compare with JSON_VALUE": {
+			file: "SELECT id FROM `project.dataset.table` WHERE JSON_VALUE(payload.name) = 'a'",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "payload",
+						Type: bq.JSONFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+			if tt.expectCode == "" {
+				if len(parsedFile.Errors) > 0 {
+					t.Fatalf("the file should be analyzed: %v", parsedFile.Errors)
+				}
+				return
+			}
+			if len(parsedFile.Errors) != 1 {
+				t.Fatalf("expect 1 error, but got %v", parsedFile.Errors)
+			}
+			got := parsedFile.Errors[0]
+			if got.Code != tt.expectCode {
+				t.Errorf("expect code %s, but got %s", tt.expectCode, got.Code)
+			}
+			if !strings.Contains(got.Msg, "JSON_VALUE") {
+				t.Errorf("the message should suggest JSON_VALUE: %s", got.Msg)
+			}
+		})
+	}
+}
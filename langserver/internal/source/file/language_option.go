@@ -0,0 +1,83 @@
+package file
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-zetasql"
+	"github.com/goccy/go-zetasql/types"
+)
+
+// LanguageOption configures the syntax which the analyzer accepts.
+type LanguageOption struct {
+	// EnabledFeatures are enabled in addition to the released features.
+	EnabledFeatures []zetasql.LanguageFeature
+	// DisabledFeatures are disabled even if they are released.
+	DisabledFeatures []zetasql.LanguageFeature
+	ProductMode      types.ProductMode
+	// ReservedKeywords are the reservable keywords like `QUALIFY` which are treated as reserved.
+	ReservedKeywords []string
+}
+
+// DefaultLanguageOption is the option which follows BigQuery.
+func DefaultLanguageOption() LanguageOption {
+	return LanguageOption{
+		EnabledFeatures: []zetasql.LanguageFeature{
+			zetasql.FeatureV13AllowDashesInTableName,
+			zetasql.FeatureV13Qualify,
+			zetasql.FeatureV13ScriptLabel,
+			zetasql.FeatureAnalyticFunctions,
+			zetasql.FeatureGeography,
+			zetasql.FeatureIntervalType,
+			zetasql.FeatureBignumericType,
+		},
+		ProductMode:      types.ProductExternal,
+		ReservedKeywords: []string{"QUALIFY"},
+	}
+}
+
+// languageFeatures are the features which can be configured by their names.
+// The names are the ones of zetasql without the `FEATURE_` prefix.
+var languageFeatures = map[string]zetasql.LanguageFeature{
+	"ANALYTIC_FUNCTIONS":               zetasql.FeatureAnalyticFunctions,
+	"BIGNUMERIC_TYPE":                  zetasql.FeatureBignumericType,
+	"GEOGRAPHY":                        zetasql.FeatureGeography,
+	"INTERVAL_TYPE":                    zetasql.FeatureIntervalType,
+	"JSON_TYPE":                        zetasql.FeatureJsonType,
+	"NUMERIC_TYPE":                     zetasql.FeatureNumericType,
+	"TABLESAMPLE":                      zetasql.FeatureTablesample,
+	"V_1_3_ALLOW_DASHES_IN_TABLE_NAME": zetasql.FeatureV13AllowDashesInTableName,
+	"V_1_3_PIVOT":                      zetasql.FeatureV13Pivot,
+	"V_1_3_QUALIFY":                    zetasql.FeatureV13Qualify,
+	"V_1_3_SCRIPT_LABEL":               zetasql.FeatureV13ScriptLabel,
+	"V_1_3_UNPIVOT":                    zetasql.FeatureV13Unpivot,
+	"V_1_3_WITH_RECURSIVE":             zetasql.FeatureV13WithRecursive,
+}
+
+// ParseLanguageFeature returns the feature of the name like `V_1_3_QUALIFY` or `FEATURE_V_1_3_QUALIFY`.
+func ParseLanguageFeature(name string) (zetasql.LanguageFeature, error) {
+	key := strings.TrimPrefix(strings.ToUpper(name), "FEATURE_")
+	feature, ok := languageFeatures[key]
+	if !ok {
+		names := make([]string, 0, len(languageFeatures))
+		for n := range languageFeatures {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return 0, fmt.Errorf("unknown language feature %q: it should be one of %s", name, strings.Join(names, ", "))
+	}
+	return feature, nil
+}
+
+// ParseProductMode returns the product mode of `external` or `internal`.
+func ParseProductMode(s string) (types.ProductMode, error) {
+	switch strings.ToLower(s) {
+	case "", "external":
+		return types.ProductExternal, nil
+	case "internal":
+		return types.ProductInternal, nil
+	default:
+		return 0, fmt.Errorf("unknown product mode %q: it should be external or internal", s)
+	}
+}
@@ -0,0 +1,119 @@
+package file_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/goccy/go-zetasql"
+	"github.com/golang/mock/gomock"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseLanguageFeature(t *testing.T) {
+	tests := map[string]struct {
+		name string
+
+		expectFeature zetasql.LanguageFeature
+		expectErr     bool
+	}{
+		"name without prefix": {
+			name:          "V_1_3_PIVOT",
+			expectFeature: zetasql.FeatureV13Pivot,
+		},
+		"name with prefix in lower case": {
+			name:          "feature_v_1_3_qualify",
+			expectFeature: zetasql.FeatureV13Qualify,
+		},
+		"unknown name": {
+			name:      "UNKNOWN",
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got, err := file.ParseLanguageFeature(tt.name)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("ParseLanguageFeature should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expectFeature {
+				t.Errorf("ParseLanguageFeature: got %v, want %v", got, tt.expectFeature)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_SetLanguageOption(t *testing.T) {
+	const src = "SELECT id FROM `project.dataset.table`\nQUALIFY ROW_NUMBER() OVER (ORDER BY id) = 1"
+
+	tests := map[string]struct {
+		option func() file.LanguageOption
+
+		expectSetErr   bool
+		expectParseErr bool
+	}{
+		"default option": {
+			option: file.DefaultLanguageOption,
+		},
+		"disable QUALIFY": {
+			option: func() file.LanguageOption {
+				option := file.DefaultLanguageOption()
+				option.DisabledFeatures = []zetasql.LanguageFeature{zetasql.FeatureV13Qualify}
+				return option
+			},
+			expectParseErr: true,
+		},
+		"unknown reserved keyword": {
+			option: func() file.LanguageOption {
+				option := file.DefaultLanguageOption()
+				option.ReservedKeywords = []string{"UNKNOWN"}
+				return option
+			},
+			expectSetErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			err := analyzer.SetLanguageOption(tt.option())
+			if tt.expectSetErr {
+				if err == nil {
+					t.Fatal("SetLanguageOption should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", src)
+			if tt.expectParseErr && len(parsedFile.Errors) == 0 {
+				t.Fatal("the file should have errors")
+			}
+			if !tt.expectParseErr && len(parsedFile.Errors) > 0 {
+				t.Fatalf("the file should be analyzed: %v", parsedFile.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package file
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// SelectStarMessage is the message of the error reported by SelectStarErrors.
+const SelectStarMessage = "SELECT * depends on the schema of the source, so the output columns change silently when the schema evolves. List the columns, or use EXCEPT or REPLACE."
+
+// NonDeterministicLimitMessage is the message of the error reported by NonDeterministicLimitErrors.
+const NonDeterministicLimitMessage = "ORDER BY with LIMIT returns non-deterministic rows when the ordering keys have ties. Order by the keys which make the rows unique."
+
+// OrdinalMessage is the message of the error reported by OrdinalErrors.
+const OrdinalMessage = "The ordinal refers to the column by its position, so it silently refers to another column when the SELECT list changes. Use the expression or the alias."
+
+// SelectStarErrors reports `SELECT *` and `SELECT t.*` as warnings.
+// The star with EXCEPT or REPLACE is not reported because the columns are chosen explicitly.
+func (p ParsedFile) SelectStarErrors() []Error {
+	result := make([]Error, 0)
+	if p.Node == nil {
+		return result
+	}
+	ast.Walk(p.Node, func(n ast.Node) error {
+		switch n.(type) {
+		case *ast.StarNode, *ast.DotStarNode:
+		default:
+			return nil
+		}
+		// the star in COUNT(*) is not the column
+		if _, ok := n.Parent().(*ast.SelectColumnNode); !ok {
+			return nil
+		}
+
+		rng, ok := p.PositionRange(n.ParseLocationRange())
+		if !ok {
+			return nil
+		}
+		pErr := Error{
+			Msg:      SelectStarMessage,
+			Position: rng.Start,
+			Severity: lsp.Warning,
+			Code:     "select-star",
+		}
+		if rng.Start.Line == rng.End.Line {
+			pErr.TermLength = rng.End.Character - rng.Start.Character
+		}
+		result = append(result, pErr)
+		return nil
+	})
+	return result
+}
+
+// NonDeterministicLimitErrors reports ORDER BY followed by LIMIT whose keys don't make the rows unique, as warnings.
+// The rows are regarded as unique when the keys contain all the GROUP BY keys, or all the columns of SELECT without GROUP BY.
+// The query which selects the star is not reported because its columns are unknown without the analysis.
+func (p ParsedFile) NonDeterministicLimitErrors() []Error {
+	result := make([]Error, 0)
+	if p.Node == nil {
+		return result
+	}
+	ast.Walk(p.Node, func(n ast.Node) error {
+		query, ok := n.(*ast.QueryNode)
+		if !ok || query.OrderBy() == nil || query.LimitOffset() == nil {
+			return nil
+		}
+		selectNode, ok := query.QueryExpr().(*ast.SelectNode)
+		if !ok {
+			return nil
+		}
+		if p.isUniqueOrder(selectNode, query.OrderBy()) {
+			return nil
+		}
+
+		rng, ok := p.PositionRange(query.OrderBy().ParseLocationRange())
+		if !ok {
+			return nil
+		}
+		pErr := Error{
+			Msg:      NonDeterministicLimitMessage,
+			Position: rng.Start,
+			Severity: lsp.Warning,
+			Code:     "non-deterministic-limit",
+		}
+		if rng.Start.Line == rng.End.Line {
+			pErr.TermLength = rng.End.Character - rng.Start.Character
+		}
+		result = append(result, pErr)
+		return nil
+	})
+	return result
+}
+
+// OrdinalErrors reports the ordinals of GROUP BY and ORDER BY like `GROUP BY 1` as warnings.
+func (p ParsedFile) OrdinalErrors() []Error {
+	result := make([]Error, 0)
+	if p.Node == nil {
+		return result
+	}
+	ast.Walk(p.Node, func(n ast.Node) error {
+		if _, ok := n.(*ast.IntLiteralNode); !ok {
+			return nil
+		}
+		switch n.Parent().(type) {
+		case *ast.GroupingItemNode, *ast.OrderingExpressionNode:
+		default:
+			return nil
+		}
+
+		rng, ok := p.PositionRange(n.ParseLocationRange())
+		if !ok {
+			return nil
+		}
+		pErr := Error{
+			Msg:      OrdinalMessage,
+			Position: rng.Start,
+			Severity: lsp.Warning,
+			Code:     "ordinal",
+		}
+		if rng.Start.Line == rng.End.Line {
+			pErr.TermLength = rng.End.Character - rng.Start.Character
+		}
+		result = append(result, pErr)
+		return nil
+	})
+	return result
+}
+
+func (p ParsedFile) isUniqueOrder(selectNode *ast.SelectNode, orderBy *ast.OrderByNode) bool {
+	columns := selectNode.SelectList().Columns()
+
+	// expression resolves the ordinal and the alias of the SELECT list into its expression
+	expression := func(expr ast.ExpressionNode) string {
+		text, ok := p.ExtractSQL(expr.ParseLocationRange())
+		if !ok {
+			return ""
+		}
+		text = normalizeExpression(text)
+		if i, err := strconv.Atoi(text); err == nil && 0 < i && i <= len(columns) {
+			if t, ok := p.ExtractSQL(columns[i-1].Expression().ParseLocationRange()); ok {
+				return normalizeExpression(t)
+			}
+		}
+		for _, c := range columns {
+			if alias := c.Alias(); alias != nil && strings.EqualFold(alias.Identifier().Name(), text) {
+				if t, ok := p.ExtractSQL(c.Expression().ParseLocationRange()); ok {
+					return normalizeExpression(t)
+				}
+			}
+		}
+		return text
+	}
+
+	keys := make(map[string]struct{})
+	for _, item := range orderBy.OrderingExpressions() {
+		keys[expression(item.Expression())] = struct{}{}
+	}
+
+	var required []ast.ExpressionNode
+	if groupBy := selectNode.GroupBy(); groupBy != nil {
+		for _, item := range groupBy.GroupingItems() {
+			if item.Expression() == nil {
+				// ROLLUP is not checked
+				return true
+			}
+			required = append(required, item.Expression())
+		}
+	} else {
+		for _, c := range columns {
+			if isStar(c.Expression()) {
+				return true
+			}
+			required = append(required, c.Expression())
+		}
+	}
+
+	for _, expr := range required {
+		if _, ok := keys[expression(expr)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func isStar(expr ast.ExpressionNode) bool {
+	switch expr.(type) {
+	case *ast.StarNode, *ast.DotStarNode, *ast.StarWithModifiersNode, *ast.DotStarWithModifiersNode:
+		return true
+	}
+	return false
+}
+
+// normalizeExpression removes the spaces and the case differences to compare the expressions by their texts.
+func normalizeExpression(expr string) string {
+	return strings.ToLower(strings.Join(strings.Fields(expr), ""))
+}
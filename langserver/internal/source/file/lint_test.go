@@ -0,0 +1,212 @@
+package file_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParsedFile_SelectStarErrors(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectErrs []file.Error
+	}{
+		"select star": {
+			file: "SELECT * FROM `project.dataset.table`",
+			expectErrs: []file.Error{
+				{
+					Msg:        file.SelectStarMessage,
+					Position:   lsp.Position{Line: 0, Character: 7},
+					TermLength: 1,
+					Severity:   lsp.Warning,
+					Code:       "select-star",
+				},
+			},
+		},
+		"select table star": {
+			file: "SELECT t.* FROM `project.dataset.table` AS t",
+			expectErrs: []file.Error{
+				{
+					Msg:        file.SelectStarMessage,
+					Position:   lsp.Position{Line: 0, Character: 7},
+					TermLength: 3,
+					Severity:   lsp.Warning,
+					Code:       "select-star",
+				},
+			},
+		},
+		"star with EXCEPT and COUNT(*) are allowed": {
+			file:       "SELECT * EXCEPT (name), (SELECT COUNT(*) FROM `project.dataset.table`) AS cnt FROM `project.dataset.table`",
+			expectErrs: []file.Error{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+
+			got := parsedFile.SelectStarErrors()
+			if diff := cmp.Diff(tt.expectErrs, got); diff != "" {
+				t.Errorf("SelectStarErrors result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParsedFile_NonDeterministicLimitErrors(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectErrs []file.Error
+	}{
+		"order by non-unique key": {
+			file: "SELECT id, name FROM `project.dataset.table` ORDER BY id LIMIT 10",
+			expectErrs: []file.Error{
+				{
+					Msg:        file.NonDeterministicLimitMessage,
+					Position:   lsp.Position{Line: 0, Character: 45},
+					TermLength: 11,
+					Severity:   lsp.Warning,
+					Code:       "non-deterministic-limit",
+				},
+			},
+		},
+		"order by all columns with ordinal and alias": {
+			file:       "SELECT id, name AS n FROM `project.dataset.table` ORDER BY 1, n DESC LIMIT 10",
+			expectErrs: []file.Error{},
+		},
+		"order by group by keys": {
+			file:       "SELECT name, COUNT(*) AS cnt FROM `project.dataset.table` GROUP BY name ORDER BY cnt DESC, name LIMIT 10",
+			expectErrs: []file.Error{},
+		},
+		"order by aggregation only": {
+			file: "SELECT name, COUNT(*) AS cnt FROM `project.dataset.table` GROUP BY 1 ORDER BY cnt DESC LIMIT 10",
+			expectErrs: []file.Error{
+				{
+					Msg:        file.NonDeterministicLimitMessage,
+					Position:   lsp.Position{Line: 0, Character: 69},
+					TermLength: 17,
+					Severity:   lsp.Warning,
+					Code:       "non-deterministic-limit",
+				},
+			},
+		},
+		"without limit": {
+			file:       "SELECT id, name FROM `project.dataset.table` ORDER BY id",
+			expectErrs: []file.Error{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+
+			got := parsedFile.NonDeterministicLimitErrors()
+			if diff := cmp.Diff(tt.expectErrs, got); diff != "" {
+				t.Errorf("NonDeterministicLimitErrors result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParsedFile_OrdinalErrors(t *testing.T) {
+	ordinalErr := func(character int) file.Error {
+		return file.Error{
+			Msg:        file.OrdinalMessage,
+			Position:   lsp.Position{Line: 0, Character: character},
+			TermLength: 1,
+			Severity:   lsp.Warning,
+			Code:       "ordinal",
+		}
+	}
+
+	tests := map[string]struct {
+		file string
+
+		expectErrs []file.Error
+	}{
+		"ordinals of group by and order by": {
+			file: "SELECT id, name FROM `project.dataset.table` GROUP BY 1, 2 ORDER BY 2 DESC",
+			expectErrs: []file.Error{
+				ordinalErr(54),
+				ordinalErr(57),
+				ordinalErr(68),
+			},
+		},
+		"expressions": {
+			file:       "SELECT id, name FROM `project.dataset.table` GROUP BY id, name ORDER BY name DESC",
+			expectErrs: []file.Error{},
+		},
+		"literal in the expression": {
+			file:       "SELECT id + 1 AS id FROM `project.dataset.table` GROUP BY id + 1",
+			expectErrs: []file.Error{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+
+			got := parsedFile.OrdinalErrors()
+			if diff := cmp.Diff(tt.expectErrs, got); diff != "" {
+				t.Errorf("OrdinalErrors result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -76,7 +76,7 @@ func ListResolvedAstNode[T locationRangeNode](output *zetasql.AnalyzerOutput) []
 }
 
 type astNode interface {
-	*ast.TablePathExpressionNode | *ast.PathExpressionNode | *ast.SelectColumnNode
+	*ast.TablePathExpressionNode | *ast.PathExpressionNode | *ast.SelectColumnNode | *ast.TVFNode | *ast.QueryNode
 }
 
 func LookupNode[T astNode](n ast.Node) (T, bool) {
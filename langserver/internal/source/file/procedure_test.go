@@ -0,0 +1,61 @@
+package file_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnalyzer_ParseFileWithProcedure(t *testing.T) {
+	tests := map[string]struct {
+		file string
+	}{
+		"parameters in the body": {
+			file: "CREATE PROCEDURE dataset.proc(x INT64, name STRING)\nBEGIN\n  SELECT id FROM `project.dataset.table` WHERE id = x AND name = 'a';\nEND;",
+		},
+		"call the procedure created in the script": {
+			file: "CREATE PROCEDURE dataset.proc(x INT64)\nBEGIN\n  SELECT x;\nEND;\nCALL dataset.proc(1);",
+		},
+		"call the persistent procedure": {
+			file: "CALL dataset.persistent_proc(1);",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetDefaultProject().Return("project").MinTimes(0)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			bqClient.EXPECT().GetRoutineMetadata(gomock.Any(), "project", "dataset", "persistent_proc").Return(&bq.RoutineMetadata{
+				Type: "PROCEDURE",
+				Arguments: []*bq.RoutineArgument{
+					{
+						Name:     "x",
+						DataType: &bq.StandardSQLDataType{TypeKind: "INT64"},
+					},
+				},
+			}, nil).MinTimes(0)
+			bqClient.EXPECT().GetRoutineMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("not found")).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+			if len(parsedFile.Errors) > 0 {
+				t.Errorf("the procedure should be analyzed: %v", parsedFile.Errors)
+			}
+		})
+	}
+}
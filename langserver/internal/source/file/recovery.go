@@ -0,0 +1,61 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/kitagry/bqls/langserver/internal/cache"
+)
+
+// maxCachedDocuments is the number of the documents whose analyses of the statements are kept to skip analyzing the unchanged statements.
+const maxCachedDocuments = cache.DefaultMaxDocuments
+
+// statementCacheKey identifies the analysis of the statement which ends at end.
+// The analysis of a statement depends only on itself and the preceding statements like DECLARE and CREATE TEMP FUNCTION,
+// so the statement whose source up to its end is unchanged reuses the last analysis while the following statements are edited.
+func statementCacheKey(src string, end int) string {
+	sum := sha256.Sum256([]byte(src[:end]))
+	return hex.EncodeToString(sum[:])
+}
+
+// blankStatement replaces the statement which contains offset with spaces, so that the other statements of the script can be parsed.
+// The newlines and the length are kept, so the positions of the other statements don't move.
+// It returns false when the statement is the only one in src, because nothing is recovered.
+func blankStatement(src string, offset int) (string, bool) {
+	start, end := 0, len(src)
+	for _, s := range semicolonOffsets(src) {
+		if s < offset {
+			start = s + 1
+			continue
+		}
+		end = s + 1
+		break
+	}
+
+	if strings.TrimSpace(src[start:end]) == "" {
+		return src, false
+	}
+	if strings.TrimSpace(src[:start]) == "" && strings.TrimSpace(src[end:]) == "" {
+		return src, false
+	}
+
+	blank := []byte(src[start:end])
+	for i, b := range blank {
+		if b != '\n' {
+			blank[i] = ' '
+		}
+	}
+	return src[:start] + string(blank) + src[end:], true
+}
+
+// semicolonOffsets returns the offsets of the semicolons which are not in the string literals, the quoted identifiers or the comments.
+func semicolonOffsets(src string) []int {
+	result := make([]int, 0)
+	for _, token := range Tokenize(src) {
+		if token.Kind == CodeToken && token.Text == ";" {
+			result = append(result, token.Start)
+		}
+	}
+	return result
+}
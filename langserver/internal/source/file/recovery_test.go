@@ -0,0 +1,114 @@
+package file_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	rast "github.com/goccy/go-zetasql/resolved_ast"
+	"github.com/golang/mock/gomock"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnalyzer_ParseFileRecoversFromSyntaxError(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectedRNodes int
+		expectedErrs   int
+	}{
+		"statement in the middle is being edited": {
+			file:           "SELECT 1;\nSELECT 2 FROM WHERE;\nSELECT 3",
+			expectedRNodes: 2,
+			expectedErrs:   1,
+		},
+		"semicolon in the string literal": {
+			file:           "SELECT ';' AS a;\nSELECT 2 FROM WHERE;\nSELECT 3",
+			expectedRNodes: 2,
+			expectedErrs:   1,
+		},
+		"only one statement": {
+			file:           "SELECT 1 FROM WHERE",
+			expectedRNodes: 0,
+			expectedErrs:   1,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			logger := logrus.New()
+			logger.SetLevel(logrus.DebugLevel)
+
+			analyzer := file.NewAnalyzer(logger, bqClient)
+			got := analyzer.ParseFile(context.Background(), "uri", tt.file)
+			if len(got.RNode) != tt.expectedRNodes {
+				t.Errorf("ParseFile should analyze %d statements, got %d", tt.expectedRNodes, len(got.RNode))
+			}
+			if len(got.Errors) != tt.expectedErrs {
+				t.Errorf("ParseFile should return %d errors, got %v", tt.expectedErrs, got.Errors)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_ParseFileReusesAnalysisOfUnchangedStatements(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+	first := analyzer.ParseFile(context.Background(), "uri", "SELECT 1;\nSELECT 2")
+
+	got := analyzer.ParseFile(context.Background(), "uri", "SELECT 1;\nSELECT 3")
+	if len(got.RNode) != 2 {
+		t.Fatalf("ParseFile should analyze 2 statements, got %d", len(got.RNode))
+	}
+	if got.RNode[0] != first.RNode[0] {
+		t.Errorf("the first statement should be reused")
+	}
+	if got.RNode[1] == first.RNode[1] {
+		t.Errorf("the changed statement should be analyzed again")
+	}
+
+	if other := analyzer.ParseFile(context.Background(), "other", "SELECT 1;\nSELECT 3"); other.RNode[0] == got.RNode[0] {
+		t.Errorf("the analysis of the other document should not be reused")
+	}
+	if stats := analyzer.StatementCacheStats(); stats.Len != 2 {
+		t.Errorf("the cache should have the statements of 2 documents, got %d", stats.Len)
+	}
+
+	analyzer.ClearStatementCache()
+	if cleared := analyzer.ParseFile(context.Background(), "uri", "SELECT 1;\nSELECT 3"); cleared.RNode[0] == got.RNode[0] {
+		t.Errorf("the statement should be analyzed again after the cache is cleared")
+	}
+}
+
+func TestAnalyzer_ParseFileIsolatesAnalysisErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+	src := "SELECT unknown_function();\nSELECT 2 AS two"
+	got := analyzer.ParseFile(context.Background(), "uri", src)
+	if len(got.RNode) != 2 {
+		t.Fatalf("ParseFile should have an entry for each statement, got %d", len(got.RNode))
+	}
+	if got.RNode[0] != nil {
+		t.Errorf("the statement which fails to be analyzed should be nil")
+	}
+
+	if _, ok := got.FindTargetAnalyzeOutput(strings.Index(src, "unknown_function")); ok {
+		t.Errorf("FindTargetAnalyzeOutput should not return the analysis of the failed statement")
+	}
+	output, ok := got.FindTargetAnalyzeOutput(strings.Index(src, "two"))
+	if !ok {
+		t.Fatalf("FindTargetAnalyzeOutput should return the analysis of the second statement")
+	}
+	stmt, ok := output.Statement().(*rast.QueryStmtNode)
+	if !ok || len(stmt.OutputColumnList()) != 1 || stmt.OutputColumnList()[0].Name() != "two" {
+		t.Errorf("FindTargetAnalyzeOutput should return the analysis of the second statement, got %v", output.Statement())
+	}
+}
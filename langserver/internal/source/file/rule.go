@@ -0,0 +1,96 @@
+package file
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// LintRule is the structural lint rule defined by the user.
+// It reports the AST nodes of the Node kind which satisfy all the predicates.
+type LintRule struct {
+	// Name is reported as the code of the diagnostic.
+	Name string
+	// Node is the kind of the AST node like `Join` or `FunctionCall`, which is the name of the node type without the `Node` suffix.
+	Node string
+	// Pattern limits the nodes to the ones whose SQL matches it.
+	Pattern *regexp.Regexp
+	// NotPattern limits the nodes to the ones whose SQL doesn't match it.
+	NotPattern *regexp.Regexp
+	// Within limits the nodes to the ones in the node of the kind.
+	Within   string
+	Message  string
+	Severity lsp.DiagnosticSeverity
+}
+
+// LintRuleErrors reports the AST nodes which match the rules.
+func (p ParsedFile) LintRuleErrors(rules []LintRule) []Error {
+	result := make([]Error, 0)
+	if p.Node == nil || len(rules) == 0 {
+		return result
+	}
+	ast.Walk(p.Node, func(n ast.Node) error {
+		kind := NodeKind(n)
+		for _, rule := range rules {
+			if !strings.EqualFold(rule.Node, kind) || !p.matchLintRule(rule, n) {
+				continue
+			}
+
+			rng, ok := p.PositionRange(n.ParseLocationRange())
+			if !ok {
+				continue
+			}
+			msg := rule.Message
+			if msg == "" {
+				msg = fmt.Sprintf("%s is reported by %s", kind, rule.Name)
+			}
+			pErr := Error{
+				Msg:      msg,
+				Position: rng.Start,
+				Severity: rule.Severity,
+				Code:     rule.Name,
+			}
+			if rng.Start.Line == rng.End.Line {
+				pErr.TermLength = rng.End.Character - rng.Start.Character
+			}
+			result = append(result, pErr)
+		}
+		return nil
+	})
+	return result
+}
+
+func (p ParsedFile) matchLintRule(rule LintRule, n ast.Node) bool {
+	if rule.Pattern != nil || rule.NotPattern != nil {
+		text, ok := p.ExtractSQL(n.ParseLocationRange())
+		if !ok {
+			return false
+		}
+		if rule.Pattern != nil && !rule.Pattern.MatchString(text) {
+			return false
+		}
+		if rule.NotPattern != nil && rule.NotPattern.MatchString(text) {
+			return false
+		}
+	}
+
+	if rule.Within == "" {
+		return true
+	}
+	for parent := n.Parent(); parent != nil; parent = parent.Parent() {
+		if strings.EqualFold(rule.Within, NodeKind(parent)) {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeKind returns the kind of the AST node like `Join` for *ast.JoinNode.
+func NodeKind(n ast.Node) string {
+	kind := fmt.Sprintf("%T", n)
+	kind = kind[strings.LastIndex(kind, ".")+1:]
+	return strings.TrimSuffix(kind, "Node")
+}
@@ -0,0 +1,87 @@
+package file_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParsedFile_LintRuleErrors(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectErrs []file.Error
+	}{
+		"node with pattern": {
+			file: "SELECT a.id FROM `project.dataset.table` a CROSS JOIN `project.dataset.table` b",
+			expectErrs: []file.Error{
+				{
+					Msg:        "Use JOIN with the condition",
+					Position:   lsp.Position{Line: 0, Character: 17},
+					TermLength: 62,
+					Severity:   lsp.Error,
+					Code:       "no-cross-join",
+				},
+			},
+		},
+		"node within": {
+			file: "SELECT (SELECT 1) AS one FROM `project.dataset.table` WHERE EXISTS (SELECT id FROM `project.dataset.table`)",
+			expectErrs: []file.Error{
+				{
+					Msg:        "ExpressionSubquery is reported by no-subquery-in-where",
+					Position:   lsp.Position{Line: 0, Character: 60},
+					TermLength: 47,
+					Severity:   lsp.Warning,
+					Code:       "no-subquery-in-where",
+				},
+			},
+		},
+		"not pattern": {
+			file:       "SELECT a.id FROM `project.dataset.table` a JOIN `project.dataset.table` b USING (id)",
+			expectErrs: []file.Error{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+					{
+						Name: "ts",
+						Type: bq.TimestampFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+
+			got := parsedFile.LintRuleErrors([]file.LintRule{
+				{Name: "no-cross-join", Node: "join", Pattern: regexp.MustCompile(`(?i)\bCROSS\s+JOIN\b`), Message: "Use JOIN with the condition", Severity: lsp.Error},
+				{Name: "no-subquery-in-where", Node: "ExpressionSubquery", Within: "WhereClause", Severity: lsp.Warning},
+				{Name: "join-without-condition", Node: "Join", NotPattern: regexp.MustCompile(`(?i)\b(CROSS|ON|USING)\b`), Severity: lsp.Warning},
+			})
+			if diff := cmp.Diff(tt.expectErrs, got); diff != "" {
+				t.Errorf("LintRuleErrors result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/goccy/go-zetasql/types"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// scheduledQueryParameters are the parameters which the Data Transfer Service sets to the scheduled queries.
+var scheduledQueryParameters = map[string]types.Type{
+	"run_time": types.TimestampType(),
+	"run_date": types.DateType(),
+}
+
+// ScheduledQueryErrors reports the SQL which can't be scheduled by the Data Transfer Service.
+// The scheduled query can only use @run_time and @run_date as the parameters.
+// When withDestination is true, the query result is written into the destination table, so the file should be a single SELECT statement.
+func (p ParsedFile) ScheduledQueryErrors(withDestination bool) []Error {
+	result := make([]Error, 0)
+	if p.Node == nil {
+		return result
+	}
+
+	ast.Walk(p.Node, func(n ast.Node) error {
+		param, ok := n.(*ast.ParameterExprNode)
+		if !ok {
+			return nil
+		}
+		msg := "positional parameters are not supported in the scheduled query"
+		if param.Name() != nil {
+			if _, ok := scheduledQueryParameters[strings.ToLower(param.Name().Name())]; ok {
+				return nil
+			}
+			msg = fmt.Sprintf("@%s is not supported in the scheduled query. Only @run_time and @run_date are available", param.Name().Name())
+		}
+		if pErr, ok := p.nodeError(n, msg); ok {
+			result = append(result, pErr)
+		}
+		return nil
+	})
+
+	if !withDestination {
+		return result
+	}
+	statements, lastEnd := 0, -1
+	ast.Walk(p.Node, func(n ast.Node) error {
+		if n == nil || !n.IsStatement() {
+			return nil
+		}
+		loc := n.ParseLocationRange()
+		if loc == nil || loc.Start().ByteOffset() < lastEnd {
+			// the statement nested in BEGIN...END is reported as the outer statement
+			return nil
+		}
+		lastEnd = loc.End().ByteOffset()
+		statements++
+		if _, ok := n.(*ast.QueryStatementNode); ok && statements == 1 {
+			return nil
+		}
+		if pErr, ok := p.nodeError(n, "the scheduled query with the destination table should be a single SELECT statement"); ok {
+			result = append(result, pErr)
+		}
+		return nil
+	})
+	return result
+}
+
+func (p ParsedFile) nodeError(n ast.Node, msg string) (Error, bool) {
+	rng, ok := p.PositionRange(n.ParseLocationRange())
+	if !ok {
+		return Error{}, false
+	}
+	pErr := Error{
+		Msg:      msg,
+		Position: rng.Start,
+		Severity: lsp.Error,
+		Code:     "scheduled-query",
+	}
+	if rng.Start.Line == rng.End.Line {
+		pErr.TermLength = rng.End.Character - rng.Start.Character
+	}
+	return pErr, true
+}
@@ -0,0 +1,94 @@
+package file_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParsedFile_ScheduledQueryErrors(t *testing.T) {
+	tests := map[string]struct {
+		file            string
+		withDestination bool
+
+		expectErrs []file.Error
+	}{
+		"run_date and run_time": {
+			file:       "SELECT id FROM `project.dataset.table` WHERE DATE(ts) = DATE_SUB(@run_date, INTERVAL 1 DAY) AND ts < @run_time",
+			expectErrs: []file.Error{},
+		},
+		"unsupported parameter": {
+			file: "SELECT id FROM `project.dataset.table` WHERE id = @id",
+			expectErrs: []file.Error{
+				{
+					Msg:        "@id is not supported in the scheduled query. Only @run_time and @run_date are available",
+					Position:   lsp.Position{Line: 0, Character: 50},
+					TermLength: 3,
+					Severity:   lsp.Error,
+					Code:       "scheduled-query",
+				},
+			},
+		},
+		"multiple statements with destination": {
+			file:            "DELETE FROM `project.dataset.table` WHERE TRUE;\nSELECT 1",
+			withDestination: true,
+			expectErrs: []file.Error{
+				{
+					Msg:        "the scheduled query with the destination table should be a single SELECT statement",
+					Position:   lsp.Position{Line: 0, Character: 0},
+					TermLength: 46,
+					Severity:   lsp.Error,
+					Code:       "scheduled-query",
+				},
+				{
+					Msg:        "the scheduled query with the destination table should be a single SELECT statement",
+					Position:   lsp.Position{Line: 1, Character: 0},
+					TermLength: 8,
+					Severity:   lsp.Error,
+					Code:       "scheduled-query",
+				},
+			},
+		},
+		"multiple statements without destination": {
+			file:       "DELETE FROM `project.dataset.table` WHERE TRUE;\nSELECT 1",
+			expectErrs: []file.Error{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "ts",
+						Type: bq.TimestampFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+			if len(parsedFile.Errors) > 0 {
+				t.Fatalf("the scheduled query should be analyzed: %v", parsedFile.Errors)
+			}
+
+			got := parsedFile.ScheduledQueryErrors(tt.withDestination)
+			if diff := cmp.Diff(tt.expectErrs, got); diff != "" {
+				t.Errorf("ScheduledQueryErrors result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
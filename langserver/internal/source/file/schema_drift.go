@@ -0,0 +1,136 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/goccy/go-zetasql/ast"
+	rast "github.com/goccy/go-zetasql/resolved_ast"
+	"github.com/goccy/go-zetasql/types"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+const SchemaDriftCode = "schema-drift"
+
+// SchemaDriftErrors reports the output columns which differ from the schema of the existing table replaced by the query,
+// because the change of the schema breaks the queries which read the table.
+// The table is replaced by CREATE OR REPLACE TABLE AS SELECT, or by the result of the last query when destination is not empty.
+// The table which doesn't exist yet is not reported.
+func (a *Analyzer) SchemaDriftErrors(ctx context.Context, p ParsedFile, destination string) []Error {
+	result := make([]Error, 0)
+	if p.Node == nil {
+		return result
+	}
+
+	for _, stmt := range ListAstNode[*ast.CreateTableStatementNode](p.Node) {
+		if !stmt.IsOrReplace() || stmt.Query() == nil || stmt.Name() == nil {
+			continue
+		}
+		if pErr, ok := a.schemaDriftError(ctx, p, stmt, stmt.Name(), pathName(stmt.Name())); ok {
+			result = append(result, pErr)
+		}
+	}
+
+	if destination == "" {
+		return result
+	}
+	queries := ListAstNode[*ast.QueryStatementNode](p.Node)
+	if len(queries) == 0 {
+		return result
+	}
+	if pErr, ok := a.schemaDriftError(ctx, p, queries[len(queries)-1], queries[len(queries)-1], destination); ok {
+		result = append(result, pErr)
+	}
+	return result
+}
+
+// schemaDriftError compares the output columns of stmt with the schema of the table. The error is reported at target.
+func (a *Analyzer) schemaDriftError(ctx context.Context, p ParsedFile, stmt ast.StatementNode, target ast.Node, table string) (Error, bool) {
+	loc := stmt.ParseLocationRange()
+	if loc == nil {
+		return Error{}, false
+	}
+	output, ok := p.FindTargetAnalyzeOutput(loc.Start().ByteOffset())
+	if !ok {
+		return Error{}, false
+	}
+	outputStmt, ok := output.Statement().(interface {
+		OutputColumnList() []*rast.OutputColumnNode
+	})
+	if !ok {
+		return Error{}, false
+	}
+	metadata, err := a.GetTableMetadataFromPath(ctx, table)
+	if err != nil || metadata == nil {
+		return Error{}, false
+	}
+
+	changes := schemaChanges(outputStmt.OutputColumnList(), metadata.Schema)
+	if len(changes) == 0 {
+		return Error{}, false
+	}
+	rng, ok := p.PositionRange(target.ParseLocationRange())
+	if !ok {
+		return Error{}, false
+	}
+	pErr := Error{
+		Msg:      fmt.Sprintf("the output columns differ from the schema of %s: %s", table, strings.Join(changes, ", ")),
+		Position: rng.Start,
+		Severity: lsp.Warning,
+		Code:     SchemaDriftCode,
+	}
+	if rng.Start.Line == rng.End.Line {
+		pErr.TermLength = rng.End.Character - rng.Start.Character
+	}
+	return pErr, true
+}
+
+// schemaChanges lists the added, removed and retyped columns. The nested fields are compared as the types of the STRUCT columns.
+func schemaChanges(columns []*rast.OutputColumnNode, schema bq.Schema) []string {
+	findField := func(name string) (*bq.FieldSchema, bool) {
+		for _, f := range schema {
+			if strings.EqualFold(f.Name, name) {
+				return f, true
+			}
+		}
+		return nil, false
+	}
+	hasColumn := func(name string) bool {
+		for _, c := range columns {
+			if strings.EqualFold(c.Name(), name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	result := make([]string, 0)
+	for _, c := range columns {
+		typ := c.Column().Type().TypeName(types.ProductExternal)
+		field, ok := findField(c.Name())
+		if !ok {
+			result = append(result, fmt.Sprintf("%s (%s) is added", c.Name(), typ))
+			continue
+		}
+		if tableType := tableFieldTypeName(field); tableType != typ {
+			result = append(result, fmt.Sprintf("%s is changed from %s to %s", c.Name(), tableType, typ))
+		}
+	}
+	for _, f := range schema {
+		if !hasColumn(f.Name) {
+			result = append(result, fmt.Sprintf("%s (%s) is removed", f.Name, tableFieldTypeName(f)))
+		}
+	}
+	return result
+}
+
+// tableFieldTypeName returns the type of the table field in the same form as the output columns like INT64.
+func tableFieldTypeName(field *bq.FieldSchema) string {
+	typ, err := bigqueryTypeToZetaSQLType(field)
+	if err != nil {
+		return FieldTypeString(field)
+	}
+	return typ.TypeName(types.ProductExternal)
+}
@@ -0,0 +1,100 @@
+package file_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnalyzer_SchemaDriftErrors(t *testing.T) {
+	tests := map[string]struct {
+		file        string
+		destination string
+
+		expectErrs []file.Error
+	}{
+		"added and removed columns": {
+			file: "CREATE OR REPLACE TABLE `project.dataset.table` AS SELECT 1 AS id, 'a' AS title",
+			expectErrs: []file.Error{
+				{
+					Msg:        "the output columns differ from the schema of project.dataset.table: title (STRING) is added, name (STRING) is removed",
+					Position:   lsp.Position{Line: 0, Character: 24},
+					TermLength: 23,
+					Severity:   lsp.Warning,
+					Code:       file.SchemaDriftCode,
+				},
+			},
+		},
+		"retyped column": {
+			file: "CREATE OR REPLACE TABLE `project.dataset.table` AS SELECT 'a' AS id, 'b' AS name",
+			expectErrs: []file.Error{
+				{
+					Msg:        "the output columns differ from the schema of project.dataset.table: id is changed from INT64 to STRING",
+					Position:   lsp.Position{Line: 0, Character: 24},
+					TermLength: 23,
+					Severity:   lsp.Warning,
+					Code:       file.SchemaDriftCode,
+				},
+			},
+		},
+		"same schema": {
+			file:       "CREATE OR REPLACE TABLE `project.dataset.table` AS SELECT 1 AS id, 'a' AS name",
+			expectErrs: []file.Error{},
+		},
+		"new table": {
+			file:       "CREATE OR REPLACE TABLE `project.dataset.new_table` AS SELECT 1 AS id",
+			expectErrs: []file.Error{},
+		},
+		"destination of the query": {
+			file:        "SELECT 1 AS id",
+			destination: "project.dataset.table",
+			expectErrs: []file.Error{
+				{
+					Msg:        "the output columns differ from the schema of project.dataset.table: name (STRING) is removed",
+					Position:   lsp.Position{Line: 0, Character: 0},
+					TermLength: 14,
+					Severity:   lsp.Warning,
+					Code:       file.SchemaDriftCode,
+				},
+			},
+		},
+		"query without destination": {
+			file:       "SELECT 1 AS id",
+			expectErrs: []file.Error{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Type: bq.RegularTable,
+				Schema: bq.Schema{
+					{Name: "id", Type: bq.IntegerFieldType},
+					{Name: "name", Type: bq.StringFieldType},
+				},
+			}, nil).MinTimes(0)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "new_table").Return(nil, errors.New("not found")).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+			if len(parsedFile.Errors) > 0 {
+				t.Fatalf("the statement should be analyzed: %v", parsedFile.Errors)
+			}
+
+			got := analyzer.SchemaDriftErrors(context.Background(), parsedFile, tt.destination)
+			if diff := cmp.Diff(tt.expectErrs, got); diff != "" {
+				t.Errorf("SchemaDriftErrors result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
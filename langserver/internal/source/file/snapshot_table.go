@@ -0,0 +1,68 @@
+package file
+
+import (
+	"context"
+	"fmt"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// SnapshotTableErrors reports the DML statements which modify the table snapshot, because the table snapshot is read-only.
+// The table clone is writable, so it is not reported.
+func (a *Analyzer) SnapshotTableErrors(ctx context.Context, p ParsedFile) []Error {
+	result := make([]Error, 0)
+	if p.Node == nil {
+		return result
+	}
+	ast.Walk(p.Node, func(n ast.Node) error {
+		path, ok := dmlTargetPath(n)
+		if !ok || path.ParseLocationRange() == nil {
+			return nil
+		}
+		name := pathName(path)
+		metadata, err := a.GetTableMetadataFromPath(ctx, name)
+		if err != nil || metadata == nil || metadata.Type != bq.Snapshot {
+			return nil
+		}
+
+		rng, ok := p.PositionRange(path.ParseLocationRange())
+		if !ok {
+			return nil
+		}
+		pErr := Error{
+			Msg:      fmt.Sprintf("%s is a table snapshot, which is read-only", name),
+			Position: rng.Start,
+			Severity: lsp.Warning,
+			Code:     "snapshot-table",
+		}
+		if rng.Start.Line == rng.End.Line {
+			pErr.TermLength = rng.End.Character - rng.Start.Character
+		}
+		result = append(result, pErr)
+		return nil
+	})
+	return result
+}
+
+// dmlTargetPath returns the table path modified by the DML statement.
+func dmlTargetPath(n ast.Node) (*ast.PathExpressionNode, bool) {
+	var target ast.Node
+	switch stmt := n.(type) {
+	case *ast.InsertStatementNode:
+		target = stmt.TargetPath()
+	case *ast.UpdateStatementNode:
+		target = stmt.TargetPath()
+	case *ast.DeleteStatementNode:
+		target = stmt.TargetPath()
+	case *ast.MergeStatementNode:
+		target = stmt.TargetPath()
+	case *ast.TrucateStatementNode:
+		target = stmt.TargetPath()
+	default:
+		return nil, false
+	}
+	path, ok := target.(*ast.PathExpressionNode)
+	return path, ok && path != nil
+}
@@ -0,0 +1,90 @@
+package file_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnalyzer_SnapshotTableErrors(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectErrs []file.Error
+	}{
+		"DELETE the table snapshot": {
+			file: "DELETE FROM `project.dataset.snapshot` WHERE id = 1",
+			expectErrs: []file.Error{
+				{
+					Msg:        "project.dataset.snapshot is a table snapshot, which is read-only",
+					Position:   lsp.Position{Line: 0, Character: 12},
+					TermLength: 26,
+					Severity:   lsp.Warning,
+					Code:       "snapshot-table",
+				},
+			},
+		},
+		"INSERT into the table snapshot": {
+			file: "INSERT INTO `project.dataset.snapshot` (id) VALUES (1)",
+			expectErrs: []file.Error{
+				{
+					Msg:        "project.dataset.snapshot is a table snapshot, which is read-only",
+					Position:   lsp.Position{Line: 0, Character: 12},
+					TermLength: 26,
+					Severity:   lsp.Warning,
+					Code:       "snapshot-table",
+				},
+			},
+		},
+		"UPDATE the table clone": {
+			file:       "UPDATE `project.dataset.clone` SET id = 2 WHERE id = 1",
+			expectErrs: []file.Error{},
+		},
+		"SELECT the table snapshot": {
+			file:       "SELECT id FROM `project.dataset.snapshot`",
+			expectErrs: []file.Error{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			schema := bq.Schema{
+				{
+					Name: "id",
+					Type: bq.IntegerFieldType,
+				},
+			}
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "snapshot").Return(&bq.TableMetadata{
+				Type:   bq.Snapshot,
+				Schema: schema,
+			}, nil).MinTimes(0)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "clone").Return(&bq.TableMetadata{
+				Type:   bq.RegularTable,
+				Schema: schema,
+				CloneDefinition: &bq.CloneDefinition{
+					BaseTableReference: &bq.Table{ProjectID: "project", DatasetID: "dataset", TableID: "table"},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+			if len(parsedFile.Errors) > 0 {
+				t.Fatalf("the statement should be analyzed: %v", parsedFile.Errors)
+			}
+
+			got := analyzer.SnapshotTableErrors(context.Background(), parsedFile)
+			if diff := cmp.Diff(tt.expectErrs, got); diff != "" {
+				t.Errorf("SnapshotTableErrors result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
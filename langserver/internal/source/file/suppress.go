@@ -0,0 +1,117 @@
+package file
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// UnusedSuppressionCode is the code of the warning for the suppression comment which suppresses nothing.
+const UnusedSuppressionCode = "unused-suppression"
+
+var suppressionRegex = regexp.MustCompile(`(?:--|#)\s*bqls:(disable-next-line|disable|enable)\b([^\n]*)`)
+
+type suppressionKind string
+
+const (
+	suppressNextLine suppressionKind = "disable-next-line"
+	suppressBlock    suppressionKind = "disable"
+	suppressEnd      suppressionKind = "enable"
+)
+
+// suppression is the comment like `-- bqls:disable-next-line select-star`.
+// The diagnostics of all the codes are suppressed when names is empty.
+type suppression struct {
+	kind     suppressionKind
+	names    []string
+	position lsp.Position
+	length   int
+	// endLine is the line of `bqls:enable` which ends `bqls:disable`. It is -1 until the end of the file.
+	endLine int
+	used    bool
+}
+
+func (s *suppression) suppresses(err Error) bool {
+	if len(s.names) > 0 && !slices.Contains(s.names, err.Code) {
+		return false
+	}
+	switch s.kind {
+	case suppressNextLine:
+		return err.Position.Line == s.position.Line+1
+	case suppressBlock:
+		return s.position.Line < err.Position.Line && (s.endLine < 0 || err.Position.Line < s.endLine)
+	}
+	return false
+}
+
+// Suppress removes the errors suppressed by the comments from errs.
+// `-- bqls:disable-next-line [code...]` suppresses the errors on the next line,
+// and `-- bqls:disable [code...]` suppresses the errors until `-- bqls:enable [code...]` or the end of the file.
+// The suppression which suppresses nothing is reported as a warning.
+func (p ParsedFile) Suppress(errs []Error) []Error {
+	suppressions := parseSuppressions(p.Src)
+	if len(suppressions) == 0 {
+		return errs
+	}
+
+	result := make([]Error, 0, len(errs))
+	for _, err := range errs {
+		suppressed := false
+		for _, s := range suppressions {
+			if s.suppresses(err) {
+				s.used = true
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			result = append(result, err)
+		}
+	}
+
+	for _, s := range suppressions {
+		if s.kind == suppressEnd || s.used {
+			continue
+		}
+		result = append(result, Error{
+			Msg:        fmt.Sprintf("bqls:%s suppresses no diagnostics", s.kind),
+			Position:   s.position,
+			TermLength: s.length,
+			Severity:   lsp.Warning,
+			Code:       UnusedSuppressionCode,
+		})
+	}
+	return result
+}
+
+func parseSuppressions(src string) []*suppression {
+	result := make([]*suppression, 0)
+	for line, text := range strings.Split(src, "\n") {
+		loc := suppressionRegex.FindStringSubmatchIndex(text)
+		if loc == nil {
+			continue
+		}
+		s := &suppression{
+			kind:     suppressionKind(text[loc[2]:loc[3]]),
+			names:    strings.FieldsFunc(text[loc[4]:loc[5]], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }),
+			position: lsp.Position{Line: line, Character: loc[0]},
+			length:   loc[1] - loc[0],
+			endLine:  -1,
+		}
+		if s.kind == suppressEnd {
+			// `bqls:enable` ends the preceding `bqls:disable` which has the same codes
+			for _, d := range result {
+				if d.kind != suppressBlock || d.endLine >= 0 {
+					continue
+				}
+				if len(s.names) == 0 || slices.ContainsFunc(d.names, func(n string) bool { return slices.Contains(s.names, n) }) {
+					d.endLine = line
+				}
+			}
+		}
+		result = append(result, s)
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package file_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+func TestParsedFile_Suppress(t *testing.T) {
+	selectStar := func(line int) file.Error {
+		return file.Error{Msg: "select star", Position: lsp.Position{Line: line, Character: 7}, TermLength: 1, Severity: lsp.Warning, Code: "select-star"}
+	}
+	analysisErr := func(line int) file.Error {
+		return file.Error{Msg: "Unrecognized name: nam", Position: lsp.Position{Line: line, Character: 7}, TermLength: 3}
+	}
+
+	tests := map[string]struct {
+		src  string
+		errs []file.Error
+
+		expectErrs []file.Error
+	}{
+		"disable next line": {
+			src: "-- bqls:disable-next-line select-star\nSELECT * FROM t;\nSELECT * FROM t",
+			errs: []file.Error{
+				selectStar(1),
+				selectStar(2),
+			},
+			expectErrs: []file.Error{
+				selectStar(2),
+			},
+		},
+		"disable next line keeps the other codes": {
+			src: "-- bqls:disable-next-line select-star\nSELECT nam, * FROM t",
+			errs: []file.Error{
+				analysisErr(1),
+				selectStar(1),
+			},
+			expectErrs: []file.Error{
+				analysisErr(1),
+			},
+		},
+		"disable block until enable": {
+			src: "# bqls:disable\nSELECT nam FROM t;\nSELECT * FROM t;\n-- bqls:enable\nSELECT * FROM t",
+			errs: []file.Error{
+				analysisErr(1),
+				selectStar(2),
+				selectStar(4),
+			},
+			expectErrs: []file.Error{
+				selectStar(4),
+			},
+		},
+		"unused suppression": {
+			src: "SELECT id FROM t -- bqls:disable-next-line select-star, duplicate-column\nSELECT id FROM t",
+			errs: []file.Error{
+				analysisErr(1),
+			},
+			expectErrs: []file.Error{
+				analysisErr(1),
+				{
+					Msg:        "bqls:disable-next-line suppresses no diagnostics",
+					Position:   lsp.Position{Line: 0, Character: 17},
+					TermLength: 55,
+					Severity:   lsp.Warning,
+					Code:       file.UnusedSuppressionCode,
+				},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			parsedFile := file.ParsedFile{Src: tt.src}
+
+			got := parsedFile.Suppress(tt.errs)
+			if diff := cmp.Diff(tt.expectErrs, got); diff != "" {
+				t.Errorf("Suppress result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// TimeTravelWindow is the max time travel window of BigQuery. The dataset can shorten it to 2 days.
+const TimeTravelWindow = 7 * 24 * time.Hour
+
+var timestampLiteralRegex = regexp.MustCompile(`(?is)^(?:TIMESTAMP\s*)?(?:'([^']*)'|"([^"]*)")$`)
+
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// Snapshot is the `FOR SYSTEM_TIME AS OF` clause of the table.
+type Snapshot struct {
+	// Expression is the SQL of the timestamp expression.
+	Expression string
+	// Timestamp is the value of the expression. It is zero when the expression is not a literal.
+	Timestamp time.Time
+}
+
+// TableSnapshot returns the `FOR SYSTEM_TIME AS OF` clause of the table. It is false when the table doesn't have the clause.
+func (p ParsedFile) TableSnapshot(node *ast.TablePathExpressionNode) (Snapshot, bool) {
+	forSystemTime := node.ForSystemTime()
+	if forSystemTime == nil || forSystemTime.Expression() == nil {
+		return Snapshot{}, false
+	}
+	expr, ok := p.ExtractSQL(forSystemTime.Expression().ParseLocationRange())
+	if !ok {
+		return Snapshot{}, false
+	}
+
+	result := Snapshot{Expression: expr}
+	if t, ok := parseTimestampLiteral(expr); ok {
+		result.Timestamp = t
+	}
+	return result, true
+}
+
+// parseTimestampLiteral parses the literal like `TIMESTAMP '2024-01-01 00:00:00+09'`. The time zone is UTC when it is omitted.
+func parseTimestampLiteral(expr string) (time.Time, bool) {
+	match := timestampLiteralRegex.FindStringSubmatch(strings.TrimSpace(expr))
+	if match == nil {
+		return time.Time{}, false
+	}
+	value := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(match[1]+match[2]), "UTC"))
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// TimeTravelErrors reports the `FOR SYSTEM_TIME AS OF` literal which is out of the time travel window of the table.
+// The window is from the later of now - TimeTravelWindow and the creation time of the table, to now.
+// The table whose metadata can't be got is only checked with now.
+func (a *Analyzer) TimeTravelErrors(ctx context.Context, p ParsedFile, now time.Time) []Error {
+	result := make([]Error, 0)
+	if p.Node == nil {
+		return result
+	}
+	ast.Walk(p.Node, func(n ast.Node) error {
+		node, ok := n.(*ast.TablePathExpressionNode)
+		if !ok {
+			return nil
+		}
+		snapshot, ok := p.TableSnapshot(node)
+		if !ok || snapshot.Timestamp.IsZero() {
+			return nil
+		}
+
+		var msg string
+		switch {
+		case snapshot.Timestamp.After(now):
+			msg = fmt.Sprintf("FOR SYSTEM_TIME AS OF %s is in the future", snapshot.Expression)
+		case snapshot.Timestamp.Before(now.Add(-TimeTravelWindow)):
+			msg = fmt.Sprintf("FOR SYSTEM_TIME AS OF %s is older than the time travel window of 7 days", snapshot.Expression)
+		default:
+			name, ok := CreateTableNameFromTablePathExpressionNode(node)
+			if !ok {
+				return nil
+			}
+			metadata, err := a.GetTableMetadataFromPath(ctx, name)
+			if err != nil || metadata.CreationTime.IsZero() || !snapshot.Timestamp.Before(metadata.CreationTime) {
+				return nil
+			}
+			msg = fmt.Sprintf("FOR SYSTEM_TIME AS OF %s is before the table was created at %s", snapshot.Expression, metadata.CreationTime.UTC().Format("2006-01-02 15:04:05 MST"))
+		}
+
+		rng, ok := p.PositionRange(node.ForSystemTime().ParseLocationRange())
+		if !ok {
+			return nil
+		}
+		pErr := Error{
+			Msg:      msg,
+			Position: rng.Start,
+			Severity: lsp.Warning,
+			Code:     "time-travel",
+		}
+		if rng.Start.Line == rng.End.Line {
+			pErr.TermLength = rng.End.Character - rng.Start.Character
+		}
+		result = append(result, pErr)
+		return nil
+	})
+	return result
+}
@@ -0,0 +1,96 @@
+package file_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnalyzer_TimeTravelErrors(t *testing.T) {
+	now := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		file string
+
+		expectErrs []file.Error
+	}{
+		"within the time travel window": {
+			file:       "SELECT id FROM `project.dataset.table` FOR SYSTEM_TIME AS OF TIMESTAMP '2024-06-08 00:00:00'",
+			expectErrs: []file.Error{},
+		},
+		"before the table is created": {
+			file: "SELECT id FROM `project.dataset.table` FOR SYSTEM_TIME AS OF TIMESTAMP '2024-06-04 00:00:00'",
+			expectErrs: []file.Error{
+				{
+					Msg:        "FOR SYSTEM_TIME AS OF TIMESTAMP '2024-06-04 00:00:00' is before the table was created at 2024-06-05 00:00:00 UTC",
+					Position:   lsp.Position{Line: 0, Character: 39},
+					TermLength: 53,
+					Severity:   lsp.Warning,
+					Code:       "time-travel",
+				},
+			},
+		},
+		"older than the time travel window": {
+			file: "SELECT id FROM `project.dataset.table` FOR SYSTEM_TIME AS OF '2024-05-01'",
+			expectErrs: []file.Error{
+				{
+					Msg:        "FOR SYSTEM_TIME AS OF '2024-05-01' is older than the time travel window of 7 days",
+					Position:   lsp.Position{Line: 0, Character: 39},
+					TermLength: 34,
+					Severity:   lsp.Warning,
+					Code:       "time-travel",
+				},
+			},
+		},
+		"future": {
+			file: "SELECT id FROM `project.dataset.table` FOR SYSTEM_TIME AS OF '2024-07-01'",
+			expectErrs: []file.Error{
+				{
+					Msg:        "FOR SYSTEM_TIME AS OF '2024-07-01' is in the future",
+					Position:   lsp.Position{Line: 0, Character: 39},
+					TermLength: 34,
+					Severity:   lsp.Warning,
+					Code:       "time-travel",
+				},
+			},
+		},
+		"not literal": {
+			file:       "SELECT id FROM `project.dataset.table` FOR SYSTEM_TIME AS OF TIMESTAMP_SUB(CURRENT_TIMESTAMP(), INTERVAL 1 HOUR)",
+			expectErrs: []file.Error{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				CreationTime: time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC),
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+			if len(parsedFile.Errors) > 0 {
+				t.Fatalf("FOR SYSTEM_TIME AS OF should be analyzed: %v", parsedFile.Errors)
+			}
+
+			got := analyzer.TimeTravelErrors(context.Background(), parsedFile, now)
+			if diff := cmp.Diff(tt.expectErrs, got); diff != "" {
+				t.Errorf("TimeTravelErrors result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package file
+
+import "strings"
+
+// TokenKind is the kind of Token.
+type TokenKind int
+
+const (
+	// CodeToken is the keyword, the identifier, the number or the punctuation.
+	CodeToken TokenKind = iota
+	// StringToken is the string or bytes literal including its prefix like r'a' and b"""a""".
+	StringToken
+	// QuotedIdentifierToken is the identifier quoted with the backquotes.
+	QuotedIdentifierToken
+	// CommentToken is the comment which starts with --, # or /*.
+	CommentToken
+)
+
+// Token is the token of SQL at src[Start:End].
+type Token struct {
+	Kind       TokenKind
+	Text       string
+	Start, End int
+}
+
+// Tokenize splits src into the tokens. The spaces are skipped.
+// The words and the numbers are single tokens, and the other punctuations are split into the characters.
+// The literal or the comment which isn't closed continues to the end of src.
+//
+// The line comment doesn't contain the newline.
+// The backslash escapes the next character in the string literals and the quoted identifiers, even in the raw string literals,
+// because the raw string literal can't contain its quote as well.
+func Tokenize(src string) []Token {
+	result := make([]Token, 0)
+	for i := 0; i < len(src); {
+		c := src[i]
+		start := i
+		kind := CodeToken
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "--") || c == '#':
+			kind = CommentToken
+			i = len(src)
+			if end := strings.IndexByte(src[start:], '\n'); end >= 0 {
+				i = start + end
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			kind = CommentToken
+			i = len(src)
+			if end := strings.Index(src[start+2:], "*/"); end >= 0 {
+				i = start + end + 4
+			}
+		case c == '`':
+			kind = QuotedIdentifierToken
+			i = quotedEnd(src, i)
+		case c == '\'' || c == '"':
+			kind = StringToken
+			i = quotedEnd(src, i)
+		case isWordByte(c):
+			for i < len(src) && isWordByte(src[i]) {
+				i++
+			}
+			if i < len(src) && (src[i] == '\'' || src[i] == '"') && isStringPrefix(src[start:i]) {
+				kind = StringToken
+				i = quotedEnd(src, i)
+			}
+		default:
+			i++
+		}
+		result = append(result, Token{Kind: kind, Text: src[start:i], Start: start, End: i})
+	}
+	return result
+}
+
+// quotedEnd returns the offset after the quoted string or identifier which starts at i.
+func quotedEnd(src string, i int) int {
+	quote := src[i : i+1]
+	if triple := strings.Repeat(quote, 3); quote != "`" && strings.HasPrefix(src[i:], triple) {
+		quote = triple
+	}
+	for j := i + len(quote); j < len(src); j++ {
+		if src[j] == '\\' {
+			j++
+			continue
+		}
+		if strings.HasPrefix(src[j:], quote) {
+			return j + len(quote)
+		}
+	}
+	return len(src)
+}
+
+// isStringPrefix reports whether the word is the prefix of the raw or bytes literal like r and rb.
+func isStringPrefix(word string) bool {
+	switch strings.ToLower(word) {
+	case "r", "b", "rb", "br":
+		return true
+	}
+	return false
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// CodeMask reports whether each byte of text is the code, which is not in the string literals, the quoted identifiers or the comments.
+// It is used to find the SQL constructs from the text without matching them in the literals and the comments.
+func CodeMask(text string) []bool {
+	mask := make([]bool, len(text))
+	for i := range mask {
+		mask[i] = true
+	}
+	for _, token := range Tokenize(text) {
+		if token.Kind == CodeToken {
+			continue
+		}
+		for i := token.Start; i < token.End; i++ {
+			mask[i] = false
+		}
+	}
+	return mask
+}
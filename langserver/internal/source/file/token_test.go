@@ -0,0 +1,70 @@
+package file_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := map[string]struct {
+		src string
+
+		expect []file.Token
+	}{
+		"words and punctuations": {
+			src: "SELECT a.b;",
+			expect: []file.Token{
+				{Kind: file.CodeToken, Text: "SELECT", Start: 0, End: 6},
+				{Kind: file.CodeToken, Text: "a", Start: 7, End: 8},
+				{Kind: file.CodeToken, Text: ".", Start: 8, End: 9},
+				{Kind: file.CodeToken, Text: "b", Start: 9, End: 10},
+				{Kind: file.CodeToken, Text: ";", Start: 10, End: 11},
+			},
+		},
+		"comments": {
+			src: "# a\n-- b\n/* c */",
+			expect: []file.Token{
+				{Kind: file.CommentToken, Text: "# a", Start: 0, End: 3},
+				{Kind: file.CommentToken, Text: "-- b", Start: 4, End: 8},
+				{Kind: file.CommentToken, Text: "/* c */", Start: 9, End: 16},
+			},
+		},
+		"raw and bytes literals": {
+			src: `r'\d' RB"a"`,
+			expect: []file.Token{
+				{Kind: file.StringToken, Text: `r'\d'`, Start: 0, End: 5},
+				{Kind: file.StringToken, Text: `RB"a"`, Start: 6, End: 11},
+			},
+		},
+		"triple-quoted literal with the escaped quote": {
+			src: `'''a\''';'''`,
+			expect: []file.Token{
+				{Kind: file.StringToken, Text: `'''a\''';'''`, Start: 0, End: 12},
+			},
+		},
+		"quoted identifier": {
+			src: "`a;b`",
+			expect: []file.Token{
+				{Kind: file.QuotedIdentifierToken, Text: "`a;b`", Start: 0, End: 5},
+			},
+		},
+		"unclosed literal": {
+			src: "SELECT 'a;",
+			expect: []file.Token{
+				{Kind: file.CodeToken, Text: "SELECT", Start: 0, End: 6},
+				{Kind: file.StringToken, Text: "'a;", Start: 7, End: 10},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := file.Tokenize(tt.src)
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("Tokenize result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// TransactionCode is the code of the errors reported by TransactionErrors.
+const TransactionCode = "transaction"
+
+// TransactionErrors reports the statements which BigQuery rejects in the multi-statement transaction
+// started by BEGIN TRANSACTION, and the transaction which is not finished by COMMIT or ROLLBACK.
+// DDL and DCL are rejected except the ones which create or drop the temporary tables and functions.
+func (p ParsedFile) TransactionErrors() []Error {
+	result := make([]Error, 0)
+	if p.Node == nil {
+		return result
+	}
+
+	var begin ast.Node
+	temporaries := make(map[string]struct{})
+	ast.Walk(p.Node, func(n ast.Node) error {
+		if n == nil || !n.IsStatement() {
+			return nil
+		}
+		sql, ok := p.ExtractSQL(n.ParseLocationRange())
+		if !ok {
+			return nil
+		}
+		words := strings.Fields(strings.ToUpper(sql))
+		if len(words) == 0 {
+			return nil
+		}
+
+		switch {
+		case isBeginTransaction(words):
+			if begin != nil {
+				if pErr, ok := p.transactionError(n, "BEGIN TRANSACTION can't be nested in the transaction"); ok {
+					result = append(result, pErr)
+				}
+				return nil
+			}
+			begin = n
+		case words[0] == "COMMIT" || words[0] == "ROLLBACK":
+			// ROLLBACK in the exception handler follows COMMIT, so the statement without the transaction is not reported.
+			begin = nil
+		case words[0] == "CREATE":
+			name, ok := temporaryName(words)
+			if ok {
+				temporaries[name] = struct{}{}
+				return nil
+			}
+			if begin != nil {
+				if pErr, ok := p.transactionError(n, "CREATE is not supported in the transaction. Only the temporary tables and functions can be created"); ok {
+					result = append(result, pErr)
+				}
+			}
+		case words[0] == "DROP":
+			if _, ok := temporaries[droppedName(words)]; ok {
+				return nil
+			}
+			if begin != nil {
+				if pErr, ok := p.transactionError(n, "DROP is not supported in the transaction. Only the temporary tables and functions can be dropped"); ok {
+					result = append(result, pErr)
+				}
+			}
+		case words[0] == "ALTER" || words[0] == "GRANT" || words[0] == "REVOKE":
+			if begin != nil {
+				if pErr, ok := p.transactionError(n, fmt.Sprintf("%s is not supported in the transaction", words[0])); ok {
+					result = append(result, pErr)
+				}
+			}
+		}
+		return nil
+	})
+
+	if begin != nil {
+		if pErr, ok := p.transactionError(begin, "the transaction is rolled back at the end of the script. Finish it with COMMIT TRANSACTION"); ok {
+			result = append(result, pErr)
+		}
+	}
+	return result
+}
+
+func (p ParsedFile) transactionError(n ast.Node, msg string) (Error, bool) {
+	rng, ok := p.PositionRange(n.ParseLocationRange())
+	if !ok {
+		return Error{}, false
+	}
+	pErr := Error{
+		Msg:      msg,
+		Position: rng.Start,
+		Severity: lsp.Warning,
+		Code:     TransactionCode,
+	}
+	if rng.Start.Line == rng.End.Line {
+		pErr.TermLength = rng.End.Character - rng.Start.Character
+	}
+	return pErr, true
+}
+
+// isBeginTransaction distinguishes `BEGIN TRANSACTION` from the block of `BEGIN ... END`.
+func isBeginTransaction(words []string) bool {
+	return words[0] == "BEGIN" && (len(words) == 1 || words[1] == "TRANSACTION")
+}
+
+// temporaryName returns the name of `CREATE [OR REPLACE] TEMP TABLE name` and `CREATE TEMP FUNCTION name(...)`.
+func temporaryName(words []string) (string, bool) {
+	words = words[1:]
+	if len(words) >= 2 && words[0] == "OR" && words[1] == "REPLACE" {
+		words = words[2:]
+	}
+	if len(words) < 3 || (words[0] != "TEMP" && words[0] != "TEMPORARY") {
+		return "", false
+	}
+	words = words[2:]
+	if len(words) > 3 && words[0] == "IF" && words[1] == "NOT" && words[2] == "EXISTS" {
+		words = words[3:]
+	}
+	return objectName(words[0]), true
+}
+
+// droppedName returns the name of `DROP TABLE [IF EXISTS] name` and `DROP FUNCTION [IF EXISTS] name`.
+func droppedName(words []string) string {
+	if len(words) < 3 {
+		return ""
+	}
+	words = words[2:]
+	if len(words) > 2 && words[0] == "IF" && words[1] == "EXISTS" {
+		words = words[2:]
+	}
+	return objectName(words[0])
+}
+
+func objectName(word string) string {
+	if i := strings.Index(word, "("); i >= 0 {
+		word = word[:i]
+	}
+	return strings.Trim(word, "`")
+}
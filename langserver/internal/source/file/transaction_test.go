@@ -0,0 +1,115 @@
+package file_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnalyzer_ParseFileWithTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+		Schema: bq.Schema{
+			{
+				Name: "id",
+				Type: bq.IntegerFieldType,
+			},
+		},
+	}, nil).MinTimes(0)
+	analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+	parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", "BEGIN TRANSACTION;\nDELETE FROM `project.dataset.table` WHERE id = 1;\nCOMMIT TRANSACTION;")
+	if len(parsedFile.Errors) > 0 {
+		t.Fatalf("the transaction should be analyzed: %v", parsedFile.Errors)
+	}
+	if len(parsedFile.RNode) != 3 {
+		t.Errorf("each statement should be analyzed, got %d analyses", len(parsedFile.RNode))
+	}
+}
+
+func TestParsedFile_TransactionErrors(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectErrs []file.Error
+	}{
+		"DML in the transaction": {
+			file:       "BEGIN TRANSACTION;\nDELETE FROM `project.dataset.table` WHERE id = 1;\nCOMMIT TRANSACTION;",
+			expectErrs: []file.Error{},
+		},
+		"temporary table in the transaction": {
+			file:       "BEGIN TRANSACTION;\nCREATE TEMP TABLE tmp AS SELECT id FROM `project.dataset.table`;\nDROP TABLE tmp;\nCOMMIT TRANSACTION;",
+			expectErrs: []file.Error{},
+		},
+		"permanent table in the transaction": {
+			file: "BEGIN TRANSACTION;\nCREATE TABLE `project.dataset.new_table` (id INT64);\nCOMMIT TRANSACTION;",
+			expectErrs: []file.Error{
+				{
+					Msg:        "CREATE is not supported in the transaction. Only the temporary tables and functions can be created",
+					Position:   lsp.Position{Line: 1, Character: 0},
+					TermLength: 51,
+					Severity:   lsp.Warning,
+					Code:       "transaction",
+				},
+			},
+		},
+		"permanent table outside the transaction": {
+			file:       "CREATE TABLE `project.dataset.new_table` (id INT64);\nBEGIN TRANSACTION;\nCOMMIT TRANSACTION;",
+			expectErrs: []file.Error{},
+		},
+		"nested transaction": {
+			file: "BEGIN TRANSACTION;\nBEGIN TRANSACTION;\nCOMMIT TRANSACTION;",
+			expectErrs: []file.Error{
+				{
+					Msg:        "BEGIN TRANSACTION can't be nested in the transaction",
+					Position:   lsp.Position{Line: 1, Character: 0},
+					TermLength: 17,
+					Severity:   lsp.Warning,
+					Code:       "transaction",
+				},
+			},
+		},
+		"transaction without COMMIT": {
+			file: "BEGIN TRANSACTION;\nDELETE FROM `project.dataset.table` WHERE id = 1;",
+			expectErrs: []file.Error{
+				{
+					Msg:        "the transaction is rolled back at the end of the script. Finish it with COMMIT TRANSACTION",
+					Position:   lsp.Position{Line: 0, Character: 0},
+					TermLength: 17,
+					Severity:   lsp.Warning,
+					Code:       "transaction",
+				},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+			got := parsedFile.TransactionErrors()
+			if diff := cmp.Diff(tt.expectErrs, got); diff != "" {
+				t.Errorf("TransactionErrors result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/goccy/go-zetasql/types"
+)
+
+// go-zetasql v0.5.5 builds a table function only from the resolved CREATE TABLE FUNCTION statement, whose C++ object isn't exposed by the public API.
+// So the call of a persistent table function is analyzed as the scan of the table which has the columns of the return table.
+//
+//	SELECT * FROM dataset.fn(1)
+//
+// becomes
+//
+//	SELECT * FROM `__bqls_tvf__.dataset.fn`
+//
+// The arguments of the call are not analyzed.
+
+// routineTablePrefix is the first name of the tables which stand for the persistent table functions.
+// The project IDs can't start with an underscore, so the tables don't collide with the real tables.
+const routineTablePrefix = "__bqls_tvf__."
+
+// RoutineTableName returns the name of the table which stands for the calls of the persistent table function.
+func RoutineTableName(routine string) string {
+	return routineTablePrefix + routine
+}
+
+// RoutineOfTable returns the persistent table function of the table of RoutineTableName.
+func RoutineOfTable(table string) (string, bool) {
+	return strings.CutPrefix(table, routineTablePrefix)
+}
+
+func (c *Catalog) findRoutineTable(routine string) (types.Table, error) {
+	tableName := RoutineTableName(routine)
+	c.mu.Lock()
+	table, err := c.catalog.FindTable([]string{tableName})
+	c.mu.Unlock()
+	if err == nil {
+		return table, nil
+	}
+
+	metadata, err := c.getRoutineMetadata(c.ctx, strings.Split(routine, "."))
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Type != "TABLE_VALUED_FUNCTION" {
+		return nil, fmt.Errorf("routine %s is not a table function", routine)
+	}
+	if metadata.ReturnTableType == nil {
+		return nil, fmt.Errorf("routine %s doesn't have an explicit return table type", routine)
+	}
+	columns := make([]types.Column, 0, len(metadata.ReturnTableType.Columns))
+	for _, column := range metadata.ReturnTableType.Columns {
+		typ, err := standardSQLDataTypeToZetaSQLType(column.Type)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert type(%s): %w", column.Name, err)
+		}
+		columns = append(columns, types.NewSimpleColumn(tableName, column.Name, typ))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// The table may have been added by the other lookup while the routine is fetched.
+	if table, err := c.catalog.FindTable([]string{tableName}); err == nil {
+		return table, nil
+	}
+	c.catalog.AddTable(types.NewSimpleTable(tableName, columns))
+	return c.catalog.FindTable([]string{tableName})
+}
+
+// fixRoutineTableValuedFunction replaces the call of the persistent table function which isn't found with the table of RoutineTableName.
+// The source is not changed when the function isn't a persistent table function.
+func fixRoutineTableValuedFunction(src string, node ast.StatementNode, parsedErr Error, catalog *Catalog) (fixedSrc string, fixOffsets []FixOffset) {
+	errOffset := positionToByteOffset(src, parsedErr.Position)
+	tvf, ok := SearchAstNode[*ast.TVFNode](node, errOffset)
+	if !ok {
+		return src, nil
+	}
+	routine := pathName(tvf.Name())
+	if metadata, err := catalog.getRoutineMetadata(catalog.ctx, strings.Split(routine, ".")); err != nil || metadata.Type != "TABLE_VALUED_FUNCTION" {
+		return src, nil
+	}
+
+	loc := tvf.Name().ParseLocationRange()
+	if loc == nil {
+		return src, nil
+	}
+	start := loc.Start().ByteOffset()
+	end, ok := argumentsEnd(src, loc.End().ByteOffset())
+	if !ok {
+		return src, nil
+	}
+	table := "`" + RoutineTableName(routine) + "`"
+	fixedSrc = src[:start] + table + src[end:]
+	return fixedSrc, []FixOffset{{Offset: start, Length: len(table) - (end - start)}}
+}
+
+// argumentsEnd returns the offset after the closing parenthesis of the arguments which follow the offset.
+func argumentsEnd(src string, offset int) (int, bool) {
+	mask := CodeMask(src)
+	depth := 0
+	for i := offset; i < len(src); i++ {
+		if !mask[i] {
+			continue
+		}
+		switch src[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,70 @@
+package file_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnalyzer_ParseFileWithPersistentTableFunction(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectErr string
+	}{
+		"call the persistent table function": {
+			file: "SELECT t.id, name FROM dataset.my_tvf(1) AS t WHERE t.id > 0",
+		},
+		"the column which the function doesn't return": {
+			file:      "SELECT unknown FROM `project.dataset.my_tvf`(1)",
+			expectErr: "Unrecognized name: unknown",
+		},
+		"the function which doesn't exist": {
+			file:      "SELECT * FROM dataset.unknown_tvf(1)",
+			expectErr: "Table-valued function not found",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetDefaultProject().Return("project").MinTimes(0)
+			bqClient.EXPECT().GetRoutineMetadata(gomock.Any(), "project", "dataset", "my_tvf").Return(&bq.RoutineMetadata{
+				Type: "TABLE_VALUED_FUNCTION",
+				Arguments: []*bq.RoutineArgument{
+					{
+						Name:     "x",
+						DataType: &bq.StandardSQLDataType{TypeKind: "INT64"},
+					},
+				},
+				ReturnTableType: &bq.StandardSQLTableType{
+					Columns: []*bq.StandardSQLField{
+						{Name: "id", Type: &bq.StandardSQLDataType{TypeKind: "INT64"}},
+						{Name: "name", Type: &bq.StandardSQLDataType{TypeKind: "STRING"}},
+					},
+				},
+			}, nil).MinTimes(0)
+			bqClient.EXPECT().GetRoutineMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("not found")).MinTimes(0)
+			analyzer := file.NewAnalyzer(logrus.New(), bqClient)
+
+			parsedFile := analyzer.ParseFile(context.Background(), "file1.sql", tt.file)
+			if tt.expectErr == "" {
+				if len(parsedFile.Errors) > 0 {
+					t.Errorf("the call of the table function should be analyzed: %v", parsedFile.Errors)
+				}
+				return
+			}
+			if len(parsedFile.Errors) != 1 || !strings.Contains(parsedFile.Errors[0].Msg, tt.expectErr) {
+				t.Errorf("expect the error %q, but got %v", tt.expectErr, parsedFile.Errors)
+			}
+		})
+	}
+}
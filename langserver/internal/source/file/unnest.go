@@ -0,0 +1,12 @@
+package file
+
+import "regexp"
+
+// ArrayScalarCode is the code of the error which uses the ARRAY value in the scalar context like `items.name` or `tags = 'a'`.
+const ArrayScalarCode = "array-scalar"
+
+var arrayScalarErrorRegex = regexp.MustCompile(`Cannot access field \S+ on a value with type ARRAY<|No matching signature for operator .+ for argument types: (?:ARRAY<|\S+, ARRAY<)`)
+
+func isArrayScalarError(msg string) bool {
+	return arrayScalarErrorRegex.MatchString(msg)
+}
@@ -28,5 +28,30 @@ func CreateTableNameFromTablePathExpressionNode(node *ast.TablePathExpressionNod
 		pathNames[i] = n.Name()
 	}
 
-	return strings.Join(pathNames, "."), true
+	name := strings.Join(pathNames, ".")
+	// the call of the persistent table function isn't the table.
+	if _, ok := RoutineOfTable(name); ok {
+		return "", false
+	}
+	return name, true
+}
+
+// ReferencedTableNames returns the table paths like `dataset.table` or `project.dataset.table` in the node.
+func ReferencedTableNames(node ast.Node) []string {
+	result := make([]string, 0)
+	ast.Walk(node, func(n ast.Node) error {
+		tablePath, ok := n.(*ast.TablePathExpressionNode)
+		if !ok {
+			return nil
+		}
+
+		name, ok := CreateTableNameFromTablePathExpressionNode(tablePath)
+		// single name is the WITH clause or the unnested column.
+		if !ok || !strings.Contains(name, ".") {
+			return nil
+		}
+		result = append(result, name)
+		return nil
+	})
+	return result
 }
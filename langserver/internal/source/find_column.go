@@ -0,0 +1,125 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+const maxFindColumnConcurrency = 4
+
+// FindColumn searches the tables which have the column in the datasets like `project.dataset` or `dataset`.
+// When datasets is empty, all datasets of the default project are searched.
+// The table metadata is read through the cache of the BigQuery client, so the second search doesn't call the API for the same tables.
+func (p *Project) FindColumn(ctx context.Context, column string, datasets []string) ([]lsp.ColumnLocation, error) {
+	if column == "" {
+		return nil, fmt.Errorf("column name is required")
+	}
+
+	targets, err := p.findColumnTargets(ctx, datasets)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]*bq.Table, 0)
+	for _, target := range targets {
+		ts, err := p.bqClient.ListTables(ctx, target.ProjectID, target.DatasetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tables of %s.%s: %w", target.ProjectID, target.DatasetID, err)
+		}
+		tables = append(tables, ts...)
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result = make([]lsp.ColumnLocation, 0)
+		sem    = make(chan struct{}, maxFindColumnConcurrency)
+	)
+	for _, table := range tables {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			metadata, err := p.bqClient.GetTableMetadata(ctx, table.ProjectID, table.DatasetID, table.TableID)
+			if err != nil {
+				p.logger.Debugf("failed to get table metadata(%s.%s.%s): %v", table.ProjectID, table.DatasetID, table.TableID, err)
+				return
+			}
+			name := fmt.Sprintf("%s.%s.%s", table.ProjectID, table.DatasetID, table.TableID)
+			locations := findColumnInSchema(metadata.Schema, column, name, "")
+
+			mu.Lock()
+			defer mu.Unlock()
+			result = append(result, locations...)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Table != result[j].Table {
+			return result[i].Table < result[j].Table
+		}
+		return result[i].Column < result[j].Column
+	})
+	return result, nil
+}
+
+type findColumnTarget struct {
+	ProjectID string
+	DatasetID string
+}
+
+func (p *Project) findColumnTargets(ctx context.Context, datasets []string) ([]findColumnTarget, error) {
+	result := make([]findColumnTarget, 0, len(datasets))
+	if len(datasets) == 0 {
+		ds, err := p.bqClient.ListDatasets(ctx, p.bqClient.GetDefaultProject())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list datasets: %w", err)
+		}
+		for _, d := range ds {
+			result = append(result, findColumnTarget{ProjectID: d.ProjectID, DatasetID: d.DatasetID})
+		}
+		return result, nil
+	}
+
+	for _, d := range datasets {
+		ids := strings.Split(d, ".")
+		switch len(ids) {
+		case 1:
+			result = append(result, findColumnTarget{ProjectID: p.bqClient.GetDefaultProject(), DatasetID: ids[0]})
+		case 2:
+			result = append(result, findColumnTarget{ProjectID: ids[0], DatasetID: ids[1]})
+		default:
+			return nil, fmt.Errorf("invalid dataset: %s", d)
+		}
+	}
+	return result, nil
+}
+
+// findColumnInSchema finds the column case-insensitively including the fields of the RECORD columns.
+func findColumnInSchema(schema bq.Schema, column, table, prefix string) []lsp.ColumnLocation {
+	result := make([]lsp.ColumnLocation, 0)
+	for _, f := range schema {
+		path := prefix + f.Name
+		if strings.EqualFold(f.Name, column) {
+			result = append(result, lsp.ColumnLocation{
+				Table:     table,
+				Reference: fmt.Sprintf("`%s`", table),
+				Column:    path,
+				Type:      string(f.Type),
+			})
+		}
+		if len(f.Schema) > 0 {
+			result = append(result, findColumnInSchema(f.Schema, column, table, path+".")...)
+		}
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_FindColumn(t *testing.T) {
+	tests := map[string]struct {
+		column   string
+		datasets []string
+
+		expectColumns []lsp.ColumnLocation
+	}{
+		"search all datasets": {
+			column: "user_id",
+			expectColumns: []lsp.ColumnLocation{
+				{Table: "project.dataset.events", Reference: "`project.dataset.events`", Column: "user.user_id", Type: "INTEGER"},
+				{Table: "project.dataset.users", Reference: "`project.dataset.users`", Column: "user_id", Type: "INTEGER"},
+			},
+		},
+		"search the dataset with the default project": {
+			column:   "USER_ID",
+			datasets: []string{"dataset"},
+			expectColumns: []lsp.ColumnLocation{
+				{Table: "project.dataset.events", Reference: "`project.dataset.events`", Column: "user.user_id", Type: "INTEGER"},
+				{Table: "project.dataset.users", Reference: "`project.dataset.users`", Column: "user_id", Type: "INTEGER"},
+			},
+		},
+		"not found": {
+			column:        "not_found",
+			datasets:      []string{"project.dataset"},
+			expectColumns: []lsp.ColumnLocation{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetDefaultProject().Return("project").MinTimes(0)
+			bqClient.EXPECT().ListDatasets(gomock.Any(), "project").Return([]*bq.Dataset{
+				{ProjectID: "project", DatasetID: "dataset"},
+			}, nil).MinTimes(0)
+			bqClient.EXPECT().ListTables(gomock.Any(), "project", "dataset").Return([]*bq.Table{
+				{ProjectID: "project", DatasetID: "dataset", TableID: "users"},
+				{ProjectID: "project", DatasetID: "dataset", TableID: "events"},
+			}, nil)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "users").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "user_id", Type: bq.IntegerFieldType},
+					{Name: "name", Type: bq.StringFieldType},
+				},
+			}, nil)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "events").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "event_id", Type: bq.IntegerFieldType},
+					{
+						Name: "user",
+						Type: bq.RecordFieldType,
+						Schema: bq.Schema{
+							{Name: "user_id", Type: bq.IntegerFieldType},
+						},
+					},
+				},
+			}, nil)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			got, err := p.FindColumn(context.Background(), tt.column, tt.datasets)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.expectColumns, got); diff != "" {
+				t.Errorf("FindColumn result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"gopkg.in/yaml.v3"
+)
+
+// FixtureFileSuffix is the suffix of the sidecar file which defines the rows of the tables referenced by the query.
+// The fixtures of `query.sql` are read from `query.fixtures.yaml`.
+const FixtureFileSuffix = ".fixtures.yaml"
+
+var withKeywordRegex = regexp.MustCompile(`(?is)^\s*WITH(\s+RECURSIVE)?\s`)
+
+// FixtureFilePath returns the path of the sidecar fixture file of the SQL file.
+func FixtureFilePath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + FixtureFileSuffix
+}
+
+// HasFixtureFile reports whether the SQL file has the sidecar fixture file.
+func HasFixtureFile(path string) bool {
+	_, err := os.Stat(FixtureFilePath(path))
+	return err == nil
+}
+
+// RunWithFixtures executes the query at position whose tables are replaced with the rows of the fixture file.
+// The query reads no table, so that the logic can be checked without the production data.
+func (p *Project) RunWithFixtures(ctx context.Context, uri string, position lsp.Position) (bigquery.BigqueryJob, error) {
+	query, err := p.FixtureQuery(ctx, uri, position)
+	if err != nil {
+		return nil, err
+	}
+	return p.runQuery(ctx, query, false, nil)
+}
+
+// FixtureQuery returns the query at position whose tables are replaced with the WITH queries of the rows in the fixture file.
+// The fixture file maps the table names to the lists of rows like below.
+//
+//	project.dataset.table:
+//	  - id: 1
+//	    name: a
+//
+// The values are converted into the literals of the column types of the table schema, and the missing columns are NULL.
+func (p *Project) FixtureQuery(ctx context.Context, uri string, position lsp.Position) (string, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return "", fmt.Errorf("failed to find document %s", uri)
+	}
+
+	fixturePath := FixtureFilePath(uri)
+	b, err := os.ReadFile(fixturePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the fixtures: %w", err)
+	}
+	var tableRows map[string][]map[string]any
+	if err := yaml.Unmarshal(b, &tableRows); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", fixturePath, err)
+	}
+
+	parsedFile := p.parseFile(uri, sql)
+	stmt, ok := parsedFile.FindTargetStatementNode(parsedFile.TermOffset(position))
+	if !ok {
+		return "", fmt.Errorf("statement is not found at the position")
+	}
+	if _, ok := stmt.(*ast.QueryStatementNode); !ok {
+		return "", fmt.Errorf("the statement is not a query")
+	}
+
+	fixtures, err := p.tableFixtures(ctx, parsedFile, stmt)
+	if err != nil {
+		return "", err
+	}
+	query, err := replaceTables(parsedFile, stmt, fixtures)
+	if err != nil {
+		return "", err
+	}
+	if len(fixtures) == 0 {
+		return query, nil
+	}
+
+	var sb strings.Builder
+	for _, f := range fixtures {
+		rows, ok := lookupFixtureRows(tableRows, f.table)
+		if !ok {
+			return "", fmt.Errorf("the fixture of %s is not defined in %s", f.table, fixturePath)
+		}
+		literal, err := fixtureRows(f.schema, rows)
+		if err != nil {
+			return "", fmt.Errorf("invalid fixture of %s: %w", f.table, err)
+		}
+		fmt.Fprintf(&sb, "%s AS (\n  %s\n),\n", f.name, literal)
+	}
+	entries := strings.TrimSuffix(sb.String(), ",\n")
+
+	// The fixtures are added to the WITH clause of the query, because the nested WITH clause can't be written after WITH.
+	if loc := withKeywordRegex.FindStringIndex(query); loc != nil {
+		return fmt.Sprintf("%s\n%s,\n%s", strings.TrimSpace(query[:loc[1]]), entries, query[loc[1]:]), nil
+	}
+	return fmt.Sprintf("WITH %s\n%s", entries, query), nil
+}
+
+// lookupFixtureRows finds the rows of the table. The name matches when the shorter path is the suffix of the longer one
+// like `dataset.table` and `project.dataset.table`.
+func lookupFixtureRows(tableRows map[string][]map[string]any, table string) ([]map[string]any, bool) {
+	table = strings.ToLower(strings.ReplaceAll(table, "`", ""))
+	for name, rows := range tableRows {
+		name = strings.ToLower(strings.ReplaceAll(name, "`", ""))
+		if name == table || strings.HasSuffix(name, "."+table) || strings.HasSuffix(table, "."+name) {
+			return rows, true
+		}
+	}
+	return nil, false
+}
+
+// fixtureRows is the UNION ALL of the SELECTs of the rows. When there are no rows, it returns the empty table with the schema.
+func fixtureRows(schema bq.Schema, rows []map[string]any) (string, error) {
+	if len(rows) == 0 {
+		columns := make([]string, 0, len(schema))
+		for _, field := range schema {
+			columns = append(columns, fmt.Sprintf("CAST(NULL AS %s) AS %s", sqlTypeName(field), quoteIdentifier(field.Name)))
+		}
+		return "SELECT " + strings.Join(columns, ", ") + " LIMIT 0", nil
+	}
+
+	selects := make([]string, 0, len(rows))
+	for i, row := range rows {
+		columns, err := fixtureColumns(schema, row)
+		if err != nil {
+			return "", fmt.Errorf("row %d: %w", i+1, err)
+		}
+		selects = append(selects, "SELECT "+strings.Join(columns, ", "))
+	}
+	return strings.Join(selects, "\n  UNION ALL\n  "), nil
+}
+
+// fixtureColumns returns the columns like 1 AS `id` in the order of the schema.
+func fixtureColumns(schema bq.Schema, row map[string]any) ([]string, error) {
+	for name := range row {
+		if !hasField(schema, name) {
+			return nil, fmt.Errorf("column %s is not found in the schema", name)
+		}
+	}
+
+	columns := make([]string, 0, len(schema))
+	for _, field := range schema {
+		var value any
+		for name, v := range row {
+			if strings.EqualFold(name, field.Name) {
+				value = v
+				break
+			}
+		}
+		literal, err := fixtureValue(field, value)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", field.Name, err)
+		}
+		columns = append(columns, fmt.Sprintf("%s AS %s", literal, quoteIdentifier(field.Name)))
+	}
+	return columns, nil
+}
+
+func hasField(schema bq.Schema, name string) bool {
+	for _, field := range schema {
+		if strings.EqualFold(field.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// fixtureValue converts the YAML value into the literal of the field type.
+func fixtureValue(field *bq.FieldSchema, value any) (string, error) {
+	if value == nil {
+		return fmt.Sprintf("CAST(NULL AS %s)", sqlTypeName(field)), nil
+	}
+	if !field.Repeated {
+		return fixtureScalarValue(field, value)
+	}
+
+	values, ok := value.([]any)
+	if !ok {
+		return "", fmt.Errorf("the value should be a list, but got %v", value)
+	}
+	element := *field
+	element.Repeated = false
+	literals := make([]string, 0, len(values))
+	for _, v := range values {
+		if v == nil {
+			return "", fmt.Errorf("the array can't have NULL")
+		}
+		literal, err := fixtureScalarValue(&element, v)
+		if err != nil {
+			return "", err
+		}
+		literals = append(literals, literal)
+	}
+	return fmt.Sprintf("ARRAY<%s>[%s]", sqlTypeName(&element), strings.Join(literals, ", ")), nil
+}
+
+func fixtureScalarValue(field *bq.FieldSchema, value any) (string, error) {
+	switch field.Type {
+	case bq.RecordFieldType:
+		row, ok := value.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("the value should be a mapping, but got %v", value)
+		}
+		columns, err := fixtureColumns(field.Schema, row)
+		if err != nil {
+			return "", err
+		}
+		return "STRUCT(" + strings.Join(columns, ", ") + ")", nil
+	case bq.StringFieldType:
+		return strconv.Quote(fmt.Sprint(value)), nil
+	case bq.IntegerFieldType:
+		if i, ok := value.(int); ok {
+			return strconv.Itoa(i), nil
+		}
+	case bq.BooleanFieldType:
+		if b, ok := value.(bool); ok {
+			return strings.ToUpper(strconv.FormatBool(b)), nil
+		}
+	case bq.JSONFieldType:
+		if s, ok := value.(string); ok {
+			return "JSON " + strconv.Quote(s), nil
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert %v into JSON: %w", value, err)
+		}
+		return "JSON " + strconv.Quote(string(b)), nil
+	case bq.GeographyFieldType:
+		return fmt.Sprintf("ST_GEOGFROMTEXT(%s)", strconv.Quote(fmt.Sprint(value))), nil
+	}
+	return fmt.Sprintf("CAST(%s AS %s)", strconv.Quote(fmt.Sprint(value)), sqlTypeName(field)), nil
+}
+
+// quoteIdentifier quotes the column name with backticks, because the name of the schema may be a reserved keyword like `from`.
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+// sqlTypeName returns the type of the field in GoogleSQL like INT64 or ARRAY<STRUCT<a STRING>>.
+func sqlTypeName(field *bq.FieldSchema) string {
+	var typ string
+	switch field.Type {
+	case bq.IntegerFieldType:
+		typ = "INT64"
+	case bq.FloatFieldType:
+		typ = "FLOAT64"
+	case bq.BooleanFieldType:
+		typ = "BOOL"
+	case bq.RecordFieldType:
+		fields := make([]string, 0, len(field.Schema))
+		for _, f := range field.Schema {
+			fields = append(fields, fmt.Sprintf("%s %s", quoteIdentifier(f.Name), sqlTypeName(f)))
+		}
+		typ = "STRUCT<" + strings.Join(fields, ", ") + ">"
+	default:
+		typ = file.FieldTypeString(field)
+	}
+	if field.Repeated {
+		return "ARRAY<" + typ + ">"
+	}
+	return typ
+}
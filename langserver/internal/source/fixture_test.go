@@ -0,0 +1,108 @@
+package source_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_FixtureQuery(t *testing.T) {
+	tests := map[string]struct {
+		file     string
+		fixtures string
+
+		expectQuery string
+		expectErr   bool
+	}{
+		"replace the table with the rows": {
+			file:     "|SELECT id, name FROM `project.dataset.table` WHERE id > 0",
+			fixtures: "project.dataset.table:\n  - id: 1\n    name: a\n    tags: [x]\n  - id: 2\n",
+			expectQuery: "WITH table AS (\n" +
+				"  SELECT 1 AS `id`, \"a\" AS `name`, ARRAY<STRING>[\"x\"] AS `tags`, CAST(NULL AS STRUCT<`a` INT64>) AS `info`\n" +
+				"  UNION ALL\n" +
+				"  SELECT 2 AS `id`, CAST(NULL AS STRING) AS `name`, CAST(NULL AS ARRAY<STRING>) AS `tags`, CAST(NULL AS STRUCT<`a` INT64>) AS `info`\n" +
+				")\n" +
+				"SELECT id, name FROM table WHERE id > 0",
+		},
+		"add the fixtures to the WITH clause": {
+			file:     "WITH t AS (SELECT * FROM `project.dataset.table`)\n|SELECT id FROM t",
+			fixtures: "dataset.table:\n  - info: {a: 1}\n",
+			expectQuery: "WITH\ntable AS (\n" +
+				"  SELECT CAST(NULL AS INT64) AS `id`, CAST(NULL AS STRING) AS `name`, CAST(NULL AS ARRAY<STRING>) AS `tags`, STRUCT(1 AS `a`) AS `info`\n" +
+				"),\n" +
+				"t AS (SELECT * FROM table)\nSELECT id FROM t",
+		},
+		"empty table": {
+			file:     "|SELECT id FROM `project.dataset.table`",
+			fixtures: "project.dataset.table: []\n",
+			expectQuery: "WITH table AS (\n" +
+				"  SELECT CAST(NULL AS INT64) AS `id`, CAST(NULL AS STRING) AS `name`, CAST(NULL AS ARRAY<STRING>) AS `tags`, CAST(NULL AS STRUCT<`a` INT64>) AS `info` LIMIT 0\n" +
+				")\n" +
+				"SELECT id FROM table",
+		},
+		"the fixture is not defined": {
+			file:      "|SELECT id FROM `project.dataset.table`",
+			fixtures:  "project.dataset.other: []\n",
+			expectErr: true,
+		},
+		"unknown column": {
+			file:      "|SELECT id FROM `project.dataset.table`",
+			fixtures:  "project.dataset.table:\n  - unknown: 1\n",
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetDefaultProject().Return("project").MinTimes(0)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "id", Type: bq.IntegerFieldType},
+					{Name: "name", Type: bq.StringFieldType},
+					{Name: "tags", Type: bq.StringFieldType, Repeated: true},
+					{Name: "info", Type: bq.RecordFieldType, Schema: bq.Schema{
+						{Name: "a", Type: bq.IntegerFieldType},
+					}},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			sqlPath := filepath.Join(t.TempDir(), "query.sql")
+			if err := os.WriteFile(source.FixtureFilePath(sqlPath), []byte(tt.fixtures), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			files, path, position, err := helper.GetLspPosition(map[string]string{sqlPath: tt.file})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.FixtureQuery(context.Background(), path, position)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("FixtureQuery should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectQuery, got); diff != "" {
+				t.Errorf("project.FixtureQuery result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
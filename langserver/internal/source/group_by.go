@@ -0,0 +1,83 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// FixUngroupedColumn returns the edits which fix the column which is neither grouped nor aggregated at position.
+// The column is appended to GROUP BY clause, or wrapped with ANY_VALUE when anyValue is true.
+//
+// The statement which has the error can't be analyzed, so the fix is based on the parsed AST.
+func (p *Project) FixUngroupedColumn(uri string, position lsp.Position, anyValue bool) ([]lsp.TextEdit, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+	if parsedFile.Node == nil {
+		return nil, fmt.Errorf("failed to parse document %s", uri)
+	}
+
+	termOffset := parsedFile.TermOffset(position)
+	column, ok := file.SearchAstNode[*ast.PathExpressionNode](parsedFile.Node, termOffset)
+	if !ok {
+		return nil, fmt.Errorf("column is not found at the position")
+	}
+	columnText, ok := parsedFile.ExtractSQL(column.ParseLocationRange())
+	if !ok {
+		return nil, fmt.Errorf("failed to extract the column")
+	}
+
+	if anyValue {
+		return wrapWithAnyValue(parsedFile, column, columnText)
+	}
+
+	selectNode, ok := file.SearchAstNode[*ast.SelectNode](parsedFile.Node, termOffset)
+	if !ok {
+		return nil, fmt.Errorf("SELECT is not found at the position")
+	}
+	return appendToGroupBy(parsedFile, selectNode, columnText)
+}
+
+func wrapWithAnyValue(parsedFile file.ParsedFile, column *ast.PathExpressionNode, columnText string) ([]lsp.TextEdit, error) {
+	rng, ok := parsedFile.PositionRange(column.ParseLocationRange())
+	if !ok {
+		return nil, fmt.Errorf("failed to find the range of the column")
+	}
+
+	newText := fmt.Sprintf("ANY_VALUE(%s)", columnText)
+	// keep the output column name when the column is selected as it is
+	if selectColumn, ok := column.Parent().(*ast.SelectColumnNode); ok && selectColumn.Alias() == nil {
+		names := column.Names()
+		newText += " AS " + names[len(names)-1].Name()
+	}
+	return []lsp.TextEdit{{Range: rng, NewText: newText}}, nil
+}
+
+func appendToGroupBy(parsedFile file.ParsedFile, selectNode *ast.SelectNode, columnText string) ([]lsp.TextEdit, error) {
+	if groupBy := selectNode.GroupBy(); groupBy != nil {
+		rng, ok := parsedFile.PositionRange(groupBy.ParseLocationRange())
+		if !ok {
+			return nil, fmt.Errorf("failed to find the range of GROUP BY clause")
+		}
+		return []lsp.TextEdit{{Range: lsp.Range{Start: rng.End, End: rng.End}, NewText: ", " + columnText}}, nil
+	}
+
+	// GROUP BY clause follows WHERE or FROM clause
+	var lastClause ast.Node = selectNode.SelectList()
+	if fromClause := selectNode.FromClause(); fromClause != nil {
+		lastClause = fromClause
+	}
+	if whereClause := selectNode.WhereClause(); whereClause != nil {
+		lastClause = whereClause
+	}
+	rng, ok := parsedFile.PositionRange(lastClause.ParseLocationRange())
+	if !ok {
+		return nil, fmt.Errorf("failed to find the position to insert GROUP BY clause")
+	}
+	return []lsp.TextEdit{{Range: lsp.Range{Start: rng.End, End: rng.End}, NewText: "\nGROUP BY " + columnText}}, nil
+}
@@ -0,0 +1,94 @@
+package source_test
+
+import (
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_FixUngroupedColumn(t *testing.T) {
+	tests := map[string]struct {
+		files    map[string]string
+		anyValue bool
+
+		expectEdits []lsp.TextEdit
+	}{
+		"add GROUP BY clause": {
+			files: map[string]string{
+				"file1.sql": "SELECT i|d, COUNT(*) AS cnt FROM `project.dataset.table`",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 55}, End: lsp.Position{Line: 0, Character: 55}},
+					NewText: "\nGROUP BY id",
+				},
+			},
+		},
+		"append to GROUP BY clause": {
+			files: map[string]string{
+				"file1.sql": "SELECT id, na|me, COUNT(*) FROM `project.dataset.table` GROUP BY id",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 66}, End: lsp.Position{Line: 0, Character: 66}},
+					NewText: ", name",
+				},
+			},
+		},
+		"wrap with ANY_VALUE": {
+			files: map[string]string{
+				"file1.sql": "SELECT i|d, COUNT(*) AS cnt FROM `project.dataset.table`",
+			},
+			anyValue: true,
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 9}},
+					NewText: "ANY_VALUE(id) AS id",
+				},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.FixUngroupedColumn(path, position, tt.anyValue)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectEdits, got); diff != "" {
+				t.Errorf("project.FixUngroupedColumn result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
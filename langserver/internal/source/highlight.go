@@ -0,0 +1,75 @@
+package source
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/goccy/go-zetasql/ast"
+	rast "github.com/goccy/go-zetasql/resolved_ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// DocumentHighlight returns the occurrences of the column under the cursor.
+// The occurrences are matched by the column ID in the resolved AST, so the columns which have the same name in the other tables are not included.
+func (p *Project) DocumentHighlight(uri string, position lsp.Position) ([]lsp.DocumentHighlight, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+
+	termOffset := parsedFile.TermOffset(position)
+	output, ok := parsedFile.FindTargetAnalyzeOutput(termOffset)
+	if !ok {
+		p.logger.Debug("not found target analyze output")
+		return nil, nil
+	}
+
+	column, ok := p.findTargetColumn(output.Statement(), parsedFile, termOffset)
+	if !ok {
+		return nil, nil
+	}
+
+	result := make([]lsp.DocumentHighlight, 0)
+	for _, n := range file.ListResolvedAstNode[*rast.ComputedColumnNode](output) {
+		if n.Column().ColumnID() != column.ColumnID() {
+			continue
+		}
+		if rng, ok := computedColumnAliasRange(parsedFile, n); ok {
+			result = append(result, lsp.DocumentHighlight{Range: rng, Kind: lsp.Write})
+		}
+	}
+
+	for _, n := range file.ListResolvedAstNode[*rast.ColumnRefNode](output) {
+		if n.Column().ColumnID() != column.ColumnID() {
+			continue
+		}
+		if rng, ok := parsedFile.PositionRange(n.ParseLocationRange()); ok {
+			result = append(result, lsp.DocumentHighlight{Range: rng, Kind: lsp.Read})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return positionLess(result[i].Range.Start, result[j].Range.Start)
+	})
+	return result, nil
+}
+
+// computedColumnAliasRange returns the range of the alias which defines the computed column like `expr AS alias`.
+func computedColumnAliasRange(parsedFile file.ParsedFile, node *rast.ComputedColumnNode) (lsp.Range, bool) {
+	lRange := node.ParseLocationRange()
+	if lRange == nil {
+		return lsp.Range{}, false
+	}
+
+	selectColumnNode, ok := file.SearchAstNode[*ast.SelectColumnNode](parsedFile.Node, lRange.Start().ByteOffset())
+	if !ok {
+		return lsp.Range{}, false
+	}
+	alias := selectColumnNode.Alias()
+	if alias == nil || alias.Identifier().Name() != node.Column().Name() {
+		return lsp.Range{}, false
+	}
+	return parsedFile.PositionRange(alias.Identifier().ParseLocationRange())
+}
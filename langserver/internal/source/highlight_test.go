@@ -0,0 +1,105 @@
+package source_test
+
+import (
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_DocumentHighlight(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+
+		expectHighlights []lsp.DocumentHighlight
+	}{
+		"column of the joined table": {
+			files: map[string]string{
+				"file1.sql": "SELECT t1.id + 1 FROM `project.dataset.table` t1 JOIN `project.dataset.table` t2 ON t1.id = t2.id WHERE t1.|id > 0",
+			},
+			expectHighlights: []lsp.DocumentHighlight{
+				{
+					Range: lsp.Range{
+						Start: lsp.Position{Line: 0, Character: 7},
+						End:   lsp.Position{Line: 0, Character: 12},
+					},
+					Kind: lsp.Read,
+				},
+				{
+					Range: lsp.Range{
+						Start: lsp.Position{Line: 0, Character: 84},
+						End:   lsp.Position{Line: 0, Character: 89},
+					},
+					Kind: lsp.Read,
+				},
+				{
+					Range: lsp.Range{
+						Start: lsp.Position{Line: 0, Character: 104},
+						End:   lsp.Position{Line: 0, Character: 109},
+					},
+					Kind: lsp.Read,
+				},
+			},
+		},
+		"alias": {
+			files: map[string]string{
+				"file1.sql": "SELECT id + 1 AS next_|id FROM `project.dataset.table` ORDER BY next_id",
+			},
+			expectHighlights: []lsp.DocumentHighlight{
+				{
+					Range: lsp.Range{
+						Start: lsp.Position{Line: 0, Character: 17},
+						End:   lsp.Position{Line: 0, Character: 24},
+					},
+					Kind: lsp.Write,
+				},
+				{
+					Range: lsp.Range{
+						Start: lsp.Position{Line: 0, Character: 63},
+						End:   lsp.Position{Line: 0, Character: 70},
+					},
+					Kind: lsp.Read,
+				},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.DocumentHighlight(path, position)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.expectHighlights, got); diff != "" {
+				t.Errorf("project.DocumentHighlight result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
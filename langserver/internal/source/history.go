@@ -0,0 +1,201 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/kitagry/bqls/langserver/internal/bigquery"
+	"github.com/kitagry/bqls/langserver/internal/history"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// DefaultQueryHistoryLimit is the number of entries of bqls.queryHistory when it is not specified.
+const DefaultQueryHistoryLimit = 20
+
+// ErrQueryHistoryDisabled is returned when the query history is not recorded.
+var ErrQueryHistoryDisabled = errors.New("query history is disabled")
+
+func openHistory() (*history.Store, error) {
+	dir, err := bigquery.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return history.Open(filepath.Join(dir, "history.sqlite3"), history.DefaultMaxEntries)
+}
+
+// recordDryrun records the dry run. The statistics of the dry run are available immediately.
+func (p *Project) recordDryrun(query string, status *bq.JobStatus, runErr error) {
+	if p.history == nil {
+		return
+	}
+
+	entry := history.Entry{
+		Query:     query,
+		ProjectID: p.BillingProjectID,
+		DryRun:    true,
+	}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+	} else if status != nil {
+		if status.Err() != nil {
+			entry.Error = status.Err().Error()
+		}
+		if status.Statistics != nil {
+			entry.TotalBytesProcessed = status.Statistics.TotalBytesProcessed
+		}
+	}
+
+	if _, err := p.history.Add(context.Background(), entry); err != nil {
+		p.logger.Warnf("failed to record query history: %v", err)
+	}
+}
+
+// recordJob records the job when it is started.
+// It returns the entry which is updated after the job is finished, or nil when the job is not recorded.
+func (p *Project) recordJob(query string, job bigquery.BigqueryJob, runErr error) *history.Entry {
+	if p.history == nil {
+		return nil
+	}
+
+	entry := history.Entry{
+		Query:     query,
+		ProjectID: p.BillingProjectID,
+	}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+		if _, err := p.history.Add(context.Background(), entry); err != nil {
+			p.logger.Warnf("failed to record query history: %v", err)
+		}
+		return nil
+	}
+
+	entry.JobID = job.ID()
+	id, err := p.history.Add(context.Background(), entry)
+	if err != nil {
+		p.logger.Warnf("failed to record query history: %v", err)
+		return nil
+	}
+	entry.ID = id
+	return &entry
+}
+
+// updateJobHistory updates the statistics of the finished job.
+func (p *Project) updateJobHistory(entry *history.Entry, job bigquery.BigqueryJob, status *bq.JobStatus, waitErr error) {
+	if p.history == nil || entry == nil {
+		return
+	}
+
+	if waitErr != nil {
+		entry.Error = waitErr.Error()
+	} else {
+		if status.Err() != nil {
+			entry.Error = status.Err().Error()
+		}
+		if s := status.Statistics; s != nil {
+			entry.TotalBytesProcessed = s.TotalBytesProcessed
+			if !s.StartTime.IsZero() && !s.EndTime.IsZero() {
+				entry.Duration = s.EndTime.Sub(s.StartTime)
+			}
+		}
+	}
+
+	if config, err := job.Config(); err == nil {
+		if c, ok := config.(*bq.QueryConfig); ok && c.Dst != nil {
+			entry.Destination = fmt.Sprintf("%s.%s.%s", c.Dst.ProjectID, c.Dst.DatasetID, c.Dst.TableID)
+		}
+	}
+
+	if err := p.history.Update(context.Background(), *entry); err != nil {
+		p.logger.Warnf("failed to update query history: %v", err)
+	}
+}
+
+// QueryHistory returns the latest queries launched by bqls.
+func (p *Project) QueryHistory(ctx context.Context, limit int) (lsp.QueryHistoryResult, error) {
+	if p.history == nil {
+		return lsp.QueryHistoryResult{}, ErrQueryHistoryDisabled
+	}
+	if limit <= 0 {
+		limit = DefaultQueryHistoryLimit
+	}
+
+	entries, err := p.history.List(ctx, limit)
+	if err != nil {
+		return lsp.QueryHistoryResult{}, err
+	}
+
+	result := lsp.QueryHistoryResult{
+		Entries: make([]lsp.QueryHistoryEntry, len(entries)),
+	}
+	for i, e := range entries {
+		result.Entries[i] = convertHistoryEntry(e)
+	}
+	result.Contents = []lsp.MarkedString{
+		{
+			Language: "markdown",
+			Value:    queryHistoryMarkdown(entries),
+		},
+	}
+	return result, nil
+}
+
+// RerunQuery runs the query of the history entry again.
+func (p *Project) RerunQuery(ctx context.Context, id int64, force bool) (bigquery.BigqueryJob, error) {
+	if p.history == nil {
+		return nil, ErrQueryHistoryDisabled
+	}
+
+	entry, err := p.history.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return p.runQuery(ctx, entry.Query, force, nil)
+}
+
+func convertHistoryEntry(e history.Entry) lsp.QueryHistoryEntry {
+	result := lsp.QueryHistoryEntry{
+		ID:                  e.ID,
+		Query:               e.Query,
+		JobID:               e.JobID,
+		DryRun:              e.DryRun,
+		TotalBytesProcessed: e.TotalBytesProcessed,
+		DurationMs:          e.Duration.Milliseconds(),
+		Destination:         e.Destination,
+		Error:               e.Error,
+		CreatedAt:           e.CreatedAt.Format(time.RFC3339),
+	}
+	if e.JobID != "" {
+		result.TextDocument.URI = lsp.NewJobVirtualTextDocumentURI(e.ProjectID, e.JobID)
+	}
+	return result
+}
+
+func queryHistoryMarkdown(entries []history.Entry) string {
+	var sb strings.Builder
+	sb.WriteString("| ID | Created | Type | Bytes processed | Duration | Destination | Query |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, e := range entries {
+		typ := "query"
+		if e.DryRun {
+			typ = "dry run"
+		}
+		if e.Error != "" {
+			typ += " (failed)"
+		}
+		sb.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s | %s | %s |\n",
+			e.ID,
+			e.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			typ,
+			bytesConvert(e.TotalBytesProcessed),
+			e.Duration,
+			escapeMarkdownTableCell(e.Destination),
+			escapeMarkdownTableCell(e.Query),
+		))
+	}
+	return sb.String()
+}
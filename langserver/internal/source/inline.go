@@ -0,0 +1,186 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// InlineCTE returns the edits which replace the only reference of the WITH query at position with its query as a subquery,
+// and delete the WITH query.
+// The position can be either the name of the WITH query or its reference.
+func (p *Project) InlineCTE(uri string, position lsp.Position) ([]lsp.TextEdit, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+	if parsedFile.Node == nil {
+		return nil, fmt.Errorf("failed to parse document %s", uri)
+	}
+
+	entry, withClause, ref, err := cteToInline(parsedFile, position)
+	if err != nil {
+		return nil, err
+	}
+	name := entry.Alias().Name()
+
+	_, body, ok := subqueryBody(parsedFile, entry.Query())
+	if !ok {
+		return nil, fmt.Errorf("failed to find the query of %s", name)
+	}
+
+	refRange, ok := parsedFile.PositionRange(ref.PathExpr().ParseLocationRange())
+	if !ok {
+		return nil, fmt.Errorf("failed to find the reference of %s", name)
+	}
+	// indent the subquery from the line of the reference
+	line := strings.Split(parsedFile.Src, "\n")[refRange.Start.Line]
+	lineIndent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	subquery := fmt.Sprintf("(\n%s\n%s)", indentBody(body, lineIndent+"  "), lineIndent)
+	// keep the columns qualified by the name like `name.column` valid
+	if ref.Alias() == nil {
+		subquery += " AS " + name
+	}
+
+	deleteRange, ok := withClauseEntryDeletionRange(parsedFile, withClause.With(), entry)
+	if !ok {
+		return nil, fmt.Errorf("failed to find the range of %s", name)
+	}
+
+	return []lsp.TextEdit{
+		{Range: deleteRange, NewText: ""},
+		{Range: refRange, NewText: subquery},
+	}, nil
+}
+
+// cteToInline finds the WITH query at position, its WITH clause and its only reference.
+func cteToInline(parsedFile file.ParsedFile, position lsp.Position) (*ast.WithClauseEntryNode, *ast.WithClauseNode, *ast.TablePathExpressionNode, error) {
+	entry, ok := findWithClauseEntry(parsedFile, parsedFile.TermOffset(position))
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("WITH query is not found at the position")
+	}
+	withClause, ok := entry.Parent().(*ast.WithClauseNode)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("failed to find the WITH clause")
+	}
+	stmt, ok := parsedFile.FindTargetStatementNode(entry.ParseLocationRange().Start().ByteOffset())
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("failed to find the statement of the WITH query")
+	}
+
+	refs := listWithClauseReferences(stmt, entry)
+	if len(refs) != 1 {
+		return nil, nil, nil, fmt.Errorf("%s should be referenced once to be inlined, but referenced %d times", entry.Alias().Name(), len(refs))
+	}
+	return entry, withClause, refs[0], nil
+}
+
+// findWithClauseEntry finds the WITH query whose name or reference is at termOffset.
+func findWithClauseEntry(parsedFile file.ParsedFile, termOffset int) (*ast.WithClauseEntryNode, bool) {
+	if entry, ok := file.SearchAstNode[*ast.WithClauseEntryNode](parsedFile.Node, termOffset); ok {
+		lRange := entry.Alias().ParseLocationRange()
+		if lRange != nil && lRange.Start().ByteOffset() <= termOffset && termOffset <= lRange.End().ByteOffset() {
+			return entry, true
+		}
+	}
+
+	tablePath, ok := file.SearchAstNode[*ast.TablePathExpressionNode](parsedFile.Node, termOffset)
+	if !ok {
+		return nil, false
+	}
+	name, ok := file.CreateTableNameFromTablePathExpressionNode(tablePath)
+	if !ok || strings.Contains(name, ".") {
+		return nil, false
+	}
+
+	// the last WITH query defined before the reference is referenced
+	var result *ast.WithClauseEntryNode
+	for _, entry := range file.ListAstNode[*ast.WithClauseEntryNode](parsedFile.Node) {
+		if entry.Alias().Name() != name {
+			continue
+		}
+		if entry.ParseLocationRange().Start().ByteOffset() > termOffset {
+			break
+		}
+		result = entry
+	}
+	return result, result != nil
+}
+
+// listWithClauseReferences lists the table paths in stmt which refer to the WITH query.
+func listWithClauseReferences(stmt ast.StatementNode, entry *ast.WithClauseEntryNode) []*ast.TablePathExpressionNode {
+	entryStart := entry.ParseLocationRange().Start().ByteOffset()
+	result := make([]*ast.TablePathExpressionNode, 0)
+	ast.Walk(stmt, func(n ast.Node) error {
+		tablePath, ok := n.(*ast.TablePathExpressionNode)
+		if !ok || tablePath.PathExpr() == nil {
+			return nil
+		}
+		lRange := tablePath.ParseLocationRange()
+		if lRange == nil || lRange.Start().ByteOffset() < entryStart {
+			return nil
+		}
+		name, ok := file.CreateTableNameFromTablePathExpressionNode(tablePath)
+		if !ok || name != entry.Alias().Name() {
+			return nil
+		}
+		result = append(result, tablePath)
+		return nil
+	})
+	return result
+}
+
+// withClauseEntryDeletionRange returns the range to delete the entry with its comma.
+// When the entry is the only one, the WITH keyword is also deleted.
+func withClauseEntryDeletionRange(parsedFile file.ParsedFile, entries []*ast.WithClauseEntryNode, entry *ast.WithClauseEntryNode) (lsp.Range, bool) {
+	index := -1
+	for i, e := range entries {
+		if e.ParseLocationRange().Start().ByteOffset() == entry.ParseLocationRange().Start().ByteOffset() {
+			index = i
+		}
+	}
+	if index < 0 {
+		return lsp.Range{}, false
+	}
+
+	entryRange, ok := parsedFile.PositionRange(entry.ParseLocationRange())
+	if !ok {
+		return lsp.Range{}, false
+	}
+
+	switch {
+	case len(entries) == 1:
+		src := parsedFile.Src
+		before := strings.TrimRight(src[:parsedFile.SrcOffset(entryRange.Start)], " \t\n")
+		if !strings.HasSuffix(strings.ToUpper(before), "WITH") {
+			return lsp.Range{}, false
+		}
+		start, ok := parsedFile.SrcPosition(len(before) - len("WITH"))
+		if !ok {
+			return lsp.Range{}, false
+		}
+		endOffset := parsedFile.SrcOffset(entryRange.End)
+		endOffset += len(src[endOffset:]) - len(strings.TrimLeft(src[endOffset:], " \t\n"))
+		end, ok := parsedFile.SrcPosition(endOffset)
+		if !ok {
+			return lsp.Range{}, false
+		}
+		return lsp.Range{Start: start, End: end}, true
+	case index == 0:
+		nextRange, ok := parsedFile.PositionRange(entries[1].ParseLocationRange())
+		if !ok {
+			return lsp.Range{}, false
+		}
+		return lsp.Range{Start: entryRange.Start, End: nextRange.Start}, true
+	default:
+		prevRange, ok := parsedFile.PositionRange(entries[index-1].ParseLocationRange())
+		if !ok {
+			return lsp.Range{}, false
+		}
+		return lsp.Range{Start: prevRange.End, End: entryRange.End}, true
+	}
+}
@@ -0,0 +1,98 @@
+package source_test
+
+import (
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_InlineCTE(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+
+		expectEdits []lsp.TextEdit
+		expectErr   bool
+	}{
+		"inline the only WITH query": {
+			files: map[string]string{
+				"file1.sql": "WITH po|sitive AS (\n  SELECT id FROM `project.dataset.table` WHERE id > 0\n)\nSELECT id FROM positive",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 0}, End: lsp.Position{Line: 3, Character: 0}},
+					NewText: "",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 3, Character: 15}, End: lsp.Position{Line: 3, Character: 23}},
+					NewText: "(\n  SELECT id FROM `project.dataset.table` WHERE id > 0\n) AS positive",
+				},
+			},
+		},
+		"inline from the reference with alias": {
+			files: map[string]string{
+				"file1.sql": "WITH a AS (SELECT 1 AS id),\nb AS (SELECT id FROM a)\nSELECT t.id FROM b| AS t",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 26}, End: lsp.Position{Line: 1, Character: 23}},
+					NewText: "",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 2, Character: 17}, End: lsp.Position{Line: 2, Character: 18}},
+					NewText: "(\n  SELECT id FROM a\n)",
+				},
+			},
+		},
+		"WITH query referenced twice": {
+			files: map[string]string{
+				"file1.sql": "WITH a| AS (SELECT 1 AS id)\nSELECT * FROM a JOIN a AS a2 USING (id)",
+			},
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.InlineCTE(path, position)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("InlineCTE should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectEdits, got); diff != "" {
+				t.Errorf("project.InlineCTE result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kitagry/bqls/langserver/internal/bigquery"
+	"github.com/kitagry/bqls/langserver/internal/history"
+)
+
+// jobShutdownTimeout is how long Close waits for the running jobs to be finished or cancelled.
+const jobShutdownTimeout = 5 * time.Second
+
+// watchJob tracks the job until it is finished, and then updates the query history.
+func (p *Project) watchJob(job bigquery.BigqueryJob, entry *history.Entry) {
+	p.jobsLock.Lock()
+	p.jobs[job.ID()] = job
+	p.jobsLock.Unlock()
+
+	p.jobWatchers.Add(1)
+	go func() {
+		defer p.jobWatchers.Done()
+
+		status, err := job.Wait(context.Background())
+
+		p.jobsLock.Lock()
+		delete(p.jobs, job.ID())
+		p.jobsLock.Unlock()
+
+		p.updateJobHistory(entry, job, status, err)
+	}()
+}
+
+// CancelJobs cancels the running query jobs launched by the project.
+func (p *Project) CancelJobs(ctx context.Context) error {
+	p.jobsLock.Lock()
+	jobs := make([]bigquery.BigqueryJob, 0, len(p.jobs))
+	for _, job := range p.jobs {
+		jobs = append(jobs, job)
+	}
+	p.jobsLock.Unlock()
+
+	errs := make([]error, 0)
+	for _, job := range jobs {
+		p.logger.Infof("cancel job %s", job.ID())
+		if err := job.Cancel(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to cancel job %s: %w", job.ID(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Project) waitJobWatchers(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		p.jobWatchers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		p.logger.Warnf("timeout waiting for the running jobs")
+	}
+}
@@ -0,0 +1,316 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/goccy/go-zetasql"
+	"github.com/goccy/go-zetasql/ast"
+	rast "github.com/goccy/go-zetasql/resolved_ast"
+	"github.com/goccy/go-zetasql/types"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// AddJoinCondition returns the edit which adds the ON clause to the JOIN at position which has neither ON nor USING.
+// The condition compares the columns of the joined table with the columns of the left tables which have the same name and comparable types.
+func (p *Project) AddJoinCondition(ctx context.Context, uri string, position lsp.Position) ([]lsp.TextEdit, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+	if parsedFile.Node == nil {
+		return nil, fmt.Errorf("failed to parse document %s", uri)
+	}
+
+	join, rhs, err := joinWithoutCondition(parsedFile, position)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := p.joinTable(ctx, rhs)
+	if err != nil {
+		return nil, err
+	}
+	lefts := make([]joinTable, 0)
+	ast.Walk(join.Lhs(), func(n ast.Node) error {
+		node, ok := n.(*ast.TablePathExpressionNode)
+		if !ok {
+			return nil
+		}
+		left, err := p.joinTable(ctx, node)
+		if err != nil {
+			p.logger.Debugf("failed to get the table of JOIN: %v", err)
+			return nil
+		}
+		lefts = append(lefts, left)
+		return nil
+	})
+
+	conditions := joinConditions(lefts, right)
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("no columns which have the same name and comparable types")
+	}
+
+	rng, ok := parsedFile.PositionRange(rhs.ParseLocationRange())
+	if !ok {
+		return nil, fmt.Errorf("failed to find the range of the joined table")
+	}
+	return []lsp.TextEdit{{Range: lsp.Range{Start: rng.End, End: rng.End}, NewText: " ON " + strings.Join(conditions, " AND ")}}, nil
+}
+
+// joinWithoutCondition finds the JOIN at position which has neither ON nor USING, and its joined table.
+func joinWithoutCondition(parsedFile file.ParsedFile, position lsp.Position) (*ast.JoinNode, *ast.TablePathExpressionNode, error) {
+	join, ok := file.SearchAstNode[*ast.JoinNode](parsedFile.Node, parsedFile.TermOffset(position))
+	if !ok {
+		return nil, nil, fmt.Errorf("JOIN is not found at the position")
+	}
+	if join.OnClause() != nil || join.UsingClause() != nil {
+		return nil, nil, fmt.Errorf("JOIN already has the condition")
+	}
+	rhs, ok := join.Rhs().(*ast.TablePathExpressionNode)
+	if !ok || rhs.ParseLocationRange() == nil {
+		return nil, nil, fmt.Errorf("the joined item is not a table")
+	}
+	return join, rhs, nil
+}
+
+// joinTable is the table of JOIN with the name which qualifies its columns.
+type joinTable struct {
+	name   string
+	schema bq.Schema
+}
+
+func (p *Project) joinTable(ctx context.Context, node *ast.TablePathExpressionNode) (joinTable, error) {
+	path, ok := file.CreateTableNameFromTablePathExpressionNode(node)
+	if !ok || node.PathExpr() == nil {
+		return joinTable{}, fmt.Errorf("the table name is not found")
+	}
+	metadata, err := p.analyzer.GetTableMetadataFromPath(ctx, path)
+	if err != nil {
+		return joinTable{}, fmt.Errorf("failed to get table metadata: %w", err)
+	}
+
+	// the table without alias is qualified by the last name of the path.
+	names := node.PathExpr().Names()
+	name := names[len(names)-1].Name()
+	if alias := node.Alias(); alias != nil {
+		name = alias.Identifier().Name()
+	}
+	return joinTable{name: name, schema: metadata.Schema}, nil
+}
+
+// joinConditions lists `left.column = right.column` in the order of the columns of the right table.
+// When the left tables have the same column, the first one is used.
+func joinConditions(lefts []joinTable, right joinTable) []string {
+	result := make([]string, 0)
+	for _, rf := range right.schema {
+		if !joinableField(rf) {
+			continue
+		}
+	left:
+		for _, l := range lefts {
+			for _, lf := range l.schema {
+				if !strings.EqualFold(lf.Name, rf.Name) || !joinableField(lf) || !comparableFieldTypes(lf.Type, rf.Type) {
+					continue
+				}
+				result = append(result, fmt.Sprintf("%s.%s = %s.%s", l.name, lf.Name, right.name, rf.Name))
+				break left
+			}
+		}
+	}
+	return result
+}
+
+// joinableField reports whether the column can be compared with `=`.
+func joinableField(f *bq.FieldSchema) bool {
+	if f.Repeated {
+		return false
+	}
+	switch f.Type {
+	case bq.RecordFieldType, bq.JSONFieldType, bq.GeographyFieldType:
+		return false
+	}
+	return true
+}
+
+var numericFieldTypes = map[bq.FieldType]struct{}{
+	bq.IntegerFieldType:    {},
+	bq.FloatFieldType:      {},
+	bq.NumericFieldType:    {},
+	bq.BigNumericFieldType: {},
+}
+
+// comparableFieldTypes reports whether the columns can be compared without the explicit cast.
+func comparableFieldTypes(a, b bq.FieldType) bool {
+	if a == b {
+		return true
+	}
+	_, aNumeric := numericFieldTypes[a]
+	_, bNumeric := numericFieldTypes[b]
+	return aNumeric && bNumeric
+}
+
+// termDocumentForJoinKeyword shows the join type and the keys of the JOIN when the term is its keyword like `LEFT JOIN`.
+// The keys whose types are coerced are warned because they are the usual causes of the unexpected fan-out.
+func (p *Project) termDocumentForJoinKeyword(termOffset int, parsedFile file.ParsedFile) ([]lsp.MarkedString, bool) {
+	join, ok := file.SearchAstNode[*ast.JoinNode](parsedFile.Node, termOffset)
+	if !ok || join.Lhs() == nil || join.Rhs() == nil {
+		return nil, false
+	}
+	lhsRange, rhsRange := join.Lhs().ParseLocationRange(), join.Rhs().ParseLocationRange()
+	if lhsRange == nil || rhsRange == nil {
+		return nil, false
+	}
+	if termOffset < lhsRange.End().ByteOffset() || rhsRange.Start().ByteOffset() < termOffset {
+		return nil, false
+	}
+	joinType, ok := joinKeyword(parsedFile, lhsRange, rhsRange)
+	if !ok {
+		return nil, false
+	}
+
+	output, ok := parsedFile.FindTargetAnalyzeOutput(termOffset)
+	if !ok {
+		p.logger.Debug("not found target analyze output")
+		return nil, false
+	}
+
+	var keys []*rast.FunctionCallNode
+	switch {
+	case join.OnClause() != nil:
+		keys = onClauseKeys(output, join.OnClause())
+	case join.UsingClause() != nil:
+		keys = usingClauseKeys(output, join.UsingClause())
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## %s\n\n", joinType)
+	if join.OnClause() == nil && join.UsingClause() == nil {
+		sb.WriteString("No join keys. Every row is joined with every row of the other side.\n")
+	}
+	for _, key := range keys {
+		args := key.ArgumentList()
+		fmt.Fprintf(&sb, "- %s = %s\n", joinKeyString(parsedFile, args[0]), joinKeyString(parsedFile, args[1]))
+		for _, arg := range args {
+			if cast, ok := arg.(*rast.CastNode); ok {
+				fmt.Fprintf(&sb, "  - WARNING: %s is coerced from %s to %s\n", joinKeyName(parsedFile, cast.Expr()),
+					cast.Expr().Type().TypeName(types.ProductExternal), cast.Type().TypeName(types.ProductExternal))
+			}
+		}
+	}
+	return []lsp.MarkedString{
+		{
+			Language: "markdown",
+			Value:    sb.String(),
+		},
+	}, true
+}
+
+// joinKeyword returns the keyword between the joined items like `LEFT OUTER JOIN`.
+// The comma join has no keyword.
+func joinKeyword(parsedFile file.ParsedFile, lhsRange, rhsRange *types.ParseLocationRange) (string, bool) {
+	start, ok := parsedFile.PositionRange(lhsRange)
+	if !ok {
+		return "", false
+	}
+	end, ok := parsedFile.PositionRange(rhsRange)
+	if !ok {
+		return "", false
+	}
+	keyword := strings.ToUpper(strings.Join(strings.Fields(parsedFile.Src[parsedFile.SrcOffset(start.End):parsedFile.SrcOffset(end.Start)]), " "))
+	if !strings.HasSuffix(keyword, "JOIN") {
+		return "", false
+	}
+	return keyword, true
+}
+
+// onClauseKeys lists the equalities in the ON clause.
+func onClauseKeys(output *zetasql.AnalyzerOutput, onClause *ast.OnClauseNode) []*rast.FunctionCallNode {
+	onRange := onClause.ParseLocationRange()
+	if onRange == nil {
+		return nil
+	}
+	result := make([]*rast.FunctionCallNode, 0)
+	rast.Walk(output.Statement(), func(n rast.Node) error {
+		call, ok := n.(*rast.FunctionCallNode)
+		if !ok || call.Function().Name() != "$equal" || len(call.ArgumentList()) != 2 {
+			return nil
+		}
+		lRange := call.ParseLocationRange()
+		if lRange == nil || lRange.Start().ByteOffset() < onRange.Start().ByteOffset() || onRange.End().ByteOffset() < lRange.End().ByteOffset() {
+			return nil
+		}
+		result = append(result, call)
+		return nil
+	})
+	return result
+}
+
+// usingClauseKeys lists the equalities which the analyzer generates for the USING clause.
+// They have no location, so the join whose keys have the same names as the USING clause is used.
+func usingClauseKeys(output *zetasql.AnalyzerOutput, usingClause *ast.UsingClauseNode) []*rast.FunctionCallNode {
+	names := make([]string, 0, len(usingClause.Keys()))
+	for _, key := range usingClause.Keys() {
+		names = append(names, key.Name())
+	}
+
+	var result []*rast.FunctionCallNode
+	rast.Walk(output.Statement(), func(n rast.Node) error {
+		scan, ok := n.(*rast.JoinScanNode)
+		if !ok || result != nil || scan.JoinExpr() == nil {
+			return nil
+		}
+		keys := make([]*rast.FunctionCallNode, 0, len(names))
+		rast.Walk(scan.JoinExpr(), func(n rast.Node) error {
+			if call, ok := n.(*rast.FunctionCallNode); ok && call.Function().Name() == "$equal" && len(call.ArgumentList()) == 2 {
+				keys = append(keys, call)
+			}
+			return nil
+		})
+		if len(keys) != len(names) {
+			return nil
+		}
+		for i, key := range keys {
+			column, ok := joinKeyColumn(key.ArgumentList()[1])
+			if !ok || !strings.EqualFold(column.Name(), names[i]) {
+				return nil
+			}
+		}
+		result = keys
+		return nil
+	})
+	return result
+}
+
+// joinKeyString is the key with its type like `u.user_id (INT64)`.
+func joinKeyString(parsedFile file.ParsedFile, expr rast.ExprNode) string {
+	if cast, ok := expr.(*rast.CastNode); ok {
+		expr = cast.Expr()
+	}
+	return fmt.Sprintf("`%s` (%s)", joinKeyName(parsedFile, expr), expr.Type().TypeName(types.ProductExternal))
+}
+
+func joinKeyName(parsedFile file.ParsedFile, expr rast.ExprNode) string {
+	if sql, ok := parsedFile.ExtractSQL(expr.ParseLocationRange()); ok {
+		return sql
+	}
+	if column, ok := joinKeyColumn(expr); ok {
+		return fmt.Sprintf("%s.%s", column.TableName(), column.Name())
+	}
+	return "expression"
+}
+
+func joinKeyColumn(expr rast.ExprNode) (*rast.Column, bool) {
+	if cast, ok := expr.(*rast.CastNode); ok {
+		expr = cast.Expr()
+	}
+	ref, ok := expr.(*rast.ColumnRefNode)
+	if !ok {
+		return nil, false
+	}
+	return ref.Column(), true
+}
@@ -0,0 +1,179 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_AddJoinCondition(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+
+		expectEdits []lsp.TextEdit
+		expectErr   bool
+	}{
+		"tables with alias": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.users` AS u |JOIN `project.dataset.orders` AS o",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 77}, End: lsp.Position{Line: 0, Character: 77}},
+					NewText: " ON u.user_id = o.user_id AND u.region = o.region",
+				},
+			},
+		},
+		"tables without alias": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.users` |JOIN `project.dataset.orders`",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 67}, End: lsp.Position{Line: 0, Character: 67}},
+					NewText: " ON users.user_id = orders.user_id AND users.region = orders.region",
+				},
+			},
+		},
+		"JOIN with ON": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.users` AS u |JOIN `project.dataset.orders` AS o ON u.user_id = o.user_id",
+			},
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "users").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "user_id", Type: bq.IntegerFieldType},
+					{Name: "name", Type: bq.StringFieldType},
+					{Name: "region", Type: bq.StringFieldType},
+					{Name: "created_at", Type: bq.TimestampFieldType},
+				},
+			}, nil).MinTimes(0)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "orders").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "order_id", Type: bq.IntegerFieldType},
+					{Name: "user_id", Type: bq.NumericFieldType},
+					{Name: "region", Type: bq.StringFieldType},
+					// the types are not comparable
+					{Name: "created_at", Type: bq.DateFieldType},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.AddJoinCondition(context.Background(), path, position)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("AddJoinCondition should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectEdits, got); diff != "" {
+				t.Errorf("project.AddJoinCondition result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProject_TermDocumentForJoinKeyword(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+
+		expectMarkedStrings []lsp.MarkedString
+	}{
+		"LEFT JOIN with coerced key": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.users` AS u LEFT |JOIN `project.dataset.orders` AS o ON u.user_id = o.user_id",
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value:    "## LEFT JOIN\n\n- `u.user_id` (INT64) = `o.user_id` (NUMERIC)\n  - WARNING: u.user_id is coerced from INT64 to NUMERIC\n",
+				},
+			},
+		},
+		"JOIN with keys": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.users` AS u\n|JOIN `project.dataset.orders` AS o ON u.region = o.region AND u.name = 'a'",
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value:    "## JOIN\n\n- `u.region` (STRING) = `o.region` (STRING)\n- `u.name` (STRING) = `'a'` (STRING)\n",
+				},
+			},
+		},
+		"CROSS JOIN": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.users` AS u |CROSS JOIN `project.dataset.orders` AS o",
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value:    "## CROSS JOIN\n\nNo join keys. Every row is joined with every row of the other side.\n",
+				},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "users").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "user_id", Type: bq.IntegerFieldType},
+					{Name: "name", Type: bq.StringFieldType},
+					{Name: "region", Type: bq.StringFieldType},
+				},
+			}, nil).MinTimes(0)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "orders").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "user_id", Type: bq.NumericFieldType},
+					{Name: "region", Type: bq.StringFieldType},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.TermDocument(path, position)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectMarkedStrings, got); diff != "" {
+				t.Errorf("project.TermDocument result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package source
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+const (
+	// legacyTableRefPattern matches the table reference like `[project:dataset.table]`.
+	legacyTableRefPattern = `\[(?:[\w-]+:)?[\w-]+\.[\w$-]+\]`
+	// tableDateRangePattern matches `TABLE_DATE_RANGE([dataset.prefix_], start, end)`.
+	tableDateRangePattern = `TABLE_DATE_RANGE\((?:[^()]|\([^()]*\))*\)`
+	legacyFromItemPattern = `(?:` + legacyTableRefPattern + `|` + tableDateRangePattern + `)`
+)
+
+var (
+	// legacyCommaUnionRegex matches the FROM clause whose tables are concatenated with commas, which means UNION ALL in legacy SQL.
+	legacyCommaUnionRegex = regexp.MustCompile(`(?i)\bFROM\s+(` + legacyFromItemPattern + `(?:\s*,\s*` + legacyFromItemPattern + `)+)`)
+	legacyFromItemRegex   = regexp.MustCompile(`(?i)\b(?:FROM|JOIN)\s+(` + legacyFromItemPattern + `)`)
+	legacyPrefixRegex     = regexp.MustCompile(`(?im)^#legacySQL\b`)
+	legacyTableRefRegex   = regexp.MustCompile(`^` + legacyTableRefPattern + `$`)
+)
+
+// ConvertLegacySQL returns the edits which rewrite the legacy SQL constructs in the file with GoogleSQL.
+// The rewritten constructs are the square-bracket table references, TABLE_DATE_RANGE and the comma as UNION ALL in FROM clause.
+//
+// Legacy SQL can't be parsed by zetasql, so the constructs are detected from the text.
+func (p *Project) ConvertLegacySQL(uri string) ([]lsp.TextEdit, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+	src := parsedFile.Src
+	// The constructs are searched in the code, and the matched offsets are used for src.
+	code := codeOnly(src)
+
+	type replacement struct {
+		start, end int
+		newText    string
+	}
+	replacements := make([]replacement, 0)
+	overlaps := func(start, end int) bool {
+		for _, r := range replacements {
+			if start < r.end && r.start < end {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, m := range legacyCommaUnionRegex.FindAllStringSubmatchIndex(code, -1) {
+		items := splitTopLevel(src[m[2]:m[3]])
+		queries := make([]string, 0, len(items))
+		for _, item := range items {
+			table, condition, ok := convertLegacyFromItem(item)
+			if !ok {
+				break
+			}
+			queries = append(queries, selectFromLegacyItem(table, condition))
+		}
+		if len(queries) != len(items) {
+			continue
+		}
+		replacements = append(replacements, replacement{m[2], m[3], "(" + strings.Join(queries, " UNION ALL ") + ")"})
+	}
+
+	for _, m := range legacyFromItemRegex.FindAllStringSubmatchIndex(code, -1) {
+		if overlaps(m[2], m[3]) {
+			continue
+		}
+		table, condition, ok := convertLegacyFromItem(src[m[2]:m[3]])
+		if !ok {
+			continue
+		}
+		newText := table
+		if condition != "" {
+			newText = "(" + selectFromLegacyItem(table, condition) + ")"
+		}
+		replacements = append(replacements, replacement{m[2], m[3], newText})
+	}
+
+	for _, m := range legacyPrefixRegex.FindAllStringIndex(src, -1) {
+		replacements = append(replacements, replacement{m[0], m[1], "#standardSQL"})
+	}
+
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].start < replacements[j].start })
+
+	result := make([]lsp.TextEdit, 0, len(replacements))
+	for _, r := range replacements {
+		start, ok := parsedFile.SrcPosition(r.start)
+		if !ok {
+			return nil, fmt.Errorf("failed to find the position of offset %d", r.start)
+		}
+		end, ok := parsedFile.SrcPosition(r.end)
+		if !ok {
+			return nil, fmt.Errorf("failed to find the position of offset %d", r.end)
+		}
+		result = append(result, lsp.TextEdit{Range: lsp.Range{Start: start, End: end}, NewText: r.newText})
+	}
+	return result, nil
+}
+
+// hasLegacySQL reports whether the source has the legacy SQL constructs which ConvertLegacySQL rewrites.
+func hasLegacySQL(src string) bool {
+	return legacyPrefixRegex.MatchString(src) || legacyFromItemRegex.MatchString(codeOnly(src))
+}
+
+// codeOnly replaces the literals and the comments with spaces, so that the regexes don't match in them.
+// The length is kept, so the offsets of the matches are the same as src.
+func codeOnly(src string) string {
+	mask := file.CodeMask(src)
+	b := []byte(src)
+	for i := range b {
+		if !mask[i] && b[i] != '\n' {
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
+
+// convertLegacyFromItem converts the legacy table reference into the GoogleSQL table path.
+// The condition is not empty when the item is TABLE_DATE_RANGE, which becomes the wildcard table filtered by _TABLE_SUFFIX.
+func convertLegacyFromItem(item string) (table string, condition string, ok bool) {
+	item = strings.TrimSpace(item)
+	if legacyTableRefRegex.MatchString(item) {
+		return legacyTableRefToPath(item), "", true
+	}
+
+	if !strings.HasPrefix(strings.ToUpper(item), "TABLE_DATE_RANGE(") || !strings.HasSuffix(item, ")") {
+		return "", "", false
+	}
+	args := splitTopLevel(item[len("TABLE_DATE_RANGE(") : len(item)-1])
+	if len(args) != 3 || !legacyTableRefRegex.MatchString(args[0]) {
+		return "", "", false
+	}
+	prefix := legacyTableRefToPath(args[0])
+	table = prefix[:len(prefix)-1] + "*`"
+	condition = fmt.Sprintf("_TABLE_SUFFIX BETWEEN FORMAT_TIMESTAMP('%%Y%%m%%d', %s) AND FORMAT_TIMESTAMP('%%Y%%m%%d', %s)", args[1], args[2])
+	return table, condition, true
+}
+
+// legacyTableRefToPath converts `[project:dataset.table]` into "`project.dataset.table`".
+func legacyTableRefToPath(ref string) string {
+	path := strings.TrimSuffix(strings.TrimPrefix(ref, "["), "]")
+	return "`" + strings.Replace(path, ":", ".", 1) + "`"
+}
+
+func selectFromLegacyItem(table, condition string) string {
+	if condition == "" {
+		return "SELECT * FROM " + table
+	}
+	return fmt.Sprintf("SELECT * FROM %s WHERE %s", table, condition)
+}
+
+// splitTopLevel splits s by the commas which are not in parentheses or quotes.
+func splitTopLevel(s string) []string {
+	result := make([]string, 0)
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			result = append(result, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(result, strings.TrimSpace(s[start:]))
+}
@@ -0,0 +1,75 @@
+package source_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_ConvertLegacySQL(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectEdits []lsp.TextEdit
+	}{
+		"square-bracket table references": {
+			file: "SELECT id FROM [project:dataset.table] JOIN [dataset.users] USING (id)",
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 15}, End: lsp.Position{Line: 0, Character: 38}},
+					NewText: "`project.dataset.table`",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 44}, End: lsp.Position{Line: 0, Character: 59}},
+					NewText: "`dataset.users`",
+				},
+			},
+		},
+		"comma as UNION ALL with TABLE_DATE_RANGE": {
+			file: "#legacySQL\nSELECT id FROM [dataset.a], TABLE_DATE_RANGE([dataset.events_], TIMESTAMP('2020-01-01'), TIMESTAMP('2020-01-31'))",
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 0}, End: lsp.Position{Line: 0, Character: 10}},
+					NewText: "#standardSQL",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 1, Character: 15}, End: lsp.Position{Line: 1, Character: 113}},
+					NewText: "(SELECT * FROM `dataset.a` UNION ALL SELECT * FROM `dataset.events_*` WHERE _TABLE_SUFFIX BETWEEN FORMAT_TIMESTAMP('%Y%m%d', TIMESTAMP('2020-01-01')) AND FORMAT_TIMESTAMP('%Y%m%d', TIMESTAMP('2020-01-31')))",
+				},
+			},
+		},
+		"standard SQL is not changed": {
+			file:        "SELECT arr[OFFSET(0)] FROM `project.dataset.table`, UNNEST([1, 2]) AS n",
+			expectEdits: []lsp.TextEdit{},
+		},
+		"string literals and comments are not changed": {
+			file:        "-- FROM [dataset.a], [dataset.b]\nSELECT 'FROM [dataset.c]' AS q /* JOIN [dataset.d] */ FROM `project.dataset.table`",
+			expectEdits: []lsp.TextEdit{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("not found")).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			p.UpdateFile("file1.sql", tt.file, 1)
+
+			got, err := p.ConvertLegacySQL("file1.sql")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectEdits, got); diff != "" {
+				t.Errorf("project.ConvertLegacySQL result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	rast "github.com/goccy/go-zetasql/resolved_ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// maxViewExpansionDepth limits the expansion of nested views.
+const maxViewExpansionDepth = 5
+
+type lineageNode struct {
+	Name     string
+	Children []lineageNode
+}
+
+func (n lineageNode) writeMarkdown(sb *strings.Builder, depth int) {
+	sb.WriteString(fmt.Sprintf("%s* %s\n", strings.Repeat("  ", depth), n.Name))
+	for _, c := range n.Children {
+		c.writeMarkdown(sb, depth+1)
+	}
+}
+
+// ColumnLineage reports the source tables/columns which the column under the cursor derives from.
+// When expandViews is true, the view query is analyzed inline and the lineage is traced into the view.
+func (p *Project) ColumnLineage(ctx context.Context, uri string, position lsp.Position, expandViews bool) ([]lsp.MarkedString, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+
+	termOffset := parsedFile.TermOffset(position)
+	output, ok := parsedFile.FindTargetAnalyzeOutput(termOffset)
+	if !ok {
+		return nil, fmt.Errorf("failed to analyze the statement")
+	}
+
+	column, ok := p.findTargetColumn(output.Statement(), parsedFile, termOffset)
+	if !ok {
+		return nil, fmt.Errorf("not found column under the cursor")
+	}
+
+	tracer := newLineageTracer(ctx, p, output.Statement(), expandViews, 0)
+	root := tracer.trace(column, make(map[int]bool))
+
+	sb := &strings.Builder{}
+	sb.WriteString(fmt.Sprintf("## Lineage of %s\n\n", column.Name()))
+	root.writeMarkdown(sb, 0)
+
+	return []lsp.MarkedString{
+		{
+			Language: "markdown",
+			Value:    sb.String(),
+		},
+	}, nil
+}
+
+func (p *Project) findTargetColumn(stmt rast.StatementNode, parsedFile file.ParsedFile, termOffset int) (*rast.Column, bool) {
+	var target *rast.Column
+	rast.Walk(stmt, func(n rast.Node) error {
+		ref, ok := n.(*rast.ColumnRefNode)
+		if !ok {
+			return nil
+		}
+		lRange := ref.ParseLocationRange()
+		if lRange == nil {
+			return nil
+		}
+		if lRange.Start().ByteOffset() <= termOffset && termOffset <= lRange.End().ByteOffset() {
+			target = ref.Column()
+		}
+		return nil
+	})
+	if target != nil {
+		return target, true
+	}
+
+	selectColumnNode, ok := file.SearchAstNode[*ast.SelectColumnNode](parsedFile.Node, termOffset)
+	if !ok {
+		return nil, false
+	}
+
+	if alias := selectColumnNode.Alias(); alias != nil {
+		scanNode, ok := getMostNarrowScanNode(termOffset, stmt)
+		if !ok {
+			return nil, false
+		}
+		for _, c := range scanNode.ColumnList() {
+			if c.Name() == alias.Identifier().Name() {
+				return c, true
+			}
+		}
+		return nil, false
+	}
+
+	for _, output := range parsedFile.RNode {
+		if output == nil || output.Statement() != stmt {
+			continue
+		}
+		column, err := p.getSelectColumnNodeToAnalyzedOutputCoumnNode(output, selectColumnNode, termOffset)
+		if err != nil {
+			return nil, false
+		}
+		return column, true
+	}
+	return nil, false
+}
+
+type lineageTracer struct {
+	ctx         context.Context
+	project     *Project
+	expandViews bool
+	depth       int
+
+	// deps maps the column id to the columns which the column is computed from.
+	deps map[int][]*rast.Column
+	// sources maps the column id to the table name which the column is read from.
+	sources map[int]string
+	// withRefs maps the column id to the name of WITH clause which the column refers.
+	withRefs map[int]string
+}
+
+func newLineageTracer(ctx context.Context, p *Project, stmt rast.Node, expandViews bool, depth int) *lineageTracer {
+	t := &lineageTracer{
+		ctx:         ctx,
+		project:     p,
+		expandViews: expandViews,
+		depth:       depth,
+		deps:        make(map[int][]*rast.Column),
+		sources:     make(map[int]string),
+		withRefs:    make(map[int]string),
+	}
+
+	withEntries := make(map[string]*rast.WithEntryNode)
+	withRefScans := make([]*rast.WithRefScanNode, 0)
+	rast.Walk(stmt, func(n rast.Node) error {
+		switch n := n.(type) {
+		case *rast.ComputedColumnNode:
+			t.deps[n.Column().ColumnID()] = referencedColumns(n.Expr())
+		case *rast.TableScanNode:
+			for _, c := range n.ColumnList() {
+				t.sources[c.ColumnID()] = n.Table().Name()
+			}
+		case *rast.ArrayScanNode:
+			t.deps[n.ElementColumn().ColumnID()] = referencedColumns(n.ArrayExpr())
+		case *rast.SetOperationScanNode:
+			columns := n.ColumnList()
+			for _, item := range n.InputItemList() {
+				for i, c := range item.OutputColumnList() {
+					if i >= len(columns) {
+						break
+					}
+					t.deps[columns[i].ColumnID()] = append(t.deps[columns[i].ColumnID()], c)
+				}
+			}
+		case *rast.WithEntryNode:
+			withEntries[n.WithQueryName()] = n
+		case *rast.WithRefScanNode:
+			withRefScans = append(withRefScans, n)
+		}
+		return nil
+	})
+
+	// WITH clause reference columns correspond to the output columns of the subquery in order.
+	for _, ref := range withRefScans {
+		entry, ok := withEntries[ref.WithQueryName()]
+		if !ok {
+			continue
+		}
+		subqueryColumns := entry.WithSubquery().ColumnList()
+		for i, c := range ref.ColumnList() {
+			if i >= len(subqueryColumns) {
+				break
+			}
+			t.deps[c.ColumnID()] = []*rast.Column{subqueryColumns[i]}
+			t.withRefs[c.ColumnID()] = ref.WithQueryName()
+		}
+	}
+
+	return t
+}
+
+func referencedColumns(node rast.Node) []*rast.Column {
+	result := make([]*rast.Column, 0)
+	rast.Walk(node, func(n rast.Node) error {
+		if ref, ok := n.(*rast.ColumnRefNode); ok {
+			result = append(result, ref.Column())
+		}
+		return nil
+	})
+	return result
+}
+
+func (t *lineageTracer) trace(column *rast.Column, visited map[int]bool) lineageNode {
+	id := column.ColumnID()
+	if table, ok := t.sources[id]; ok {
+		node := lineageNode{Name: fmt.Sprintf("%s.%s", table, column.Name())}
+		if t.expandViews {
+			node.Children = t.traceView(table, column.Name())
+		}
+		return node
+	}
+
+	node := lineageNode{Name: column.Name()}
+	if name, ok := t.withRefs[id]; ok {
+		node.Name = fmt.Sprintf("%s (%s)", column.Name(), name)
+	}
+
+	if visited[id] {
+		return node
+	}
+	visited[id] = true
+
+	for _, dep := range t.deps[id] {
+		node.Children = append(node.Children, t.trace(dep, visited))
+	}
+	return node
+}
+
+func (t *lineageTracer) traceView(table, columnName string) []lineageNode {
+	if t.depth >= maxViewExpansionDepth {
+		return nil
+	}
+
+	metadata, err := t.project.analyzer.GetTableMetadataFromPath(t.ctx, table)
+	if err != nil {
+		return nil
+	}
+	query, ok := viewQuery(metadata)
+	if !ok {
+		return nil
+	}
+
+	parsedFile := t.project.analyzer.ParseFile(t.ctx, table, query)
+	if len(parsedFile.RNode) == 0 || parsedFile.RNode[len(parsedFile.RNode)-1] == nil {
+		t.project.logger.Debugf("failed to analyze view %s", table)
+		return nil
+	}
+	stmt, ok := parsedFile.RNode[len(parsedFile.RNode)-1].Statement().(*rast.QueryStmtNode)
+	if !ok {
+		return nil
+	}
+
+	for _, c := range stmt.OutputColumnList() {
+		if c.Name() != columnName {
+			continue
+		}
+		tracer := newLineageTracer(t.ctx, t.project, stmt, t.expandViews, t.depth+1)
+		return tracer.trace(c.Column(), make(map[int]bool)).Children
+	}
+	return nil
+}
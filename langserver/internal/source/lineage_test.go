@@ -0,0 +1,103 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_ColumnLineage(t *testing.T) {
+	tests := map[string]struct {
+		files           map[string]string
+		bqTableMetadata *bq.TableMetadata
+
+		expectMarkedStrings []lsp.MarkedString
+	}{
+		"column through WITH clause": {
+			files: map[string]string{
+				"file1.sql": "WITH data AS (SELECT id + 1 AS next_id FROM `project.dataset.table`)\nSELECT next_|id FROM data",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value: "## Lineage of next_id\n\n" +
+						"* next_id (data)\n" +
+						"  * next_id\n" +
+						"    * project.dataset.table.id\n",
+				},
+			},
+		},
+		"column with alias": {
+			files: map[string]string{
+				"file1.sql": "SELECT id + age AS to|tal FROM `project.dataset.table`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "age",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value: "## Lineage of total\n\n" +
+						"* total\n" +
+						"  * project.dataset.table.id\n" +
+						"  * project.dataset.table.age\n",
+				},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.bqTableMetadata, nil).MinTimes(0)
+			logger := logrus.New()
+			logger.SetLevel(logrus.DebugLevel)
+			p := source.NewProjectWithBQClient("/", bqClient, logger)
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatalf("failed to get position: %v", err)
+			}
+
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.ColumnLineage(context.Background(), path, position, false)
+			if err != nil {
+				t.Fatalf("failed to ColumnLineage: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expectMarkedStrings, got, cmpopts.IgnoreUnexported(lsp.MarkedString{})); diff != "" {
+				t.Errorf("project.ColumnLineage result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// DocumentLinks returns the links from the fully-qualified table paths like `project.dataset.table` to the BigQuery console.
+func (p *Project) DocumentLinks(uri string) ([]lsp.DocumentLink, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+	if parsedFile.Node == nil {
+		return nil, nil
+	}
+
+	result := make([]lsp.DocumentLink, 0)
+	for _, node := range file.ListAstNode[*ast.TablePathExpressionNode](parsedFile.Node) {
+		name, ok := file.CreateTableNameFromTablePathExpressionNode(node)
+		if !ok {
+			continue
+		}
+
+		paths := strings.Split(name, ".")
+		if len(paths) != 3 || strings.Contains(paths[2], "*") || strings.EqualFold(paths[1], "INFORMATION_SCHEMA") {
+			continue
+		}
+
+		rng, ok := parsedFile.PositionRange(node.ParseLocationRange())
+		if !ok {
+			continue
+		}
+
+		tooltip := fmt.Sprintf("Open %s in the BigQuery console", name)
+		if p.location != "" {
+			tooltip += fmt.Sprintf(" (%s)", p.location)
+		}
+		result = append(result, lsp.DocumentLink{
+			Range:   rng,
+			Target:  tableConsoleURL(paths[0], paths[1], paths[2]),
+			Tooltip: tooltip,
+		})
+	}
+
+	return result, nil
+}
+
+func tableConsoleURL(projectID, datasetID, tableID string) string {
+	return fmt.Sprintf("https://console.cloud.google.com/bigquery?project=%[1]s&ws=!1m5!1m4!4m3!1s%[1]s!2s%[2]s!3s%[3]s", projectID, datasetID, tableID)
+}
@@ -0,0 +1,69 @@
+package source_test
+
+import (
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_DocumentLinks(t *testing.T) {
+	tests := map[string]struct {
+		file string
+
+		expectLinks []lsp.DocumentLink
+	}{
+		"fully-qualified table path": {
+			file: "SELECT * FROM `project.dataset.table`",
+			expectLinks: []lsp.DocumentLink{
+				{
+					Range: lsp.Range{
+						Start: lsp.Position{Line: 0, Character: 14},
+						End:   lsp.Position{Line: 0, Character: 37},
+					},
+					Target:  "https://console.cloud.google.com/bigquery?project=project&ws=!1m5!1m4!4m3!1sproject!2sdataset!3stable",
+					Tooltip: "Open project.dataset.table in the BigQuery console",
+				},
+			},
+		},
+		"table path without project": {
+			file:        "SELECT * FROM dataset.table",
+			expectLinks: []lsp.DocumentLink{},
+		},
+		"wildcard table": {
+			file:        "SELECT * FROM `project.dataset.table_*`",
+			expectLinks: []lsp.DocumentLink{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "id", Type: bq.IntegerFieldType},
+				},
+			}, nil).MinTimes(0)
+			logger := logrus.New()
+			p := source.NewProjectWithBQClient("/", bqClient, logger)
+
+			uri := "file1.sql"
+			p.UpdateFile(uri, tt.file, 1)
+
+			got, err := p.DocumentLinks(uri)
+			if err != nil {
+				t.Fatalf("failed to DocumentLinks: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expectLinks, got); diff != "" {
+				t.Errorf("DocumentLinks result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
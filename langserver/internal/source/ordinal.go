@@ -0,0 +1,178 @@
+package source
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// ReplaceOrdinals returns the edits which replace the ordinals of GROUP BY and ORDER BY like `GROUP BY 1, 2` in the query at position
+// with the expressions of the SELECT list which they refer to.
+// When toOrdinals is true, the expressions and the aliases of the SELECT list are replaced with their ordinals instead.
+func (p *Project) ReplaceOrdinals(uri string, position lsp.Position, toOrdinals bool) ([]lsp.TextEdit, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+	if parsedFile.Node == nil {
+		return nil, fmt.Errorf("failed to parse document %s", uri)
+	}
+
+	items, err := ordinalsAt(parsedFile, position)
+	if err != nil {
+		return nil, err
+	}
+
+	edits := make([]lsp.TextEdit, 0)
+	for _, o := range items {
+		if o.isOrdinal == toOrdinals {
+			continue
+		}
+		rng, ok := parsedFile.PositionRange(o.expr.ParseLocationRange())
+		if !ok {
+			continue
+		}
+		if toOrdinals {
+			edits = append(edits, lsp.TextEdit{Range: rng, NewText: strconv.Itoa(o.index + 1)})
+			continue
+		}
+		text, ok := parsedFile.ExtractSQL(o.column.Expression().ParseLocationRange())
+		if !ok {
+			continue
+		}
+		edits = append(edits, lsp.TextEdit{Range: rng, NewText: text})
+	}
+	if len(edits) == 0 {
+		if toOrdinals {
+			return nil, fmt.Errorf("GROUP BY and ORDER BY have no columns of the SELECT list")
+		}
+		return nil, fmt.Errorf("GROUP BY and ORDER BY have no ordinals")
+	}
+	return edits, nil
+}
+
+// ordinalsAt lists the ordinals of the query at position.
+func ordinalsAt(parsedFile file.ParsedFile, position lsp.Position) ([]ordinal, error) {
+	query, ok := file.SearchAstNode[*ast.QueryNode](parsedFile.Node, parsedFile.TermOffset(position))
+	if !ok {
+		return nil, fmt.Errorf("query is not found at the position")
+	}
+	selectNode, ok := query.QueryExpr().(*ast.SelectNode)
+	if !ok {
+		return nil, fmt.Errorf("SELECT is not found at the position")
+	}
+	return ordinals(parsedFile, query, selectNode), nil
+}
+
+// termDocumentForOrdinal shows the column of the SELECT list when the term is the ordinal of GROUP BY or ORDER BY.
+func (p *Project) termDocumentForOrdinal(termOffset int, parsedFile file.ParsedFile) ([]lsp.MarkedString, bool) {
+	literal, ok := file.SearchAstNode[*ast.IntLiteralNode](parsedFile.Node, termOffset)
+	if !ok {
+		return nil, false
+	}
+	query, ok := file.LookupNode[*ast.QueryNode](literal)
+	if !ok {
+		return nil, false
+	}
+	selectNode, ok := query.QueryExpr().(*ast.SelectNode)
+	if !ok {
+		return nil, false
+	}
+
+	for _, o := range ordinals(parsedFile, query, selectNode) {
+		if !o.isOrdinal || o.expr != literal {
+			continue
+		}
+		text, ok := parsedFile.ExtractSQL(o.column.ParseLocationRange())
+		if !ok {
+			return nil, false
+		}
+		return []lsp.MarkedString{
+			{
+				Language: "sql",
+				Value:    text,
+			},
+		}, true
+	}
+	return nil, false
+}
+
+// ordinal is the item of GROUP BY or ORDER BY which refers to the column of the SELECT list,
+// either by its position like `GROUP BY 1` or by its expression or alias.
+type ordinal struct {
+	expr      ast.ExpressionNode
+	column    *ast.SelectColumnNode
+	index     int
+	isOrdinal bool
+}
+
+// ordinals lists the items of GROUP BY of selectNode and ORDER BY of query which refer to the columns of the SELECT list.
+// The column of the star is skipped, because the star has no expression to refer to.
+func ordinals(parsedFile file.ParsedFile, query *ast.QueryNode, selectNode *ast.SelectNode) []ordinal {
+	exprs := make([]ast.ExpressionNode, 0)
+	if groupBy := selectNode.GroupBy(); groupBy != nil {
+		for _, item := range groupBy.GroupingItems() {
+			// ROLLUP has no expression
+			if item.Expression() != nil {
+				exprs = append(exprs, item.Expression())
+			}
+		}
+	}
+	if orderBy := query.OrderBy(); orderBy != nil {
+		for _, item := range orderBy.OrderingExpressions() {
+			exprs = append(exprs, item.Expression())
+		}
+	}
+
+	columns := selectNode.SelectList().Columns()
+	result := make([]ordinal, 0)
+	for _, expr := range exprs {
+		text, ok := parsedFile.ExtractSQL(expr.ParseLocationRange())
+		if !ok {
+			continue
+		}
+		index, isOrdinal, ok := referredColumn(parsedFile, columns, expr, text)
+		if !ok {
+			continue
+		}
+		column := columns[index]
+		switch column.Expression().(type) {
+		case *ast.StarNode, *ast.DotStarNode, *ast.StarWithModifiersNode, *ast.DotStarWithModifiersNode:
+			continue
+		}
+		result = append(result, ordinal{expr: expr, column: column, index: index, isOrdinal: isOrdinal})
+	}
+	return result
+}
+
+// referredColumn returns the index of the column of the SELECT list which expr refers to,
+// and whether expr is the ordinal.
+func referredColumn(parsedFile file.ParsedFile, columns []*ast.SelectColumnNode, expr ast.ExpressionNode, text string) (int, bool, bool) {
+	if _, ok := expr.(*ast.IntLiteralNode); ok {
+		i, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil || i <= 0 || len(columns) < i {
+			return 0, false, false
+		}
+		return i - 1, true, true
+	}
+
+	for i, c := range columns {
+		if alias := c.Alias(); alias != nil && strings.EqualFold(alias.Identifier().Name(), strings.TrimSpace(text)) {
+			return i, false, true
+		}
+		if columnText, ok := parsedFile.ExtractSQL(c.Expression().ParseLocationRange()); ok && sameExpression(columnText, text) {
+			return i, false, true
+		}
+	}
+	return 0, false, false
+}
+
+// sameExpression compares the expressions by their texts ignoring the spaces and the case differences.
+func sameExpression(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), ""), strings.Join(strings.Fields(b), ""))
+}
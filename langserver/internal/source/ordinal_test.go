@@ -0,0 +1,153 @@
+package source_test
+
+import (
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_ReplaceOrdinals(t *testing.T) {
+	tests := map[string]struct {
+		files      map[string]string
+		toOrdinals bool
+
+		expectEdits []lsp.TextEdit
+		expectErr   bool
+	}{
+		"replace ordinals": {
+			files: map[string]string{
+				"file1.sql": "SELECT id, UPPER(name) AS upper_name, COUNT(*) AS cnt FROM `project.dataset.table` GROUP BY |1, 2",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 92}, End: lsp.Position{Line: 0, Character: 93}},
+					NewText: "id",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 95}, End: lsp.Position{Line: 0, Character: 96}},
+					NewText: "UPPER(name)",
+				},
+			},
+		},
+		"replace ordinals of ORDER BY": {
+			files: map[string]string{
+				"file1.sql": "SELECT id, COUNT(*) AS cnt FROM `project.dataset.table` GROUP BY id ORDER BY |2 DESC",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 77}, End: lsp.Position{Line: 0, Character: 78}},
+					NewText: "COUNT(*)",
+				},
+			},
+		},
+		"replace expressions and aliases with ordinals": {
+			files: map[string]string{
+				"file1.sql": "SELECT id, UPPER(name) AS upper_name, COUNT(*) AS cnt FROM `project.dataset.table` GROUP BY |id, upper_name ORDER BY cnt DESC, UPPER( name )",
+			},
+			toOrdinals: true,
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 92}, End: lsp.Position{Line: 0, Character: 94}},
+					NewText: "1",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 96}, End: lsp.Position{Line: 0, Character: 106}},
+					NewText: "2",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 116}, End: lsp.Position{Line: 0, Character: 119}},
+					NewText: "3",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 126}, End: lsp.Position{Line: 0, Character: 139}},
+					NewText: "2",
+				},
+			},
+		},
+		"GROUP BY without ordinals": {
+			files: map[string]string{
+				"file1.sql": "SELECT id, COUNT(*) AS cnt FROM `project.dataset.table` GROUP BY |id",
+			},
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "id", Type: bq.IntegerFieldType},
+					{Name: "name", Type: bq.StringFieldType},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.ReplaceOrdinals(path, position, tt.toOrdinals)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("ReplaceOrdinals should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectEdits, got); diff != "" {
+				t.Errorf("project.ReplaceOrdinals result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProject_TermDocumentForOrdinal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+		Schema: bq.Schema{
+			{Name: "id", Type: bq.IntegerFieldType},
+			{Name: "name", Type: bq.StringFieldType},
+		},
+	}, nil).MinTimes(0)
+	p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+	files, path, position, err := helper.GetLspPosition(map[string]string{
+		"file1.sql": "SELECT id, UPPER(name) AS upper_name, COUNT(*) AS cnt FROM `project.dataset.table` GROUP BY 1, |2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for uri, content := range files {
+		p.UpdateFile(uri, content, 1)
+	}
+
+	got, err := p.TermDocument(path, position)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []lsp.MarkedString{
+		{
+			Language: "sql",
+			Value:    "UPPER(name) AS upper_name",
+		},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("project.TermDocument result diff (-expect, +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,105 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/goccy/go-zetasql"
+	"github.com/goccy/go-zetasql/ast"
+	rast "github.com/goccy/go-zetasql/resolved_ast"
+	"github.com/goccy/go-zetasql/types"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// outputColumnsStatement is the statement which outputs the columns like SELECT or CREATE TABLE AS SELECT.
+type outputColumnsStatement interface {
+	OutputColumnList() []*rast.OutputColumnNode
+}
+
+// OutputSchema returns the output columns of the statement under the cursor as YAML.
+func (p *Project) OutputSchema(uri string, position lsp.Position) ([]lsp.MarkedString, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+
+	output, ok := parsedFile.FindTargetAnalyzeOutput(parsedFile.TermOffset(position))
+	if !ok {
+		return nil, fmt.Errorf("failed to analyze the statement")
+	}
+	result, ok := outputSchemaMarkedString(output)
+	if !ok {
+		return nil, fmt.Errorf("the statement doesn't output columns")
+	}
+	return result, nil
+}
+
+// termDocumentForSelectKeyword shows the output columns of the statement when the term is the SELECT keyword of the outermost query.
+func (p *Project) termDocumentForSelectKeyword(termOffset int, parsedFile file.ParsedFile) ([]lsp.MarkedString, bool) {
+	selectNode, ok := file.SearchAstNode[*ast.SelectNode](parsedFile.Node, termOffset)
+	if !ok {
+		return nil, false
+	}
+	lRange := selectNode.ParseLocationRange()
+	if lRange == nil {
+		return nil, false
+	}
+	start := lRange.Start().ByteOffset()
+	if termOffset < start || start+len("SELECT") < termOffset || !strings.EqualFold(parsedFile.Src[start:min(start+len("SELECT"), len(parsedFile.Src))], "SELECT") {
+		return nil, false
+	}
+	for n := selectNode.Parent(); n != nil; n = n.Parent() {
+		switch n.(type) {
+		case *ast.TableSubqueryNode, *ast.ExpressionSubqueryNode, *ast.WithClauseEntryNode:
+			// the subquery doesn't output the columns of the statement
+			return nil, false
+		}
+	}
+
+	output, ok := parsedFile.FindTargetAnalyzeOutput(termOffset)
+	if !ok {
+		p.logger.Debug("not found target analyze output")
+		return nil, false
+	}
+	return outputSchemaMarkedString(output)
+}
+
+func outputSchemaMarkedString(output *zetasql.AnalyzerOutput) ([]lsp.MarkedString, bool) {
+	stmt, ok := output.Statement().(outputColumnsStatement)
+	if !ok {
+		return nil, false
+	}
+
+	schema := make(bigquery.Schema, 0, len(stmt.OutputColumnList()))
+	for _, c := range stmt.OutputColumnList() {
+		schema = append(schema, outputFieldSchema(c.Name(), c.Column().Type()))
+	}
+	return []lsp.MarkedString{
+		{
+			Language: "yaml",
+			Value:    createBigQuerySchemaYamlString(schema, 0),
+		},
+	}, true
+}
+
+// outputFieldSchema converts the type into the field schema so that the nested fields are rendered like the table schema.
+func outputFieldSchema(name string, typ types.Type) *bigquery.FieldSchema {
+	field := &bigquery.FieldSchema{Name: name}
+	if typ.IsArray() {
+		field.Repeated = true
+		typ = typ.AsArray().ElementType()
+	}
+	if !typ.IsStruct() {
+		field.Type = bigquery.FieldType(typ.TypeName(types.ProductExternal))
+		return field
+	}
+
+	field.Type = bigquery.RecordFieldType
+	for _, f := range typ.AsStruct().Fields() {
+		field.Schema = append(field.Schema, outputFieldSchema(f.Name(), f.Type()))
+	}
+	return field
+}
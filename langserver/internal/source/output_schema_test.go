@@ -0,0 +1,112 @@
+package source_test
+
+import (
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_OutputSchema(t *testing.T) {
+	tests := map[string]struct {
+		files           map[string]string
+		bqTableMetadata *bq.TableMetadata
+
+		expectMarkedStrings []lsp.MarkedString
+	}{
+		"nested columns": {
+			files: map[string]string{
+				"file1.sql": "SELECT id, |user, [name] AS names FROM `project.dataset.table`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "name",
+						Type: bq.StringFieldType,
+					},
+					{
+						Name: "user",
+						Type: bq.RecordFieldType,
+						Schema: bq.Schema{
+							{
+								Name: "email",
+								Type: bq.StringFieldType,
+							},
+						},
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value: `- name: id
+  type: INT64
+- name: user
+  type: RECORD
+  - name: email
+    type: STRING
+- name: names
+  type: STRING
+  mode: REPEATED
+`,
+				},
+			},
+		},
+		"CREATE TABLE AS SELECT": {
+			files: map[string]string{
+				"file1.sql": "CREATE TABLE `project.dataset.new_table` AS\nSELECT id * 2 AS |double_id FROM `project.dataset.table`",
+			},
+			bqTableMetadata: &bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "yaml",
+					Value: `- name: double_id
+  type: INT64
+`,
+				},
+			},
+		},
+	}
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.bqTableMetadata, nil).MinTimes(0)
+			logger := logrus.New()
+			logger.SetLevel(logrus.DebugLevel)
+			p := source.NewProjectWithBQClient("/", bqClient, logger)
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatalf("failed to get position: %v", err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+			got, err := p.OutputSchema(path, position)
+			if err != nil {
+				t.Fatalf("failed to OutputSchema: %v", err)
+			}
+			if diff := cmp.Diff(tt.expectMarkedStrings, got, cmpopts.IgnoreUnexported(lsp.MarkedString{})); diff != "" {
+				t.Errorf("project.OutputSchema result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package source
+
+import (
+	"context"
+	"sync"
+
+	"github.com/kitagry/bqls/langserver/internal/cache"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+const maxPrefetchConcurrency = 4
+
+// prefetcher fetches the table metadata in the background,
+// so that the first hover or completion doesn't wait for the BigQuery API.
+// The fetched metadata is stored in the cache of the BigQuery client.
+type prefetcher struct {
+	analyzer *file.Analyzer
+	logger   *logrus.Logger
+
+	// sem limits the number of concurrent requests.
+	sem chan struct{}
+
+	mu sync.Mutex
+	// requested has the table names which are being prefetched.
+	requested map[string]struct{}
+	// fetched has the table names which have been prefetched. It has the same size as the metadata cache,
+	// so that the table evicted from the cache is prefetched again.
+	fetched *cache.LRU[string, struct{}]
+}
+
+func newPrefetcher(analyzer *file.Analyzer, logger *logrus.Logger, cacheSize int) *prefetcher {
+	return &prefetcher{
+		analyzer:  analyzer,
+		logger:    logger,
+		sem:       make(chan struct{}, maxPrefetchConcurrency),
+		requested: make(map[string]struct{}),
+		fetched:   cache.NewLRU[string, struct{}](cacheSize),
+	}
+}
+
+func (p *prefetcher) Prefetch(tableNames []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, name := range tableNames {
+		if _, ok := p.requested[name]; ok {
+			continue
+		}
+		if _, ok := p.fetched.Get(name); ok {
+			continue
+		}
+		p.requested[name] = struct{}{}
+
+		go func() {
+			p.sem <- struct{}{}
+			defer func() { <-p.sem }()
+
+			_, err := p.analyzer.GetTableMetadataFromPath(context.Background(), name)
+
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			delete(p.requested, name)
+			if err != nil {
+				// The table is prefetched again by the next request, e.g. after the table is created.
+				p.logger.Debugf("failed to prefetch table metadata(%s): %v", name, err)
+				return
+			}
+			p.fetched.Put(name, struct{}{})
+		}()
+	}
+}
@@ -0,0 +1,94 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"google.golang.org/api/iterator"
+)
+
+// DefaultPreviewRows is the number of rows of bqls.previewTable when it is not specified.
+const DefaultPreviewRows = 10
+
+// PreviewTable returns the first rows of the table like `dataset.table` as a Markdown table.
+func (p *Project) PreviewTable(ctx context.Context, name string, rows int) ([]lsp.MarkedString, error) {
+	if rows <= 0 {
+		rows = DefaultPreviewRows
+	}
+
+	metadata, err := p.analyzer.GetTableMetadataFromPath(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table metadata: %w", err)
+	}
+
+	preview, err := p.previewTableMarkdown(ctx, metadata, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return []lsp.MarkedString{
+		{
+			Language: "markdown",
+			Value:    fmt.Sprintf("## %s\n\n%s", name, preview),
+		},
+	}, nil
+}
+
+// previewTableMarkdown reads the rows with tabledata.list, which is free of charge.
+// Views and external tables are not previewed because tabledata.list doesn't support them.
+func (p *Project) previewTableMarkdown(ctx context.Context, metadata *bq.TableMetadata, rows int) (string, error) {
+	if metadata.Type != bq.RegularTable {
+		return "", fmt.Errorf("%s table can't be previewed", metadata.Type)
+	}
+
+	projectID, datasetID, tableID, ok := extractTableIDsFromMedatada(metadata)
+	if !ok {
+		return "", fmt.Errorf("failed to extract table id from %s", metadata.FullID)
+	}
+
+	it, err := p.bqClient.GetTableRecord(ctx, projectID, datasetID, tableID)
+	if err != nil {
+		return "", err
+	}
+	it.Schema = metadata.Schema
+	it.PageInfo().MaxSize = rows
+
+	var sb strings.Builder
+	sb.WriteString("|")
+	for _, field := range metadata.Schema {
+		sb.WriteString(fmt.Sprintf(" %s |", escapeMarkdownTableCell(field.Name)))
+	}
+	sb.WriteString("\n|")
+	for range metadata.Schema {
+		sb.WriteString(" --- |")
+	}
+	sb.WriteString("\n")
+
+	for i := 0; i < rows; i++ {
+		var values []bq.Value
+		err := it.Next(&values)
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString("|")
+		for _, v := range values {
+			sb.WriteString(fmt.Sprintf(" %s |", escapeMarkdownTableCell(fmt.Sprint(v))))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
+
+func escapeMarkdownTableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
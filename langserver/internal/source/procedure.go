@@ -0,0 +1,58 @@
+package source
+
+import (
+	"context"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// termDocumentForCall shows the signature of the procedure when the term is the procedure name of CALL.
+// The procedure created in the script shows its CREATE PROCEDURE without the body, and the persistent procedure shows its routine.
+func (p *Project) termDocumentForCall(ctx context.Context, termOffset int, parsedFile file.ParsedFile) ([]lsp.MarkedString, bool) {
+	call, ok := file.SearchAstNode[*ast.CallStatementNode](parsedFile.Node, termOffset)
+	if !ok || call.ProcedureName() == nil {
+		return nil, false
+	}
+	loc := call.ProcedureName().ParseLocationRange()
+	if loc == nil || termOffset < loc.Start().ByteOffset() || loc.End().ByteOffset() < termOffset {
+		return nil, false
+	}
+	name := createNameFromPathExpressionNode(call.ProcedureName())
+
+	if decl, ok := parsedFile.FindProcedureDeclaration(name); ok {
+		if signature, ok := procedureSignature(parsedFile, decl); ok {
+			return []lsp.MarkedString{
+				{
+					Language: "sql",
+					Value:    signature,
+				},
+			}, true
+		}
+	}
+
+	routine, err := p.analyzer.GetRoutineMetadataFromPath(ctx, name)
+	if err != nil {
+		p.logger.Debugf("failed to get the procedure %s: %v", name, err)
+		return nil, false
+	}
+	return buildRoutineMarkedString(name, routine), true
+}
+
+// procedureSignature returns the text of CREATE PROCEDURE before its body.
+func procedureSignature(parsedFile file.ParsedFile, decl *ast.CreateProcedureStatementNode) (string, bool) {
+	if decl.Body() == nil {
+		return "", false
+	}
+	declRange, ok := parsedFile.PositionRange(decl.ParseLocationRange())
+	if !ok {
+		return "", false
+	}
+	bodyRange, ok := parsedFile.PositionRange(decl.Body().ParseLocationRange())
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(parsedFile.Src[parsedFile.SrcOffset(declRange.Start):parsedFile.SrcOffset(bodyRange.Start)]), true
+}
@@ -0,0 +1,91 @@
+package source_test
+
+import (
+	"errors"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_TermDocumentForCall(t *testing.T) {
+	tests := map[string]struct {
+		files   map[string]string
+		routine *bq.RoutineMetadata
+
+		expectMarkedStrings []lsp.MarkedString
+	}{
+		"procedure created in the script": {
+			files: map[string]string{
+				"file1.sql": "CREATE PROCEDURE dataset.proc(x INT64)\nBEGIN\n  SELECT x;\nEND;\nCALL dataset.p|roc(1);",
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "sql",
+					Value:    "CREATE PROCEDURE dataset.proc(x INT64)",
+				},
+			},
+		},
+		"persistent procedure": {
+			files: map[string]string{
+				"file1.sql": "DECLARE result STRING;\nCALL dataset.p|roc(1, result);",
+			},
+			routine: &bq.RoutineMetadata{
+				Type: "PROCEDURE",
+				Arguments: []*bq.RoutineArgument{
+					{
+						Name:     "x",
+						DataType: &bq.StandardSQLDataType{TypeKind: "INT64"},
+					},
+					{
+						Name:     "result",
+						Mode:     "OUT",
+						DataType: &bq.StandardSQLDataType{TypeKind: "STRING"},
+					},
+				},
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "markdown",
+					Value:    "## dataset.proc\n\n`dataset.proc(x INT64, OUT result STRING)`\n",
+				},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetDefaultProject().Return("project").MinTimes(0)
+			if tt.routine != nil {
+				bqClient.EXPECT().GetRoutineMetadata(gomock.Any(), "project", "dataset", "proc").Return(tt.routine, nil).MinTimes(0)
+			} else {
+				bqClient.EXPECT().GetRoutineMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("not found")).MinTimes(0)
+			}
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.TermDocument(path, position)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectMarkedStrings, got); diff != "" {
+				t.Errorf("project.TermDocument result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -5,11 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	bq "cloud.google.com/go/bigquery"
 	"github.com/kitagry/bqls/langserver/internal/bigquery"
 	"github.com/kitagry/bqls/langserver/internal/cache"
+	"github.com/kitagry/bqls/langserver/internal/history"
 	"github.com/kitagry/bqls/langserver/internal/lsp"
 	"github.com/kitagry/bqls/langserver/internal/source/file"
 	"github.com/sirupsen/logrus"
@@ -17,12 +22,70 @@ import (
 )
 
 type Project struct {
+	// BigQueryProjectID is the default project of the tables.
 	BigQueryProjectID string
-	rootPath          string
-	logger            *logrus.Logger
-	cache             *cache.GlobalCache
-	bqClient          bigquery.Client
-	analyzer          *file.Analyzer
+	// BillingProjectID is the project which runs query jobs.
+	BillingProjectID string
+	// LintSelectStar reports `SELECT *` which is not used with EXCEPT or REPLACE as a warning.
+	LintSelectStar bool
+	// LintNonDeterministicLimit reports ORDER BY with LIMIT whose keys may have ties as a warning.
+	LintNonDeterministicLimit bool
+	// LintOrdinals reports the ordinals of GROUP BY and ORDER BY as warnings.
+	LintOrdinals bool
+	// diagnosticOption is replaced by SetDiagnosticOption while the diagnostics are computed by the other goroutines.
+	diagnosticOption     DiagnosticOption
+	diagnosticOptionLock sync.RWMutex
+	// SampleOption samples the exploratory queries executed by Run.
+	SampleOption SampleOption
+	// location is the location of the query jobs. It is empty when BigQuery infers it.
+	location string
+	rootPath string
+	logger   *logrus.Logger
+	cache    *cache.GlobalCache
+	bqClient bigquery.Client
+	analyzer *file.Analyzer
+
+	// resultPageSize is the number of rows in a page of the query result.
+	resultPageSize int
+
+	// hoverPreviewRows is the number of rows shown in the hover of tables. When it is 0, the rows are not shown.
+	hoverPreviewRows int
+
+	// maxBytesProcessed is the limit of the estimated bytes processed of the executed query. When it is 0, there is no limit.
+	maxBytesProcessed int64
+
+	// jobs are the running query jobs keyed by their IDs, which are cancelled on shutdown.
+	jobs        map[string]bigquery.BigqueryJob
+	jobsLock    sync.Mutex
+	jobWatchers sync.WaitGroup
+
+	// history is nil when the query history is not recorded.
+	history *history.Store
+
+	// prefetcher is nil when the table metadata is not prefetched.
+	prefetcher *prefetcher
+
+	// parsedFiles holds the last analysis of each document,
+	// so that hover, completion and diagnostics don't analyze the same version again.
+	parsedFiles   *cache.LRU[string, *parsedFileEntry]
+	parsedFilesMu sync.Mutex
+
+	// metadataErrorsCache holds the errors of the last version of each document which are checked with the table metadata.
+	metadataErrorsCache *cache.LRU[string, metadataErrorsEntry]
+
+	// documentLocks serializes the updates of each document.
+	documentLocks *cache.KeyLocks
+}
+
+// parsedFileEntry is the analysis of a version of the document.
+type parsedFileEntry struct {
+	version int
+	hash    string
+
+	// once makes the requests for the same version wait for the analysis in progress
+	// instead of running it again.
+	once       sync.Once
+	parsedFile file.ParsedFile
 }
 
 type File struct {
@@ -30,9 +93,70 @@ type File struct {
 	Version int
 }
 
-func NewProject(ctx context.Context, rootPath string, projectID string, logger *logrus.Logger) (*Project, error) {
-	cache := cache.NewGlobalCache()
+// Config is the configuration of the Project.
+type Config struct {
+	RootPath string
+
+	// ProjectID is the default project of the tables.
+	// When it is empty, the project of `gcloud config get project` is used.
+	ProjectID string
+
+	// BillingProjectID is the project which runs query jobs.
+	// When it is empty, ProjectID is used.
+	BillingProjectID string
+
+	// Location is the location of the query jobs.
+	Location string
+
+	ConnectionOption bigquery.ConnectionOption
+
+	// QueryOption configures the query jobs like maximum bytes billed, labels and priority.
+	QueryOption bigquery.QueryOption
+
+	// MaxDocuments is the max number of the cached closed documents and analyses.
+	MaxDocuments int
+
+	// MaxTableMetadata is the max number of the cached table metadata.
+	MaxTableMetadata int
+
+	// HoverPreviewRows is the number of rows shown in the hover of tables.
+	HoverPreviewRows int
+
+	// ResultPageSize is the number of rows in a page of the query result.
+	// When it is not positive, DefaultResultPageSize is used.
+	ResultPageSize int
+
+	// MaxBytesProcessed is the limit of the estimated bytes processed of the executed query.
+	// When it is not positive, there is no limit.
+	MaxBytesProcessed int64
+
+	// SampleOption samples the exploratory queries executed from the editor.
+	SampleOption SampleOption
+
+	// DisableQueryHistory disables recording the executed and dry-run queries.
+	DisableQueryHistory bool
+
+	// LintSelectStar reports `SELECT *` which is not used with EXCEPT or REPLACE as a warning.
+	LintSelectStar bool
+
+	// LintNonDeterministicLimit reports ORDER BY with LIMIT whose keys may have ties as a warning.
+	LintNonDeterministicLimit bool
+
+	// LintOrdinals reports the ordinals of GROUP BY and ORDER BY as warnings.
+	LintOrdinals bool
+}
+
+// DefaultResultPageSize is the default number of rows in a page of the query result.
+const DefaultResultPageSize = 100
 
+func NewProject(ctx context.Context, config Config, logger *logrus.Logger) (*Project, error) {
+	maxDocuments := config.MaxDocuments
+	if maxDocuments <= 0 {
+		maxDocuments = cache.DefaultMaxDocuments
+	}
+	globalCache := cache.NewGlobalCache(maxDocuments)
+
+	projectID := config.ProjectID
 	if projectID == "" {
 		out, err := exec.CommandContext(ctx, "gcloud", "config", "get", "project").Output()
 		if err != nil {
@@ -46,41 +170,150 @@ func NewProject(ctx context.Context, rootPath string, projectID string, logger *
 		logger.Infof("You don't set Bigquery projectID. And fallback to run `gcloud config get project`. set projectID: %s", projectID)
 	}
 
-	bqClient, err := bigquery.New(ctx, projectID, true)
+	billingProjectID := config.BillingProjectID
+	if billingProjectID == "" {
+		billingProjectID = projectID
+	}
+
+	resultPageSize := config.ResultPageSize
+	if resultPageSize <= 0 {
+		resultPageSize = DefaultResultPageSize
+	}
+
+	cacheSize := config.MaxTableMetadata
+	if cacheSize <= 0 {
+		cacheSize = bigquery.DefaultCacheSize
+	}
+
+	bqClient, err := bigquery.New(ctx, projectID, billingProjectID, config.Location, cacheSize, config.ConnectionOption, config.QueryOption, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bigquery client: %w", err)
 	}
 
 	analyzer := file.NewAnalyzer(logger, bqClient)
 
+	var historyStore *history.Store
+	if !config.DisableQueryHistory {
+		historyStore, err = openHistory()
+		if err != nil {
+			// The query history is optional, so the project works without it.
+			logger.Warnf("failed to open query history: %v", err)
+		}
+	}
+
 	return &Project{
-		BigQueryProjectID: projectID,
-		rootPath:          rootPath,
-		logger:            logger,
-		cache:             cache,
-		bqClient:          bqClient,
-		analyzer:          analyzer,
+		BigQueryProjectID:         projectID,
+		BillingProjectID:          billingProjectID,
+		LintSelectStar:            config.LintSelectStar,
+		LintNonDeterministicLimit: config.LintNonDeterministicLimit,
+		LintOrdinals:              config.LintOrdinals,
+		SampleOption:              config.SampleOption,
+		location:                  config.Location,
+		resultPageSize:            resultPageSize,
+		hoverPreviewRows:          config.HoverPreviewRows,
+		rootPath:                  config.RootPath,
+		logger:                    logger,
+		cache:                     globalCache,
+		bqClient:                  bqClient,
+		analyzer:                  analyzer,
+		maxBytesProcessed:         config.MaxBytesProcessed,
+		history:                   historyStore,
+		prefetcher:                newPrefetcher(analyzer, logger, cacheSize),
+		parsedFiles:               cache.NewLRU[string, *parsedFileEntry](maxDocuments),
+		metadataErrorsCache:       cache.NewLRU[string, metadataErrorsEntry](maxDocuments),
+		documentLocks:             cache.NewKeyLocks(),
+		jobs:                      make(map[string]bigquery.BigqueryJob),
 	}, nil
 }
 
+// NewOfflineProject creates the project which loads table schemas from schemaDir instead of the BigQuery API.
+func NewOfflineProject(rootPath, schemaDir, projectID string, logger *logrus.Logger) *Project {
+	p := NewProjectWithBQClient(rootPath, bigquery.NewOffline(schemaDir, projectID), logger)
+	p.BigQueryProjectID = projectID
+	p.BillingProjectID = projectID
+	return p
+}
+
 func NewProjectWithBQClient(rootPath string, bqClient bigquery.Client, logger *logrus.Logger) *Project {
-	cache := cache.NewGlobalCache()
 	analyzer := file.NewAnalyzer(logger, bqClient)
 	return &Project{
-		rootPath: rootPath,
-		logger:   logger,
-		cache:    cache,
-		bqClient: bqClient,
-		analyzer: analyzer,
+		rootPath:            rootPath,
+		logger:              logger,
+		resultPageSize:      DefaultResultPageSize,
+		cache:               cache.NewGlobalCache(cache.DefaultMaxDocuments),
+		bqClient:            bqClient,
+		analyzer:            analyzer,
+		parsedFiles:         cache.NewLRU[string, *parsedFileEntry](cache.DefaultMaxDocuments),
+		metadataErrorsCache: cache.NewLRU[string, metadataErrorsEntry](cache.DefaultMaxDocuments),
+		documentLocks:       cache.NewKeyLocks(),
+		jobs:                make(map[string]bigquery.BigqueryJob),
 	}
 }
 
+// SetLanguageOption configures the syntax which the analyzer accepts.
+func (p *Project) SetLanguageOption(option file.LanguageOption) error {
+	return p.analyzer.SetLanguageOption(option)
+}
+
+// SetEmbeddedSQLOption configures the extraction of SQL from the string literals of Python and Go files.
+func (p *Project) SetEmbeddedSQLOption(option file.EmbeddedSQLOption) {
+	p.analyzer.SetEmbeddedSQLOption(option)
+}
+
+// IsHostFile reports whether the SQL is extracted from the string literals of the file at path.
+func (p *Project) IsHostFile(path string) bool {
+	return p.analyzer.IsHostFile(path)
+}
+
 func (p *Project) Close() error {
+	p.waitJobWatchers(jobShutdownTimeout)
+	for name, stats := range p.CacheStats() {
+		p.logger.Debugf("cache(%s): hits=%d misses=%d evictions=%d len=%d/%d hit_rate=%.2f", name, stats.Hits, stats.Misses, stats.Evictions, stats.Len, stats.Capacity, stats.HitRate())
+	}
+	if p.history != nil {
+		if err := p.history.Close(); err != nil {
+			p.logger.Warnf("failed to close query history: %v", err)
+		}
+	}
 	return p.bqClient.Close()
 }
 
+// CacheStats returns the statistics of the in-memory caches.
+func (p *Project) CacheStats() map[string]cache.Stats {
+	result := map[string]cache.Stats{
+		"documents":       p.cache.Stats(),
+		"parsed_files":    p.parsedFiles.Stats(),
+		"metadata_errors": p.metadataErrorsCache.Stats(),
+		"statements":      p.analyzer.StatementCacheStats(),
+	}
+	if c, ok := p.bqClient.(interface{ Stats() cache.Stats }); ok {
+		result["table_metadata"] = c.Stats()
+	}
+	return result
+}
+
 func (p *Project) UpdateFile(path string, text string, version int) error {
-	p.cache.Put(path, text)
+	unlock := p.documentLocks.Lock(path)
+	defer unlock()
+
+	if current := p.cache.Get(path); current != nil {
+		// The update is older than the current document.
+		if version < current.Version {
+			return nil
+		}
+		// Editors may send didChange without any change of the text, e.g. on undo.
+		// Then the previous parse and analysis are kept with the new version.
+		if current.Hash == cache.HashText(text) {
+			p.cache.SetVersion(path, version)
+			return nil
+		}
+	}
+
+	sql := p.cache.Put(path, text, version)
+
+	if p.prefetcher != nil && sql.Node != nil {
+		p.prefetcher.Prefetch(file.ReferencedTableNames(sql.Node))
+	}
 
 	return nil
 }
@@ -94,23 +327,147 @@ func (p *Project) GetFile(path string) (string, bool) {
 }
 
 func (p *Project) DeleteFile(path string) {
-	p.cache.Delete(path)
+	unlock := p.documentLocks.Lock(path)
+	defer unlock()
+
+	p.cache.Close(path)
+	p.parsedFiles.Delete(path)
+	p.metadataErrorsCache.Delete(path)
 }
 
-func (p *Project) GetErrors(path string) map[string][]file.Error {
+// parseFile returns the analysis of the document.
+// The analysis is shared by the requests for the same version of the document.
+func (p *Project) parseFile(path string, sql *cache.SQL) file.ParsedFile {
+	p.parsedFilesMu.Lock()
+	entry, ok := p.parsedFiles.Get(path)
+	if ok && entry.hash == sql.Hash {
+		// The version is changed without any change of the text.
+		if entry.version < sql.Version {
+			entry.version = sql.Version
+		}
+	} else {
+		newEntry := &parsedFileEntry{version: sql.Version, hash: sql.Hash}
+		// Don't overwrite the analysis of the newer version by the request for the old snapshot.
+		if !ok || entry.version <= sql.Version {
+			p.parsedFiles.Put(path, newEntry)
+		}
+		entry = newEntry
+	}
+	p.parsedFilesMu.Unlock()
+
+	entry.once.Do(func() {
+		// The analysis is shared by the requests, so it isn't cancelled with one of them.
+		entry.parsedFile = p.analyzer.ParseFile(context.Background(), path, sql.RawText)
+	})
+	return entry.parsedFile
+}
+
+func (p *Project) GetErrors(ctx context.Context, path string) map[string][]file.Error {
 	sql := p.cache.Get(path)
 	if sql == nil {
 		return nil
 	}
 
-	parsedFile := p.analyzer.ParseFile(path, sql.RawText)
-	if len(parsedFile.Errors) > 0 {
-		return map[string][]file.Error{path: parsedFile.Errors}
+	parsedFile := p.parseFile(path, sql)
+	if errs := p.fileErrors(ctx, parsedFile); len(errs) > 0 {
+		return map[string][]file.Error{path: errs}
 	}
 
 	return map[string][]file.Error{path: nil}
 }
 
+// DiagnosticOption is the user-defined checks of the project, which can be changed without recreating the project.
+type DiagnosticOption struct {
+	// BannedFunctions are reported when they are called.
+	BannedFunctions []file.BannedFunction
+	// LintRules are the structural lint rules defined by the user.
+	LintRules []file.LintRule
+	// DestinationTables maps the glob patterns of the file paths relative to the root to the tables which the query results are written into.
+	// The output columns of the query are compared with the schema of the table.
+	DestinationTables map[string]string
+}
+
+// SetDiagnosticOption replaces the user-defined checks. It can be called while the files are analyzed.
+func (p *Project) SetDiagnosticOption(option DiagnosticOption) {
+	p.diagnosticOptionLock.Lock()
+	defer p.diagnosticOptionLock.Unlock()
+	p.diagnosticOption = option
+}
+
+// diagnosticOpt returns the current option. Its slices and map are not modified after it is set, so the caller reads them without the lock.
+func (p *Project) diagnosticOpt() DiagnosticOption {
+	p.diagnosticOptionLock.RLock()
+	defer p.diagnosticOptionLock.RUnlock()
+	return p.diagnosticOption
+}
+
+// fileErrors returns the errors of the analysis and the enabled lints, except the ones suppressed by the comments.
+func (p *Project) fileErrors(ctx context.Context, parsedFile file.ParsedFile) []file.Error {
+	// don't modify the errors of the cached analysis
+	errs := slices.Clone(parsedFile.Errors)
+	if p.LintSelectStar {
+		errs = append(errs, parsedFile.SelectStarErrors()...)
+	}
+	if p.LintNonDeterministicLimit {
+		errs = append(errs, parsedFile.NonDeterministicLimitErrors()...)
+	}
+	if p.LintOrdinals {
+		errs = append(errs, parsedFile.OrdinalErrors()...)
+	}
+	option := p.diagnosticOpt()
+	errs = append(errs, parsedFile.BannedFunctionErrors(option.BannedFunctions)...)
+	errs = append(errs, parsedFile.LintRuleErrors(option.LintRules)...)
+	errs = append(errs, p.metadataErrors(ctx, parsedFile, destinationTable(p.rootPath, option.DestinationTables, parsedFile.URI))...)
+	errs = append(errs, parsedFile.TransactionErrors()...)
+	return parsedFile.Suppress(errs)
+}
+
+// metadataErrorsEntry is the errors of a version of the document which are checked with the table metadata.
+type metadataErrorsEntry struct {
+	hash        string
+	destination string
+	errs        []file.Error
+}
+
+// metadataErrors returns the errors which are checked with the table metadata, like the time travel window, the snapshots and the schema of the destination of the tables.
+// They are cached for the version of the document as the analysis, so that the repeated diagnostics don't look up the tables again.
+// The destination is a part of the key, because it changes with the workspace config.
+func (p *Project) metadataErrors(ctx context.Context, parsedFile file.ParsedFile, destination string) []file.Error {
+	hash := cache.HashText(parsedFile.Src)
+	if entry, ok := p.metadataErrorsCache.Get(parsedFile.URI); ok && entry.hash == hash && entry.destination == destination {
+		return entry.errs
+	}
+
+	errs := p.analyzer.TimeTravelErrors(ctx, parsedFile, time.Now())
+	errs = append(errs, p.analyzer.SnapshotTableErrors(ctx, parsedFile)...)
+	errs = append(errs, p.analyzer.SchemaDriftErrors(ctx, parsedFile, destination)...)
+	// The result of the cancelled request may lack the errors of the tables which failed to be looked up.
+	if parsedFile.URI != "" && ctx.Err() == nil {
+		p.metadataErrorsCache.Put(parsedFile.URI, metadataErrorsEntry{hash: hash, destination: destination, errs: errs})
+	}
+	return errs
+}
+
+// destinationTable returns the table which the query result of the file is written into by destinationTables.
+// The patterns are tried in the sorted order, so that the result doesn't depend on the order of the map.
+func destinationTable(rootPath string, destinationTables map[string]string, path string) string {
+	rel, err := filepath.Rel(rootPath, path)
+	if err != nil {
+		return ""
+	}
+	patterns := make([]string, 0, len(destinationTables))
+	for pattern := range destinationTables {
+		patterns = append(patterns, pattern)
+	}
+	slices.Sort(patterns)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, filepath.ToSlash(rel)); ok {
+			return destinationTables[pattern]
+		}
+	}
+	return ""
+}
+
 func (p *Project) Dryrun(ctx context.Context, path string) (*bq.JobStatus, error) {
 	sql := p.cache.Get(path)
 	if sql == nil {
@@ -120,23 +477,65 @@ func (p *Project) Dryrun(ctx context.Context, path string) (*bq.JobStatus, error
 	dryrun := true
 	result, err := p.bqClient.Run(ctx, sql.RawText, dryrun)
 	if err != nil {
+		// The dry run canceled by the newer save is not worth recording.
+		if ctx.Err() == nil {
+			p.recordDryrun(sql.RawText, nil, err)
+		}
 		return nil, err
 	}
 
-	return result.LastStatus(), nil
+	status := result.LastStatus()
+	p.recordDryrun(sql.RawText, status, nil)
+	return status, nil
 }
 
-func (p *Project) Run(ctx context.Context, path string) (bigquery.BigqueryJob, error) {
+// Run executes the query of the document.
+// When force is false, the query whose estimated bytes processed exceed MaxBytesProcessed is not executed.
+// When sample is true, the exploratory query is sampled with the sample option of the project.
+func (p *Project) Run(ctx context.Context, path string, force, sample bool) (bigquery.BigqueryJob, error) {
 	sql := p.cache.Get(path)
 	if sql == nil {
 		return nil, nil
 	}
 
-	dryrun := false
-	result, err := p.bqClient.Run(ctx, sql.RawText, dryrun)
+	query := sql.RawText
+	if sample {
+		query = p.sampleQuery(ctx, query)
+	}
+	return p.runQuery(ctx, query, force, nil)
+}
+
+// RunWithDestination executes the query of the document and writes the result into the destination table.
+func (p *Project) RunWithDestination(ctx context.Context, path string, force bool, destination bigquery.Destination) (bigquery.BigqueryJob, error) {
+	sql := p.cache.Get(path)
+	if sql == nil {
+		return nil, nil
+	}
+
+	return p.runQuery(ctx, sql.RawText, force, &destination)
+}
+
+// runQuery executes the query. When destination is not nil, the result is written into the table.
+func (p *Project) runQuery(ctx context.Context, query string, force bool, destination *bigquery.Destination) (bigquery.BigqueryJob, error) {
+	if !force {
+		if err := p.checkBytesProcessed(ctx, query); err != nil {
+			return nil, err
+		}
+	}
+
+	var result bigquery.BigqueryJob
+	var err error
+	if destination != nil {
+		result, err = p.bqClient.RunWithDestination(ctx, query, *destination)
+	} else {
+		dryrun := false
+		result, err = p.bqClient.Run(ctx, query, dryrun)
+	}
+	entry := p.recordJob(query, result, err)
 	if err != nil {
 		return nil, err
 	}
+	p.watchJob(result, entry)
 
 	return result, nil
 }
@@ -151,6 +550,9 @@ func (p *Project) ListTables(ctx context.Context, projectID, datasetID string) (
 
 func (p *Project) ListJobs(ctx context.Context, projectID string, allUsers bool) ([]lsp.JobHistory, error) {
 	it := p.bqClient.Jobs(ctx)
+	if it == nil {
+		return nil, bigquery.ErrOffline
+	}
 	it.ProjectID = projectID
 	it.AllUsers = allUsers
 
@@ -197,18 +599,54 @@ func (p *Project) ListJobs(ctx context.Context, projectID string, allUsers bool)
 			continue
 		}
 
-		result = append(result, lsp.JobHistory{
+		history := lsp.JobHistory{
 			TextDocument: lsp.TextDocumentIdentifier{
 				URI: lsp.NewJobVirtualTextDocumentURI(projectID, job.ID()),
 			},
 			ID:      job.ID(),
 			Owner:   job.Email(),
 			Summary: summary,
-		})
+		}
+		if status := job.LastStatus(); status != nil {
+			setJobHistoryStatus(&history, status)
+		}
+		result = append(result, history)
 	}
 	return result, nil
 }
 
+func setJobHistoryStatus(history *lsp.JobHistory, status *bq.JobStatus) {
+	history.State = jobStateString(status.State)
+	if status.Err() != nil {
+		history.ErrorMessage = status.Err().Error()
+	}
+
+	stats := status.Statistics
+	if stats == nil {
+		return
+	}
+	history.CreationTime = stats.CreationTime
+	if !stats.StartTime.IsZero() && !stats.EndTime.IsZero() {
+		history.DurationMs = stats.EndTime.Sub(stats.StartTime).Milliseconds()
+	}
+	if details, ok := stats.Details.(*bq.QueryStatistics); ok {
+		history.BytesBilled = details.TotalBytesBilled
+	}
+}
+
+func jobStateString(state bq.State) string {
+	switch state {
+	case bq.Pending:
+		return "PENDING"
+	case bq.Running:
+		return "RUNNING"
+	case bq.Done:
+		return "DONE"
+	default:
+		return "STATE_UNSPECIFIED"
+	}
+}
+
 func (p *Project) GetJobInfo(ctx context.Context, projectID, jobID string) (lsp.VirtualTextDocument, error) {
 	job, err := p.bqClient.JobFromProject(ctx, projectID, jobID)
 	if err != nil {
@@ -225,7 +663,7 @@ func (p *Project) GetJobInfo(ctx context.Context, projectID, jobID string) (lsp.
 	if err != nil {
 		return lsp.VirtualTextDocument{Contents: markedStrings}, nil
 	}
-	queryResult, err := buildQueryResult(it)
+	queryResult, err := buildQueryResult(it, p.resultPageSize)
 	if err != nil {
 		return lsp.VirtualTextDocument{}, err
 	}
@@ -233,6 +671,22 @@ func (p *Project) GetJobInfo(ctx context.Context, projectID, jobID string) (lsp.
 	return lsp.VirtualTextDocument{Contents: markedStrings, Result: queryResult}, nil
 }
 
+// GetJobResultPage returns the page of the query result which starts from the pageToken.
+func (p *Project) GetJobResultPage(ctx context.Context, projectID, jobID, pageToken string) (lsp.QueryResult, error) {
+	job, err := p.bqClient.JobFromProject(ctx, projectID, jobID)
+	if err != nil {
+		return lsp.QueryResult{}, err
+	}
+
+	it, err := job.Read(ctx)
+	if err != nil {
+		return lsp.QueryResult{}, err
+	}
+	it.PageInfo().Token = pageToken
+
+	return buildQueryResult(it, p.resultPageSize)
+}
+
 func buildBigQueryJobMarkedString(projectID, region string, job bigquery.BigqueryJob) ([]lsp.MarkedString, error) {
 	var result []lsp.MarkedString
 
@@ -308,7 +762,7 @@ func (p *Project) GetTableInfo(ctx context.Context, projectID, datasetID, tableI
 		return lsp.VirtualTextDocument{}, err
 	}
 	it.Schema = tableMetadata.Schema
-	queryResult, err := buildQueryResult(it)
+	queryResult, err := buildQueryResult(it, p.resultPageSize)
 	if err != nil {
 		return lsp.VirtualTextDocument{}, err
 	}
@@ -316,18 +770,38 @@ func (p *Project) GetTableInfo(ctx context.Context, projectID, datasetID, tableI
 	return lsp.VirtualTextDocument{Contents: markedStrings, Result: queryResult}, nil
 }
 
+// GetTableRecordPage returns the page of the table rows which starts from the pageToken.
+func (p *Project) GetTableRecordPage(ctx context.Context, projectID, datasetID, tableID, pageToken string) (lsp.QueryResult, error) {
+	tableMetadata, err := p.bqClient.GetTableMetadata(ctx, projectID, datasetID, tableID)
+	if err != nil {
+		return lsp.QueryResult{}, err
+	}
+
+	it, err := p.bqClient.GetTableRecord(ctx, projectID, datasetID, tableID)
+	if err != nil {
+		return lsp.QueryResult{}, err
+	}
+	it.Schema = tableMetadata.Schema
+	it.PageInfo().Token = pageToken
+
+	return buildQueryResult(it, p.resultPageSize)
+}
+
 func (p *Project) GetTablePreview(ctx context.Context, projectID, datasetID, tableID string) (*bq.RowIterator, error) {
 	return p.bqClient.GetTableRecord(ctx, projectID, datasetID, tableID)
 }
 
-func buildQueryResult(it *bq.RowIterator) (lsp.QueryResult, error) {
+// buildQueryResult reads a page of pageSize rows.
+// When more rows exist, the result has the token of the next page.
+func buildQueryResult(it *bq.RowIterator, pageSize int) (lsp.QueryResult, error) {
 	var result lsp.QueryResult
 
 	for _, field := range it.Schema {
 		result.Columns = append(result.Columns, field.Name)
 	}
 
-	for i := 0; i < 100; i++ {
+	it.PageInfo().MaxSize = pageSize
+	for i := 0; i < pageSize; i++ {
 		var values []bq.Value
 		err := it.Next(&values)
 		if errors.Is(err, iterator.Done) {
@@ -340,5 +814,10 @@ func buildQueryResult(it *bq.RowIterator) (lsp.QueryResult, error) {
 		result.Data = append(result.Data, values)
 	}
 
+	// The token is updated when the page is fetched, so it points to the next page after the page is consumed.
+	if len(result.Data) == pageSize && it.PageInfo().Remaining() == 0 {
+		result.NextPageToken = it.PageInfo().Token
+	}
+
 	return result, nil
 }
@@ -0,0 +1,237 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/kitagry/bqls/langserver/internal/bigquery"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_ReuseAnalysisOfSameVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+
+	var calls int
+	bqClient.EXPECT().GetTableMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, projectID, datasetID, tableID string) (*bq.TableMetadata, error) {
+			calls++
+			return &bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "id", Type: bq.IntegerFieldType},
+				},
+			}, nil
+		}).MinTimes(0)
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+	p := source.NewProjectWithBQClient("/", bqClient, logger)
+
+	uri := "file1.sql"
+	p.UpdateFile(uri, "SELECT id FROM `project.dataset.table`", 1)
+
+	p.GetErrors(context.Background(), uri)
+	firstCalls := calls
+	if firstCalls == 0 {
+		t.Fatalf("GetTableMetadata should be called by the analysis")
+	}
+
+	p.GetErrors(context.Background(), uri)
+	if calls != firstCalls {
+		t.Errorf("the same version should not be analyzed again: expect %d calls, got %d calls", firstCalls, calls)
+	}
+
+	p.UpdateFile(uri, "SELECT id, id FROM `project.dataset.table`", 2)
+	p.GetErrors(context.Background(), uri)
+	if calls == firstCalls {
+		t.Errorf("the new version should be analyzed")
+	}
+}
+
+func TestProject_ReuseMetadataErrorsOfSameVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+
+	var calls int
+	bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").DoAndReturn(
+		func(ctx context.Context, projectID, datasetID, tableID string) (*bq.TableMetadata, error) {
+			calls++
+			return &bq.TableMetadata{
+				CreationTime: time.Now().Add(-time.Hour),
+				Schema: bq.Schema{
+					{Name: "id", Type: bq.IntegerFieldType},
+				},
+			}, nil
+		}).MinTimes(0)
+	p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+	uri := "file1.sql"
+	snapshot := time.Now().Add(-2 * time.Hour).UTC().Format("2006-01-02 15:04:05")
+	p.UpdateFile(uri, "SELECT id FROM `project.dataset.table` FOR SYSTEM_TIME AS OF TIMESTAMP '"+snapshot+"'", 1)
+
+	hasTimeTravelError := func(errs []file.Error) bool {
+		for _, err := range errs {
+			if err.Code == "time-travel" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if errs := p.GetErrors(context.Background(), uri); !hasTimeTravelError(errs[uri]) {
+		t.Fatalf("the snapshot before the creation of the table should be reported, but got %v", errs[uri])
+	}
+	firstCalls := calls
+
+	if errs := p.GetErrors(context.Background(), uri); !hasTimeTravelError(errs[uri]) {
+		t.Errorf("the cached error should be reported, but got %v", errs[uri])
+	}
+	if calls != firstCalls {
+		t.Errorf("the tables of the same version should not be looked up again: expect %d calls, got %d calls", firstCalls, calls)
+	}
+}
+
+func TestProject_RecheckMetadataErrorsOfNewDestination(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+		Type: bq.RegularTable,
+		Schema: bq.Schema{
+			{Name: "id", Type: bq.IntegerFieldType},
+			{Name: "name", Type: bq.StringFieldType},
+		},
+	}, nil).MinTimes(0)
+	p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+	uri := "/file1.sql"
+	p.UpdateFile(uri, "SELECT 1 AS id", 1)
+
+	hasSchemaDriftError := func(errs []file.Error) bool {
+		for _, err := range errs {
+			if err.Code == file.SchemaDriftCode {
+				return true
+			}
+		}
+		return false
+	}
+
+	if errs := p.GetErrors(context.Background(), uri); hasSchemaDriftError(errs[uri]) {
+		t.Fatalf("the query without the destination should not be reported, but got %v", errs[uri])
+	}
+
+	p.SetDiagnosticOption(source.DiagnosticOption{
+		DestinationTables: map[string]string{"*.sql": "project.dataset.table"},
+	})
+	if errs := p.GetErrors(context.Background(), uri); !hasSchemaDriftError(errs[uri]) {
+		t.Errorf("the query should be checked with the new destination, but got %v", errs[uri])
+	}
+}
+
+func TestProject_IgnoreOlderVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	logger := logrus.New()
+	p := source.NewProjectWithBQClient("/", bqClient, logger)
+
+	uri := "file1.sql"
+	p.UpdateFile(uri, "SELECT 2", 2)
+	p.UpdateFile(uri, "SELECT 1", 1)
+
+	got, ok := p.GetFile(uri)
+	if !ok {
+		t.Fatalf("file should exist")
+	}
+	if got != "SELECT 2" {
+		t.Errorf("the older version should be ignored: got %q", got)
+	}
+}
+
+func TestProject_StoreVersionOfUnchangedText(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	logger := logrus.New()
+	p := source.NewProjectWithBQClient("/", bqClient, logger)
+
+	uri := "file1.sql"
+	p.UpdateFile(uri, "SELECT 1", 1)
+	p.UpdateFile(uri, "SELECT 1", 3)
+	p.UpdateFile(uri, "SELECT 2", 2)
+
+	got, ok := p.GetFile(uri)
+	if !ok {
+		t.Fatalf("file should exist")
+	}
+	if got != "SELECT 1" {
+		t.Errorf("the version older than the unchanged update should be ignored: got %q", got)
+	}
+}
+
+func TestProject_CancelJobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	cancelled := make(chan struct{})
+
+	job := mock_bigquery.NewMockBigqueryJob(ctrl)
+	job.EXPECT().ID().Return("job1").MinTimes(0)
+	job.EXPECT().Wait(gomock.Any()).DoAndReturn(func(ctx context.Context) (*bq.JobStatus, error) {
+		<-cancelled
+		return &bq.JobStatus{State: bq.Done}, nil
+	})
+	job.EXPECT().Cancel(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+		close(cancelled)
+		return nil
+	})
+	bqClient.EXPECT().Run(gomock.Any(), gomock.Any(), false).Return(job, nil)
+	bqClient.EXPECT().Close().Return(nil)
+	p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+	uri := "file1.sql"
+	p.UpdateFile(uri, "SELECT 1", 1)
+	if _, err := p.Run(context.Background(), uri, false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.CancelJobs(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProject_RunWithDestination(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	destination := bigquery.Destination{
+		ProjectID:        "project",
+		DatasetID:        "dataset",
+		TableID:          "table",
+		WriteDisposition: bq.WriteTruncate,
+	}
+
+	job := mock_bigquery.NewMockBigqueryJob(ctrl)
+	job.EXPECT().ID().Return("job1").MinTimes(0)
+	job.EXPECT().Wait(gomock.Any()).Return(&bq.JobStatus{State: bq.Done}, nil)
+	bqClient.EXPECT().RunWithDestination(gomock.Any(), "SELECT 1", destination).Return(job, nil)
+	bqClient.EXPECT().Close().Return(nil)
+	p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+	uri := "file1.sql"
+	p.UpdateFile(uri, "SELECT 1", 1)
+	got, err := p.RunWithDestination(context.Background(), uri, true, destination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID() != "job1" {
+		t.Errorf("RunWithDestination should return the job: got %s", got.ID())
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
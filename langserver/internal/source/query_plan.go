@@ -0,0 +1,172 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// QueryPlan renders the stages of the query job as a markdown tree, whose stages can be collapsed.
+// The dry run doesn't return the query plan, so the job should be already executed. QueryPlan waits for the job to be finished.
+func (p *Project) QueryPlan(ctx context.Context, projectID, jobID string) ([]lsp.MarkedString, error) {
+	job, err := p.bqClient.JobFromProject(ctx, projectID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := status.Err(); err != nil {
+		return nil, err
+	}
+
+	var stages []*bq.ExplainQueryStage
+	if status.Statistics != nil {
+		if details, ok := status.Statistics.Details.(*bq.QueryStatistics); ok {
+			stages = details.QueryPlan
+		}
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("job %s has no query plan", jobID)
+	}
+
+	return []lsp.MarkedString{
+		{
+			Language: "markdown",
+			Value:    buildQueryPlanMarkdown(jobID, stages),
+		},
+	}, nil
+}
+
+// DryRunPlan dry-runs the query of the document and renders the statistics of the dry run.
+// The document should be a single query statement, so that explaining it never runs DML or DDL.
+func (p *Project) DryRunPlan(ctx context.Context, uri string) ([]lsp.MarkedString, error) {
+	query, err := p.singleQuery(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	dryrun := true
+	job, err := p.bqClient.Run(ctx, query, dryrun)
+	if err != nil {
+		return nil, err
+	}
+	status := job.LastStatus()
+	if status == nil {
+		return nil, fmt.Errorf("the dry run has no status")
+	}
+	if err := status.Err(); err != nil {
+		return nil, err
+	}
+	if status.Statistics == nil {
+		return nil, fmt.Errorf("the dry run has no statistics")
+	}
+	details, _ := status.Statistics.Details.(*bq.QueryStatistics)
+
+	return []lsp.MarkedString{
+		{
+			Language: "markdown",
+			Value:    buildDryRunMarkdown(status.Statistics.TotalBytesProcessed, details),
+		},
+	}, nil
+}
+
+// IsSingleQuery reports whether the document is a single query statement, which can be run without changing any data.
+func (p *Project) IsSingleQuery(uri string) bool {
+	_, err := p.singleQuery(uri)
+	return err == nil
+}
+
+func (p *Project) singleQuery(uri string) (string, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return "", fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.analyzer.ParseFileWithoutAnalysis(uri, sql.RawText)
+	if len(parsedFile.Errors) > 0 {
+		return "", fmt.Errorf("failed to parse %s: %w", uri, parsedFile.Errors[0])
+	}
+	statements := topLevelStatements(parsedFile.Node)
+	if len(statements) != 1 {
+		return "", fmt.Errorf("%s should be a single query statement", uri)
+	}
+	if _, ok := statements[0].(*ast.QueryStatementNode); !ok {
+		return "", fmt.Errorf("%s should be a query statement, not DML or DDL", uri)
+	}
+	return sql.RawText, nil
+}
+
+func buildDryRunMarkdown(totalBytesProcessed int64, details *bq.QueryStatistics) string {
+	sb := &strings.Builder{}
+	sb.WriteString("## Dry run\n\n")
+	if details != nil && details.StatementType != "" {
+		sb.WriteString(fmt.Sprintf("* Statement type: %s\n", details.StatementType))
+	}
+	sb.WriteString(fmt.Sprintf("* Bytes processed: %s\n", bytesConvert(totalBytesProcessed)))
+	if details == nil {
+		return sb.String()
+	}
+
+	if len(details.ReferencedTables) > 0 {
+		sb.WriteString("* Referenced tables:\n")
+		for _, t := range details.ReferencedTables {
+			sb.WriteString(fmt.Sprintf("  * `%s.%s.%s`\n", t.ProjectID, t.DatasetID, t.TableID))
+		}
+	}
+	if len(details.Schema) > 0 {
+		sb.WriteString("* Output columns:\n")
+		for _, f := range details.Schema {
+			sb.WriteString(fmt.Sprintf("  * %s %s\n", f.Name, f.Type))
+		}
+	}
+	return sb.String()
+}
+
+func buildQueryPlanMarkdown(jobID string, stages []*bq.ExplainQueryStage) string {
+	printer := message.NewPrinter(language.English)
+	names := make(map[int64]string, len(stages))
+	for _, s := range stages {
+		names[s.ID] = s.Name
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(fmt.Sprintf("## Query plan of %s\n", jobID))
+	for _, s := range stages {
+		sb.WriteString("\n<details>\n")
+		sb.WriteString(fmt.Sprintf("<summary>%s (%s)</summary>\n\n", s.Name, s.Status))
+
+		if len(s.InputStages) > 0 {
+			inputs := make([]string, 0, len(s.InputStages))
+			for _, id := range s.InputStages {
+				inputs = append(inputs, names[id])
+			}
+			sb.WriteString(fmt.Sprintf("* Input stages: %s\n", strings.Join(inputs, ", ")))
+		}
+		sb.WriteString(printer.Sprintf("* Records read: %d\n", s.RecordsRead))
+		sb.WriteString(printer.Sprintf("* Records written: %d\n", s.RecordsWritten))
+		sb.WriteString(fmt.Sprintf("* Shuffle output: %s\n", bytesConvert(s.ShuffleOutputBytes)))
+		if s.ShuffleOutputBytesSpilled > 0 {
+			sb.WriteString(fmt.Sprintf("* Shuffle spilled to disk: %s\n", bytesConvert(s.ShuffleOutputBytesSpilled)))
+		}
+
+		if len(s.Steps) > 0 {
+			sb.WriteString("* Steps:\n")
+			for _, step := range s.Steps {
+				sb.WriteString(fmt.Sprintf("  * %s\n", step.Kind))
+				for _, substep := range step.Substeps {
+					sb.WriteString(fmt.Sprintf("    * %s\n", substep))
+				}
+			}
+		}
+		sb.WriteString("\n</details>\n")
+	}
+	return sb.String()
+}
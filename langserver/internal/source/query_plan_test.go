@@ -0,0 +1,151 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_QueryPlan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	job := mock_bigquery.NewMockBigqueryJob(ctrl)
+	job.EXPECT().Wait(gomock.Any()).Return(&bq.JobStatus{
+		State: bq.Done,
+		Statistics: &bq.JobStatistics{
+			Details: &bq.QueryStatistics{
+				QueryPlan: []*bq.ExplainQueryStage{
+					{
+						ID:                 0,
+						Name:               "S00: Input",
+						Status:             "COMPLETE",
+						RecordsRead:        1200,
+						RecordsWritten:     3,
+						ShuffleOutputBytes: 2048,
+						Steps: []*bq.ExplainQueryStep{
+							{Kind: "READ", Substeps: []string{"$1:id", "FROM project.dataset.table"}},
+							{Kind: "WRITE", Substeps: []string{"$1", "TO __stage00_output"}},
+						},
+					},
+					{
+						ID:             1,
+						Name:           "S01: Output",
+						Status:         "COMPLETE",
+						InputStages:    []int64{0},
+						RecordsRead:    3,
+						RecordsWritten: 1,
+					},
+				},
+			},
+		},
+	}, nil)
+	bqClient.EXPECT().JobFromProject(gomock.Any(), "project", "job1").Return(job, nil)
+	p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+	got, err := p.QueryPlan(context.Background(), "project", "job1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []lsp.MarkedString{
+		{
+			Language: "markdown",
+			Value: "## Query plan of job1\n" +
+				"\n<details>\n<summary>S00: Input (COMPLETE)</summary>\n\n" +
+				"* Records read: 1,200\n" +
+				"* Records written: 3\n" +
+				"* Shuffle output: 2 KiB\n" +
+				"* Steps:\n" +
+				"  * READ\n" +
+				"    * $1:id\n" +
+				"    * FROM project.dataset.table\n" +
+				"  * WRITE\n" +
+				"    * $1\n" +
+				"    * TO __stage00_output\n" +
+				"\n</details>\n" +
+				"\n<details>\n<summary>S01: Output (COMPLETE)</summary>\n\n" +
+				"* Input stages: S00: Input\n" +
+				"* Records read: 3\n" +
+				"* Records written: 1\n" +
+				"* Shuffle output: 0 bytes\n" +
+				"\n</details>\n",
+		},
+	}
+	if diff := cmp.Diff(expect, got, cmpopts.IgnoreUnexported(lsp.MarkedString{})); diff != "" {
+		t.Errorf("project.QueryPlan result diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestProject_DryRunPlan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	job := mock_bigquery.NewMockBigqueryJob(ctrl)
+	job.EXPECT().LastStatus().Return(&bq.JobStatus{
+		State: bq.Done,
+		Statistics: &bq.JobStatistics{
+			TotalBytesProcessed: 2048,
+			Details: &bq.QueryStatistics{
+				StatementType:    "SELECT",
+				ReferencedTables: []*bq.Table{{ProjectID: "project", DatasetID: "dataset", TableID: "table"}},
+				Schema:           bq.Schema{{Name: "id", Type: bq.IntegerFieldType}},
+			},
+		},
+	})
+	bqClient.EXPECT().Run(gomock.Any(), "SELECT id FROM `project.dataset.table`", true).Return(job, nil)
+	p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+	p.UpdateFile("file1.sql", "SELECT id FROM `project.dataset.table`", 1)
+
+	got, err := p.DryRunPlan(context.Background(), "file1.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []lsp.MarkedString{
+		{
+			Language: "markdown",
+			Value: "## Dry run\n\n" +
+				"* Statement type: SELECT\n" +
+				"* Bytes processed: 2 KiB\n" +
+				"* Referenced tables:\n" +
+				"  * `project.dataset.table`\n" +
+				"* Output columns:\n" +
+				"  * id INTEGER\n",
+		},
+	}
+	if diff := cmp.Diff(expect, got, cmpopts.IgnoreUnexported(lsp.MarkedString{})); diff != "" {
+		t.Errorf("project.DryRunPlan result diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestProject_DryRunPlanRefusesDML(t *testing.T) {
+	tests := map[string]string{
+		"DML":                 "DELETE FROM `project.dataset.table` WHERE true",
+		"DDL":                 "DROP TABLE `project.dataset.table`",
+		"multiple statements": "SELECT 1;\nSELECT 2",
+	}
+
+	for n, src := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			// the query must not be run
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+			p.UpdateFile("file1.sql", src, 1)
+
+			if _, err := p.DryRunPlan(context.Background(), "file1.sql"); err == nil {
+				t.Fatal("DryRunPlan should return the error")
+			}
+			if p.IsSingleQuery("file1.sql") {
+				t.Error("IsSingleQuery should be false")
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package source
+
+import (
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// Refactorings is the refactorings which are available at the selection.
+type Refactorings struct {
+	ExtractSubqueryToCTE bool
+	InlineCTE            bool
+	AddJoinCondition     bool
+	// ReplaceOrdinals is true when GROUP BY or ORDER BY has the ordinals which can be replaced with the expressions.
+	ReplaceOrdinals bool
+	// ReplaceWithOrdinals is true when GROUP BY or ORDER BY has the expressions which can be replaced with the ordinals.
+	ReplaceWithOrdinals bool
+	EvaluateExpression  bool
+	ConvertLegacySQL    bool
+}
+
+// AvailableRefactorings checks which refactorings are available at rng with the syntax of the document.
+// It neither looks up the tables nor builds the edits, so that the code actions are listed quickly.
+// The edits are built when the command is executed, which may still fail like when the joined tables have no columns in common.
+func (p *Project) AvailableRefactorings(uri string, rng lsp.Range) Refactorings {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return Refactorings{}
+	}
+	parsedFile := p.parseFile(uri, sql)
+
+	result := Refactorings{
+		EvaluateExpression: isConstantExpression(parsedFile, rng),
+		ConvertLegacySQL:   hasLegacySQL(parsedFile.Src),
+	}
+	if parsedFile.Node == nil {
+		return result
+	}
+	_, _, err := subqueryToExtract(parsedFile, rng)
+	result.ExtractSubqueryToCTE = err == nil
+	_, _, _, err = cteToInline(parsedFile, rng.Start)
+	result.InlineCTE = err == nil
+	_, _, err = joinWithoutCondition(parsedFile, rng.Start)
+	result.AddJoinCondition = err == nil
+	if items, err := ordinalsAt(parsedFile, rng.Start); err == nil {
+		for _, o := range items {
+			if o.isOrdinal {
+				result.ReplaceOrdinals = true
+			} else {
+				result.ReplaceWithOrdinals = true
+			}
+		}
+	}
+	return result
+}
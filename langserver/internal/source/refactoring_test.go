@@ -0,0 +1,127 @@
+package source_test
+
+import (
+	"errors"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_AvailableRefactorings(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+		// the selection starts at the cursor
+		selectionLength int
+
+		expect source.Refactorings
+	}{
+		"subquery": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM (SELECT |id FROM `project.dataset.table`)",
+			},
+			expect: source.Refactorings{ExtractSubqueryToCTE: true},
+		},
+		"WITH query referenced once": {
+			files: map[string]string{
+				"file1.sql": "WITH t AS (SELECT id FROM `project.dataset.table`) SELECT * FROM |t",
+			},
+			expect: source.Refactorings{InlineCTE: true},
+		},
+		"JOIN without condition": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.table` AS a |JOIN `project.dataset.table` AS b",
+			},
+			expect: source.Refactorings{AddJoinCondition: true},
+		},
+		"JOIN with condition": {
+			files: map[string]string{
+				"file1.sql": "SELECT * FROM `project.dataset.table` AS a |JOIN `project.dataset.table` AS b ON a.id = b.id",
+			},
+			expect: source.Refactorings{},
+		},
+		"ordinals and expressions": {
+			files: map[string]string{
+				"file1.sql": "SELECT id, name FROM `project.dataset.table` GROUP BY |1, name",
+			},
+			expect: source.Refactorings{ReplaceOrdinals: true, ReplaceWithOrdinals: true},
+		},
+		"constant expression": {
+			files: map[string]string{
+				"file1.sql": "SELECT |DATE_DIFF(DATE '2024-03-01', DATE '2024-01-01', DAY) AS days",
+			},
+			selectionLength: 52,
+			expect:          source.Refactorings{EvaluateExpression: true},
+		},
+		"CAST of constant": {
+			files: map[string]string{
+				"file1.sql": "SELECT |CAST('1' AS INT64) + 1 AS n",
+			},
+			selectionLength: 21,
+			expect:          source.Refactorings{EvaluateExpression: true},
+		},
+		"function which can't be evaluated": {
+			files: map[string]string{
+				"file1.sql": "SELECT |CURRENT_DATE() AS d",
+			},
+			selectionLength: 14,
+			expect:          source.Refactorings{},
+		},
+		"operator which can't be evaluated": {
+			files: map[string]string{
+				"file1.sql": "SELECT |'a' LIKE 'b' AS b",
+			},
+			selectionLength: 12,
+			expect:          source.Refactorings{},
+		},
+		"expression with column": {
+			files: map[string]string{
+				"file1.sql": "SELECT |id + 1 FROM `project.dataset.table`",
+			},
+			selectionLength: 6,
+			expect:          source.Refactorings{},
+		},
+		"legacy SQL": {
+			files: map[string]string{
+				"file1.sql": "SELECT id FROM |[project:dataset.table]",
+			},
+			expect: source.Refactorings{ConvertLegacySQL: true},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetDefaultProject().Return("project").MinTimes(0)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "id", Type: bq.IntegerFieldType},
+					{Name: "name", Type: bq.StringFieldType},
+				},
+			}, nil).MinTimes(0)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("not found")).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			rng := lsp.Range{Start: position, End: lsp.Position{Line: position.Line, Character: position.Character + tt.selectionLength}}
+			got := p.AvailableRefactorings(path, rng)
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("project.AvailableRefactorings result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// SampleOption reduces the cost of the exploratory queries executed from the editor.
+type SampleOption struct {
+	// Percent adds `TABLESAMPLE SYSTEM (Percent PERCENT)` to the tables. When it is 0, the tables are not sampled.
+	Percent float64
+
+	// Limit appends `LIMIT Limit` to the query without LIMIT. When it is 0, LIMIT is not appended.
+	Limit int
+}
+
+func (o SampleOption) enabled() bool {
+	return o.Percent > 0 || o.Limit > 0
+}
+
+// sampleQuery rewrites the exploratory query with the sample option.
+// Only the query which consists of a single SELECT statement is rewritten, because the sampled DML or DDL changes the data.
+// TABLESAMPLE is added only to the tables, because BigQuery doesn't support it for the views and the external tables.
+func (p *Project) sampleQuery(ctx context.Context, query string) string {
+	if !p.SampleOption.enabled() {
+		return query
+	}
+
+	parsedFile := p.analyzer.ParseFileWithoutAnalysis("", query)
+	if len(parsedFile.Errors) > 0 {
+		return query
+	}
+	stmts := topLevelStatements(parsedFile.Node)
+	if len(stmts) != 1 {
+		return query
+	}
+	stmt, ok := stmts[0].(*ast.QueryStatementNode)
+	if !ok {
+		return query
+	}
+
+	type insertion struct {
+		offset int
+		text   string
+	}
+	insertions := make([]insertion, 0)
+	if p.SampleOption.Percent > 0 {
+		clause := fmt.Sprintf(" TABLESAMPLE SYSTEM (%s PERCENT)", strconv.FormatFloat(p.SampleOption.Percent, 'f', -1, 64))
+		for _, table := range p.sampledTables(ctx, parsedFile, stmt) {
+			insertions = append(insertions, insertion{offset: table.ParseLocationRange().End().ByteOffset(), text: clause})
+		}
+	}
+	if p.SampleOption.Limit > 0 && stmt.Query().LimitOffset() == nil {
+		insertions = append(insertions, insertion{offset: stmt.ParseLocationRange().End().ByteOffset(), text: fmt.Sprintf("\nLIMIT %d", p.SampleOption.Limit)})
+	}
+
+	sort.SliceStable(insertions, func(i, j int) bool { return insertions[i].offset < insertions[j].offset })
+	var b strings.Builder
+	prev := 0
+	for _, ins := range insertions {
+		b.WriteString(query[prev:ins.offset])
+		b.WriteString(ins.text)
+		prev = ins.offset
+	}
+	b.WriteString(query[prev:])
+	return b.String()
+}
+
+// sampledTables lists the references of the tables which can be sampled.
+func (p *Project) sampledTables(ctx context.Context, parsedFile file.ParsedFile, stmt *ast.QueryStatementNode) []*ast.TablePathExpressionNode {
+	cteNames := make(map[string]struct{})
+	for _, entry := range file.ListAstNode[*ast.WithClauseEntryNode](parsedFile.Node) {
+		cteNames[strings.ToLower(entry.Alias().Name())] = struct{}{}
+	}
+
+	result := make([]*ast.TablePathExpressionNode, 0)
+	ast.Walk(stmt, func(n ast.Node) error {
+		table, ok := n.(*ast.TablePathExpressionNode)
+		if !ok || table.PathExpr() == nil || table.SampleClause() != nil || table.ParseLocationRange() == nil {
+			return nil
+		}
+		name, ok := file.CreateTableNameFromTablePathExpressionNode(table)
+		if !ok {
+			return nil
+		}
+		if _, ok := cteNames[strings.ToLower(name)]; ok {
+			return nil
+		}
+		metadata, err := p.analyzer.GetTableMetadataFromPath(ctx, name)
+		if err != nil || metadata.Type != bq.RegularTable {
+			return nil
+		}
+		result = append(result, table)
+		return nil
+	})
+	return result
+}
@@ -0,0 +1,78 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_RunWithSample(t *testing.T) {
+	tests := map[string]struct {
+		file         string
+		sampleOption source.SampleOption
+		sample       bool
+
+		expectQuery string
+	}{
+		"sample tables and append LIMIT": {
+			file:         "WITH data AS (SELECT id FROM `project.dataset.table` t)\nSELECT * FROM data JOIN `project.dataset.view` USING (id)",
+			sampleOption: source.SampleOption{Percent: 10, Limit: 100},
+			sample:       true,
+			expectQuery:  "WITH data AS (SELECT id FROM `project.dataset.table` t TABLESAMPLE SYSTEM (10 PERCENT))\nSELECT * FROM data JOIN `project.dataset.view` USING (id)\nLIMIT 100",
+		},
+		"keep LIMIT and TABLESAMPLE": {
+			file:         "SELECT id FROM `project.dataset.table` TABLESAMPLE SYSTEM (1 PERCENT) LIMIT 10;",
+			sampleOption: source.SampleOption{Percent: 0.5, Limit: 100},
+			sample:       true,
+			expectQuery:  "SELECT id FROM `project.dataset.table` TABLESAMPLE SYSTEM (1 PERCENT) LIMIT 10;",
+		},
+		"not sample DML": {
+			file:         "DELETE FROM `project.dataset.table` WHERE id = 1",
+			sampleOption: source.SampleOption{Percent: 10, Limit: 100},
+			sample:       true,
+			expectQuery:  "DELETE FROM `project.dataset.table` WHERE id = 1",
+		},
+		"without sample": {
+			file:         "SELECT id FROM `project.dataset.table`",
+			sampleOption: source.SampleOption{Percent: 10, Limit: 100},
+			sample:       false,
+			expectQuery:  "SELECT id FROM `project.dataset.table`",
+		},
+	}
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Type:   bq.RegularTable,
+				Schema: bq.Schema{{Name: "id", Type: bq.IntegerFieldType}},
+			}, nil).MinTimes(0)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "view").Return(&bq.TableMetadata{
+				Type:   bq.ViewTable,
+				Schema: bq.Schema{{Name: "id", Type: bq.IntegerFieldType}},
+			}, nil).MinTimes(0)
+
+			job := mock_bigquery.NewMockBigqueryJob(ctrl)
+			job.EXPECT().ID().Return("job1").MinTimes(0)
+			job.EXPECT().Wait(gomock.Any()).Return(&bq.JobStatus{State: bq.Done}, nil)
+			bqClient.EXPECT().Run(gomock.Any(), tt.expectQuery, false).Return(job, nil)
+			bqClient.EXPECT().Close().Return(nil)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+			p.SampleOption = tt.sampleOption
+
+			uri := "file1.sql"
+			p.UpdateFile(uri, tt.file, 1)
+			if _, err := p.Run(context.Background(), uri, false, tt.sample); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
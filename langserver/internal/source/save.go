@@ -0,0 +1,223 @@
+package source
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+	"google.golang.org/api/iterator"
+)
+
+type ResultFormat string
+
+const (
+	ResultFormatCSV  ResultFormat = "csv"
+	ResultFormatJSON ResultFormat = "json"
+)
+
+// ResultFormatFromPath infers the format from the extension of the path.
+func ResultFormatFromPath(path string) (ResultFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ResultFormatCSV, nil
+	case ".json", ".jsonl", ".ndjson":
+		return ResultFormatJSON, nil
+	default:
+		return "", fmt.Errorf("failed to infer the format from %s. Specify csv or json", path)
+	}
+}
+
+// SaveJobResult writes all rows of the query result of the job into the path.
+// It returns the number of the written rows.
+func (p *Project) SaveJobResult(ctx context.Context, projectID, jobID, path string, format ResultFormat) (int, error) {
+	if format != ResultFormatCSV && format != ResultFormatJSON {
+		return 0, fmt.Errorf("unsupported format: %s. Use csv or json", format)
+	}
+
+	job, err := p.bqClient.JobFromProject(ctx, projectID, jobID)
+	if err != nil {
+		return 0, err
+	}
+
+	it, err := job.Read(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the query result: %w", err)
+	}
+
+	// The schema of the iterator is set by the first Next.
+	var first []bq.Value
+	err = it.Next(&first)
+	if err != nil && !errors.Is(err, iterator.Done) {
+		return 0, err
+	}
+	empty := errors.Is(err, iterator.Done)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	w, err := NewResultWriter(f, format, it.Schema)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for row := first; !empty; {
+		if err := w.Write(row); err != nil {
+			return count, err
+		}
+		count++
+
+		row = nil
+		err := it.Next(&row)
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return count, err
+	}
+	return count, f.Close()
+}
+
+// ResultWriter writes the rows of the query result.
+type ResultWriter interface {
+	Write(row []bq.Value) error
+	Flush() error
+}
+
+// NewResultWriter creates the writer of the format.
+// CSV has the header row, and JSON is written as newline-delimited JSON.
+func NewResultWriter(w io.Writer, format ResultFormat, schema bq.Schema) (ResultWriter, error) {
+	switch format {
+	case ResultFormatCSV:
+		cw := &csvResultWriter{w: csv.NewWriter(w), schema: schema}
+		header := make([]string, len(schema))
+		for i, field := range schema {
+			header[i] = field.Name
+		}
+		if err := cw.w.Write(header); err != nil {
+			return nil, err
+		}
+		return cw, nil
+	case ResultFormatJSON:
+		return &jsonResultWriter{enc: json.NewEncoder(w), schema: schema}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+type csvResultWriter struct {
+	w      *csv.Writer
+	schema bq.Schema
+}
+
+func (c *csvResultWriter) Write(row []bq.Value) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		var field *bq.FieldSchema
+		if i < len(c.schema) {
+			field = c.schema[i]
+		}
+
+		switch v := normalizeResultValue(field, v).(type) {
+		case nil:
+			record[i] = ""
+		case string:
+			record[i] = v
+		case map[string]any, []any:
+			// RECORD and REPEATED columns are written as JSON.
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			record[i] = string(b)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvResultWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonResultWriter struct {
+	enc    *json.Encoder
+	schema bq.Schema
+}
+
+func (j *jsonResultWriter) Write(row []bq.Value) error {
+	return j.enc.Encode(recordToMap(j.schema, row))
+}
+
+func (j *jsonResultWriter) Flush() error {
+	return nil
+}
+
+func recordToMap(schema bq.Schema, row []bq.Value) map[string]any {
+	result := make(map[string]any, len(row))
+	for i, v := range row {
+		if i >= len(schema) {
+			break
+		}
+		result[schema[i].Name] = normalizeResultValue(schema[i], v)
+	}
+	return result
+}
+
+// normalizeResultValue converts the value into the representation which is the same as the BigQuery console.
+func normalizeResultValue(field *bq.FieldSchema, v bq.Value) any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []bq.Value:
+		if field != nil && field.Type == bq.RecordFieldType && !field.Repeated {
+			return recordToMap(field.Schema, v)
+		}
+		// The element of REPEATED column has the same schema except for the mode.
+		var elemField *bq.FieldSchema
+		if field != nil {
+			f := *field
+			f.Repeated = false
+			elemField = &f
+		}
+		result := make([]any, len(v))
+		for i, e := range v {
+			result[i] = normalizeResultValue(elemField, e)
+		}
+		return result
+	case *big.Rat:
+		if field != nil && field.Type == bq.BigNumericFieldType {
+			return bq.BigNumericString(v)
+		}
+		return bq.NumericString(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	default:
+		if s, ok := v.(fmt.Stringer); ok {
+			return s.String()
+		}
+		return v
+	}
+}
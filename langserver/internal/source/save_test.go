@@ -0,0 +1,111 @@
+package source_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+)
+
+func TestResultWriter(t *testing.T) {
+	schema := bq.Schema{
+		{Name: "id", Type: bq.IntegerFieldType},
+		{Name: "name", Type: bq.StringFieldType},
+		{Name: "price", Type: bq.NumericFieldType},
+		{
+			Name: "tags",
+			Type: bq.RecordFieldType,
+			Schema: bq.Schema{
+				{Name: "key", Type: bq.StringFieldType},
+			},
+			Repeated: true,
+		},
+	}
+	rows := [][]bq.Value{
+		{int64(1), "foo, bar", big.NewRat(3, 2), []bq.Value{[]bq.Value{"a"}}},
+		{int64(2), nil, nil, []bq.Value{}},
+	}
+
+	tests := map[string]struct {
+		format source.ResultFormat
+
+		expect string
+	}{
+		"csv": {
+			format: source.ResultFormatCSV,
+			expect: "id,name,price,tags\n" +
+				"1,\"foo, bar\",1.500000000,\"[{\"\"key\"\":\"\"a\"\"}]\"\n" +
+				"2,,,[]\n",
+		},
+		"json": {
+			format: source.ResultFormatJSON,
+			expect: `{"id":1,"name":"foo, bar","price":"1.500000000","tags":[{"key":"a"}]}` + "\n" +
+				`{"id":2,"name":null,"price":null,"tags":[]}` + "\n",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := source.NewResultWriter(&buf, tt.format, schema)
+			if err != nil {
+				t.Fatalf("failed to NewResultWriter: %v", err)
+			}
+			for _, row := range rows {
+				if err := w.Write(row); err != nil {
+					t.Fatalf("failed to Write: %v", err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("failed to Flush: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.expect, buf.String()); diff != "" {
+				t.Errorf("ResultWriter output diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResultFormatFromPath(t *testing.T) {
+	tests := map[string]struct {
+		path string
+
+		expect    source.ResultFormat
+		expectErr bool
+	}{
+		"csv": {
+			path:   "/tmp/result.csv",
+			expect: source.ResultFormatCSV,
+		},
+		"newline-delimited json": {
+			path:   "/tmp/result.ndjson",
+			expect: source.ResultFormatJSON,
+		},
+		"parquet is not supported": {
+			path:      "/tmp/result.parquet",
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got, err := source.ResultFormatFromPath(tt.path)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("ResultFormatFromPath should return error, but got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expect {
+				t.Errorf("ResultFormatFromPath expect %s, but got %s", tt.expect, got)
+			}
+		})
+	}
+}
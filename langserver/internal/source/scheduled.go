@@ -0,0 +1,20 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// ValidateScheduledQuery reports the errors of the file as the scheduled query of the Data Transfer Service.
+// When withDestination is true, the query result is written into the destination table.
+func (p *Project) ValidateScheduledQuery(uri string, withDestination bool) ([]file.Error, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+
+	errs := append([]file.Error{}, parsedFile.Errors...)
+	return append(errs, parsedFile.ScheduledQueryErrors(withDestination)...), nil
+}
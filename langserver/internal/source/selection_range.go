@@ -0,0 +1,80 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// SelectionRanges returns the selection range for each position.
+// The range grows along the AST node hierarchy, e.g. identifier, expression, SELECT item, clause and statement.
+func (p *Project) SelectionRanges(uri string, positions []lsp.Position) ([]lsp.SelectionRange, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+
+	result := make([]lsp.SelectionRange, len(positions))
+	for i, position := range positions {
+		result[i] = selectionRange(parsedFile, position)
+	}
+	return result, nil
+}
+
+func selectionRange(parsedFile file.ParsedFile, position lsp.Position) lsp.SelectionRange {
+	// the client requires the range which contains the position even if no node is found
+	result := &lsp.SelectionRange{Range: lsp.Range{Start: position, End: position}}
+	if parsedFile.Node == nil {
+		return *result
+	}
+
+	termOffset := parsedFile.TermOffset(position)
+
+	// ast.Walk visits the parent before its children, so the ranges are ordered from the outermost.
+	ranges := make([]lsp.Range, 0)
+	ast.Walk(parsedFile.Node, func(n ast.Node) error {
+		if n == nil {
+			return nil
+		}
+		lRange := n.ParseLocationRange()
+		if lRange == nil {
+			return nil
+		}
+		if termOffset < lRange.Start().ByteOffset() || lRange.End().ByteOffset() < termOffset {
+			return nil
+		}
+		rng, ok := parsedFile.PositionRange(lRange)
+		if !ok {
+			return nil
+		}
+		// skip the same range and the sibling which touches the position
+		if len(ranges) > 0 && (ranges[len(ranges)-1] == rng || !rangeContains(ranges[len(ranges)-1], rng)) {
+			return nil
+		}
+		ranges = append(ranges, rng)
+		return nil
+	})
+
+	var parent *lsp.SelectionRange
+	for _, rng := range ranges {
+		parent = &lsp.SelectionRange{Range: rng, Parent: parent}
+	}
+	if parent == nil {
+		return *result
+	}
+	return *parent
+}
+
+func rangeContains(outer, inner lsp.Range) bool {
+	return !positionLess(inner.Start, outer.Start) && !positionLess(outer.End, inner.End)
+}
+
+func positionLess(a, b lsp.Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Character < b.Character
+}
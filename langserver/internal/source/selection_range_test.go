@@ -0,0 +1,78 @@
+package source_test
+
+import (
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_SelectionRanges(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+
+		expectRanges []lsp.Range
+	}{
+		"expand from identifier to statement": {
+			files: map[string]string{
+				"file1.sql": "SELECT i|d + 1 AS next_id FROM `project.dataset.table`",
+			},
+			expectRanges: []lsp.Range{
+				// identifier
+				{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 9}},
+				// expression
+				{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 13}},
+				// SELECT item
+				{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 24}},
+				// statement
+				{Start: lsp.Position{Line: 0, Character: 0}, End: lsp.Position{Line: 0, Character: 53}},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.SelectionRanges(path, []lsp.Position{position})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("SelectionRanges should return 1 range, got %d", len(got))
+			}
+
+			gotRanges := make([]lsp.Range, 0)
+			for r := &got[0]; r != nil; r = r.Parent {
+				gotRanges = append(gotRanges, r.Range)
+			}
+			if diff := cmp.Diff(tt.expectRanges, gotRanges); diff != "" {
+				t.Errorf("project.SelectionRanges result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+const maxWorkspaceSymbols = 100
+
+// WorkspaceSymbols returns the tables of the default project and the CTEs in the workspace
+// whose names contain the query.
+func (p *Project) WorkspaceSymbols(ctx context.Context, query string, limit int) ([]lsp.SymbolInformation, error) {
+	if limit <= 0 {
+		limit = maxWorkspaceSymbols
+	}
+	query = strings.ToLower(query)
+
+	result := make([]lsp.SymbolInformation, 0)
+
+	cteSymbols, err := p.cteSymbols(query)
+	if err != nil {
+		p.logger.Debugf("failed to list CTEs: %v", err)
+	}
+	result = append(result, cteSymbols...)
+	if len(result) >= limit {
+		return result[:limit], nil
+	}
+
+	tableSymbols, err := p.tableSymbols(ctx, query, limit-len(result))
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, tableSymbols...)
+
+	return result, nil
+}
+
+func (p *Project) cteSymbols(query string) ([]lsp.SymbolInformation, error) {
+	srcs, err := p.workspaceSQLFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(srcs))
+	for path := range srcs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	result := make([]lsp.SymbolInformation, 0)
+	for _, path := range paths {
+		// The CTEs are found from the cached parse of the file, which doesn't need the analysis.
+		node, ok := srcs[path].Node.(ast.ScriptNode)
+		if !ok || node == nil {
+			continue
+		}
+		parsedFile := file.ParsedFile{URI: path, Src: srcs[path].RawText, Node: node}
+
+		for _, entry := range file.ListAstNode[*ast.WithClauseEntryNode](parsedFile.Node) {
+			name := entry.Alias().Name()
+			if !strings.Contains(strings.ToLower(name), query) {
+				continue
+			}
+			rng, ok := parsedFile.PositionRange(entry.Alias().ParseLocationRange())
+			if !ok {
+				continue
+			}
+			result = append(result, lsp.SymbolInformation{
+				Name: name,
+				Kind: lsp.SKStruct,
+				Location: lsp.Location{
+					URI:   lsp.DocumentURI(fmt.Sprintf("file://%s", path)),
+					Range: rng,
+				},
+				ContainerName: path,
+			})
+		}
+	}
+	return result, nil
+}
+
+func (p *Project) tableSymbols(ctx context.Context, query string, limit int) ([]lsp.SymbolInformation, error) {
+	result := make([]lsp.SymbolInformation, 0)
+	if limit <= 0 {
+		return result, nil
+	}
+
+	projectID := p.bqClient.GetDefaultProject()
+	datasets, err := p.bqClient.ListDatasets(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list datasets: %w", err)
+	}
+
+	for _, dataset := range datasets {
+		// The tables of the following datasets are not listed after the request is canceled.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		tables, err := p.bqClient.ListTables(ctx, projectID, dataset.DatasetID)
+		if err != nil {
+			p.logger.Debugf("failed to list tables of %s: %v", dataset.DatasetID, err)
+			continue
+		}
+
+		for _, table := range tables {
+			name := fmt.Sprintf("%s.%s", table.DatasetID, table.TableID)
+			if !strings.Contains(strings.ToLower(name), query) {
+				continue
+			}
+			result = append(result, lsp.SymbolInformation{
+				Name: table.TableID,
+				Kind: lsp.SKClass,
+				Location: lsp.Location{
+					URI: lsp.NewTableVirtualTextDocumentURI(table.ProjectID, table.DatasetID, table.TableID),
+				},
+				ContainerName: fmt.Sprintf("%s.%s", table.ProjectID, table.DatasetID),
+			})
+			if len(result) >= limit {
+				return result, nil
+			}
+		}
+	}
+	return result, nil
+}
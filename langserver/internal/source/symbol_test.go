@@ -0,0 +1,106 @@
+package source_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_WorkspaceSymbols(t *testing.T) {
+	rootPath := t.TempDir()
+	path := filepath.Join(rootPath, "query.sql")
+	if err := os.WriteFile(path, []byte("WITH user_data AS (SELECT 1 AS id)\nSELECT * FROM user_data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	bqClient.EXPECT().GetDefaultProject().Return("project").MinTimes(0)
+	bqClient.EXPECT().ListDatasets(gomock.Any(), "project").Return([]*bq.Dataset{
+		{ProjectID: "project", DatasetID: "dataset"},
+	}, nil).MinTimes(0)
+	bqClient.EXPECT().ListTables(gomock.Any(), "project", "dataset").Return([]*bq.Table{
+		{ProjectID: "project", DatasetID: "dataset", TableID: "users"},
+		{ProjectID: "project", DatasetID: "dataset", TableID: "orders"},
+	}, nil).MinTimes(0)
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+	p := source.NewProjectWithBQClient(rootPath, bqClient, logger)
+
+	got, err := p.WorkspaceSymbols(context.Background(), "USER", 0)
+	if err != nil {
+		t.Fatalf("failed to WorkspaceSymbols: %v", err)
+	}
+
+	expect := []lsp.SymbolInformation{
+		{
+			Name: "user_data",
+			Kind: lsp.SKStruct,
+			Location: lsp.Location{
+				URI: lsp.DocumentURI("file://" + path),
+				Range: lsp.Range{
+					Start: lsp.Position{Line: 0, Character: 5},
+					End:   lsp.Position{Line: 0, Character: 14},
+				},
+			},
+			ContainerName: path,
+		},
+		{
+			Name: "users",
+			Kind: lsp.SKClass,
+			Location: lsp.Location{
+				URI: lsp.NewTableVirtualTextDocumentURI("project", "dataset", "users"),
+			},
+			ContainerName: "project.dataset",
+		},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("WorkspaceSymbols result diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestProject_WorkspaceSymbolsStopsAtLimit(t *testing.T) {
+	rootPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rootPath, "query.sql"), []byte("WITH user_data AS (SELECT 1 AS id)\nSELECT * FROM user_data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	bqClient.EXPECT().GetDefaultProject().Return("project").MinTimes(0)
+	bqClient.EXPECT().ListDatasets(gomock.Any(), "project").Return([]*bq.Dataset{
+		{ProjectID: "project", DatasetID: "dataset1"},
+		{ProjectID: "project", DatasetID: "dataset2"},
+	}, nil).Times(1)
+	bqClient.EXPECT().ListTables(gomock.Any(), "project", "dataset1").Return([]*bq.Table{
+		{ProjectID: "project", DatasetID: "dataset1", TableID: "users"},
+	}, nil).Times(1)
+	// dataset2 is not listed because the limit is reached.
+	p := source.NewProjectWithBQClient(rootPath, bqClient, logrus.New())
+
+	got, err := p.WorkspaceSymbols(context.Background(), "user", 2)
+	if err != nil {
+		t.Fatalf("failed to WorkspaceSymbols: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("WorkspaceSymbols should return 2 symbols, got %d", len(got))
+	}
+
+	// The CTEs fill the limit, so the API is not called.
+	got, err = p.WorkspaceSymbols(context.Background(), "user", 1)
+	if err != nil {
+		t.Fatalf("failed to WorkspaceSymbols: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "user_data" {
+		t.Errorf("WorkspaceSymbols should return the CTE, got %v", got)
+	}
+}
@@ -0,0 +1,257 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// GenerateTestScaffold returns the SQL which tests the query at position without the production data.
+// The referenced tables are replaced with the WITH queries of the literal rows which match their schemas,
+// and the result of the query is compared with the expected rows, whose columns are the output columns of the query.
+func (p *Project) GenerateTestScaffold(ctx context.Context, uri string, position lsp.Position) ([]lsp.MarkedString, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+
+	termOffset := parsedFile.TermOffset(position)
+	stmt, ok := parsedFile.FindTargetStatementNode(termOffset)
+	if !ok {
+		return nil, fmt.Errorf("statement is not found at the position")
+	}
+	if _, ok := stmt.(*ast.QueryStatementNode); !ok {
+		return nil, fmt.Errorf("the statement is not a query")
+	}
+	output, ok := parsedFile.FindTargetAnalyzeOutput(termOffset)
+	if !ok {
+		return nil, fmt.Errorf("failed to analyze the statement")
+	}
+	outputStmt, ok := output.Statement().(outputColumnsStatement)
+	if !ok {
+		return nil, fmt.Errorf("the statement doesn't output columns")
+	}
+
+	fixtures, err := p.tableFixtures(ctx, parsedFile, stmt)
+	if err != nil {
+		return nil, err
+	}
+	query, err := replaceTables(parsedFile, stmt, fixtures)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(bq.Schema, 0, len(outputStmt.OutputColumnList()))
+	for _, c := range outputStmt.OutputColumnList() {
+		expected = append(expected, outputFieldSchema(c.Name(), c.Column().Type()))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("-- the fixtures of the referenced tables\nWITH ")
+	for _, f := range fixtures {
+		fmt.Fprintf(&sb, "%s AS (\n  %s\n),\n", f.name, literalRow(f.schema))
+	}
+	fmt.Fprintf(&sb, "expected AS (\n  %s\n),\n", literalRow(expected))
+	fmt.Fprintf(&sb, "actual AS (\n  %s\n)\n", strings.ReplaceAll(query, "\n", "\n  "))
+	sb.WriteString("-- the expected rows which are missing, and the rows which are not expected\n")
+	sb.WriteString("SELECT 'missing' AS diff, * FROM (SELECT * FROM expected EXCEPT DISTINCT SELECT * FROM actual)\n")
+	sb.WriteString("UNION ALL\n")
+	sb.WriteString("SELECT 'unexpected' AS diff, * FROM (SELECT * FROM actual EXCEPT DISTINCT SELECT * FROM expected)\n")
+	return []lsp.MarkedString{{Language: "sql", Value: sb.String()}}, nil
+}
+
+// tableFixture is the WITH query which replaces the table.
+type tableFixture struct {
+	name   string
+	table  string
+	schema bq.Schema
+}
+
+// tableFixtures lists the fixtures of the tables referenced in stmt in the order of their first references.
+// The fixture is named after the last name of the table path, so that the columns qualified by the table name keep working.
+func (p *Project) tableFixtures(ctx context.Context, parsedFile file.ParsedFile, stmt ast.StatementNode) ([]tableFixture, error) {
+	cteNames := make(map[string]struct{})
+	for _, entry := range file.ListAstNode[*ast.WithClauseEntryNode](parsedFile.Node) {
+		cteNames[strings.ToLower(entry.Alias().Name())] = struct{}{}
+	}
+
+	result := make([]tableFixture, 0)
+	seen := make(map[string]struct{})
+	var err error
+	ast.Walk(stmt, func(n ast.Node) error {
+		table, ok := n.(*ast.TablePathExpressionNode)
+		if !ok || table.PathExpr() == nil || err != nil {
+			return nil
+		}
+		name, ok := file.CreateTableNameFromTablePathExpressionNode(table)
+		if !ok {
+			return nil
+		}
+		if _, ok := cteNames[strings.ToLower(name)]; ok {
+			return nil
+		}
+		if _, ok := seen[name]; ok {
+			return nil
+		}
+		seen[name] = struct{}{}
+
+		metadata, mErr := p.analyzer.GetTableMetadataFromPath(ctx, name)
+		if mErr != nil {
+			err = fmt.Errorf("failed to get the schema of %s: %w", name, mErr)
+			return nil
+		}
+		names := table.PathExpr().Names()
+		result = append(result, tableFixture{
+			name:   fixtureName(names[len(names)-1].Name(), result),
+			table:  name,
+			schema: metadata.Schema,
+		})
+		return nil
+	})
+	return result, err
+}
+
+// fixtureName makes the identifier of the WITH query from the table name, which doesn't conflict with the other fixtures.
+func fixtureName(tableName string, fixtures []tableFixture) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 0x80 && isIdentifierByte(byte(r)) {
+			return r
+		}
+		return '_'
+	}, tableName)
+	if name == "" || ('0' <= name[0] && name[0] <= '9') {
+		name = "_" + name
+	}
+
+	candidate := name
+	for i := 2; ; i++ {
+		conflict := false
+		for _, f := range fixtures {
+			if strings.EqualFold(f.name, candidate) {
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+}
+
+// replaceTables returns the SQL of stmt whose table references are replaced with the fixtures.
+func replaceTables(parsedFile file.ParsedFile, stmt ast.StatementNode, fixtures []tableFixture) (string, error) {
+	stmtRange, ok := parsedFile.PositionRange(stmt.ParseLocationRange())
+	if !ok {
+		return "", fmt.Errorf("failed to find the range of the statement")
+	}
+	start, end := parsedFile.SrcOffset(stmtRange.Start), parsedFile.SrcOffset(stmtRange.End)
+
+	type replacement struct {
+		start, end int
+		text       string
+	}
+	replacements := make([]replacement, 0)
+	for _, table := range file.ListAstNode[*ast.TablePathExpressionNode](stmt) {
+		if table.PathExpr() == nil {
+			continue
+		}
+		name, ok := file.CreateTableNameFromTablePathExpressionNode(table)
+		if !ok {
+			continue
+		}
+		for _, f := range fixtures {
+			if f.table != name {
+				continue
+			}
+			rng, ok := parsedFile.PositionRange(table.PathExpr().ParseLocationRange())
+			if !ok {
+				return "", fmt.Errorf("failed to find the range of %s", name)
+			}
+			replacements = append(replacements, replacement{start: parsedFile.SrcOffset(rng.Start), end: parsedFile.SrcOffset(rng.End), text: f.name})
+			break
+		}
+	}
+
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].start < replacements[j].start })
+	var sb strings.Builder
+	prev := start
+	for _, r := range replacements {
+		sb.WriteString(parsedFile.Src[prev:r.start])
+		sb.WriteString(r.text)
+		prev = r.end
+	}
+	sb.WriteString(parsedFile.Src[prev:end])
+	return sb.String(), nil
+}
+
+// literalRow is the SELECT of a row whose columns are the placeholder literals of the schema.
+// The anonymous output columns like $col1 have no alias, because EXCEPT DISTINCT compares the columns by their positions.
+func literalRow(schema bq.Schema) string {
+	columns := make([]string, 0, len(schema))
+	for _, field := range schema {
+		if field.Name == "" || strings.HasPrefix(field.Name, "$") {
+			columns = append(columns, fieldLiteral(field))
+			continue
+		}
+		columns = append(columns, fmt.Sprintf("%s AS %s", fieldLiteral(field), field.Name))
+	}
+	return "SELECT " + strings.Join(columns, ", ")
+}
+
+// fieldLiteral returns the placeholder literal of the field, which has the same type as the field.
+// The type is either of the table schema like INTEGER or of the analyzed column like INT64.
+func fieldLiteral(field *bq.FieldSchema) string {
+	literal := scalarLiteral(field)
+	if field.Repeated {
+		return "[" + literal + "]"
+	}
+	return literal
+}
+
+func scalarLiteral(field *bq.FieldSchema) string {
+	switch field.Type {
+	case bq.RecordFieldType, "STRUCT":
+		columns := make([]string, 0, len(field.Schema))
+		for _, f := range field.Schema {
+			columns = append(columns, fmt.Sprintf("%s AS %s", fieldLiteral(f), f.Name))
+		}
+		return "STRUCT(" + strings.Join(columns, ", ") + ")"
+	case bq.StringFieldType:
+		return "''"
+	case bq.BytesFieldType:
+		return "b''"
+	case bq.IntegerFieldType, "INT64":
+		return "0"
+	case bq.FloatFieldType, "FLOAT64":
+		return "0.0"
+	case bq.NumericFieldType:
+		return "NUMERIC '0'"
+	case bq.BigNumericFieldType:
+		return "BIGNUMERIC '0'"
+	case bq.BooleanFieldType, "BOOL":
+		return "FALSE"
+	case bq.DateFieldType:
+		return "DATE '1970-01-01'"
+	case bq.DateTimeFieldType:
+		return "DATETIME '1970-01-01 00:00:00'"
+	case bq.TimeFieldType:
+		return "TIME '00:00:00'"
+	case bq.TimestampFieldType:
+		return "TIMESTAMP '1970-01-01 00:00:00+00'"
+	case bq.GeographyFieldType:
+		return "ST_GEOGPOINT(0, 0)"
+	case bq.JSONFieldType:
+		return "JSON '{}'"
+	case bq.IntervalFieldType:
+		return "INTERVAL 0 DAY"
+	}
+	return "NULL"
+}
@@ -0,0 +1,107 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_GenerateTestScaffold(t *testing.T) {
+	const comparison = "-- the expected rows which are missing, and the rows which are not expected\n" +
+		"SELECT 'missing' AS diff, * FROM (SELECT * FROM expected EXCEPT DISTINCT SELECT * FROM actual)\n" +
+		"UNION ALL\n" +
+		"SELECT 'unexpected' AS diff, * FROM (SELECT * FROM actual EXCEPT DISTINCT SELECT * FROM expected)\n"
+
+	tests := map[string]struct {
+		files map[string]string
+
+		expectMarkedStrings []lsp.MarkedString
+		expectErr           bool
+	}{
+		"replace the table with the fixture": {
+			files: map[string]string{
+				"file1.sql": "|SELECT id, name FROM `project.dataset.table` WHERE id > 0",
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "sql",
+					Value: "-- the fixtures of the referenced tables\n" +
+						"WITH table AS (\n  SELECT 0 AS id, '' AS name, [''] AS tags, STRUCT(0 AS a) AS info\n),\n" +
+						"expected AS (\n  SELECT 0 AS id, '' AS name\n),\n" +
+						"actual AS (\n  SELECT id, name FROM table WHERE id > 0\n)\n" +
+						comparison,
+				},
+			},
+		},
+		"keep the WITH queries": {
+			files: map[string]string{
+				"file1.sql": "WITH t AS (SELECT * FROM `project.dataset.table`)\n|SELECT t.id, COUNT(*) FROM t GROUP BY t.id",
+			},
+			expectMarkedStrings: []lsp.MarkedString{
+				{
+					Language: "sql",
+					Value: "-- the fixtures of the referenced tables\n" +
+						"WITH table AS (\n  SELECT 0 AS id, '' AS name, [''] AS tags, STRUCT(0 AS a) AS info\n),\n" +
+						"expected AS (\n  SELECT 0 AS id, 0\n),\n" +
+						"actual AS (\n  WITH t AS (SELECT * FROM table)\n  SELECT t.id, COUNT(*) FROM t GROUP BY t.id\n)\n" +
+						comparison,
+				},
+			},
+		},
+		"not a query": {
+			files: map[string]string{
+				"file1.sql": "|DELETE FROM `project.dataset.table` WHERE id = 1",
+			},
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetDefaultProject().Return("project").MinTimes(0)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{Name: "id", Type: bq.IntegerFieldType},
+					{Name: "name", Type: bq.StringFieldType},
+					{Name: "tags", Type: bq.StringFieldType, Repeated: true},
+					{Name: "info", Type: bq.RecordFieldType, Schema: bq.Schema{
+						{Name: "a", Type: bq.IntegerFieldType},
+					}},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.GenerateTestScaffold(context.Background(), path, position)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("GenerateTestScaffold should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectMarkedStrings, got); diff != "" {
+				t.Errorf("project.GenerateTestScaffold result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
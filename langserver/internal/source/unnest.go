@@ -0,0 +1,156 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/goccy/go-zetasql/ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// UnnestArrayColumn returns the edits which fix the REPEATED column used as the scalar value at position.
+// `, UNNEST(column) AS element` is added to the FROM clause, and the column in the reference is replaced with the element.
+//
+//	SELECT items.name FROM table
+//
+// becomes
+//
+//	SELECT item.name FROM table, UNNEST(items) AS item
+func (p *Project) UnnestArrayColumn(ctx context.Context, uri string, position lsp.Position) ([]lsp.TextEdit, error) {
+	sql := p.cache.Get(uri)
+	if sql == nil {
+		return nil, fmt.Errorf("failed to find document %s", uri)
+	}
+	parsedFile := p.parseFile(uri, sql)
+	if parsedFile.Node == nil {
+		return nil, fmt.Errorf("failed to parse document %s", uri)
+	}
+
+	termOffset := parsedFile.TermOffset(position)
+	selectNode, ok := file.SearchAstNode[*ast.SelectNode](parsedFile.Node, termOffset)
+	if !ok || selectNode.FromClause() == nil {
+		return nil, fmt.Errorf("SELECT with FROM is not found at the position")
+	}
+
+	tables := make([]joinTable, 0)
+	ast.Walk(selectNode.FromClause(), func(n ast.Node) error {
+		node, ok := n.(*ast.TablePathExpressionNode)
+		if !ok {
+			return nil
+		}
+		table, err := p.joinTable(ctx, node)
+		if err != nil {
+			p.logger.Debugf("failed to get the table of FROM: %v", err)
+			return nil
+		}
+		tables = append(tables, table)
+		return nil
+	})
+
+	// the error of the comparison is reported at the start of the expression, so the both operands are candidates.
+	candidates := make([]*ast.PathExpressionNode, 0, 2)
+	if path, ok := file.SearchAstNode[*ast.PathExpressionNode](parsedFile.Node, termOffset); ok {
+		candidates = append(candidates, path)
+	}
+	if binary, ok := file.SearchAstNode[*ast.BinaryExpressionNode](parsedFile.Node, termOffset); ok {
+		for _, operand := range []ast.ExpressionNode{binary.Lhs(), binary.Rhs()} {
+			if path, ok := operand.(*ast.PathExpressionNode); ok {
+				candidates = append(candidates, path)
+			}
+		}
+	}
+
+	for _, path := range candidates {
+		names := make([]string, 0, len(path.Names()))
+		for _, n := range path.Names() {
+			names = append(names, n.Name())
+		}
+		k, ok := repeatedColumnIndex(tables, names)
+		if !ok {
+			continue
+		}
+
+		pathRange, ok := parsedFile.PositionRange(path.ParseLocationRange())
+		if !ok {
+			return nil, fmt.Errorf("failed to find the range of the column")
+		}
+		arrayRange, ok := parsedFile.PositionRange(path.Names()[k].ParseLocationRange())
+		if !ok {
+			return nil, fmt.Errorf("failed to find the range of the column")
+		}
+		fromRange, ok := parsedFile.PositionRange(selectNode.FromClause().ParseLocationRange())
+		if !ok {
+			return nil, fmt.Errorf("failed to find the range of FROM clause")
+		}
+
+		element := elementAlias(names[k], tables)
+		return []lsp.TextEdit{
+			{
+				Range:   lsp.Range{Start: pathRange.Start, End: arrayRange.End},
+				NewText: element,
+			},
+			{
+				Range:   lsp.Range{Start: fromRange.End, End: fromRange.End},
+				NewText: fmt.Sprintf(", UNNEST(%s) AS %s", strings.Join(names[:k+1], "."), element),
+			},
+		}, nil
+	}
+	return nil, fmt.Errorf("REPEATED column is not found at the position")
+}
+
+// repeatedColumnIndex returns the index of the first REPEATED column in names like `table.items.name`.
+func repeatedColumnIndex(tables []joinTable, names []string) (int, bool) {
+	for _, table := range tables {
+		start := 0
+		if len(names) > 1 && strings.EqualFold(names[0], table.name) {
+			start = 1
+		}
+
+		fields := table.schema
+		for i := start; i < len(names); i++ {
+			field, ok := lookupFieldSchemaFold(fields, names[i])
+			if !ok {
+				break
+			}
+			if field.Repeated {
+				return i, true
+			}
+			fields = field.Schema
+		}
+	}
+	return 0, false
+}
+
+// elementAlias names the element of the ARRAY column like `item` for `items`.
+// The name which conflicts with the tables or their columns is suffixed with `_item`.
+func elementAlias(column string, tables []joinTable) string {
+	candidate := strings.TrimSuffix(column, "s")
+	if candidate == "" || candidate == column || aliasConflicts(candidate, tables) {
+		candidate = column + "_item"
+	}
+	return candidate
+}
+
+func aliasConflicts(alias string, tables []joinTable) bool {
+	for _, table := range tables {
+		if strings.EqualFold(table.name, alias) {
+			return true
+		}
+		if _, ok := lookupFieldSchemaFold(table.schema, alias); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupFieldSchemaFold(schema bq.Schema, name string) (*bq.FieldSchema, bool) {
+	for _, f := range schema {
+		if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,115 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/helper"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_UnnestArrayColumn(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+
+		expectEdits []lsp.TextEdit
+		expectErr   bool
+	}{
+		"field of ARRAY of STRUCT": {
+			files: map[string]string{
+				"file1.sql": "SELECT items.|name FROM `project.dataset.table`",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 7}, End: lsp.Position{Line: 0, Character: 12}},
+					NewText: "item",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 46}, End: lsp.Position{Line: 0, Character: 46}},
+					NewText: ", UNNEST(items) AS item",
+				},
+			},
+		},
+		"qualified ARRAY in the comparison": {
+			files: map[string]string{
+				"file1.sql": "SELECT id FROM `project.dataset.table` AS t WHERE |t.tags = \"a\"",
+			},
+			expectEdits: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 50}, End: lsp.Position{Line: 0, Character: 56}},
+					NewText: "tag",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 43}, End: lsp.Position{Line: 0, Character: 43}},
+					NewText: ", UNNEST(t.tags) AS tag",
+				},
+			},
+		},
+		"not ARRAY": {
+			files: map[string]string{
+				"file1.sql": "SELECT |id FROM `project.dataset.table`",
+			},
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(&bq.TableMetadata{
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name:     "tags",
+						Type:     bq.StringFieldType,
+						Repeated: true,
+					},
+					{
+						Name:     "items",
+						Type:     bq.RecordFieldType,
+						Repeated: true,
+						Schema: bq.Schema{
+							{
+								Name: "name",
+								Type: bq.StringFieldType,
+							},
+						},
+					},
+				},
+			}, nil).MinTimes(0)
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			files, path, position, err := helper.GetLspPosition(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for uri, content := range files {
+				p.UpdateFile(uri, content, 1)
+			}
+
+			got, err := p.UnnestArrayColumn(context.Background(), path, position)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("UnnestArrayColumn should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectEdits, got); diff != "" {
+				t.Errorf("project.UnnestArrayColumn result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
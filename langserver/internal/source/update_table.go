@@ -0,0 +1,104 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// TableUpdate is the change of the table metadata. The nil or empty fields are not changed.
+type TableUpdate struct {
+	Description *string
+
+	// Labels are set to the table. The label whose value is empty is deleted.
+	Labels map[string]string
+
+	// ExpirationTime is set to the table. The zero time removes the expiration.
+	ExpirationTime *time.Time
+
+	// ColumnDescriptions are the descriptions of the columns. The key is the column path like `record.field`.
+	ColumnDescriptions map[string]string
+}
+
+// UpdateTable updates the metadata of the table and returns the updated table info.
+func (p *Project) UpdateTable(ctx context.Context, name string, update TableUpdate) ([]lsp.MarkedString, error) {
+	metadata, err := p.analyzer.GetTableMetadataFromPath(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table metadata: %w", err)
+	}
+	projectID, datasetID, tableID, ok := extractTableIDsFromMedatada(metadata)
+	if !ok {
+		return nil, fmt.Errorf("%s can't be updated", name)
+	}
+
+	var toUpdate bq.TableMetadataToUpdate
+	if update.Description != nil {
+		toUpdate.Description = *update.Description
+	}
+	for k, v := range update.Labels {
+		if v == "" {
+			toUpdate.DeleteLabel(k)
+		} else {
+			toUpdate.SetLabel(k, v)
+		}
+	}
+	if update.ExpirationTime != nil {
+		toUpdate.ExpirationTime = *update.ExpirationTime
+		if toUpdate.ExpirationTime.IsZero() {
+			toUpdate.ExpirationTime = bq.NeverExpire
+		}
+	}
+	if len(update.ColumnDescriptions) > 0 {
+		schema, err := updateColumnDescriptions(metadata.Schema, update.ColumnDescriptions)
+		if err != nil {
+			return nil, err
+		}
+		toUpdate.Schema = schema
+	}
+
+	updated, err := p.bqClient.UpdateTableMetadata(ctx, projectID, datasetID, tableID, toUpdate)
+	// The analyses of the statements may have the old schema even when the update fails halfway.
+	p.analyzer.ClearStatementCache()
+	if err != nil {
+		return nil, err
+	}
+	return buildBigQueryTableMetadataMarkedString(updated)
+}
+
+// updateColumnDescriptions returns the copy of the schema whose column descriptions are replaced.
+// The columns which don't exist in the schema are reported, because the API can't add the columns with only the description.
+func updateColumnDescriptions(schema bq.Schema, descriptions map[string]string) (bq.Schema, error) {
+	result := copySchema(schema)
+	notFound := make([]string, 0)
+	for path, description := range descriptions {
+		field, ok := lookupFieldSchema(result, strings.Split(path, "."))
+		if !ok {
+			notFound = append(notFound, path)
+			continue
+		}
+		field.Description = description
+	}
+	if len(notFound) > 0 {
+		sort.Strings(notFound)
+		return nil, fmt.Errorf("columns not found: %s", strings.Join(notFound, ", "))
+	}
+	return result, nil
+}
+
+func copySchema(schema bq.Schema) bq.Schema {
+	if schema == nil {
+		return nil
+	}
+	result := make(bq.Schema, 0, len(schema))
+	for _, f := range schema {
+		field := *f
+		field.Schema = copySchema(f.Schema)
+		result = append(result, &field)
+	}
+	return result
+}
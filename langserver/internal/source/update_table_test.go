@@ -0,0 +1,144 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_UpdateTable(t *testing.T) {
+	description := "new description"
+	expiration := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		update source.TableUpdate
+
+		expectDescription    any
+		expectExpirationTime time.Time
+		expectSchema         bq.Schema
+		expectErr            bool
+	}{
+		"update description": {
+			update: source.TableUpdate{
+				Description: &description,
+			},
+			expectDescription: description,
+		},
+		"remove expiration": {
+			update: source.TableUpdate{
+				ExpirationTime: &time.Time{},
+			},
+			expectExpirationTime: bq.NeverExpire,
+		},
+		"set expiration": {
+			update: source.TableUpdate{
+				ExpirationTime: &expiration,
+			},
+			expectExpirationTime: expiration,
+		},
+		"update column descriptions": {
+			update: source.TableUpdate{
+				ColumnDescriptions: map[string]string{
+					"id":         "id description",
+					"user.email": "email description",
+				},
+			},
+			expectSchema: bq.Schema{
+				{
+					Name:        "id",
+					Type:        bq.IntegerFieldType,
+					Description: "id description",
+				},
+				{
+					Name: "user",
+					Type: bq.RecordFieldType,
+					Schema: bq.Schema{
+						{
+							Name:        "email",
+							Type:        bq.StringFieldType,
+							Description: "email description",
+						},
+					},
+				},
+			},
+		},
+		"column not found": {
+			update: source.TableUpdate{
+				ColumnDescriptions: map[string]string{
+					"not_found": "description",
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bqClient := mock_bigquery.NewMockClient(ctrl)
+			metadata := &bq.TableMetadata{
+				FullID: "project:dataset.table",
+				Schema: bq.Schema{
+					{
+						Name: "id",
+						Type: bq.IntegerFieldType,
+					},
+					{
+						Name: "user",
+						Type: bq.RecordFieldType,
+						Schema: bq.Schema{
+							{
+								Name: "email",
+								Type: bq.StringFieldType,
+							},
+						},
+					},
+				},
+			}
+			bqClient.EXPECT().GetTableMetadata(gomock.Any(), "project", "dataset", "table").Return(metadata, nil)
+
+			var got bq.TableMetadataToUpdate
+			if !tt.expectErr {
+				bqClient.EXPECT().UpdateTableMetadata(gomock.Any(), "project", "dataset", "table", gomock.Any()).DoAndReturn(
+					func(ctx context.Context, projectID, datasetID, tableID string, update bq.TableMetadataToUpdate) (*bq.TableMetadata, error) {
+						got = update
+						return metadata, nil
+					})
+			}
+			p := source.NewProjectWithBQClient("/", bqClient, logrus.New())
+
+			_, err := p.UpdateTable(context.Background(), "project.dataset.table", tt.update)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.expectDescription, got.Description); diff != "" {
+				t.Errorf("description diff (-expect, +got)\n%s", diff)
+			}
+			if !tt.expectExpirationTime.Equal(got.ExpirationTime) {
+				t.Errorf("expiration time: expect %v, but got %v", tt.expectExpirationTime, got.ExpirationTime)
+			}
+			if diff := cmp.Diff(tt.expectSchema, got.Schema); diff != "" {
+				t.Errorf("schema diff (-expect, +got)\n%s", diff)
+			}
+
+			// the cached metadata must not be changed before the update succeeds.
+			if metadata.Schema[0].Description != "" {
+				t.Errorf("the original schema is changed: %v", metadata.Schema[0].Description)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package source
+
+import (
+	"strings"
+
+	"github.com/goccy/go-zetasql/ast"
+	rast "github.com/goccy/go-zetasql/resolved_ast"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+// termDocumentForVariable shows the DECLARE statement when the term is the script variable.
+func (p *Project) termDocumentForVariable(parsedFile file.ParsedFile, position lsp.Position) ([]lsp.MarkedString, bool) {
+	decl, _, ok := variableDeclarationAt(parsedFile, position)
+	if !ok {
+		return nil, false
+	}
+	sql, ok := parsedFile.ExtractSQL(decl.ParseLocationRange())
+	if !ok {
+		return nil, false
+	}
+	return []lsp.MarkedString{
+		{
+			Language: "sql",
+			Value:    sql,
+		},
+	}, true
+}
+
+// variableDeclarationAt finds the DECLARE statement of the script variable referenced at position.
+// The reference of the variable is replaced with the dummy value before the analysis, so it is searched in the original source.
+func variableDeclarationAt(parsedFile file.ParsedFile, position lsp.Position) (*ast.VariableDeclarationNode, *ast.IdentifierNode, bool) {
+	name, ok := identifierAt(parsedFile.Src, parsedFile.SrcOffset(position))
+	if !ok {
+		return nil, nil, false
+	}
+	decl, identifier, ok := parsedFile.FindVariableDeclaration(name)
+	if !ok {
+		return nil, nil, false
+	}
+
+	termOffset := parsedFile.TermOffset(position)
+	// the column which has the same name as the variable hides it
+	if output, ok := parsedFile.FindTargetAnalyzeOutput(termOffset); ok {
+		if ref, ok := file.SearchResolvedAstNode[*rast.ColumnRefNode](output, termOffset); ok && strings.EqualFold(ref.Column().Name(), name) {
+			return nil, nil, false
+		}
+	}
+	return decl, identifier, true
+}
+
+// identifierAt returns the identifier which contains offset.
+// The field like `t.name` is not the variable, so the identifier after the dot is ignored.
+func identifierAt(src string, offset int) (string, bool) {
+	if offset < 0 || len(src) < offset {
+		return "", false
+	}
+	start, end := offset, offset
+	for start > 0 && isIdentifierByte(src[start-1]) {
+		start--
+	}
+	for end < len(src) && isIdentifierByte(src[end]) {
+		end++
+	}
+	if start == end || ('0' <= src[start] && src[start] <= '9') {
+		return "", false
+	}
+	if start > 0 && src[start-1] == '.' {
+		return "", false
+	}
+	return src[start:end], true
+}
+
+func isIdentifierByte(b byte) bool {
+	return b == '_' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
@@ -0,0 +1,115 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/kitagry/bqls/langserver/internal/cache"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+)
+
+const maxWorkspaceAnalysisConcurrency = 4
+
+// AnalyzeWorkspace analyzes all .sql files under the rootPath and returns the errors of each file.
+func (p *Project) AnalyzeWorkspace(ctx context.Context) (map[string][]file.Error, error) {
+	return p.AnalyzeWorkspaceWithProgress(ctx, nil)
+}
+
+// AnalyzeWorkspaceWithProgress is the same as AnalyzeWorkspace, but calls progress each time a file is analyzed.
+// progress is called from multiple goroutines, but never concurrently.
+func (p *Project) AnalyzeWorkspaceWithProgress(ctx context.Context, progress func(done, total int)) (map[string][]file.Error, error) {
+	srcs, err := p.workspaceSQLFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.analyzeFiles(ctx, srcs, progress), nil
+}
+
+// AnalyzeFileOnDisk analyzes the file which is changed outside of the editor.
+// Opened documents are skipped because they are diagnosed on each change.
+// When the file is deleted, the result has the path with no errors so that the diagnostics are cleared.
+func (p *Project) AnalyzeFileOnDisk(ctx context.Context, path string) (map[string][]file.Error, error) {
+	if filepath.Ext(path) != ".sql" {
+		return nil, nil
+	}
+	if sql := p.cache.Get(path); sql != nil {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string][]file.Error{path: nil}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return p.analyzeFiles(ctx, map[string]*cache.SQL{path: p.cache.GetClosed(path, string(b))}, nil), nil
+}
+
+// AnalyzeFiles analyzes the files on disk and returns the errors of each file.
+func (p *Project) AnalyzeFiles(ctx context.Context, paths []string) (map[string][]file.Error, error) {
+	srcs := make(map[string]*cache.SQL, len(paths))
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		srcs[path] = p.cache.GetClosed(path, string(b))
+	}
+
+	return p.analyzeFiles(ctx, srcs, nil), nil
+}
+
+// AnalyzeSource analyzes the SQL which is not saved in the file like stdin. path is used as the file of the SQL.
+func (p *Project) AnalyzeSource(ctx context.Context, path, src string) map[string][]file.Error {
+	return p.analyzeFiles(ctx, map[string]*cache.SQL{path: p.cache.GetClosed(path, src)}, nil)
+}
+
+// analyzeFiles analyzes the snapshots of the files. The opened documents are preferred because they may be changed meanwhile.
+func (p *Project) analyzeFiles(ctx context.Context, srcs map[string]*cache.SQL, progress func(done, total int)) map[string][]file.Error {
+	paths := make(chan string)
+	result := make(map[string][]file.Error, len(srcs))
+	var mu sync.Mutex
+
+	var wg sync.WaitGroup
+	for i := 0; i < maxWorkspaceAnalysisConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				sql := p.cache.Get(path)
+				if sql == nil {
+					sql = srcs[path]
+				}
+				parsedFile := p.parseFile(path, sql)
+				errs := p.fileErrors(ctx, parsedFile)
+
+				mu.Lock()
+				result[path] = errs
+				if progress != nil {
+					progress(len(result), len(srcs))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	func() {
+		defer close(paths)
+		for path := range srcs {
+			select {
+			case paths <- path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	return result
+}
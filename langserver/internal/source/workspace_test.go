@@ -0,0 +1,132 @@
+package source_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProject_AnalyzeWorkspace(t *testing.T) {
+	rootPath := t.TempDir()
+	files := map[string]string{
+		"valid.sql":        "SELECT 1",
+		"nested/error.sql": "SELECT * FROM",
+		".hidden/skip.sql": "SELECT * FROM",
+		"README.md":        "SELECT * FROM",
+	}
+	for path, content := range files {
+		path = filepath.Join(rootPath, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+	p := source.NewProjectWithBQClient(rootPath, bqClient, logger)
+
+	got, err := p.AnalyzeWorkspace(context.Background())
+	if err != nil {
+		t.Fatalf("failed to AnalyzeWorkspace: %v", err)
+	}
+
+	paths := make([]string, 0, len(got))
+	for path := range got {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	expectPaths := []string{
+		filepath.Join(rootPath, "nested", "error.sql"),
+		filepath.Join(rootPath, "valid.sql"),
+	}
+	if diff := cmp.Diff(expectPaths, paths); diff != "" {
+		t.Errorf("AnalyzeWorkspace paths diff (-expect, +got)\n%s", diff)
+	}
+
+	if errs := got[filepath.Join(rootPath, "valid.sql")]; len(errs) > 0 {
+		t.Errorf("valid.sql should have no errors, but got %v", errs)
+	}
+	if errs := got[filepath.Join(rootPath, "nested", "error.sql")]; len(errs) == 0 {
+		t.Errorf("error.sql should have errors")
+	}
+}
+
+func TestProject_AnalyzeWorkspaceWithProgress(t *testing.T) {
+	rootPath := t.TempDir()
+	for _, name := range []string{"a.sql", "b.sql", "c.sql"} {
+		if err := os.WriteFile(filepath.Join(rootPath, name), []byte("SELECT 1"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	p := source.NewProjectWithBQClient(rootPath, bqClient, logrus.New())
+
+	var got [][2]int
+	_, err := p.AnalyzeWorkspaceWithProgress(context.Background(), func(done, total int) {
+		got = append(got, [2]int{done, total})
+	})
+	if err != nil {
+		t.Fatalf("failed to AnalyzeWorkspaceWithProgress: %v", err)
+	}
+
+	expect := [][2]int{{1, 3}, {2, 3}, {3, 3}}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("progress diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestProject_AnalyzeFileOnDisk(t *testing.T) {
+	rootPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	logger := logrus.New()
+	p := source.NewProjectWithBQClient(rootPath, bqClient, logger)
+
+	path := filepath.Join(rootPath, "deleted.sql")
+	got, err := p.AnalyzeFileOnDisk(context.Background(), path)
+	if err != nil {
+		t.Fatalf("failed to AnalyzeFileOnDisk: %v", err)
+	}
+	errs, ok := got[path]
+	if !ok || len(errs) > 0 {
+		t.Errorf("deleted file should have empty errors to clear the diagnostics, but got %v", got)
+	}
+}
+
+func TestProject_AnalyzeSource(t *testing.T) {
+	rootPath := t.TempDir()
+	// the file doesn't exist on disk, like the SQL read from stdin
+	path := filepath.Join(rootPath, "stdin.sql")
+
+	ctrl := gomock.NewController(t)
+	bqClient := mock_bigquery.NewMockClient(ctrl)
+	p := source.NewProjectWithBQClient(rootPath, bqClient, logrus.New())
+
+	got := p.AnalyzeSource(context.Background(), path, "SELECT * FROM")
+	if len(got) != 1 {
+		t.Fatalf("AnalyzeSource should return the errors of the path, but got %v", got)
+	}
+	if errs := got[path]; len(errs) == 0 {
+		t.Errorf("stdin.sql should have errors")
+	}
+
+	got = p.AnalyzeSource(context.Background(), path, "SELECT 1")
+	if errs := got[path]; len(errs) > 0 {
+		t.Errorf("stdin.sql should have no errors, but got %v", errs)
+	}
+}
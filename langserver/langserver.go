@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/kitagry/bqls/langserver/internal/cache"
 	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/metrics"
 	"github.com/kitagry/bqls/langserver/internal/source"
 	"github.com/sirupsen/logrus"
 	"github.com/sourcegraph/jsonrpc2"
@@ -18,35 +22,58 @@ type Handler struct {
 	conn   *jsonrpc2.Conn
 	logger *logrus.Logger
 
+	// project is the project of the root path.
 	project *source.Project
+	// workspaceProjects has the projects of the workspace folders keyed by their paths.
+	workspaceProjects     map[string]*source.Project
+	workspaceProjectsLock sync.RWMutex
+	// jobProjects has the projects which run the query jobs keyed by the job IDs.
+	jobProjects *cache.LRU[string, *source.Project]
+
+	// lastJobURI is the virtual text document of the last query executed by executeQuery.
+	lastJobURI     lsp.DocumentURI
+	lastJobURILock sync.RWMutex
 
 	diagnosticRequest chan lsp.DocumentURI
 	dryrunRequest     chan lsp.DocumentURI
 	initializeParams  lsp.InitializeParams[InitializeOption]
+	// positionEncoding is the encoding of the character offsets negotiated with the client.
+	positionEncoding lsp.PositionEncodingKind
+
+	// trace is the value set by the client. The logs are forwarded to the client unless it is off.
+	trace     lsp.Trace
+	traceLock sync.RWMutex
+	// levelBeforeVerbose is the log level to restore when the trace is changed from verbose.
+	levelBeforeVerbose logrus.Level
+
+	// shutdownReceived is true after the shutdown request. Then the requests except exit are rejected.
+	shutdownReceived atomic.Bool
+	shutdownOnce     sync.Once
+	shutdownErr      error
 }
 
 var _ jsonrpc2.Handler = (*Handler)(nil)
 
-func NewHandler(isDebug bool) *Handler {
-	logger := logrus.New()
-	logger.Out = os.Stderr
-	if isDebug {
-		logger.SetLevel(logrus.DebugLevel)
-	} else {
-		logger.SetLevel(logrus.InfoLevel)
-	}
-
+func NewHandler(logger *logrus.Logger) *Handler {
 	handler := &Handler{
 		logger:            logger,
+		workspaceProjects: make(map[string]*source.Project),
+		jobProjects:       cache.NewLRU[string, *source.Project](maxJobProjects),
 		diagnosticRequest: make(chan lsp.DocumentURI, 3),
 		dryrunRequest:     make(chan lsp.DocumentURI, 3),
 	}
+	logger.AddHook(&traceHook{h: handler})
+	metrics.Default.RegisterCacheStats(handler, handler.cacheStats)
 	go handler.scheduleDiagnostics()
 	go handler.scheduleDryRun()
 	return handler
 }
 
 func (h *Handler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	start := time.Now()
+	defer func() {
+		metrics.Default.ObserveRequest(req.Method, time.Since(start))
+	}()
 	defer func() {
 		err := recover()
 		if err != nil {
@@ -57,44 +84,132 @@ func (h *Handler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2
 }
 
 func (h *Handler) Close() error {
-	var errs []error
+	errs := []error{h.shutdown(context.Background())}
 	if h.conn != nil {
-		errs = append(errs, h.conn.Close())
-	}
-	if h.project != nil {
-		errs = append(errs, h.project.Close())
+		if err := h.conn.Close(); err != nil && !errors.Is(err, jsonrpc2.ErrClosed) {
+			errs = append(errs, err)
+		}
 	}
-	close(h.diagnosticRequest)
-	close(h.dryrunRequest)
 	return errors.Join(errs...)
 }
 
+// ExitCode returns the exit code of the server process following the LSP spec:
+// 0 when the shutdown request has been received before the exit notification, otherwise 1.
+func (h *Handler) ExitCode() int {
+	if h.shutdownReceived.Load() {
+		return 0
+	}
+	return 1
+}
+
+// shutdown stops the diagnostics, cancels the running query jobs and closes the projects.
+// It is called only once by the shutdown request or Close.
+func (h *Handler) shutdown(ctx context.Context) error {
+	h.shutdownOnce.Do(func() {
+		close(h.diagnosticRequest)
+		close(h.dryrunRequest)
+		metrics.Default.UnregisterCacheStats(h)
+
+		var errs []error
+		for _, p := range h.projects() {
+			if err := p.CancelJobs(ctx); err != nil {
+				errs = append(errs, err)
+			}
+			errs = append(errs, p.Close())
+		}
+		h.shutdownErr = errors.Join(errs...)
+	})
+	return h.shutdownErr
+}
+
+func (h *Handler) handleShutdown(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	h.shutdownReceived.Store(true)
+	if err := h.shutdown(ctx); err != nil {
+		h.logger.Errorf("failed to shutdown: %v", err)
+	}
+	return nil, nil
+}
+
+func (h *Handler) handleExit(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	// Closing the connection makes the server process exit with ExitCode.
+	return nil, conn.Close()
+}
+
+// cacheStats sums up the cache statistics of all projects.
+func (h *Handler) cacheStats() map[string]cache.Stats {
+	result := make(map[string]cache.Stats)
+	for _, p := range h.projects() {
+		for name, s := range p.CacheStats() {
+			result[name] = result[name].Add(s)
+		}
+	}
+	return result
+}
+
 func (h *Handler) handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if h.shutdownReceived.Load() && req.Method != "exit" {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidRequest, Message: "server is shutting down"}
+	}
+
 	switch req.Method {
 	case "initialize":
 		return h.handleInitialize(ctx, conn, req)
 	case "initialized":
-		return
+		return h.handleInitialized(ctx, conn, req)
+	case "shutdown":
+		return h.handleShutdown(ctx, conn, req)
+	case "exit":
+		return h.handleExit(ctx, conn, req)
+	case "$/setTrace":
+		return h.handleSetTrace(ctx, conn, req)
 	case "textDocument/didOpen":
-		return ignoreMiddleware(h.handleTextDocumentDidOpen)(ctx, conn, req)
+		return h.embeddedSQLMiddleware(h.handleTextDocumentDidOpen)(ctx, conn, req)
 	case "textDocument/didChange":
-		return ignoreMiddleware(h.handleTextDocumentDidChange)(ctx, conn, req)
+		return h.embeddedSQLMiddleware(h.handleTextDocumentDidChange)(ctx, conn, req)
 	case "textDocument/didClose":
-		return ignoreMiddleware(h.handleTextDocumentDidClose)(ctx, conn, req)
+		return h.embeddedSQLMiddleware(h.handleTextDocumentDidClose)(ctx, conn, req)
 	case "textDocument/didSave":
 		return ignoreMiddleware(h.handleTextDocumentDidSave)(ctx, conn, req)
 	case "textDocument/formatting":
 		return ignoreMiddleware(h.handleTextDocumentFormatting)(ctx, conn, req)
+	case "textDocument/rangeFormatting":
+		return ignoreMiddleware(h.handleTextDocumentRangeFormatting)(ctx, conn, req)
+	case "textDocument/onTypeFormatting":
+		return ignoreMiddleware(h.handleTextDocumentOnTypeFormatting)(ctx, conn, req)
 	case "textDocument/hover":
-		return ignoreMiddleware(h.handleTextDocumentHover)(ctx, conn, req)
+		return h.embeddedSQLMiddleware(h.handleTextDocumentHover)(ctx, conn, req)
 	case "textDocument/completion":
 		return ignoreMiddleware(h.handleTextDocumentCompletion)(ctx, conn, req)
+	case "completionItem/resolve":
+		return ignoreMiddleware(h.handleCompletionItemResolve)(ctx, conn, req)
+	case "textDocument/definition":
+		return ignoreMiddleware(h.handleTextDocumentDefinition)(ctx, conn, req)
+	case "textDocument/documentHighlight":
+		return ignoreMiddleware(h.handleTextDocumentDocumentHighlight)(ctx, conn, req)
+	case "textDocument/selectionRange":
+		return ignoreMiddleware(h.handleTextDocumentSelectionRange)(ctx, conn, req)
+	case "textDocument/documentLink":
+		return ignoreMiddleware(h.handleTextDocumentDocumentLink)(ctx, conn, req)
 	case "textDocument/codeAction":
 		return h.handleTextDocumentCodeAction(ctx, conn, req)
+	case "workspace/symbol":
+		return h.handleWorkspaceSymbol(ctx, conn, req)
+	case "workspace/didChangeWorkspaceFolders":
+		return h.handleWorkspaceDidChangeWorkspaceFolders(ctx, conn, req)
+	case "workspace/didChangeWatchedFiles":
+		return h.handleWorkspaceDidChangeWatchedFiles(ctx, conn, req)
 	case "workspace/executeCommand":
 		return h.handleWorkspaceExecuteCommand(ctx, conn, req)
 	case "bqls/virtualTextDocument":
 		return h.handleVirtualTextDocument(ctx, conn, req)
+	case "bqls/listDatasets":
+		return h.handleListDatasets(ctx, conn, req)
+	case "bqls/listTables":
+		return h.handleListTables(ctx, conn, req)
+	case "bqls/listJobs":
+		return h.handleListJobs(ctx, conn, req)
+	case "bqls/getJob":
+		return h.handleGetJob(ctx, conn, req)
 	}
 	return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", req.Method)}
 }
@@ -123,6 +238,29 @@ func ignoreMiddleware(next HandleFunc) HandleFunc {
 	}
 }
 
+// embeddedSQLMiddleware is ignoreMiddleware which also accepts the files whose string literals are analyzed as SQL.
+func (h *Handler) embeddedSQLMiddleware(next HandleFunc) HandleFunc {
+	type TStruct struct {
+		TextDocument lsp.TextDocumentIdentifier `json:"textDocument"`
+	}
+
+	return func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+		var s TStruct
+		if err := json.Unmarshal(*req.Params, &s); err != nil {
+			return nil, err
+		}
+
+		uri := s.TextDocument.URI
+		if strings.HasSuffix(string(uri), ".sql") {
+			return next(ctx, conn, req)
+		}
+		if p := h.projectOf(uri); p != nil && p.IsHostFile(documentURIToURI(uri)) {
+			return next(ctx, conn, req)
+		}
+		return nil, nil
+	}
+}
+
 func uriToDocumentURI(uri string) lsp.DocumentURI {
 	return lsp.DocumentURI(fmt.Sprintf("file://%s", uri))
 }
@@ -0,0 +1,252 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+// stdinFilename is the name of the SQL read from stdin without the file name.
+const stdinFilename = "<standard input>"
+
+const (
+	LintFormatHuman  = "human"
+	LintFormatJSON   = "json"
+	LintFormatGitHub = "github"
+)
+
+type LintOption struct {
+	// RootPath is the workspace root. When Files is empty, all .sql files under it are linted.
+	RootPath  string
+	ProjectID string
+	// SchemaDir is the directory of the local schema files. When it is set, the tables are resolved offline.
+	SchemaDir string
+	// Files are the absolute paths of the linted files.
+	Files []string
+	// Stdin is the SQL linted instead of Files, e.g. in the pre-commit hooks and the editor pipes.
+	Stdin io.Reader
+	// StdinFilename is the absolute path of the SQL read from Stdin. It is used for the diagnostics and the options which depend on the path like destination_tables.
+	// When it is empty, the diagnostics are reported as <standard input>.
+	StdinFilename string
+	// Format is human, json or github.
+	Format string
+	// SelectStar reports `SELECT *` which is not used with EXCEPT or REPLACE as a warning.
+	SelectStar bool
+	// NonDeterministicLimit reports ORDER BY with LIMIT whose keys may have ties as a warning.
+	NonDeterministicLimit bool
+	// Ordinals reports the ordinals of GROUP BY and ORDER BY as warnings.
+	Ordinals bool
+	IsDebug  bool
+}
+
+// LintDiagnostic is a diagnostic of the lint. Line and Column are 1-based.
+type LintDiagnostic struct {
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	Code      string `json:"code,omitempty"`
+}
+
+// Lint analyzes the files in the same way as the diagnostics of the language server, and writes the diagnostics into w.
+// It returns the number of the diagnostics whose severity is error.
+func Lint(ctx context.Context, opt LintOption, w io.Writer) (int, error) {
+	logger := logrus.New()
+	logger.Out = os.Stderr
+	if opt.IsDebug {
+		logger.SetLevel(logrus.DebugLevel)
+	}
+
+	switch opt.Format {
+	case "", LintFormatHuman, LintFormatJSON, LintFormatGitHub:
+	default:
+		return 0, fmt.Errorf("unknown format %q: it should be human, json or github", opt.Format)
+	}
+
+	// the banned functions and the lint rules are shared with the language server by the workspace config file
+	var option InitializeOption
+	if err := readWorkspaceConfig(opt.RootPath, &option); err != nil {
+		return 0, err
+	}
+	bannedFunctions, err := option.bannedFunctions()
+	if err != nil {
+		return 0, err
+	}
+	lintRules, err := option.lintRules()
+	if err != nil {
+		return 0, err
+	}
+	languageOption, err := option.languageOption()
+	if err != nil {
+		return 0, err
+	}
+
+	var p *source.Project
+	if opt.SchemaDir != "" {
+		p = source.NewOfflineProject(opt.RootPath, opt.SchemaDir, opt.ProjectID, logger)
+	} else {
+		p, err = source.NewProject(ctx, source.Config{RootPath: opt.RootPath, ProjectID: opt.ProjectID, DisableQueryHistory: true}, logger)
+		if err != nil {
+			return 0, err
+		}
+	}
+	defer p.Close()
+	p.LintSelectStar = opt.SelectStar
+	p.LintNonDeterministicLimit = opt.NonDeterministicLimit
+	p.LintOrdinals = opt.Ordinals
+	p.SetDiagnosticOption(source.DiagnosticOption{
+		BannedFunctions:   bannedFunctions,
+		LintRules:         lintRules,
+		DestinationTables: option.DestinationTables,
+	})
+	if err := p.SetLanguageOption(languageOption); err != nil {
+		return 0, err
+	}
+	p.SetEmbeddedSQLOption(option.embeddedSQLOption())
+
+	var pathToErrs map[string][]file.Error
+	switch {
+	case opt.Stdin != nil:
+		b, rErr := io.ReadAll(opt.Stdin)
+		if rErr != nil {
+			return 0, fmt.Errorf("failed to read stdin: %w", rErr)
+		}
+		filename := opt.StdinFilename
+		if filename == "" {
+			filename = stdinFilename
+		}
+		pathToErrs = p.AnalyzeSource(ctx, filename, string(b))
+	case len(opt.Files) == 0:
+		pathToErrs, err = p.AnalyzeWorkspace(ctx)
+	default:
+		pathToErrs, err = p.AnalyzeFiles(ctx, opt.Files)
+	}
+	if err != nil {
+		return 0, err
+	}
+	diagnostics := convertLintDiagnostics(pathToErrs)
+
+	if err := WriteLintDiagnostics(w, opt.Format, diagnostics); err != nil {
+		return 0, err
+	}
+
+	errorCount := 0
+	for _, d := range diagnostics {
+		if d.Severity == "error" {
+			errorCount++
+		}
+	}
+	return errorCount, nil
+}
+
+// convertLintDiagnostics converts the errors into the diagnostics sorted by the path and the position.
+// The paths are relative to the current directory, so that GitHub can annotate the files in the repository.
+func convertLintDiagnostics(pathToErrs map[string][]file.Error) []LintDiagnostic {
+	cwd, _ := os.Getwd()
+
+	result := make([]LintDiagnostic, 0)
+	for path, errs := range pathToErrs {
+		displayPath := path
+		if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			displayPath = rel
+		}
+
+		for _, d := range convertErrorsToDiagnostics(errs) {
+			result = append(result, LintDiagnostic{
+				Path:      displayPath,
+				Line:      d.Range.Start.Line + 1,
+				Column:    d.Range.Start.Character + 1,
+				EndLine:   d.Range.End.Line + 1,
+				EndColumn: d.Range.End.Character + 1,
+				Severity:  severityName(d.Severity),
+				Message:   d.Message,
+				Code:      d.Code,
+			})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Path != result[j].Path {
+			return result[i].Path < result[j].Path
+		}
+		if result[i].Line != result[j].Line {
+			return result[i].Line < result[j].Line
+		}
+		return result[i].Column < result[j].Column
+	})
+	return result
+}
+
+func severityName(s lsp.DiagnosticSeverity) string {
+	switch s {
+	case lsp.Warning:
+		return "warning"
+	case lsp.Information:
+		return "info"
+	case lsp.Hint:
+		return "hint"
+	default:
+		return "error"
+	}
+}
+
+// WriteLintDiagnostics writes the diagnostics in the format.
+func WriteLintDiagnostics(w io.Writer, format string, diagnostics []LintDiagnostic) error {
+	switch format {
+	case "", LintFormatHuman:
+		for _, d := range diagnostics {
+			if _, err := fmt.Fprintf(w, "%s:%d:%d: %s: %s\n", d.Path, d.Line, d.Column, d.Severity, d.Message); err != nil {
+				return err
+			}
+		}
+		return nil
+	case LintFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diagnostics)
+	case LintFormatGitHub:
+		// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-an-error-message
+		for _, d := range diagnostics {
+			command := "error"
+			switch d.Severity {
+			case "warning":
+				command = "warning"
+			case "info", "hint":
+				command = "notice"
+			}
+			_, err := fmt.Fprintf(w, "::%s file=%s,line=%d,col=%d,endLine=%d,endColumn=%d::%s\n",
+				command, escapeGitHubProperty(d.Path), d.Line, d.Column, d.EndLine, d.EndColumn, escapeGitHubData(d.Message))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q: it should be human, json or github", format)
+	}
+}
+
+func escapeGitHubData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+func escapeGitHubProperty(s string) string {
+	s = escapeGitHubData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
@@ -0,0 +1,68 @@
+package langserver_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver"
+)
+
+func TestWriteLintDiagnostics(t *testing.T) {
+	diagnostics := []langserver.LintDiagnostic{
+		{Path: "a.sql", Line: 1, Column: 8, EndLine: 1, EndColumn: 11, Severity: "error", Message: "Unrecognized name: nam"},
+		{Path: "b,c.sql", Line: 2, Column: 1, EndLine: 2, EndColumn: 1, Severity: "warning", Message: "50%\nused"},
+	}
+
+	tests := map[string]struct {
+		format string
+		expect string
+	}{
+		"human": {
+			format: langserver.LintFormatHuman,
+			expect: "a.sql:1:8: error: Unrecognized name: nam\n" +
+				"b,c.sql:2:1: warning: 50%\nused\n",
+		},
+		"github": {
+			format: langserver.LintFormatGitHub,
+			expect: "::error file=a.sql,line=1,col=8,endLine=1,endColumn=11::Unrecognized name: nam\n" +
+				"::warning file=b%2Cc.sql,line=2,col=1,endLine=2,endColumn=1::50%25%0Aused\n",
+		},
+		"json": {
+			format: langserver.LintFormatJSON,
+			expect: `[
+  {
+    "path": "a.sql",
+    "line": 1,
+    "column": 8,
+    "endLine": 1,
+    "endColumn": 11,
+    "severity": "error",
+    "message": "Unrecognized name: nam"
+  },
+  {
+    "path": "b,c.sql",
+    "line": 2,
+    "column": 1,
+    "endLine": 2,
+    "endColumn": 1,
+    "severity": "warning",
+    "message": "50%\nused"
+  }
+]
+`,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := langserver.WriteLintDiagnostics(&buf, tt.format, diagnostics); err != nil {
+				t.Fatalf("failed to WriteLintDiagnostics: %v", err)
+			}
+			if diff := cmp.Diff(tt.expect, buf.String()); diff != "" {
+				t.Errorf("WriteLintDiagnostics result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package langserver
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogOption configures the logger of the language server.
+type LogOption struct {
+	// Level is trace, debug, info, warn or error. When it is empty, info is used.
+	Level string
+
+	// Format is text or json. When it is empty, text is used.
+	Format string
+
+	// Out is the destination of the logs. When it is nil, stderr is used.
+	// It must not be stdout, which is used by the stdio transport.
+	Out io.Writer
+}
+
+func NewLogger(opt LogOption) (*logrus.Logger, error) {
+	logger := logrus.New()
+
+	logger.Out = os.Stderr
+	if opt.Out != nil {
+		logger.Out = opt.Out
+	}
+
+	logger.SetLevel(logrus.InfoLevel)
+	if opt.Level != "" {
+		level, err := logrus.ParseLevel(opt.Level)
+		if err != nil {
+			return nil, err
+		}
+		logger.SetLevel(level)
+	}
+
+	switch opt.Format {
+	case "", "text":
+	case "json":
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return nil, fmt.Errorf("invalid log format %q: it should be text or json", opt.Format)
+	}
+
+	return logger, nil
+}
@@ -0,0 +1,15 @@
+package langserver
+
+import (
+	"net/http"
+
+	"github.com/kitagry/bqls/langserver/internal/metrics"
+)
+
+// ServeMetrics serves the request latencies, the analysis durations, the BigQuery API calls and the cache statistics
+// at /metrics in the Prometheus text format.
+func ServeMetrics(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Default.Handler())
+	return http.ListenAndServe(addr, mux)
+}
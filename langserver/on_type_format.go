@@ -0,0 +1,164 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// commaStyleLeading places the comma at the head of the item. Other values mean the trailing comma.
+const commaStyleLeading = "leading"
+
+var clauseLineRegex = regexp.MustCompile(`(?i)^(WITH|SELECT|FROM|WHERE|GROUP\s+BY|HAVING|QUALIFY|WINDOW|ORDER\s+BY|LIMIT|UNION|INTERSECT|EXCEPT)\b`)
+
+func (h *Handler) handleTextDocumentOnTypeFormatting(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.DocumentOnTypeFormattingParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	rawText, ok := h.projectOf(params.TextDocument.URI).GetFile(documentURIToURI(params.TextDocument.URI))
+	if !ok {
+		return nil, fmt.Errorf("failed to find document %s", params.TextDocument.URI)
+	}
+
+	option, err := h.formatOption(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	converter := h.positionConverter(params.TextDocument.URI)
+	edits := FormatOnType(rawText, converter.toByte(params.Position), params.Ch, params.Options, option.CommaStyle)
+	return converter.fromByteEdits(edits), nil
+}
+
+// FormatOnType re-indents the line at position in the clause which contains it, after ch is typed.
+// commaStyle decides which side of the line the comma between the items is placed on.
+func FormatOnType(text string, position lsp.Position, ch string, options lsp.FormattingOptions, commaStyle string) []lsp.TextEdit {
+	lines := strings.Split(text, "\n")
+	if position.Line <= 0 || position.Line >= len(lines) {
+		return nil
+	}
+
+	clauseIndent, ok := findClauseIndent(lines, position.Line)
+	if !ok {
+		return nil
+	}
+	itemIndent := clauseIndent + indentUnit(options)
+
+	line := lines[position.Line]
+	content := strings.TrimLeft(line, " \t")
+	indentRange := lsp.Range{
+		Start: lsp.Position{Line: position.Line, Character: 0},
+		End:   lsp.Position{Line: position.Line, Character: len(line) - len(content)},
+	}
+	if clauseLineRegex.MatchString(content) {
+		return []lsp.TextEdit{{Range: indentRange, NewText: clauseIndent}}
+	}
+
+	prevLineIndex, ok := previousNonEmptyLine(lines, position.Line)
+	if !ok {
+		return nil
+	}
+	prevLine := strings.TrimRight(lines[prevLineIndex], " \t")
+
+	switch ch {
+	case "\n":
+		if commaStyle == commaStyleLeading && strings.HasSuffix(prevLine, ",") && !strings.HasPrefix(content, ",") {
+			// move the comma at the end of the previous line to the head of the new line
+			return []lsp.TextEdit{
+				{
+					Range: lsp.Range{
+						Start: lsp.Position{Line: prevLineIndex, Character: len(prevLine) - 1},
+						End:   lsp.Position{Line: prevLineIndex, Character: len(prevLine)},
+					},
+				},
+				{Range: indentRange, NewText: itemIndent + ", "},
+			}
+		}
+		return []lsp.TextEdit{{Range: indentRange, NewText: itemIndent}}
+	case ",":
+		if !strings.HasPrefix(content, ",") {
+			// the comma is typed in the middle of the line
+			return nil
+		}
+		if commaStyle == commaStyleLeading {
+			rest := strings.TrimLeft(strings.TrimPrefix(content, ","), " \t")
+			return []lsp.TextEdit{
+				{
+					Range: lsp.Range{
+						Start: indentRange.Start,
+						End:   lsp.Position{Line: position.Line, Character: len(line) - len(rest)},
+					},
+					NewText: itemIndent + ", ",
+				},
+			}
+		}
+
+		// move the comma at the head of the line to the end of the previous line
+		rest := strings.TrimLeft(strings.TrimPrefix(content, ","), " \t")
+		edits := make([]lsp.TextEdit, 0, 2)
+		if !strings.HasSuffix(prevLine, ",") && !isClauseKeywordOnly(prevLine) {
+			edits = append(edits, lsp.TextEdit{
+				Range: lsp.Range{
+					Start: lsp.Position{Line: prevLineIndex, Character: len(prevLine)},
+					End:   lsp.Position{Line: prevLineIndex, Character: len(prevLine)},
+				},
+				NewText: ",",
+			})
+		}
+		edits = append(edits, lsp.TextEdit{
+			Range: lsp.Range{
+				Start: indentRange.Start,
+				End:   lsp.Position{Line: position.Line, Character: len(line) - len(rest)},
+			},
+			NewText: itemIndent,
+		})
+		return edits
+	}
+	return nil
+}
+
+// findClauseIndent returns the indent of the nearest clause keyword above the line.
+func findClauseIndent(lines []string, line int) (string, bool) {
+	for i := line - 1; i >= 0; i-- {
+		content := strings.TrimLeft(lines[i], " \t")
+		if clauseLineRegex.MatchString(content) {
+			return lines[i][:len(lines[i])-len(content)], true
+		}
+	}
+	return "", false
+}
+
+// isClauseKeywordOnly reports whether the line has no item after the clause keyword like `SELECT`.
+func isClauseKeywordOnly(line string) bool {
+	content := strings.TrimLeft(line, " \t")
+	return clauseLineRegex.MatchString(content) && strings.TrimSpace(clauseLineRegex.ReplaceAllString(content, "")) == ""
+}
+
+func previousNonEmptyLine(lines []string, line int) (int, bool) {
+	for i := line - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func indentUnit(options lsp.FormattingOptions) string {
+	if !options.InsertSpaces {
+		return "\t"
+	}
+	if options.TabSize <= 0 {
+		return "  "
+	}
+	return strings.Repeat(" ", options.TabSize)
+}
@@ -0,0 +1,90 @@
+package langserver_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kitagry/bqls/langserver"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+func TestFormatOnType(t *testing.T) {
+	options := lsp.FormattingOptions{TabSize: 2, InsertSpaces: true}
+
+	tests := map[string]struct {
+		text       string
+		position   lsp.Position
+		ch         string
+		commaStyle string
+
+		expect []lsp.TextEdit
+	}{
+		"indent the item after newline": {
+			text:     "SELECT\n  id,\nname",
+			position: lsp.Position{Line: 2, Character: 0},
+			ch:       "\n",
+			expect: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 2, Character: 0}, End: lsp.Position{Line: 2, Character: 0}},
+					NewText: "  ",
+				},
+			},
+		},
+		"dedent the clause keyword": {
+			text:     "  SELECT\n    id\n    FROM",
+			position: lsp.Position{Line: 2, Character: 8},
+			ch:       "\n",
+			expect: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 2, Character: 0}, End: lsp.Position{Line: 2, Character: 4}},
+					NewText: "  ",
+				},
+			},
+		},
+		"move the comma to the head with leading style": {
+			text:       "SELECT\n  id,\n",
+			position:   lsp.Position{Line: 2, Character: 0},
+			ch:         "\n",
+			commaStyle: "leading",
+			expect: []lsp.TextEdit{
+				{
+					Range: lsp.Range{Start: lsp.Position{Line: 1, Character: 4}, End: lsp.Position{Line: 1, Character: 5}},
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 2, Character: 0}, End: lsp.Position{Line: 2, Character: 0}},
+					NewText: "  , ",
+				},
+			},
+		},
+		"move the comma to the end with trailing style": {
+			text:     "SELECT\n  id\n  ,",
+			position: lsp.Position{Line: 2, Character: 3},
+			ch:       ",",
+			expect: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 1, Character: 4}, End: lsp.Position{Line: 1, Character: 4}},
+					NewText: ",",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 2, Character: 0}, End: lsp.Position{Line: 2, Character: 3}},
+					NewText: "  ",
+				},
+			},
+		},
+		"comma in the middle of the line": {
+			text:     "SELECT\n  id,",
+			position: lsp.Position{Line: 1, Character: 5},
+			ch:       ",",
+			expect:   nil,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := langserver.FormatOnType(tt.text, tt.position, tt.ch, options, tt.commaStyle)
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("FormatOnType result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
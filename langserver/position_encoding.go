@@ -0,0 +1,112 @@
+package langserver
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+// negotiatePositionEncoding chooses the encoding of the character offsets from the encodings supported by the client.
+// The analyzer handles the offsets as UTF-8 bytes, so utf-8 is preferred to avoid the conversion.
+// utf-16 is the default of LSP, which every client supports.
+func negotiatePositionEncoding(encodings []lsp.PositionEncodingKind) lsp.PositionEncodingKind {
+	if slices.Contains(encodings, lsp.PositionEncodingUTF8) {
+		return lsp.PositionEncodingUTF8
+	}
+	return lsp.PositionEncodingUTF16
+}
+
+// positionConverter converts the positions between the encoding of the client and UTF-8 bytes of the document.
+// The zero value doesn't convert the positions.
+type positionConverter struct {
+	lines []string
+}
+
+func newPositionConverter(text string) positionConverter {
+	return positionConverter{lines: strings.Split(text, "\n")}
+}
+
+// positionConverter returns the converter of the document.
+// The positions are not converted when the client uses utf-8 or the document is not found.
+func (h *Handler) positionConverter(uri lsp.DocumentURI) positionConverter {
+	if h.positionEncoding == lsp.PositionEncodingUTF8 {
+		return positionConverter{}
+	}
+
+	path := documentURIToURI(uri)
+	if text, ok := h.projectOf(uri).GetFile(path); ok {
+		return newPositionConverter(text)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return positionConverter{}
+	}
+	return newPositionConverter(string(b))
+}
+
+// toByte converts the position of the client to the position whose character is the UTF-8 byte offset.
+func (c positionConverter) toByte(pos lsp.Position) lsp.Position {
+	if pos.Line < 0 || pos.Line >= len(c.lines) {
+		return pos
+	}
+	pos.Character = utf16ToByteOffset(c.lines[pos.Line], pos.Character)
+	return pos
+}
+
+// fromByte converts the position whose character is the UTF-8 byte offset to the position of the client.
+func (c positionConverter) fromByte(pos lsp.Position) lsp.Position {
+	if pos.Line < 0 || pos.Line >= len(c.lines) {
+		return pos
+	}
+	pos.Character = byteToUTF16Offset(c.lines[pos.Line], pos.Character)
+	return pos
+}
+
+func (c positionConverter) toByteRange(rng lsp.Range) lsp.Range {
+	return lsp.Range{Start: c.toByte(rng.Start), End: c.toByte(rng.End)}
+}
+
+func (c positionConverter) fromByteRange(rng lsp.Range) lsp.Range {
+	return lsp.Range{Start: c.fromByte(rng.Start), End: c.fromByte(rng.End)}
+}
+
+func (c positionConverter) fromByteEdits(edits []lsp.TextEdit) []lsp.TextEdit {
+	if c.lines == nil {
+		return edits
+	}
+	result := make([]lsp.TextEdit, len(edits))
+	for i, e := range edits {
+		e.Range = c.fromByteRange(e.Range)
+		result[i] = e
+	}
+	return result
+}
+
+// utf16ToByteOffset converts the offset of UTF-16 code units in the line to the offset of UTF-8 bytes.
+// The offset beyond the line is kept beyond the line by the same amount.
+func utf16ToByteOffset(line string, character int) int {
+	units := 0
+	for i, r := range line {
+		if units >= character {
+			return i
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(line) + max(character-units, 0)
+}
+
+// byteToUTF16Offset converts the offset of UTF-8 bytes in the line to the offset of UTF-16 code units.
+// The offset beyond the line is kept beyond the line by the same amount.
+func byteToUTF16Offset(line string, offset int) int {
+	units := 0
+	for i, r := range line {
+		if i >= offset {
+			return units
+		}
+		units += utf16.RuneLen(r)
+	}
+	return units + max(offset-len(line), 0)
+}
@@ -0,0 +1,83 @@
+package langserver
+
+import (
+	"testing"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+func TestNegotiatePositionEncoding(t *testing.T) {
+	tests := map[string]struct {
+		encodings []lsp.PositionEncodingKind
+		expect    lsp.PositionEncodingKind
+	}{
+		"default": {
+			encodings: nil,
+			expect:    lsp.PositionEncodingUTF16,
+		},
+		"prefer utf-8": {
+			encodings: []lsp.PositionEncodingKind{lsp.PositionEncodingUTF16, lsp.PositionEncodingUTF8},
+			expect:    lsp.PositionEncodingUTF8,
+		},
+		"utf-32 is not supported": {
+			encodings: []lsp.PositionEncodingKind{lsp.PositionEncodingUTF32},
+			expect:    lsp.PositionEncodingUTF16,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := negotiatePositionEncoding(tt.encodings)
+			if got != tt.expect {
+				t.Errorf("negotiatePositionEncoding expect %s, but got %s", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestPositionConverter(t *testing.T) {
+	// "日本" is 3 bytes and 1 code unit per character, and "😀" is 4 bytes and 2 code units.
+	text := "SELECT 1\n-- 日本 😀\nSELECT `列` FROM t"
+
+	tests := map[string]struct {
+		utf16 lsp.Position
+		bytes lsp.Position
+	}{
+		"ascii line": {
+			utf16: lsp.Position{Line: 0, Character: 7},
+			bytes: lsp.Position{Line: 0, Character: 7},
+		},
+		"after multibyte characters": {
+			utf16: lsp.Position{Line: 1, Character: 6},
+			bytes: lsp.Position{Line: 1, Character: 10},
+		},
+		"after surrogate pair": {
+			utf16: lsp.Position{Line: 1, Character: 8},
+			bytes: lsp.Position{Line: 1, Character: 14},
+		},
+		"after multibyte identifier": {
+			utf16: lsp.Position{Line: 2, Character: 11},
+			bytes: lsp.Position{Line: 2, Character: 13},
+		},
+		"end of line": {
+			utf16: lsp.Position{Line: 2, Character: 17},
+			bytes: lsp.Position{Line: 2, Character: 19},
+		},
+		"beyond the last line": {
+			utf16: lsp.Position{Line: 3, Character: 1},
+			bytes: lsp.Position{Line: 3, Character: 1},
+		},
+	}
+
+	converter := newPositionConverter(text)
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			if got := converter.toByte(tt.utf16); got != tt.bytes {
+				t.Errorf("toByte expect %v, but got %v", tt.bytes, got)
+			}
+			if got := converter.fromByte(tt.bytes); got != tt.utf16 {
+				t.Errorf("fromByte expect %v, but got %v", tt.utf16, got)
+			}
+		})
+	}
+}
@@ -6,6 +6,15 @@ import (
 	"github.com/kitagry/bqls/langserver/internal/lsp"
 )
 
+// workDoneProgressCreate asks the client to create the progress token.
+// It must not be called in the goroutine of the request handler, because it waits for the response of the client.
+func (h *Handler) workDoneProgressCreate(ctx context.Context, token lsp.ProgressToken) error {
+	if !h.initializeParams.Capabilities.Window.WorkDoneProgress {
+		return nil
+	}
+	return h.conn.Call(ctx, "window/workDoneProgress/create", lsp.WorkDoneProgressCreateParams{Token: token}, nil)
+}
+
 func (h *Handler) workDoneProgressBegin(ctx context.Context, token lsp.ProgressToken, params lsp.WorkDoneProgressBegin) error {
 	if !h.initializeParams.Capabilities.Window.WorkDoneProgress {
 		return nil
@@ -0,0 +1,104 @@
+package langserver
+
+import (
+	"context"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+const (
+	formattingMethod       = "textDocument/formatting"
+	rangeFormattingMethod  = "textDocument/rangeFormatting"
+	onTypeFormattingMethod = "textDocument/onTypeFormatting"
+	watchedFilesMethod     = "workspace/didChangeWatchedFiles"
+)
+
+var onTypeFormattingOptions = lsp.DocumentOnTypeFormattingOptions{
+	FirstTriggerCharacter: "\n",
+	MoreTriggerCharacter:  []string{","},
+}
+
+// dynamicRegistration reports whether the client registers the capability of the method after initialization.
+func (h *Handler) dynamicRegistration(method string) bool {
+	c := h.initializeParams.Capabilities
+	switch method {
+	case formattingMethod:
+		return c.TextDocument.Formatting != nil && c.TextDocument.Formatting.DynamicRegistration
+	case rangeFormattingMethod:
+		return c.TextDocument.RangeFormatting != nil && c.TextDocument.RangeFormatting.DynamicRegistration
+	case onTypeFormattingMethod:
+		return c.TextDocument.OnTypeFormatting != nil && c.TextDocument.OnTypeFormatting.DynamicRegistration
+	case watchedFilesMethod:
+		return c.Workspace.DidChangeWatchedFiles != nil && c.Workspace.DidChangeWatchedFiles.DynamicRegistration
+	}
+	return false
+}
+
+// staticFormattingProvider reports whether the formatting provider is advertised in the result of initialize.
+// It is false when the provider is registered dynamically.
+func (h *Handler) staticFormattingProvider(method string) bool {
+	return !h.initializeParams.InitializationOptions.DisableFormatting && !h.dynamicRegistration(method)
+}
+
+func (h *Handler) staticOnTypeFormattingProvider() *lsp.DocumentOnTypeFormattingOptions {
+	if !h.staticFormattingProvider(onTypeFormattingMethod) {
+		return nil
+	}
+	options := onTypeFormattingOptions
+	return &options
+}
+
+// registrations returns the capabilities registered with client/registerCapability depending on the configuration.
+func (h *Handler) registrations() []lsp.Registration {
+	result := make([]lsp.Registration, 0)
+
+	if !h.initializeParams.InitializationOptions.DisableFormatting {
+		// The null document selector applies the registration to the same documents as the static capability,
+		// including the untitled buffers and the files which are not *.sql.
+		selector := lsp.TextDocumentRegistrationOptions{DocumentSelector: nil}
+		if h.dynamicRegistration(formattingMethod) {
+			result = append(result, lsp.Registration{ID: formattingMethod, Method: formattingMethod, RegisterOptions: selector})
+		}
+		if h.dynamicRegistration(rangeFormattingMethod) {
+			result = append(result, lsp.Registration{ID: rangeFormattingMethod, Method: rangeFormattingMethod, RegisterOptions: selector})
+		}
+		if h.dynamicRegistration(onTypeFormattingMethod) {
+			result = append(result, lsp.Registration{
+				ID:     onTypeFormattingMethod,
+				Method: onTypeFormattingMethod,
+				RegisterOptions: lsp.DocumentOnTypeFormattingRegistrationOptions{
+					TextDocumentRegistrationOptions: selector,
+					DocumentOnTypeFormattingOptions: onTypeFormattingOptions,
+				},
+			})
+		}
+	}
+
+	if h.dynamicRegistration(watchedFilesMethod) {
+		// The workspace config files are always watched to apply their changes without restarting the server.
+		watchers := make([]lsp.FileSystemWatcher, 0, len(workspaceConfigFiles)+1)
+		for _, name := range workspaceConfigFiles {
+			watchers = append(watchers, lsp.FileSystemWatcher{GlobPattern: "**/" + name})
+		}
+		if h.initializeParams.InitializationOptions.WorkspaceDiagnostics {
+			watchers = append(watchers, lsp.FileSystemWatcher{GlobPattern: "**/*.sql"})
+		}
+		result = append(result, lsp.Registration{
+			ID:              watchedFilesMethod,
+			Method:          watchedFilesMethod,
+			RegisterOptions: lsp.DidChangeWatchedFilesRegistrationOptions{Watchers: watchers},
+		})
+	}
+
+	return result
+}
+
+// registerCapabilities registers the capabilities which are not advertised in the result of initialize.
+func (h *Handler) registerCapabilities(ctx context.Context) error {
+	registrations := h.registrations()
+	if len(registrations) == 0 {
+		return nil
+	}
+
+	return h.conn.Call(ctx, "client/registerCapability", lsp.RegistrationParams{Registrations: registrations}, nil)
+}
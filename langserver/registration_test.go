@@ -0,0 +1,100 @@
+package langserver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+)
+
+func TestHandler_registrations(t *testing.T) {
+	tests := map[string]struct {
+		params string
+
+		expectMethods  []string
+		expectWatchers []string
+		expectStatic   bool
+	}{
+		"static capabilities": {
+			params:        `{}`,
+			expectMethods: nil,
+			expectStatic:  true,
+		},
+		"dynamic formatting": {
+			params:        `{"capabilities": {"textDocument": {"formatting": {"dynamicRegistration": true}, "rangeFormatting": {"dynamicRegistration": true}, "onTypeFormatting": {"dynamicRegistration": true}}}}`,
+			expectMethods: []string{formattingMethod, rangeFormattingMethod, onTypeFormattingMethod},
+			expectStatic:  false,
+		},
+		"disable formatting": {
+			params:        `{"capabilities": {"textDocument": {"formatting": {"dynamicRegistration": true}}}, "initializationOptions": {"disable_formatting": true}}`,
+			expectMethods: nil,
+			expectStatic:  false,
+		},
+		"watch config file": {
+			params:         `{"capabilities": {"workspace": {"didChangeWatchedFiles": {"dynamicRegistration": true}}}}`,
+			expectMethods:  []string{watchedFilesMethod},
+			expectWatchers: []string{"**/.bqls.yaml", "**/.bqls.json"},
+			expectStatic:   true,
+		},
+		"watch sql files with workspace diagnostics": {
+			params:         `{"capabilities": {"workspace": {"didChangeWatchedFiles": {"dynamicRegistration": true}}}, "initializationOptions": {"workspace_diagnostics": true}}`,
+			expectMethods:  []string{watchedFilesMethod},
+			expectWatchers: []string{"**/.bqls.yaml", "**/.bqls.json", "**/*.sql"},
+			expectStatic:   true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			h := &Handler{}
+			if err := json.Unmarshal([]byte(tt.params), &h.initializeParams); err != nil {
+				t.Fatal(err)
+			}
+
+			var methods, watchers []string
+			for _, r := range h.registrations() {
+				methods = append(methods, r.Method)
+				if o, ok := r.RegisterOptions.(lsp.DidChangeWatchedFilesRegistrationOptions); ok {
+					for _, w := range o.Watchers {
+						watchers = append(watchers, w.GlobPattern)
+					}
+				}
+			}
+			if diff := cmp.Diff(tt.expectMethods, methods, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("registrations methods diff (-expect, +got)\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectWatchers, watchers, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("registrations watchers diff (-expect, +got)\n%s", diff)
+			}
+			if got := h.staticFormattingProvider(formattingMethod); got != tt.expectStatic {
+				t.Errorf("staticFormattingProvider expect %v, but got %v", tt.expectStatic, got)
+			}
+		})
+	}
+}
+
+func TestHandler_registrationsDocumentSelector(t *testing.T) {
+	h := &Handler{}
+	if err := json.Unmarshal([]byte(`{"capabilities": {"textDocument": {"formatting": {"dynamicRegistration": true}, "onTypeFormatting": {"dynamicRegistration": true}}}}`), &h.initializeParams); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range h.registrations() {
+		b, err := json.Marshal(r.RegisterOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var options struct {
+			DocumentSelector json.RawMessage `json:"documentSelector"`
+		}
+		if err := json.Unmarshal(b, &options); err != nil {
+			t.Fatal(err)
+		}
+		// null makes the client use the document selector of the server, which isn't limited to *.sql files.
+		if got := string(options.DocumentSelector); got != "null" {
+			t.Errorf("%s documentSelector should be null, but got %s", r.Method, got)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *Handler) handleTextDocumentSelectionRange(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.SelectionRangeParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	converter := h.positionConverter(params.TextDocument.URI)
+	positions := make([]lsp.Position, len(params.Positions))
+	for i, p := range params.Positions {
+		positions[i] = converter.toByte(p)
+	}
+	selectionRanges, err := h.projectOf(params.TextDocument.URI).SelectionRanges(documentURIToURI(params.TextDocument.URI), positions)
+	if err != nil {
+		return nil, err
+	}
+	for i := range selectionRanges {
+		for r := &selectionRanges[i]; r != nil; r = r.Parent {
+			r.Range = converter.fromByteRange(r.Range)
+		}
+	}
+	return selectionRanges, nil
+}
@@ -0,0 +1,121 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/sirupsen/logrus"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *Handler) handleSetTrace(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.SetTraceParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	h.setTrace(params.Value)
+	return nil, nil
+}
+
+// setTrace changes which logs are forwarded to the client.
+// verbose forwards the debug logs too, so the log level is lowered to debug while the trace is verbose.
+func (h *Handler) setTrace(value lsp.Trace) {
+	if value == "" {
+		value = lsp.TraceOff
+	}
+
+	h.traceLock.Lock()
+	defer h.traceLock.Unlock()
+
+	if value == lsp.TraceVerbose && h.trace != lsp.TraceVerbose {
+		h.levelBeforeVerbose = h.logger.GetLevel()
+		if !h.logger.IsLevelEnabled(logrus.DebugLevel) {
+			h.logger.SetLevel(logrus.DebugLevel)
+		}
+	}
+	if value != lsp.TraceVerbose && h.trace == lsp.TraceVerbose {
+		h.logger.SetLevel(h.levelBeforeVerbose)
+	}
+	h.trace = value
+}
+
+func (h *Handler) getTrace() lsp.Trace {
+	h.traceLock.RLock()
+	defer h.traceLock.RUnlock()
+	return h.trace
+}
+
+// traceHook forwards the logs to the client with window/logMessage.
+// messages forwards the logs whose level is info or higher, and verbose forwards all logs.
+type traceHook struct {
+	h *Handler
+}
+
+func (t *traceHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (t *traceHook) Fire(entry *logrus.Entry) error {
+	conn := t.h.conn
+	if conn == nil {
+		return nil
+	}
+
+	switch t.h.getTrace() {
+	case lsp.TraceMessages:
+		if entry.Level > logrus.InfoLevel {
+			return nil
+		}
+	case lsp.TraceVerbose:
+	default:
+		return nil
+	}
+
+	// The error is ignored, because logging it calls this hook again.
+	_ = conn.Notify(context.Background(), "window/logMessage", lsp.LogMessageParams{
+		Type:    logMessageType(entry.Level),
+		Message: formatLogEntry(entry),
+	})
+	return nil
+}
+
+func logMessageType(level logrus.Level) lsp.MessageType {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		return lsp.MTError
+	case logrus.WarnLevel:
+		return lsp.MTWarning
+	case logrus.InfoLevel:
+		return lsp.Info
+	default:
+		return lsp.Log
+	}
+}
+
+func formatLogEntry(entry *logrus.Entry) string {
+	if len(entry.Data) == 0 {
+		return entry.Message
+	}
+
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(entry.Message)
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf(" %s=%v", k, entry.Data[k]))
+	}
+	return sb.String()
+}
@@ -26,33 +26,26 @@ func ParseVirtualTextDocument(textDocument lsp.DocumentURI) (VirtualTextDocument
 		return VirtualTextDocumentInfo{}, errors.New("invalid text document URI")
 	}
 
+	// the path is the pairs of the key and the value like `project/${project}/job/${job}`.
+	segments := strings.Split(strings.TrimSuffix(suffix, "/"), "/")
+	if len(segments)%2 != 0 {
+		return VirtualTextDocumentInfo{}, fmt.Errorf("invalid text document URI: %s", textDocument)
+	}
+
 	result := VirtualTextDocumentInfo{}
-	for suffix != "" {
-		for _, prefix := range []string{"project/", "dataset/", "table/", "job/"} {
-			after, ok := strings.CutPrefix(suffix, prefix)
-			if !ok {
-				continue
-			}
-
-			ind := strings.Index(after, "/")
-			var val string
-			if ind == -1 {
-				val = after
-				suffix = ""
-			} else {
-				val = after[:ind]
-				suffix = after[ind+1:]
-			}
-
-			if prefix == "project/" {
-				result.ProjectID = val
-			} else if prefix == "dataset/" {
-				result.DatasetID = val
-			} else if prefix == "table/" {
-				result.TableID = val
-			} else if prefix == "job/" {
-				result.JobID = val
-			}
+	for i := 0; i < len(segments); i += 2 {
+		key, val := segments[i], segments[i+1]
+		switch key {
+		case "project":
+			result.ProjectID = val
+		case "dataset":
+			result.DatasetID = val
+		case "table":
+			result.TableID = val
+		case "job":
+			result.JobID = val
+		default:
+			return VirtualTextDocumentInfo{}, fmt.Errorf("unknown path %q of text document URI: %s", key, textDocument)
 		}
 	}
 
@@ -105,7 +98,7 @@ func (h *Handler) handleVirtualTextDocument(ctx context.Context, conn *jsonrpc2.
 		h.workDoneProgressReport(ctx, workDoneToken, lsp.WorkDoneProgressReport{
 			Message: "Fetching table info...",
 		})
-		result, err := h.project.GetTableInfo(ctx, virtualTextDocument.ProjectID, virtualTextDocument.DatasetID, virtualTextDocument.TableID)
+		result, err := h.projectOfTable(virtualTextDocument.ProjectID).GetTableInfo(ctx, virtualTextDocument.ProjectID, virtualTextDocument.DatasetID, virtualTextDocument.TableID)
 		if err != nil {
 			return nil, err
 		}
@@ -117,7 +110,7 @@ func (h *Handler) handleVirtualTextDocument(ctx context.Context, conn *jsonrpc2.
 		h.workDoneProgressReport(ctx, workDoneToken, lsp.WorkDoneProgressReport{
 			Message: "Fetching job info...",
 		})
-		result, err := h.project.GetJobInfo(ctx, virtualTextDocument.ProjectID, virtualTextDocument.JobID)
+		result, err := h.projectOfJob(virtualTextDocument.ProjectID, virtualTextDocument.JobID).GetJobInfo(ctx, virtualTextDocument.ProjectID, virtualTextDocument.JobID)
 		if err != nil {
 			return nil, err
 		}
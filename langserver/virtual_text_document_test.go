@@ -10,9 +10,9 @@ import (
 
 func TestParseVirtualTextDocument(t *testing.T) {
 	tests := map[string]struct {
-		uri         string
-		expected    langserver.VirtualTextDocumentInfo
-		expectedErr error
+		uri       string
+		expected  langserver.VirtualTextDocumentInfo
+		expectErr bool
 	}{
 		"Parse project/dataset/table": {
 			uri: "bqls://project/p/dataset/d/table/t",
@@ -29,13 +29,25 @@ func TestParseVirtualTextDocument(t *testing.T) {
 				JobID:     "j",
 			},
 		},
+		"Unknown path": {
+			uri:       "bqls://project/p/routine/r",
+			expectErr: true,
+		},
+		"Path without value": {
+			uri:       "bqls://project/p/job",
+			expectErr: true,
+		},
+		"Table without dataset": {
+			uri:       "bqls://project/p/table/t",
+			expectErr: true,
+		},
 	}
 
 	for n, tt := range tests {
 		t.Run(n, func(t *testing.T) {
 			got, err := langserver.ParseVirtualTextDocument(lsp.DocumentURI(tt.uri))
-			if err != tt.expectedErr {
-				t.Fatalf("ParseVirtualTextDocument error expected %v, got %v", tt.expectedErr, err)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ParseVirtualTextDocument error expected %v, got %v", tt.expectErr, err)
 			}
 
 			if diff := cmp.Diff(tt.expected, got); diff != "" {
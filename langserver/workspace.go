@@ -0,0 +1,153 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source/file"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *Handler) handleInitialized(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	// The handler must not wait for the response of the client in the same goroutine.
+	go func() {
+		if err := h.registerCapabilities(context.Background()); err != nil {
+			h.logger.Errorf("failed to register capabilities: %v", err)
+		}
+	}()
+
+	if h.initializeParams.InitializationOptions.WorkspaceDiagnostics {
+		go h.diagnoseWorkspace(context.Background())
+	}
+
+	return nil, nil
+}
+
+func (h *Handler) diagnoseWorkspace(ctx context.Context) {
+	defer func() {
+		if err := recover(); err != nil {
+			h.logger.Errorf("panic in workspace diagnostics: %v", err)
+		}
+	}()
+
+	// The progress is shown so that the large workspace doesn't look frozen while analyzing all files.
+	workDoneToken := lsp.ProgressToken("workspace_diagnostics")
+	reportProgress := true
+	if err := h.workDoneProgressCreate(ctx, workDoneToken); err != nil {
+		h.logger.Debugf("failed to create work done progress: %v", err)
+		reportProgress = false
+	}
+	if reportProgress {
+		h.workDoneProgressBegin(ctx, workDoneToken, lsp.WorkDoneProgressBegin{
+			Title:   "Workspace diagnostics",
+			Message: "Analyzing workspace files...",
+		})
+		defer h.workDoneProgressEnd(ctx, workDoneToken, lsp.WorkDoneProgressEnd{})
+	}
+
+	for _, p := range h.projects() {
+		var lastPercentage float64
+		pathToErrs, err := p.AnalyzeWorkspaceWithProgress(ctx, func(done, total int) {
+			percentage := float64(done * 100 / total)
+			if !reportProgress || percentage == lastPercentage {
+				return
+			}
+			lastPercentage = percentage
+			h.workDoneProgressReport(ctx, workDoneToken, lsp.WorkDoneProgressReport{
+				Message:    fmt.Sprintf("Analyzing %d/%d files", done, total),
+				Percentage: percentage,
+			})
+		})
+		if err != nil {
+			h.logger.Errorf("failed to analyze workspace: %v", err)
+			continue
+		}
+
+		// The files in the nested workspace folder are reported by the project of the folder.
+		for path := range pathToErrs {
+			if h.projectOf(uriToDocumentURI(path)) != p {
+				delete(pathToErrs, path)
+			}
+		}
+		h.publishFileErrors(ctx, pathToErrs)
+	}
+}
+
+func (h *Handler) handleWorkspaceDidChangeWatchedFiles(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.DidChangeWatchedFilesParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ctx := context.Background()
+		for _, change := range params.Changes {
+			if path := documentURIToURI(change.URI); isWorkspaceConfigFile(path) {
+				if err := h.reloadWorkspaceConfig(filepath.Dir(path)); err != nil {
+					h.logger.Errorf("failed to reload %s: %v", path, err)
+				}
+				continue
+			}
+			if !h.initializeParams.InitializationOptions.WorkspaceDiagnostics {
+				continue
+			}
+
+			pathToErrs, err := h.projectOf(change.URI).AnalyzeFileOnDisk(ctx, documentURIToURI(change.URI))
+			if err != nil {
+				h.logger.Errorf("failed to analyze %s: %v", change.URI, err)
+				continue
+			}
+			h.publishFileErrors(ctx, pathToErrs)
+		}
+	}()
+
+	return nil, nil
+}
+
+func (h *Handler) publishFileErrors(ctx context.Context, pathToErrs map[string][]file.Error) {
+	for path, errs := range pathToErrs {
+		uri := uriToDocumentURI(path)
+		if err := h.publishDiagnostics(ctx, uri, convertErrorsToDiagnostics(errs)); err != nil {
+			h.logger.Errorf(`failed to send "textDocument/publishDiagnostics" for %s: %v`, uri, err)
+		}
+	}
+}
+
+func (h *Handler) handleWorkspaceSymbol(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.WorkspaceSymbolParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	symbols := make([]lsp.SymbolInformation, 0)
+	for _, p := range h.projects() {
+		s, err := p.WorkspaceSymbols(ctx, params.Query, params.Limit)
+		if err != nil {
+			h.logger.Errorf("failed to search workspace symbols: %v", err)
+			continue
+		}
+		symbols = append(symbols, s...)
+	}
+
+	converters := make(map[lsp.DocumentURI]positionConverter)
+	for i, s := range symbols {
+		converter, ok := converters[s.Location.URI]
+		if !ok {
+			converter = h.positionConverter(s.Location.URI)
+			converters[s.Location.URI] = converter
+		}
+		symbols[i].Location.Range = converter.fromByteRange(s.Location.Range)
+	}
+	return symbols, nil
+}
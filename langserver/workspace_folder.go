@@ -0,0 +1,328 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kitagry/bqls/langserver/internal/lsp"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sourcegraph/jsonrpc2"
+	"gopkg.in/yaml.v3"
+)
+
+// workspaceConfigFiles are the configuration files placed at the root of each workspace folder.
+// They have the same fields as initializationOptions and override them for the folder.
+// When both exist, the first one is used. .bqls.json is read for the compatibility.
+var workspaceConfigFiles = []string{".bqls.yaml", ".bqls.json"}
+
+// isWorkspaceConfigFile reports whether path is one of workspaceConfigFiles.
+func isWorkspaceConfigFile(path string) bool {
+	return slices.Contains(workspaceConfigFiles, filepath.Base(path))
+}
+
+// newProject creates the project of the rootPath with initializationOptions and the workspace config file.
+func (h *Handler) newProject(ctx context.Context, rootPath string) (*source.Project, error) {
+	option := h.initializeParams.InitializationOptions
+	if err := readWorkspaceConfig(rootPath, &option); err != nil {
+		return nil, err
+	}
+
+	var p *source.Project
+	if schemaDir := option.SchemaDir; schemaDir != "" {
+		if !filepath.IsAbs(schemaDir) {
+			schemaDir = filepath.Join(rootPath, schemaDir)
+		}
+		p = source.NewOfflineProject(rootPath, schemaDir, option.ProjectID, h.logger)
+	} else {
+		var err error
+		p, err = source.NewProject(ctx, option.projectConfig(rootPath), h.logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := applyAnalysisOption(p, option); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// applyAnalysisOption sets the options of the analysis and the lint, which can be changed without recreating the project.
+func applyAnalysisOption(p *source.Project, option InitializeOption) error {
+	bannedFunctions, err := option.bannedFunctions()
+	if err != nil {
+		return err
+	}
+	lintRules, err := option.lintRules()
+	if err != nil {
+		return err
+	}
+	languageOption, err := option.languageOption()
+	if err != nil {
+		return err
+	}
+
+	if err := p.SetLanguageOption(languageOption); err != nil {
+		return err
+	}
+	p.SetDiagnosticOption(source.DiagnosticOption{
+		BannedFunctions:   bannedFunctions,
+		LintRules:         lintRules,
+		DestinationTables: option.DestinationTables,
+	})
+	p.SetEmbeddedSQLOption(option.embeddedSQLOption())
+	return nil
+}
+
+// reloadWorkspaceConfig applies the changed workspace config file of the rootPath to its project.
+// The options of the connection like project_id take effect after restarting the server.
+func (h *Handler) reloadWorkspaceConfig(rootPath string) error {
+	h.workspaceProjectsLock.RLock()
+	p, ok := h.workspaceProjects[rootPath]
+	h.workspaceProjectsLock.RUnlock()
+	if !ok && rootPath == h.initializeParams.RootPath {
+		p, ok = h.project, h.project != nil
+	}
+	if !ok {
+		return nil
+	}
+
+	option := h.initializeParams.InitializationOptions
+	if err := readWorkspaceConfig(rootPath, &option); err != nil {
+		return err
+	}
+	return applyAnalysisOption(p, option)
+}
+
+// readWorkspaceConfig overrides option with the workspace config file of the rootPath if it exists.
+func readWorkspaceConfig(rootPath string, option *InitializeOption) error {
+	path, ok := workspaceConfigPath(rootPath)
+	if !ok {
+		return nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if filepath.Ext(path) == ".yaml" {
+		// The YAML is converted into JSON to share the field names and the decoders of initializationOptions.
+		b, err = yamlToJSON(b)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	if err := json.Unmarshal(b, option); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// workspaceConfigPath returns the path of the workspace config file of the rootPath.
+func workspaceConfigPath(rootPath string) (string, bool) {
+	if rootPath == "" {
+		return "", false
+	}
+	for _, name := range workspaceConfigFiles {
+		path := filepath.Join(rootPath, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func yamlToJSON(b []byte) ([]byte, error) {
+	var v any
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	if v == nil {
+		// the empty file
+		return []byte("{}"), nil
+	}
+	return json.Marshal(v)
+}
+
+func (h *Handler) addWorkspaceFolder(ctx context.Context, folder lsp.WorkspaceFolder) error {
+	rootPath := documentURIToURI(folder.URI)
+
+	h.workspaceProjectsLock.Lock()
+	if _, ok := h.workspaceProjects[rootPath]; ok {
+		h.workspaceProjectsLock.Unlock()
+		return nil
+	}
+	// The root of the initialize request shares the project.
+	if rootPath == h.initializeParams.RootPath && h.project != nil {
+		h.workspaceProjects[rootPath] = h.project
+		h.workspaceProjectsLock.Unlock()
+		return nil
+	}
+	h.workspaceProjectsLock.Unlock()
+
+	// The project is created without the lock, because the authentication may take long and projectOf must not wait for it.
+	p, err := h.newProject(ctx, rootPath)
+	if err != nil {
+		return err
+	}
+
+	h.workspaceProjectsLock.Lock()
+	defer h.workspaceProjectsLock.Unlock()
+	if _, ok := h.workspaceProjects[rootPath]; ok {
+		// The folder has been added by another request while creating the project.
+		return p.Close()
+	}
+	h.workspaceProjects[rootPath] = p
+	return nil
+}
+
+func (h *Handler) removeWorkspaceFolder(folder lsp.WorkspaceFolder) error {
+	rootPath := documentURIToURI(folder.URI)
+
+	h.workspaceProjectsLock.Lock()
+	p, ok := h.workspaceProjects[rootPath]
+	delete(h.workspaceProjects, rootPath)
+	// Closing the project waits for its job watchers, which must not block the requests looking up the projects.
+	h.workspaceProjectsLock.Unlock()
+
+	if !ok || p == h.project {
+		return nil
+	}
+	return p.Close()
+}
+
+// projectOf returns the project of the workspace folder which contains the document.
+// When no folder contains it, the project of the root is returned.
+func (h *Handler) projectOf(uri lsp.DocumentURI) *source.Project {
+	path := documentURIToURI(uri)
+
+	h.workspaceProjectsLock.RLock()
+	defer h.workspaceProjectsLock.RUnlock()
+
+	var result *source.Project
+	var resultRoot string
+	for rootPath, p := range h.workspaceProjects {
+		if path != rootPath && !strings.HasPrefix(path, strings.TrimSuffix(rootPath, "/")+"/") {
+			continue
+		}
+		// The nested folder is preferred.
+		if len(rootPath) > len(resultRoot) {
+			result = p
+			resultRoot = rootPath
+		}
+	}
+
+	if result == nil {
+		return h.project
+	}
+	return result
+}
+
+// projects returns the projects of all workspace folders including the root.
+func (h *Handler) projects() []*source.Project {
+	h.workspaceProjectsLock.RLock()
+	defer h.workspaceProjectsLock.RUnlock()
+
+	result := make([]*source.Project, 0, len(h.workspaceProjects)+1)
+	if h.project != nil {
+		result = append(result, h.project)
+	}
+	for _, p := range h.workspaceProjects {
+		if p != h.project {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// maxJobProjects is the number of the jobs whose projects are remembered by the handler.
+const maxJobProjects = 1000
+
+// recordJob remembers the project which runs the job, so that its result is read with the same project.
+func (h *Handler) recordJob(p *source.Project, jobID string) {
+	h.jobProjects.Put(jobID, p)
+}
+
+// projectOfJob returns the project which runs the job, whose credentials and location are needed to read the job.
+// The job which is not run by this server like the one listed by bqls/listJobs is looked up by its billing project.
+func (h *Handler) projectOfJob(projectID, jobID string) *source.Project {
+	if p, ok := h.jobProjects.Get(jobID); ok && h.hasProject(p) {
+		return p
+	}
+	return h.projectOfBillingProject(projectID)
+}
+
+// projectOfBillingProject returns the project which runs the query jobs in projectID.
+func (h *Handler) projectOfBillingProject(projectID string) *source.Project {
+	return h.projectMatching(func(p *source.Project) bool { return p.BillingProjectID == projectID })
+}
+
+// projectOfTable returns the project whose default project is projectID, because its credentials are expected to read the tables.
+func (h *Handler) projectOfTable(projectID string) *source.Project {
+	return h.projectMatching(func(p *source.Project) bool { return p.BigQueryProjectID == projectID })
+}
+
+// projectOfTablePath returns the project of the table path like project.dataset.table.
+// The path without the project is read with the project of the root.
+func (h *Handler) projectOfTablePath(tablePath string) *source.Project {
+	names := strings.Split(strings.Trim(tablePath, "`"), ".")
+	if len(names) < 3 {
+		return h.project
+	}
+	return h.projectOfTable(names[0])
+}
+
+// projectOfURIFlag returns the project of the document or the workspace folder given by the --uri flag of the commands.
+func (h *Handler) projectOfURIFlag(uri string) *source.Project {
+	if uri == "" {
+		return h.project
+	}
+	return h.projectOf(lsp.DocumentURI(uri))
+}
+
+// projectMatching returns the first project which matches. The project of the root is preferred, and is returned when nothing matches.
+func (h *Handler) projectMatching(match func(p *source.Project) bool) *source.Project {
+	for _, p := range h.projects() {
+		if match(p) {
+			return p
+		}
+	}
+	return h.project
+}
+
+func (h *Handler) hasProject(target *source.Project) bool {
+	for _, p := range h.projects() {
+		if p == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) handleWorkspaceDidChangeWorkspaceFolders(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.DidChangeWorkspaceFoldersParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	for _, folder := range params.Event.Removed {
+		if err := h.removeWorkspaceFolder(folder); err != nil {
+			h.logger.Errorf("failed to close the project of %s: %v", folder.URI, err)
+		}
+	}
+	for _, folder := range params.Event.Added {
+		if err := h.addWorkspaceFolder(ctx, folder); err != nil {
+			h.logger.Errorf("failed to create the project of %s: %v", folder.URI, err)
+		}
+	}
+
+	return nil, nil
+}
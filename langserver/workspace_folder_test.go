@@ -0,0 +1,69 @@
+package langserver
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kitagry/bqls/langserver/internal/bigquery/mock_bigquery"
+	"github.com/kitagry/bqls/langserver/internal/cache"
+	"github.com/kitagry/bqls/langserver/internal/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestHandler_projectOfJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	newProject := func(rootPath, projectID string) *source.Project {
+		p := source.NewProjectWithBQClient(rootPath, mock_bigquery.NewMockClient(ctrl), logrus.New())
+		p.BigQueryProjectID = projectID
+		p.BillingProjectID = projectID
+		return p
+	}
+	root := newProject("/root", "root-project")
+	folder := newProject("/root/folder", "folder-project")
+	shared := newProject("/other", "root-project")
+
+	h := &Handler{
+		project:           root,
+		workspaceProjects: map[string]*source.Project{"/root": root, "/root/folder": folder, "/other": shared},
+		jobProjects:       cache.NewLRU[string, *source.Project](maxJobProjects),
+	}
+	h.recordJob(shared, "job1")
+
+	tests := map[string]struct {
+		projectID string
+		jobID     string
+
+		expect *source.Project
+	}{
+		"the job run by the folder": {
+			projectID: "root-project",
+			jobID:     "job1",
+			expect:    shared,
+		},
+		"the job of the billing project": {
+			projectID: "folder-project",
+			jobID:     "job2",
+			expect:    folder,
+		},
+		"the job of the unknown project": {
+			projectID: "unknown",
+			jobID:     "job3",
+			expect:    root,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			if got := h.projectOfJob(tt.projectID, tt.jobID); got != tt.expect {
+				t.Errorf("projectOfJob expect %s, but got %s", tt.expect.BillingProjectID, got.BillingProjectID)
+			}
+		})
+	}
+
+	if got := h.projectOfTablePath("folder-project.dataset.table"); got != folder {
+		t.Errorf("projectOfTablePath should return the project of the folder, but got %s", got.BigQueryProjectID)
+	}
+	if got := h.projectOfTablePath("dataset.table"); got != root {
+		t.Errorf("projectOfTablePath should return the project of the root, but got %s", got.BigQueryProjectID)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/websocket"
+	"github.com/kitagry/bqls/langserver"
+	"github.com/sourcegraph/jsonrpc2"
+	jsonrpc2websocket "github.com/sourcegraph/jsonrpc2/websocket"
+)
+
+// listen serves the language server on the address like `tcp://127.0.0.1:2089` or `ws://127.0.0.1:2089/lsp`.
+// Each connection has its own handler, so the projects and the documents are not shared between the connections.
+// The connections are not authenticated, so only the loopback address is allowed unless allowRemote is true.
+func listen(ctx context.Context, addr string, allowRemote bool, logOpt langserver.LogOption) error {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	if !isLoopbackHost(u.Host) {
+		if !allowRemote {
+			return fmt.Errorf("invalid listen address %q: the clients are not authenticated, so listen on a loopback address like 127.0.0.1, or give -listen-allow-remote to accept the connections from the network", addr)
+		}
+		log.Printf("warning: listening on %s without authentication. Anyone who can connect runs queries with your credentials", addr)
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		return listenTCP(ctx, u.Host, logOpt)
+	case "ws":
+		return listenWebSocket(ctx, u.Host, u.Path, logOpt)
+	default:
+		return fmt.Errorf("invalid listen address %q: the scheme should be tcp or ws", addr)
+	}
+}
+
+// isLoopbackHost reports whether the host of `host:port` accepts only the local connections.
+// The empty host like `:2089` listens on all the interfaces.
+func isLoopbackHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func listenTCP(ctx context.Context, host string, logOpt langserver.LogOption) error {
+	lis, err := net.Listen("tcp", host)
+	if err != nil {
+		return err
+	}
+	defer lis.Close()
+	log.Printf("listening on tcp://%s", lis.Addr())
+
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			return err
+		}
+		go serve(ctx, jsonrpc2.NewBufferedStream(conn, jsonrpc2.VSCodeObjectCodec{}), logOpt)
+	}
+}
+
+func listenWebSocket(ctx context.Context, host, path string, logOpt langserver.LogOption) error {
+	if path == "" {
+		path = "/"
+	}
+
+	// The default CheckOrigin rejects the cross-origin requests,
+	// so that a web page can't run queries with the credentials of the user.
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("failed to upgrade the connection: %v", err)
+			return
+		}
+		serve(ctx, jsonrpc2websocket.NewObjectStream(conn), logOpt)
+	})
+
+	lis, err := net.Listen("tcp", host)
+	if err != nil {
+		return err
+	}
+	log.Printf("listening on ws://%s%s", lis.Addr(), path)
+	return http.Serve(lis, mux)
+}
+
+// serve creates the logger for each connection, because the client can change the log level.
+// It returns the exit code after the connection is closed.
+func serve(ctx context.Context, stream jsonrpc2.ObjectStream, logOpt langserver.LogOption) exitCode {
+	logger, err := langserver.NewLogger(logOpt)
+	if err != nil {
+		log.Printf("failed to create logger: %v", err)
+		return exitCodeErr
+	}
+
+	handler := langserver.NewHandler(logger)
+	<-jsonrpc2.NewConn(ctx, stream, handler).DisconnectNotify()
+	if err := handler.Close(); err != nil {
+		logger.Errorf("failed to close: %v", err)
+	}
+	return exitCode(handler.ExitCode())
+}
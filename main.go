@@ -4,7 +4,9 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"runtime"
 	"runtime/debug"
 
@@ -33,6 +35,19 @@ func main() {
 }
 
 func run(args []string) exitCode {
+	if len(args) > 0 && args[0] == "export-schemas" {
+		return runExportSchemas(args[1:])
+	}
+	if len(args) > 0 && args[0] == "lint" {
+		return runLint(args[1:])
+	}
+	if len(args) > 0 && args[0] == "fmt" {
+		return runFmt(args[1:])
+	}
+	if len(args) > 0 && args[0] == "dry-run" {
+		return runDryRun(args[1:])
+	}
+
 	fs := flag.NewFlagSet(name, flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 	fs.Usage = func() {
@@ -42,12 +57,24 @@ func run(args []string) exitCode {
 Version: %s (rev: %s/%s)
 
 You can use your favorite lsp client.
+
+Subcommands:
+  export-schemas  export the schemas of the tables referenced in the workspace
+  lint            report the diagnostics of .sql files without an editor
+  fmt             format .sql files
+  dry-run         estimate the bytes processed of each statement in .sql files
 `, name, version, getRevision(), runtime.Version())
 		fs.PrintDefaults()
 	}
 
 	showVersion := fs.Bool("version", false, "print version")
-	isDebug := fs.Bool("debug", false, "log debug")
+	isDebug := fs.Bool("debug", false, "log debug. It is the same as -log-level=debug")
+	logLevel := fs.String("log-level", "info", "log level: trace, debug, info, warn or error")
+	logFormat := fs.String("log-format", "text", "log format: text or json")
+	logFile := fs.String("log-file", "", "write the logs into the file instead of stderr")
+	metricsAddr := fs.String("metrics-addr", "", "serve the metrics in the Prometheus format on the address like 127.0.0.1:9090")
+	listenAddr := fs.String("listen", "", "serve on the address like tcp://127.0.0.1:2089 or ws://127.0.0.1:2089/lsp instead of stdio")
+	listenAllowRemote := fs.Bool("listen-allow-remote", false, "allow -listen on the address other than loopback. The clients are not authenticated")
 	if err := fs.Parse(args); err != nil {
 		if err == flag.ErrHelp {
 			return exitCodeOK
@@ -60,9 +87,285 @@ You can use your favorite lsp client.
 		return exitCodeOK
 	}
 
-	handler := langserver.NewHandler(*isDebug)
-	defer handler.Close()
-	<-jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(stdrwc{}, jsonrpc2.VSCodeObjectCodec{}), handler).DisconnectNotify()
+	logOpt := langserver.LogOption{
+		Level:  *logLevel,
+		Format: *logFormat,
+	}
+	if *isDebug {
+		logOpt.Level = "debug"
+	}
+	if *logFile != "" {
+		f, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitCodeErr
+		}
+		defer f.Close()
+		logOpt.Out = f
+	}
+	// Validate the options before serving.
+	if _, err := langserver.NewLogger(logOpt); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeErr
+	}
+
+	if *metricsAddr != "" {
+		go func() {
+			if err := langserver.ServeMetrics(*metricsAddr); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to serve metrics: %v\n", err)
+			}
+		}()
+	}
+
+	if *listenAddr != "" {
+		if err := listen(context.Background(), *listenAddr, *listenAllowRemote, logOpt); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitCodeErr
+		}
+		return exitCodeOK
+	}
+
+	return serve(context.Background(), jsonrpc2.NewBufferedStream(stdrwc{}, jsonrpc2.VSCodeObjectCodec{}), logOpt)
+}
+
+func runExportSchemas(args []string) exitCode {
+	fs := flag.NewFlagSet(name+" export-schemas", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	projectID := fs.String("project", "", "default BigQuery project")
+	rootPath := fs.String("root", ".", "workspace root which contains .sql files")
+	outputDir := fs.String("output", "schemas", "output directory")
+	isDebug := fs.Bool("debug", false, "log debug")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitCodeOK
+		}
+		return exitCodeErr
+	}
+
+	rootAbs, err := filepath.Abs(*rootPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeErr
+	}
+
+	files, err := langserver.ExportSchemas(context.Background(), rootAbs, *projectID, *outputDir, *isDebug)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeErr
+	}
+
+	for _, f := range files {
+		fmt.Println(f)
+	}
+	return exitCodeOK
+}
+
+func runLint(args []string) exitCode {
+	fs := flag.NewFlagSet(name+" lint", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s lint [flags] [files...]\n\nWhen no file is given, all .sql files under -root are linted. When the file is -, the SQL is read from stdin.\n\n", name)
+		fs.PrintDefaults()
+	}
+	projectID := fs.String("project", "", "default BigQuery project")
+	rootPath := fs.String("root", ".", "workspace root which contains .sql files")
+	schemaDir := fs.String("schema-dir", "", "directory of the local schema files. When it is set, the BigQuery API is not called")
+	format := fs.String("format", langserver.LintFormatHuman, "output format: human, json or github")
+	selectStar := fs.Bool("select-star", false, "report SELECT * which is not used with EXCEPT or REPLACE as a warning")
+	nonDeterministicLimit := fs.Bool("non-deterministic-limit", false, "report ORDER BY with LIMIT whose keys may have ties as a warning")
+	ordinals := fs.Bool("ordinals", false, "report the ordinals of GROUP BY and ORDER BY as warnings")
+	filename := fs.String("filename", "", "path of the SQL read from stdin, which is used for the diagnostics and the workspace config")
+	isDebug := fs.Bool("debug", false, "log debug")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitCodeOK
+		}
+		return exitCodeErr
+	}
+
+	rootAbs, err := filepath.Abs(*rootPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeErr
+	}
+
+	var stdin io.Reader
+	var stdinFilename string
+	if fs.NArg() == 1 && fs.Arg(0) == "-" {
+		stdin = os.Stdin
+		if *filename != "" {
+			stdinFilename, err = filepath.Abs(*filename)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitCodeErr
+			}
+		}
+	}
+
+	files := make([]string, 0, fs.NArg())
+	for _, f := range fs.Args() {
+		if f == "-" {
+			if stdin == nil {
+				fmt.Fprintln(os.Stderr, "- can't be used with the other files")
+				return exitCodeErr
+			}
+			continue
+		}
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitCodeErr
+		}
+		files = append(files, abs)
+	}
+
+	dir := *schemaDir
+	if dir != "" && !filepath.IsAbs(dir) {
+		dir = filepath.Join(rootAbs, dir)
+	}
+
+	errorCount, err := langserver.Lint(context.Background(), langserver.LintOption{
+		RootPath:              rootAbs,
+		ProjectID:             *projectID,
+		SchemaDir:             dir,
+		Files:                 files,
+		Stdin:                 stdin,
+		StdinFilename:         stdinFilename,
+		Format:                *format,
+		SelectStar:            *selectStar,
+		NonDeterministicLimit: *nonDeterministicLimit,
+		Ordinals:              *ordinals,
+		IsDebug:               *isDebug,
+	}, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeErr
+	}
+
+	if errorCount > 0 {
+		return exitCodeErr
+	}
+	return exitCodeOK
+}
+
+func runFmt(args []string) exitCode {
+	fs := flag.NewFlagSet(name+" fmt", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s fmt [flags] [files...]\n\nWhen no file is given or the file is -, the SQL is read from stdin.\n\n", name)
+		fs.PrintDefaults()
+	}
+	write := fs.Bool("w", false, "write the result to the files instead of stdout")
+	check := fs.Bool("check", false, "print the files which are not formatted and exit with 1 if any")
+	filename := fs.String("filename", "", "path of the SQL read from stdin, which is printed instead of <standard input>")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitCodeOK
+		}
+		return exitCodeErr
+	}
+
+	if fs.NArg() == 0 || (fs.NArg() == 1 && fs.Arg(0) == "-") {
+		displayName := "<standard input>"
+		if *filename != "" {
+			displayName = *filename
+		}
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitCodeErr
+		}
+		// the style is shared with the language server by .bqls.yaml or .bqls.json of the workspace which has the file
+		formatted, err := langserver.FormatSQLForFile(*filename, string(b))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", displayName, err)
+			return exitCodeErr
+		}
+		if *check {
+			if formatted != string(b) {
+				fmt.Println(displayName)
+				return exitCodeErr
+			}
+			return exitCodeOK
+		}
+		fmt.Print(formatted)
+		return exitCodeOK
+	}
+
+	code := exitCodeOK
+	for _, path := range fs.Args() {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = exitCodeErr
+			continue
+		}
+		formatted, err := langserver.FormatSQLForFile(path, string(b))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			code = exitCodeErr
+			continue
+		}
+
+		switch {
+		case *check:
+			if formatted != string(b) {
+				fmt.Println(path)
+				code = exitCodeErr
+			}
+		case *write:
+			if formatted == string(b) {
+				continue
+			}
+			if err := os.WriteFile(path, []byte(formatted), 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				code = exitCodeErr
+			}
+		default:
+			fmt.Print(formatted)
+		}
+	}
+	return code
+}
+
+func runDryRun(args []string) exitCode {
+	fs := flag.NewFlagSet(name+" dry-run", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s dry-run [flags] files...\n\n", name)
+		fs.PrintDefaults()
+	}
+	projectID := fs.String("project", "", "default BigQuery project")
+	billingProjectID := fs.String("billing-project", "", "project which runs the dry-run jobs")
+	location := fs.String("location", "", "location of the dry-run jobs")
+	isDebug := fs.Bool("debug", false, "log debug")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitCodeOK
+		}
+		return exitCodeErr
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitCodeErr
+	}
+
+	failed, err := langserver.DryRun(context.Background(), langserver.DryRunOption{
+		ProjectID:        *projectID,
+		BillingProjectID: *billingProjectID,
+		Location:         *location,
+		Files:            fs.Args(),
+		IsDebug:          *isDebug,
+	}, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeErr
+	}
+
+	if failed > 0 {
+		return exitCodeErr
+	}
 	return exitCodeOK
 }
 